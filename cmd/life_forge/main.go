@@ -2,11 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"life_forge/internal/ai"
+	"life_forge/internal/calsync"
+	"life_forge/internal/chat"
 	"life_forge/internal/config"
+	"life_forge/internal/digest"
+	"life_forge/internal/goals"
+	"life_forge/internal/habits"
 	"life_forge/internal/handlers"
+	"life_forge/internal/journal"
 	"life_forge/internal/models"
+	"life_forge/internal/review"
+	"life_forge/internal/scheduler"
+	"life_forge/internal/secrets"
 	"life_forge/internal/storage"
+	"life_forge/internal/tasks"
+	"life_forge/internal/telegram"
+	"life_forge/internal/timetrack"
 	"log"
 	"net/http"
 	"os/signal"
@@ -17,15 +31,30 @@ import (
 )
 
 type Router struct {
+	userHandler     *handlers.UserHandler
 	chatHandler     *handlers.ChatHandler
 	authHandler     *handlers.AuthHandler
 	calendarHandler *handlers.CalendarHandler
+	caldavHandler   *handlers.CalDAVHandler
+	exportHandler   *handlers.ExportHandler
+	importHandler   *handlers.ImportHandler
+	projectHandler  *handlers.ProjectHandler
+	reviewHandler   *handlers.ReviewHandler
+	digestHandler   *handlers.DigestHandler
+	telegramHandler *handlers.TelegramHandler
+	jobHandler      *handlers.JobHandler
+	goalHandler     *handlers.GoalHandler
+	goalPlanHandler *handlers.GoalPlanHandler
+	habitHandler    *handlers.HabitHandler
+	journalHandler  *handlers.JournalHandler
+	taskHandler     *handlers.TaskHandler
+	timeHandler     *handlers.TimeHandler
 }
 
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, HX-Request")
 
 		if r.Method == "OPTIONS" {
@@ -39,15 +68,16 @@ func corsMiddleware(next http.Handler) http.Handler {
 
 func main() {
 	cfg := config.New()
-	if cfg.GigaChatKey == "" {
-		log.Fatal("Couldnt find Gigachat key")
-	}
 
 	//signals
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 
-	ai_client := ai.NewGigaChatClient(cfg.GigaChatKey)
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Printf("Unknown timezone %s, using UTC: %v", cfg.Timezone, err)
+		location = time.UTC
+	}
 
 	pool, err := pgxpool.New(ctx, cfg.PostgresDSN) //пул соединений с БД
 	if err != nil {
@@ -59,36 +89,123 @@ func main() {
 		log.Fatal("unable to ping db", err)
 	}
 
+	keyring, err := secrets.LoadOrCreate(cfg.SecretsKey, cfg.SecretsKeyFile)
+	if err != nil {
+		log.Fatal("unable to load secrets key ", err)
+	}
+	secretStorage := storage.NewSecretStorage(pool, keyring)
+
+	// key from .env wins, otherwise it is taken from encrypted store
+	if cfg.GigaChatKey == "" {
+		cfg.GigaChatKey, err = secretStorage.Get(ctx, secrets.GigaChatKey)
+		if err != nil && !errors.Is(err, storage.ErrSecretNotFound) {
+			log.Printf("Failed to load Gigachat key: %v", err)
+		}
+	}
+	if cfg.GigaChatKey == "" {
+		log.Fatal("Couldnt find Gigachat key")
+	}
+
+	ai_client := ai.NewGigaChatClient(cfg.GigaChatKey)
+
 	contextStorage := storage.NewContextStorage(pool)
+	userStorage := storage.NewUserStorage(pool)
+	tokenStorage := storage.NewTokenStorage(pool, keyring)
+	caldavAccountStorage := storage.NewCalDAVAccountStorage(pool, keyring)
+	reencryptSecrets(ctx, tokenStorage, caldavAccountStorage, secretStorage)
 
-	calendarStorage, err := storage.NewGoogleCalendarStorage(pool)
+	mirrorStorage := storage.NewEventMirrorStorage(pool, location)
+
+	calendarStorage, err := storage.NewGoogleCalendarStorage(tokenStorage, mirrorStorage, cfg.GoogleAPIEndpoint, handlers.OAuthRedirectURL(cfg.PublicURL), location)
 	if err != nil {
 		log.Fatal("Error to connect to Google Calendar", err)
 	}
-	log.Printf("Calendar status: authorized=%v", calendarStorage.IsAuthorized())
-
-	//debug
-	if calendarStorage.IsAuthorized() {
-		events, err := calendarStorage.ListEvents(context.Background(), time.Now().UTC(), time.Now().AddDate(0, 0, 5).UTC())
-		if err != nil {
-			log.Printf("Error listing events: %v", err)
+	if cfg.LegacyTokenUserID > 0 {
+		// user may not be registered yet, so the file is kept for the next start
+		if err := calendarStorage.ImportLegacyToken(ctx, cfg.LegacyTokenUserID); err != nil {
+			log.Printf("Failed to import token.json, it can be moved by `go run ./cmd/secrets migrate-token -user %d`: %v", cfg.LegacyTokenUserID, err)
 		}
-		log.Printf("📅 Calendar events found: %d", len(events))
-	} else {
-		log.Println("Go by url: 🔗 http://localhost:8080/auth/google")
 	}
 
-	chatHandler := handlers.NewChatHandler(contextStorage, ai_client, calendarStorage)
-	authHandler := handlers.NewAuthHandler(calendarStorage)
-	calendarHandler := handlers.NewCalendarHandler(calendarStorage)
+	localCalendarStorage := storage.NewLocalCalendarStorage(pool)
+
+	syncer := calsync.NewSyncer(calendarStorage, mirrorStorage, localCalendarStorage, location)
+
+	calendarRouter := storage.NewCalendarRouter(calendarStorage, localCalendarStorage)
+	loadCalDAVAccounts(ctx, caldavAccountStorage, calendarRouter, cfg.CalDAVAllowPrivate, location)
+
+	eventStorage := storage.NewEventStorage(pool)
+	projectStorage := storage.NewProjectStorage(pool)
+
+	journalStorage := storage.NewJournalStorage(pool)
+	reviewStorage := storage.NewReviewStorage(pool)
+	reviewer := review.NewReviewer(ai_client, calendarRouter, contextStorage, journalStorage, reviewStorage, location)
+
+	digestStorage := storage.NewDigestStorage(pool)
+	mailer := digest.NewMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	digester := digest.NewDigester(ai_client, calendarRouter, contextStorage, digestStorage, mailer, location, cfg.PublicURL, cfg.DigestSendInterval)
+
+	diary := journal.NewJournal(ai_client, calendarRouter, journalStorage, location)
+
+	// without sync tasks live only here, remote is nil
+	var taskRemote storage.TaskRemote
+	if cfg.GoogleTasksSync {
+		taskRemote = storage.NewGoogleTasksStorage(calendarStorage.HTTPClient, cfg.GoogleAPIEndpoint, cfg.GoogleTasksList)
+	}
+	taskStorage := storage.NewTaskStorage(pool)
+	taskManager := tasks.NewManager(taskStorage, taskRemote, cfg.GoogleTasksList)
+	timesheet := timetrack.NewTimesheet(calendarRouter, storage.NewTimeEntryStorage(pool), taskStorage, location)
+
+	jobStorage := storage.NewJobStorage(pool)
+	jobScheduler := scheduler.New(jobStorage, location)
+	registerJobs(ctx, jobScheduler, cfg, jobStorage, userStorage, tokenStorage, syncer, digester, diary, taskManager)
+	schedulerDone := make(chan struct{})
+	go func() {
+		jobScheduler.Run(ctx)
+		close(schedulerDone)
+	}()
+
+	goalStorage := storage.NewGoalStorage(pool)
+	planner := goals.NewPlanner(ai_client, calendarRouter, contextStorage, goalStorage, location)
+
+	habitStorage := storage.NewHabitStorage(pool)
+	tracker := habits.NewTracker(calendarRouter, contextStorage, habitStorage, location)
+
+	assistant := chat.NewAssistant(contextStorage, ai_client, calendarRouter, eventStorage, reviewer, planner, tracker, diary, taskManager, timesheet, calendarStorage)
+
+	telegramStorage := storage.NewTelegramStorage(pool)
+	if cfg.TelegramBotToken != "" {
+		bot := telegram.NewBot(telegram.NewClient(cfg.TelegramAPIURL, cfg.TelegramBotToken), assistant, planner, telegramStorage, calendarRouter, location)
+		go bot.Run(ctx)
+		log.Println("Telegram bot started")
+	}
+
+	userHandler := handlers.NewUserHandler(userStorage, contextStorage, cfg.SessionTTL)
+	chatHandler := handlers.NewChatHandler(assistant)
+	authHandler := handlers.NewAuthHandler(calendarStorage, cfg.PublicURL)
+	calendarHandler := handlers.NewCalendarHandler(calendarRouter, projectStorage, mirrorStorage, location)
+	caldavHandler := handlers.NewCalDAVHandler(caldavAccountStorage, calendarRouter, cfg.CalDAVAllowPrivate, location)
+	exportHandler := handlers.NewExportHandler(calendarRouter, storage.NewFeedStorage(pool), location)
+	importHandler := handlers.NewImportHandler(calendarRouter, storage.NewImportStorage(pool), location)
+	projectHandler := handlers.NewProjectHandler(projectStorage)
+	reviewHandler := handlers.NewReviewHandler(reviewer, reviewStorage)
+	digestHandler := handlers.NewDigestHandler(digester, digestStorage)
+	telegramHandler := handlers.NewTelegramHandler(telegramStorage)
+	jobHandler := handlers.NewJobHandler(jobScheduler, jobStorage, cfg.AdminUserIDs)
+	goalHandler := handlers.NewGoalHandler(contextStorage)
+	goalPlanHandler := handlers.NewGoalPlanHandler(planner)
+	habitHandler := handlers.NewHabitHandler(tracker, location)
+	journalHandler := handlers.NewJournalHandler(diary, location)
+	taskHandler := handlers.NewTaskHandler(taskManager)
+	timeHandler := handlers.NewTimeHandler(timesheet, location)
 
 	mux := http.NewServeMux()
 
-	router := newRouter(chatHandler, authHandler, calendarHandler)
+	router := newRouter(userHandler, chatHandler, authHandler, calendarHandler, caldavHandler, exportHandler, importHandler, projectHandler, reviewHandler, digestHandler, telegramHandler, jobHandler, goalHandler, goalPlanHandler, habitHandler, journalHandler, taskHandler, timeHandler)
 
 	router.register(mux)
 
-	handler := corsMiddleware(mux)
+	handler := corsMiddleware(userHandler.Middleware(mux))
 
 	srv := &http.Server{
 		Addr:    ":8080",
@@ -97,7 +214,7 @@ func main() {
 
 	log.Println("Server starting on http://localhost:8080")
 	go func() {
-		if err := http.ListenAndServe(":8080", handler); err != nil && err != http.ErrServerClosed {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal("Fail Listen and Serve with error ", err)
 		}
 	}()
@@ -106,32 +223,165 @@ func main() {
 
 	log.Println("Shutting down server...")
 
-	shutdownCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, stop := context.WithTimeout(context.Background(), 30*time.Second)
 	defer stop()
 
 	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Println("Server cannot be stoped. error %w", err)
+		log.Printf("Server cannot be stoped. error %v", err)
+	}
+
+	log.Println("Draining background jobs...")
+	<-schedulerDone
+	if err := jobScheduler.Shutdown(shutdownCtx); err != nil {
+		log.Println(err)
 	}
 }
 
-func initStorageContext(ctx context.Context, storageInstance *storage.ContextStorage) {
-	storageInstance.SaveContext(ctx, &models.Context{
-		ID:       1,
-		Goals:    []string{},
-		Recent5:  []string{},
-		Progress: map[string]string{},
-	})
+// userLister is satisfied by UserStorage (every user) and TokenStorage (users with Google connected)
+type userLister interface {
+	ListUserIDs(ctx context.Context) ([]int, error)
+}
+
+// forEachUser turns per-user job into one that runs for all listed users, a failed user doesn't stop the rest
+func forEachUser(users userLister, run scheduler.JobFunc) scheduler.JobFunc {
+	return func(ctx context.Context) error {
+		ids, err := users.ListUserIDs(ctx)
+		if err != nil {
+			return err
+		}
+
+		var failed int
+		for _, id := range ids {
+			if err := run(models.WithUserID(ctx, id)); err != nil {
+				log.Printf("Job failed for user %d: %v", id, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("job failed for %d of %d users", failed, len(ids))
+		}
+		return nil
+	}
+}
+
+// registerJobs plugs periodic work into the scheduler, jobs with invalid schedule are skipped
+func registerJobs(ctx context.Context, s *scheduler.Scheduler, cfg *config.Config, jobStorage *storage.JobStorage, users *storage.UserStorage, tokens *storage.TokenStorage, syncer *calsync.Syncer, digester *digest.Digester, diary *journal.Journal, taskManager *tasks.Manager) {
+	jobs := []struct {
+		name string
+		spec string
+		run  scheduler.JobFunc
+		opts []scheduler.Option
+	}{
+		{"calendar_sync", "@every " + cfg.CalendarSyncInterval.String(), forEachUser(tokens, syncer.SyncAll), []scheduler.Option{scheduler.WithRetries(2)}},
+		// digest checks send times every minute, a failed check is simply repeated by the next one
+		{"morning_digest", "* * * * *", func(ctx context.Context) error {
+			return digester.SendDue(ctx, time.Now())
+		}, nil},
+		{"journal_reflection", "0 20 * * 0", forEachUser(users, diary.ReflectWeekly), []scheduler.Option{scheduler.WithRetries(1)}},
+		// every tick of the jobs above leaves a run, morning_digest alone about 1440 a day
+		{"job_runs_prune", "30 3 * * *", func(ctx context.Context) error {
+			n, err := jobStorage.PruneRuns(ctx, time.Now().Add(-cfg.JobRunsRetention))
+			if err == nil && n > 0 {
+				log.Printf("Job runs pruned: %d", n)
+			}
+			return err
+		}, nil},
+	}
+
+	for _, j := range jobs {
+		if err := s.Register(ctx, j.name, j.spec, j.run, j.opts...); err != nil {
+			log.Printf("Skip job %s: %v", j.name, err)
+		}
+	}
+
+	// tasks follow calendar sync interval, the job exists only when sync is on
+	if taskManager.SyncEnabled() {
+		spec := "@every " + cfg.CalendarSyncInterval.String()
+		if err := s.Register(ctx, "tasks_sync", spec, forEachUser(tokens, taskManager.RunSync), scheduler.WithRetries(2)); err != nil {
+			log.Printf("Skip job tasks_sync: %v", err)
+		}
+	}
+}
+
+// reencryptSecrets moves values left in plaintext by older versions or encrypted with previous key
+// to current key, app does not start while plaintext credentials remain in db
+func reencryptSecrets(ctx context.Context, tokens *storage.TokenStorage, caldav *storage.CalDAVAccountStorage, named *storage.SecretStorage) {
+	tokenCount, err := tokens.ReencryptTokens(ctx)
+	if err != nil {
+		log.Fatal("unable to encrypt Google tokens ", err)
+	}
+	passwordCount, err := caldav.ReencryptPasswords(ctx)
+	if err != nil {
+		log.Fatal("unable to encrypt CalDAV passwords ", err)
+	}
+	secretCount, err := named.Reencrypt(ctx)
+	if err != nil {
+		log.Fatal("unable to re-encrypt secrets ", err)
+	}
+
+	if tokenCount+passwordCount+secretCount > 0 {
+		log.Printf("Re-encrypted with current key: %d Google tokens, %d CalDAV passwords, %d secrets", tokenCount, passwordCount, secretCount)
+	}
+}
+
+// loadCalDAVAccounts plugs linked CalDAV accounts into calendar router
+func loadCalDAVAccounts(ctx context.Context, accountStorage *storage.CalDAVAccountStorage, router *storage.CalendarRouter, allowPrivate bool, location *time.Location) {
+	accounts, err := accountStorage.ListAllAccounts(ctx)
+	if err != nil {
+		log.Printf("Failed to load CalDAV accounts: %v", err)
+		return
+	}
+
+	for _, account := range accounts {
+		provider, err := storage.NewCalDAVCalendarStorage(account, allowPrivate, location)
+		if err != nil {
+			log.Printf("Skip CalDAV account %d: %v", account.ID, err)
+			continue
+		}
+		router.SetCalDAVAccount(provider)
+	}
+	log.Printf("CalDAV accounts linked: %d", len(accounts))
 }
 
 func newRouter(
+	userHandler *handlers.UserHandler,
 	chatHandler *handlers.ChatHandler,
 	authHandler *handlers.AuthHandler,
 	calendarHandler *handlers.CalendarHandler,
+	caldavHandler *handlers.CalDAVHandler,
+	exportHandler *handlers.ExportHandler,
+	importHandler *handlers.ImportHandler,
+	projectHandler *handlers.ProjectHandler,
+	reviewHandler *handlers.ReviewHandler,
+	digestHandler *handlers.DigestHandler,
+	telegramHandler *handlers.TelegramHandler,
+	jobHandler *handlers.JobHandler,
+	goalHandler *handlers.GoalHandler,
+	goalPlanHandler *handlers.GoalPlanHandler,
+	habitHandler *handlers.HabitHandler,
+	journalHandler *handlers.JournalHandler,
+	taskHandler *handlers.TaskHandler,
+	timeHandler *handlers.TimeHandler,
 ) *Router {
 	return &Router{
+		userHandler:     userHandler,
 		chatHandler:     chatHandler,
 		authHandler:     authHandler,
 		calendarHandler: calendarHandler,
+		caldavHandler:   caldavHandler,
+		exportHandler:   exportHandler,
+		importHandler:   importHandler,
+		projectHandler:  projectHandler,
+		reviewHandler:   reviewHandler,
+		digestHandler:   digestHandler,
+		telegramHandler: telegramHandler,
+		jobHandler:      jobHandler,
+		goalHandler:     goalHandler,
+		goalPlanHandler: goalPlanHandler,
+		habitHandler:    habitHandler,
+		journalHandler:  journalHandler,
+		taskHandler:     taskHandler,
+		timeHandler:     timeHandler,
 	}
 }
 
@@ -141,14 +391,52 @@ func (r *Router) register(mux *http.ServeMux) {
 	})
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
+	mux.HandleFunc("/auth/register", r.userHandler.HandleRegister)
+	mux.HandleFunc("/auth/login", r.userHandler.HandleLogin)
+	mux.HandleFunc("/auth/logout", r.userHandler.HandleLogout)
+	mux.HandleFunc("/api/me", r.userHandler.HandleMe)
 	mux.HandleFunc("/chat", r.chatHandler.HandleChat)
 	mux.HandleFunc("/auth/google", r.authHandler.HandleGoogleLogin)
 	mux.HandleFunc("/auth/callback", r.authHandler.HandleGoogleCallback)
+	mux.HandleFunc("/auth/google/disconnect", r.authHandler.HandleDisconnect)
+	mux.HandleFunc("/api/auth/status", r.authHandler.HandleStatus)
 	mux.HandleFunc("/api/gantt", r.calendarHandler.HandleGanttDiagramm)
 	mux.HandleFunc("/api/calendars", r.calendarHandler.HandleGetCalendars)
-
-	// Init storage context if needed
-	//initStorageContext(ctx, storage)
+	mux.HandleFunc("/api/layout", r.calendarHandler.HandleLayout)
+	mux.HandleFunc("/api/stats", r.calendarHandler.HandleStats)
+	mux.HandleFunc("/api/caldav/accounts", r.caldavHandler.HandleAccounts)
+	mux.HandleFunc("/api/export.ics", r.exportHandler.HandleExportICS)
+	mux.HandleFunc("/api/feeds", r.exportHandler.HandleFeeds)
+	mux.HandleFunc("/feed/", r.exportHandler.HandleFeed)
+	mux.HandleFunc("/api/import/ics", r.importHandler.HandleImportPreview)
+	mux.HandleFunc("/api/import/ics/commit", r.importHandler.HandleImportCommit)
+	mux.HandleFunc("/api/projects", r.projectHandler.HandleProjects)
+	mux.HandleFunc("/api/projects/tasks", r.projectHandler.HandleTasks)
+	mux.HandleFunc("/api/projects/dependencies", r.projectHandler.HandleDependencies)
+	mux.HandleFunc("/api/reviews", r.reviewHandler.HandleReviews)
+	mux.HandleFunc("/api/digest/settings", r.digestHandler.HandleSettings)
+	mux.HandleFunc("/api/digest/deliveries", r.digestHandler.HandleDeliveries)
+	mux.HandleFunc("/api/digest/send", r.digestHandler.HandleSend)
+	mux.HandleFunc("/api/digest/verify", r.digestHandler.HandleVerify)
+	mux.HandleFunc("/api/telegram/link", r.telegramHandler.HandleLink)
+	mux.HandleFunc("/api/admin/jobs", r.jobHandler.HandleJobs)
+	mux.HandleFunc("/api/goals", r.goalHandler.HandleGoals)
+	mux.HandleFunc("/api/progress", r.goalHandler.HandleProgress)
+	mux.HandleFunc("/api/goal-plans", r.goalPlanHandler.HandlePlans)
+	mux.HandleFunc("/api/goal-plans/accept", r.goalPlanHandler.HandleAccept)
+	mux.HandleFunc("/api/tracked-goals", r.goalPlanHandler.HandleGoals)
+	mux.HandleFunc("/api/tracked-goals/sessions", r.goalPlanHandler.HandleSessions)
+	mux.HandleFunc("/api/habits", r.habitHandler.HandleHabits)
+	mux.HandleFunc("/api/habits/checkins", r.habitHandler.HandleCheckins)
+	mux.HandleFunc("/api/habits/history", r.habitHandler.HandleHistory)
+	mux.HandleFunc("/api/journal", r.journalHandler.HandleEntries)
+	mux.HandleFunc("/api/journal/mood", r.journalHandler.HandleMood)
+	mux.HandleFunc("/api/journal/reflections", r.journalHandler.HandleReflections)
+	mux.HandleFunc("/api/tasks", r.taskHandler.HandleTasks)
+	mux.HandleFunc("/api/tasks/sync", r.taskHandler.HandleSync)
+	mux.HandleFunc("/api/time/entries", r.timeHandler.HandleEntries)
+	mux.HandleFunc("/api/time/timer", r.timeHandler.HandleTimer)
+	mux.HandleFunc("/api/time/report", r.timeHandler.HandleReport)
 
 	//mux.HandleFunc("/entry", handler.HandleCreateEntry)
 	//mux.HandleFunc("/entries", handler.HandleGetEntries)
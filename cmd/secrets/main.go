@@ -0,0 +1,178 @@
+// secrets manages encrypted secret store:
+//
+//	go run ./cmd/secrets keygen [-write]               new master key, -write puts it first in SECRETS_KEY_FILE
+//	go run ./cmd/secrets rotate                        re-encrypt tokens, passwords and secrets with current key
+//	go run ./cmd/secrets migrate-token [-user 1] [-file token.json]
+//	go run ./cmd/secrets set <name>                    value is read from stdin, e.g. gigachat_auth_key
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"life_forge/internal/config"
+	"life_forge/internal/secrets"
+	"life_forge/internal/storage"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/oauth2"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal("usage: secrets keygen|rotate|migrate-token|set")
+	}
+
+	cfg := config.New()
+	ctx := context.Background()
+	command, args := os.Args[1], os.Args[2:]
+
+	var err error
+	switch command {
+	case "keygen":
+		err = keygen(cfg, args)
+	case "rotate":
+		err = rotate(ctx, cfg)
+	case "migrate-token":
+		err = migrateToken(ctx, cfg, args)
+	case "set":
+		err = set(ctx, cfg, args)
+	default:
+		err = fmt.Errorf("unknown command %s", command)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func keygen(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	write := fs.Bool("write", false, "put new key first in SECRETS_KEY_FILE")
+	fs.Parse(args)
+
+	if !*write {
+		entry, err := secrets.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(entry)
+		return nil
+	}
+
+	id, err := secrets.AddKeyToFile(cfg.SecretsKeyFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("key %s is current in %s, run rotate to re-encrypt stored values\n", id, cfg.SecretsKeyFile)
+	return nil
+}
+
+// rotate needs old keys in keyring until it finishes, after that they can be removed
+func rotate(ctx context.Context, cfg *config.Config) error {
+	pool, keyring, err := connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	tokens, err := storage.NewTokenStorage(pool, keyring).ReencryptTokens(ctx)
+	if err != nil {
+		return err
+	}
+	passwords, err := storage.NewCalDAVAccountStorage(pool, keyring).ReencryptPasswords(ctx)
+	if err != nil {
+		return err
+	}
+	named, err := storage.NewSecretStorage(pool, keyring).Reencrypt(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("re-encrypted with key %s: %d Google tokens, %d CalDAV passwords, %d secrets\n", keyring.CurrentKeyID(), tokens, passwords, named)
+	return nil
+}
+
+func migrateToken(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate-token", flag.ExitOnError)
+	userID := fs.Int("user", 1, "owner of the token")
+	file := fs.String("file", "token.json", "token file of single-user setup")
+	fs.Parse(args)
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("failed to read token file: %w", err)
+	}
+	tok := &oauth2.Token{}
+	if err := json.Unmarshal(data, tok); err != nil {
+		return fmt.Errorf("failed to parse token file: %w", err)
+	}
+
+	pool, keyring, err := connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	// same check as import of token.json on app start, user who linked Google keeps the account
+	_, err = storage.NewTokenStorage(pool, keyring).ImportToken(ctx, *userID, tok)
+	if errors.Is(err, storage.ErrAccountExists) {
+		return fmt.Errorf("user %d already has Google account, %s is kept", *userID, *file)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(*file); err != nil {
+		return fmt.Errorf("token is saved, but %s is not removed: %w", *file, err)
+	}
+
+	fmt.Printf("token from %s is encrypted and saved for user %d\n", *file, *userID)
+	return nil
+}
+
+func set(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: secrets set <name> < value")
+	}
+
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("empty value: %v", err)
+	}
+
+	pool, keyring, err := connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	if err := storage.NewSecretStorage(pool, keyring).Put(ctx, args[0], value); err != nil {
+		return err
+	}
+	fmt.Printf("secret %s is saved with key %s\n", args[0], keyring.CurrentKeyID())
+	return nil
+}
+
+func connect(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, *secrets.Keyring, error) {
+	keyring, err := secrets.Load(cfg.SecretsKey, cfg.SecretsKeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load master key: %w", err)
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.PostgresDSN)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to db: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, nil, fmt.Errorf("unable to ping db: %w", err)
+	}
+	return pool, keyring, nil
+}
@@ -0,0 +1,255 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	timeRangeLayout = "20060102T150405Z"
+)
+
+// Client speaks the small part of CalDAV (RFC 4791) we need: discovery, calendar-query and object CRUD
+type Client struct {
+	baseURL  *url.URL
+	username string
+	password string
+	http     *http.Client
+}
+
+type CalendarInfo struct {
+	Href  string
+	Name  string
+	Color string
+}
+
+type Object struct {
+	Href string
+	ETag string
+	Data string
+}
+
+// NewClient allowPrivate lets the client reach servers in local networks (self-hosted Radicale),
+// otherwise only public addresses are allowed
+func NewClient(rawURL, username, password string, allowPrivate bool) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("bad caldav url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("bad caldav url scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("bad caldav url: no host")
+	}
+	if !allowPrivate {
+		if err := checkHost(u.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	return &Client{
+		baseURL:  u,
+		username: username,
+		password: password,
+		http:     newHTTPClient(allowPrivate),
+	}, nil
+}
+
+// FindCalendars walks principal -> calendar-home-set -> calendars with VEVENT support
+func (c *Client) FindCalendars(ctx context.Context) ([]CalendarInfo, error) {
+	root, err := c.propfind(ctx, c.baseURL.Path, "0", propfindDiscovery)
+	if err != nil {
+		return nil, err
+	}
+
+	home := ""
+	principal := ""
+	for _, resp := range root.Responses {
+		p := resp.okProp()
+		if p.ResourceType.Calendar != nil {
+			return []CalendarInfo{{Href: resp.Href, Name: p.DisplayName, Color: p.CalendarColor}}, nil
+		}
+		home = p.CalendarHomeSet.Href
+		principal = p.CurrentUserPrincipal.Href
+	}
+
+	if home == "" && principal != "" {
+		ms, err := c.propfind(ctx, principal, "0", propfindHomeSet)
+		if err != nil {
+			return nil, err
+		}
+		for _, resp := range ms.Responses {
+			if h := resp.okProp().CalendarHomeSet.Href; h != "" {
+				home = h
+			}
+		}
+	}
+	if home == "" {
+		home = c.baseURL.Path
+	}
+
+	ms, err := c.propfind(ctx, home, "1", propfindCalendars)
+	if err != nil {
+		return nil, err
+	}
+
+	var calendars []CalendarInfo
+	for _, resp := range ms.Responses {
+		p := resp.okProp()
+		if p.ResourceType.Calendar == nil || !p.supportsEvents() {
+			continue
+		}
+		name := p.DisplayName
+		if name == "" {
+			name = strings.Trim(resp.Href, "/")
+		}
+		calendars = append(calendars, CalendarInfo{Href: resp.Href, Name: name, Color: p.CalendarColor})
+	}
+
+	return calendars, nil
+}
+
+// QueryEvents returns VEVENT objects of calendar overlapping [start, end)
+func (c *Client) QueryEvents(ctx context.Context, calendarHref string, start, end time.Time) ([]Object, error) {
+	body := fmt.Sprintf(reportCalendarQuery, start.UTC().Format(timeRangeLayout), end.UTC().Format(timeRangeLayout))
+
+	ms, err := c.multistatus(ctx, "REPORT", calendarHref, "1", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []Object
+	for _, resp := range ms.Responses {
+		p := resp.okProp()
+		if p.CalendarData == "" {
+			continue
+		}
+		objects = append(objects, Object{Href: resp.Href, ETag: p.GetETag, Data: p.CalendarData})
+	}
+	return objects, nil
+}
+
+func (c *Client) GetObject(ctx context.Context, href string) (Object, error) {
+	resp, err := c.do(ctx, http.MethodGet, href, nil, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Object{}, statusError(resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Href: href, ETag: resp.Header.Get("ETag"), Data: string(data)}, nil
+}
+
+// PutObject stores calendar object, create=true refuses to overwrite existing one
+func (c *Client) PutObject(ctx context.Context, href, data string, create bool) (string, error) {
+	headers := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+	if create {
+		headers["If-None-Match"] = "*"
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, href, strings.NewReader(data), headers)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return "", statusError(resp)
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+func (c *Client) DeleteObject(ctx context.Context, href string) error {
+	resp, err := c.do(ctx, http.MethodDelete, href, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return statusError(resp)
+	}
+	return nil
+}
+
+func (c *Client) propfind(ctx context.Context, href, depth, body string) (*multistatus, error) {
+	return c.multistatus(ctx, "PROPFIND", href, depth, body)
+}
+
+func (c *Client) multistatus(ctx context.Context, method, href, depth, body string) (*multistatus, error) {
+	headers := map[string]string{
+		"Content-Type": "application/xml; charset=utf-8",
+		"Depth":        depth,
+	}
+
+	resp, err := c.do(ctx, method, href, strings.NewReader(body), headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, statusError(resp)
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("%s %s: decode multistatus: %w", method, href, err)
+	}
+	return &ms, nil
+}
+
+func (c *Client) do(ctx context.Context, method, href string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return nil, fmt.Errorf("bad href %q: %w", href, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.ResolveReference(ref).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, href, err)
+	}
+	return resp, nil
+}
+
+// StatusError keeps http status so callers can tell forbidden from other failures
+type StatusError struct {
+	Method string
+	URL    string
+	Code   int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("caldav %s %s: http %d", e.Method, e.URL, e.Code)
+}
+
+func statusError(resp *http.Response) error {
+	return &StatusError{Method: resp.Request.Method, URL: resp.Request.URL.String(), Code: resp.StatusCode}
+}
@@ -0,0 +1,197 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	multistatusOpen  = `<?xml version="1.0" encoding="utf-8"?><d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:a="http://apple.com/ns/ical/">`
+	multistatusClose = `</d:multistatus>`
+	okStatus         = `<d:status>HTTP/1.1 200 OK</d:status>`
+)
+
+const standupICS = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:standup\r\nSUMMARY:Standup\r\n" +
+	"DTSTART:20260105T070000Z\r\nDTEND:20260105T071500Z\r\nRRULE:FREQ=DAILY\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+// fakeCalDAV is a server with principal at /principals/u/, home at /cal/u/ and one event calendar
+type fakeCalDAV struct {
+	mu      sync.Mutex
+	objects map[string]string
+	reports []string
+}
+
+func (f *fakeCalDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method + " " + r.URL.Path {
+	case "PROPFIND /dav/":
+		writeMultistatus(w, `<d:response><d:href>/dav/</d:href><d:propstat><d:prop>
+			<d:current-user-principal><d:href>/principals/u/</d:href></d:current-user-principal>
+			</d:prop>`+okStatus+`</d:propstat></d:response>`)
+	case "PROPFIND /principals/u/":
+		writeMultistatus(w, `<d:response><d:href>/principals/u/</d:href><d:propstat><d:prop>
+			<c:calendar-home-set><d:href>/cal/u/</d:href></c:calendar-home-set>
+			</d:prop>`+okStatus+`</d:propstat></d:response>`)
+	case "PROPFIND /cal/u/":
+		if r.Header.Get("Depth") != "1" {
+			http.Error(w, "depth", http.StatusBadRequest)
+			return
+		}
+		writeMultistatus(w, `
+			<d:response><d:href>/cal/u/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop>`+okStatus+`</d:propstat></d:response>
+			<d:response><d:href>/cal/u/work/</d:href><d:propstat><d:prop>
+				<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>
+				<d:displayname>Work</d:displayname><a:calendar-color>#ff0000</a:calendar-color>
+				<c:supported-calendar-component-set><c:comp name="VEVENT"/></c:supported-calendar-component-set>
+			</d:prop>`+okStatus+`</d:propstat>
+			<d:propstat><d:prop><d:getetag/></d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat></d:response>
+			<d:response><d:href>/cal/u/tasks/</d:href><d:propstat><d:prop>
+				<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>
+				<d:displayname>Tasks</d:displayname>
+				<c:supported-calendar-component-set><c:comp name="VTODO"/></c:supported-calendar-component-set>
+			</d:prop>`+okStatus+`</d:propstat></d:response>`)
+	case "REPORT /cal/u/work/":
+		f.reports = append(f.reports, string(body))
+		var items strings.Builder
+		for href, data := range f.objects {
+			fmt.Fprintf(&items, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>"1"</d:getetag><c:calendar-data>%s</c:calendar-data></d:prop>%s</d:propstat></d:response>`,
+				href, data, okStatus)
+		}
+		writeMultistatus(w, items.String())
+	case "PUT " + r.URL.Path:
+		if _, exists := f.objects[r.URL.Path]; exists && r.Header.Get("If-None-Match") == "*" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.objects[r.URL.Path] = string(body)
+		w.Header().Set("ETag", `"2"`)
+		w.WriteHeader(http.StatusCreated)
+	case "GET " + r.URL.Path:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"2"`)
+		io.WriteString(w, data)
+	case "DELETE " + r.URL.Path:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusMethodNotAllowed)
+	}
+}
+
+func writeMultistatus(w http.ResponseWriter, responses string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, multistatusOpen+responses+multistatusClose)
+}
+
+func newFakeClient(t *testing.T, password string) (*Client, *fakeCalDAV) {
+	t.Helper()
+	fake := &fakeCalDAV{objects: map[string]string{"/cal/u/work/standup.ics": standupICS}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(srv.URL+"/dav/", "user", password, true)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c, fake
+}
+
+func TestFindCalendars(t *testing.T) {
+	c, _ := newFakeClient(t, "secret")
+
+	calendars, err := c.FindCalendars(context.Background())
+	if err != nil {
+		t.Fatalf("FindCalendars: %v", err)
+	}
+	if len(calendars) != 1 {
+		t.Fatalf("calendars = %+v, want only event calendar", calendars)
+	}
+	want := CalendarInfo{Href: "/cal/u/work/", Name: "Work", Color: "#ff0000"}
+	if calendars[0] != want {
+		t.Fatalf("calendar = %+v, want %+v", calendars[0], want)
+	}
+}
+
+func TestFindCalendarsUnauthorized(t *testing.T) {
+	c, _ := newFakeClient(t, "wrong")
+
+	_, err := c.FindCalendars(context.Background())
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusUnauthorized {
+		t.Fatalf("error = %v, want 401 StatusError", err)
+	}
+}
+
+func TestQueryEvents(t *testing.T) {
+	c, fake := newFakeClient(t, "secret")
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	objects, err := c.QueryEvents(context.Background(), "/cal/u/work/",
+		time.Date(2026, 1, 5, 3, 0, 0, 0, moscow), time.Date(2026, 1, 12, 3, 0, 0, 0, moscow))
+	if err != nil {
+		t.Fatalf("QueryEvents: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Href != "/cal/u/work/standup.ics" || objects[0].ETag != `"1"` {
+		t.Fatalf("objects = %+v", objects)
+	}
+	// XML decoder normalizes line endings of calendar-data
+	if objects[0].Data != strings.ReplaceAll(standupICS, "\r\n", "\n") {
+		t.Fatalf("calendar data = %q", objects[0].Data)
+	}
+	if len(fake.reports) != 1 || !strings.Contains(fake.reports[0], `start="20260105T000000Z" end="20260112T000000Z"`) {
+		t.Fatalf("report = %v, want time range in UTC", fake.reports)
+	}
+}
+
+func TestPutGetDeleteObject(t *testing.T) {
+	c, _ := newFakeClient(t, "secret")
+	ctx := context.Background()
+	href := "/cal/u/work/new.ics"
+
+	etag, err := c.PutObject(ctx, href, standupICS, true)
+	if err != nil || etag != `"2"` {
+		t.Fatalf("PutObject = %q, %v", etag, err)
+	}
+
+	_, err = c.PutObject(ctx, href, standupICS, true)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusPreconditionFailed {
+		t.Fatalf("second create error = %v, want 412", err)
+	}
+	if _, err := c.PutObject(ctx, href, standupICS, false); err != nil {
+		t.Fatalf("PutObject overwrite: %v", err)
+	}
+
+	obj, err := c.GetObject(ctx, href)
+	if err != nil || obj.Data != standupICS || obj.ETag != `"2"` {
+		t.Fatalf("GetObject = %+v, %v", obj, err)
+	}
+
+	if err := c.DeleteObject(ctx, href); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if _, err := c.GetObject(ctx, href); !errors.As(err, &statusErr) || statusErr.Code != http.StatusNotFound {
+		t.Fatalf("GetObject after delete error = %v, want 404", err)
+	}
+}
@@ -0,0 +1,79 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewClientRejectsPrivateHosts(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr error
+		anyErr  bool
+	}{
+		{url: "https://caldav.yandex.ru/"},
+		{url: "https://93.184.216.34/dav/"},
+		{url: "http://localhost:5232/", wantErr: ErrPrivateAddress},
+		{url: "http://api.localhost/", wantErr: ErrPrivateAddress},
+		{url: "http://127.0.0.1:5232/", wantErr: ErrPrivateAddress},
+		{url: "http://[::1]/", wantErr: ErrPrivateAddress},
+		{url: "http://10.0.0.5/", wantErr: ErrPrivateAddress},
+		{url: "http://192.168.1.10/", wantErr: ErrPrivateAddress},
+		{url: "http://169.254.169.254/latest/meta-data/", wantErr: ErrPrivateAddress},
+		{url: "http://[fd00::1]/", wantErr: ErrPrivateAddress},
+		{url: "http://[::ffff:127.0.0.1]/", wantErr: ErrPrivateAddress},
+		{url: "http://100.64.0.1/", wantErr: ErrPrivateAddress},
+		{url: "http://0.0.0.0/", wantErr: ErrPrivateAddress},
+		{url: "file:///etc/passwd", anyErr: true},
+		{url: "http:///no-host", anyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			_, err := NewClient(tt.url, "", "", false)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.anyErr:
+				if err == nil {
+					t.Fatal("NewClient succeeded, want error")
+				}
+			default:
+				if err != nil {
+					t.Fatalf("NewClient: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestClientDoesNotDialPrivateAddress(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("internal server was reached")
+	}))
+	defer internal.Close()
+
+	// every request of the client, including redirects and resolved names, is checked on dial
+	c, err := NewClient("http://public.example/", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, internal.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.http.Do(req); !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("error = %v, want ErrPrivateAddress", err)
+	}
+}
+
+func TestAllowPrivate(t *testing.T) {
+	if _, err := NewClient("http://127.0.0.1:5232/", "", "", true); err != nil {
+		t.Fatalf("NewClient with allowPrivate: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package caldav
+
+import (
+	"strings"
+)
+
+const propfindDiscovery = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:a="http://apple.com/ns/ical/">
+  <d:prop>
+    <d:current-user-principal/>
+    <d:resourcetype/>
+    <d:displayname/>
+    <c:calendar-home-set/>
+    <a:calendar-color/>
+  </d:prop>
+</d:propfind>`
+
+const propfindHomeSet = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <c:calendar-home-set/>
+  </d:prop>
+</d:propfind>`
+
+const propfindCalendars = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:a="http://apple.com/ns/ical/">
+  <d:prop>
+    <d:resourcetype/>
+    <d:displayname/>
+    <a:calendar-color/>
+    <c:supported-calendar-component-set/>
+  </d:prop>
+</d:propfind>`
+
+// reportCalendarQuery takes time-range start and end in UTC basic format
+const reportCalendarQuery = `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:getetag/>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="%s" end="%s"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+type multistatus struct {
+	Responses []response `xml:"DAV: response"`
+}
+
+type response struct {
+	Href      string     `xml:"DAV: href"`
+	Propstats []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Prop   prop   `xml:"DAV: prop"`
+	Status string `xml:"DAV: status"`
+}
+
+type prop struct {
+	DisplayName          string       `xml:"DAV: displayname"`
+	ResourceType         resourceType `xml:"DAV: resourcetype"`
+	CurrentUserPrincipal hrefProp     `xml:"DAV: current-user-principal"`
+	CalendarHomeSet      hrefProp     `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+	CalendarColor        string       `xml:"http://apple.com/ns/ical/ calendar-color"`
+	GetETag              string       `xml:"DAV: getetag"`
+	CalendarData         string       `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+	SupportedComponents  struct {
+		Comps []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"urn:ietf:params:xml:ns:caldav comp"`
+	} `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-component-set"`
+}
+
+type resourceType struct {
+	Collection *struct{} `xml:"DAV: collection"`
+	Calendar   *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+}
+
+type hrefProp struct {
+	Href string `xml:"DAV: href"`
+}
+
+// okProp merges props of propstats with 2xx status
+func (r response) okProp() prop {
+	var merged prop
+	for _, ps := range r.Propstats {
+		if ps.Status != "" && !strings.Contains(ps.Status, " 2") {
+			continue
+		}
+		p := ps.Prop
+		if p.DisplayName != "" {
+			merged.DisplayName = p.DisplayName
+		}
+		if p.ResourceType.Calendar != nil {
+			merged.ResourceType.Calendar = p.ResourceType.Calendar
+		}
+		if p.ResourceType.Collection != nil {
+			merged.ResourceType.Collection = p.ResourceType.Collection
+		}
+		if p.CurrentUserPrincipal.Href != "" {
+			merged.CurrentUserPrincipal = p.CurrentUserPrincipal
+		}
+		if p.CalendarHomeSet.Href != "" {
+			merged.CalendarHomeSet = p.CalendarHomeSet
+		}
+		if p.CalendarColor != "" {
+			merged.CalendarColor = p.CalendarColor
+		}
+		if p.GetETag != "" {
+			merged.GetETag = p.GetETag
+		}
+		if p.CalendarData != "" {
+			merged.CalendarData = p.CalendarData
+		}
+		if len(p.SupportedComponents.Comps) > 0 {
+			merged.SupportedComponents = p.SupportedComponents
+		}
+	}
+	return merged
+}
+
+// supportsEvents is true when server did not restrict components or allowed VEVENT
+func (p prop) supportsEvents() bool {
+	if len(p.SupportedComponents.Comps) == 0 {
+		return true
+	}
+	for _, c := range p.SupportedComponents.Comps {
+		if strings.EqualFold(c.Name, "VEVENT") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package calsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"log"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+const (
+	// mirror covers instances from syncWindowPast ago to syncWindowFuture ahead,
+	// other ranges are read from Google directly
+	syncWindowPast   = 365 * 24 * time.Hour
+	syncWindowFuture = 2 * 365 * 24 * time.Hour
+	// incremental sync keeps the window of the last full sync, it is moved forward by full resync
+	// once its end is this close to the wanted one
+	syncWindowSlide = 30 * 24 * time.Hour
+)
+
+// EventSource is Google side of sync, implemented by storage.GoogleCalendarStorage
+type EventSource interface {
+	IsAuthorized(ctx context.Context) bool
+	GetUserCalendars(ctx context.Context) ([]*calendar.CalendarListEntry, error)
+	SyncEvents(ctx context.Context, calendarID, syncToken string, windowStart, windowEnd time.Time) ([]*calendar.Event, string, error)
+	CreateEventWithID(ctx context.Context, calendarID, eventID string, event models.EventRequest) (*models.CalendarEvent, error)
+}
+
+// Mirror is local copy of calendars, implemented by storage.EventMirrorStorage
+type Mirror interface {
+	GetSyncState(ctx context.Context, calendarID string) (models.SyncState, bool, error)
+	SaveSyncState(ctx context.Context, state *models.SyncState) error
+	ReplaceEvents(ctx context.Context, calendarID string, events []*calendar.Event) error
+	ApplyChanges(ctx context.Context, calendarID string, events []*calendar.Event) error
+}
+
+// LocalEvents are events created while Google was not connected, implemented by storage.LocalCalendarStorage
+type LocalEvents interface {
+	PendingPush(ctx context.Context, timeMin, timeMax time.Time) ([]*models.EventRequest, error)
+	MarkPushed(ctx context.Context, id int, externalID string) error
+}
+
+// Syncer mirrors selected Google calendars into Postgres using incremental sync tokens,
+// SyncAll is run periodically by the job scheduler
+type Syncer struct {
+	calendarStorage EventSource
+	mirror          Mirror
+	local           LocalEvents
+	location        *time.Location // all-day events start at midnight here, as in the mirror
+}
+
+func NewSyncer(cs EventSource, mirror Mirror, local LocalEvents, location *time.Location) *Syncer {
+	return &Syncer{
+		calendarStorage: cs,
+		mirror:          mirror,
+		local:           local,
+		location:        location,
+	}
+}
+
+// SyncAll syncs every selected calendar of the user from context
+func (s *Syncer) SyncAll(ctx context.Context) error {
+	op := "internal/calsync/syncer.go SyncAll"
+
+	if !s.calendarStorage.IsAuthorized(ctx) {
+		return nil
+	}
+
+	if err := s.PushLocalEvents(ctx); err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+
+	calendars, err := s.calendarStorage.GetUserCalendars(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to load calendars: %w", op, err)
+	}
+
+	var failed int
+	for _, cal := range calendars {
+		if !cal.Selected && !cal.Primary {
+			continue
+		}
+		if err := s.SyncCalendar(ctx, cal); err != nil {
+			log.Printf("%s: calendar %s: %v", op, cal.Id, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s: %d calendars failed to sync", op, failed)
+	}
+	return nil
+}
+
+// SyncCalendar pulls changes for one calendar of the list, on expired token or outdated window it drops the mirror
+// and resyncs from scratch. Color of the calendar is saved with sync state
+func (s *Syncer) SyncCalendar(ctx context.Context, cal *calendar.CalendarListEntry) error {
+	calendarID := cal.Id
+	state, _, err := s.mirror.GetSyncState(ctx, calendarID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	windowStart, windowEnd := now.Add(-syncWindowPast), now.Add(syncWindowFuture)
+	if state.WindowStart == nil || state.WindowEnd == nil || state.WindowEnd.Before(windowEnd.Add(-syncWindowSlide)) {
+		state.SyncToken = ""
+	}
+	if state.SyncToken != "" {
+		windowStart, windowEnd = *state.WindowStart, *state.WindowEnd
+	}
+
+	events, nextToken, err := s.calendarStorage.SyncEvents(ctx, calendarID, state.SyncToken, windowStart, windowEnd)
+	if errors.Is(err, storage.ErrSyncTokenExpired) {
+		log.Printf("Sync token for %s expired, full resync", calendarID)
+		state.SyncToken = ""
+		windowStart, windowEnd = now.Add(-syncWindowPast), now.Add(syncWindowFuture)
+		events, nextToken, err = s.calendarStorage.SyncEvents(ctx, calendarID, "", windowStart, windowEnd)
+	}
+	if err != nil {
+		return err
+	}
+
+	if state.SyncToken == "" {
+		err = s.mirror.ReplaceEvents(ctx, calendarID, events)
+	} else {
+		err = s.mirror.ApplyChanges(ctx, calendarID, s.clipToWindow(events, windowStart, windowEnd))
+	}
+	if err != nil {
+		return err
+	}
+
+	newState := models.SyncState{
+		CalendarID:   calendarID,
+		IsPrimary:    cal.Primary,
+		SyncToken:    nextToken,
+		LastSyncedAt: &now,
+		WindowStart:  &windowStart,
+		WindowEnd:    &windowEnd,
+		Color:        cal.BackgroundColor,
+	}
+	if err := s.mirror.SaveSyncState(ctx, &newState); err != nil {
+		return err
+	}
+
+	log.Printf("Calendar %s synced: %d changes", calendarID, len(events))
+	return nil
+}
+
+// clipToWindow turns changes that moved outside the window into cancellations, so the mirror drops
+// the old copy instead of keeping it. Events with unknown bounds are kept, mirror skips them itself
+func (s *Syncer) clipToWindow(events []*calendar.Event, windowStart, windowEnd time.Time) []*calendar.Event {
+	clipped := make([]*calendar.Event, 0, len(events))
+	for _, event := range events {
+		if event.Status != "cancelled" {
+			start, end, err := storage.EventBounds(event, s.location)
+			if err == nil && (!start.Before(windowEnd) || !end.After(windowStart)) {
+				event = &calendar.Event{Id: event.Id, Status: "cancelled"}
+			}
+		}
+		clipped = append(clipped, event)
+	}
+	return clipped
+}
+
+// PushLocalEvents copies events created while Google was not connected into primary calendar.
+// Only events inside the sync window are pushed, the rest stay local. Google id of pushed event
+// is derived from the local one, so event whose MarkPushed failed is not duplicated by the next run
+func (s *Syncer) PushLocalEvents(ctx context.Context) error {
+	op := "internal/calsync/syncer.go PushLocalEvents"
+
+	now := time.Now().UTC()
+	events, err := s.local.PendingPush(ctx, now.Add(-syncWindowPast), now.Add(syncWindowFuture))
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, event := range events {
+		created, err := s.calendarStorage.CreateEventWithID(ctx, "primary", pushedEventID(models.UserID(ctx), event.ID), *event)
+		if err != nil {
+			log.Printf("%s: event %d: %v", op, event.ID, err)
+			failed++
+			continue
+		}
+		if err := s.local.MarkPushed(ctx, event.ID, created.ID); err != nil {
+			return err
+		}
+	}
+
+	if len(events) > 0 {
+		log.Printf("Local events pushed to Google: %d of %d", len(events)-failed, len(events))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s: %d events failed to push", op, failed)
+	}
+	return nil
+}
+
+// pushedEventID is Google id of pushed local event, Google allows only letters a-v and digits in it
+func pushedEventID(userID, localID int) string {
+	return "lifeforge" + strconv.Itoa(userID) + "u" + strconv.Itoa(localID)
+}
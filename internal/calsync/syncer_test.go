@@ -0,0 +1,329 @@
+package calsync
+
+import (
+	"context"
+	"errors"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+type syncCall struct {
+	token                  string
+	windowStart, windowEnd time.Time
+}
+
+type fakeSource struct {
+	calendars []*calendar.CalendarListEntry
+	calls     []syncCall
+	// titles of created events by id, inserts counts ids that were new
+	created map[string]string
+	inserts int
+	// answers by sync token, "" is full sync
+	answers map[string][]*calendar.Event
+	next    map[string]string
+	expired map[string]bool
+}
+
+func (f *fakeSource) IsAuthorized(ctx context.Context) bool { return true }
+
+func (f *fakeSource) GetUserCalendars(ctx context.Context) ([]*calendar.CalendarListEntry, error) {
+	return f.calendars, nil
+}
+
+func (f *fakeSource) SyncEvents(ctx context.Context, calendarID, syncToken string, windowStart, windowEnd time.Time) ([]*calendar.Event, string, error) {
+	f.calls = append(f.calls, syncCall{token: syncToken, windowStart: windowStart, windowEnd: windowEnd})
+	if f.expired[syncToken] {
+		return nil, "", storage.ErrSyncTokenExpired
+	}
+	return f.answers[syncToken], f.next[syncToken], nil
+}
+
+// CreateEventWithID returns already created event for taken id, as GoogleCalendarStorage does
+func (f *fakeSource) CreateEventWithID(ctx context.Context, calendarID, eventID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	if f.created == nil {
+		f.created = make(map[string]string)
+	}
+	if _, ok := f.created[eventID]; !ok {
+		f.created[eventID] = event.Title
+		f.inserts++
+	}
+	return &models.CalendarEvent{ID: eventID, Summary: f.created[eventID]}, nil
+}
+
+type fakeMirror struct {
+	state    *models.SyncState
+	replaced []*calendar.Event
+	applied  []*calendar.Event
+	saved    *models.SyncState
+}
+
+func (m *fakeMirror) GetSyncState(ctx context.Context, calendarID string) (models.SyncState, bool, error) {
+	if m.state == nil {
+		return models.SyncState{CalendarID: calendarID}, false, nil
+	}
+	return *m.state, true, nil
+}
+
+func (m *fakeMirror) SaveSyncState(ctx context.Context, state *models.SyncState) error {
+	m.saved = state
+	return nil
+}
+
+func (m *fakeMirror) ReplaceEvents(ctx context.Context, calendarID string, events []*calendar.Event) error {
+	m.replaced = events
+	return nil
+}
+
+func (m *fakeMirror) ApplyChanges(ctx context.Context, calendarID string, events []*calendar.Event) error {
+	m.applied = events
+	return nil
+}
+
+var primaryCalendar = &calendar.CalendarListEntry{Id: "me@example.com", Primary: true, BackgroundColor: "#039be5"}
+
+type noLocalEvents struct{}
+
+func (noLocalEvents) PendingPush(ctx context.Context, timeMin, timeMax time.Time) ([]*models.EventRequest, error) {
+	return nil, nil
+}
+func (noLocalEvents) MarkPushed(ctx context.Context, id int, externalID string) error { return nil }
+
+// fakeLocal keeps events until they are marked pushed, marking fails once for ids in failMark
+type fakeLocal struct {
+	pending          []*models.EventRequest
+	pushed           map[int]string
+	failMark         map[int]bool
+	timeMin, timeMax time.Time
+}
+
+func (l *fakeLocal) PendingPush(ctx context.Context, timeMin, timeMax time.Time) ([]*models.EventRequest, error) {
+	l.timeMin, l.timeMax = timeMin, timeMax
+	var events []*models.EventRequest
+	for _, e := range l.pending {
+		if _, ok := l.pushed[e.ID]; !ok {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (l *fakeLocal) MarkPushed(ctx context.Context, id int, externalID string) error {
+	if l.failMark[id] {
+		delete(l.failMark, id)
+		return errors.New("connection reset")
+	}
+	l.pushed[id] = externalID
+	return nil
+}
+
+func TestSyncCalendar(t *testing.T) {
+	now := time.Now().UTC()
+	freshStart, freshEnd := now.Add(-syncWindowPast), now.Add(syncWindowFuture)
+	oldStart, oldEnd := freshStart.Add(-time.Hour), freshEnd.Add(-time.Hour)
+	staleEnd := freshEnd.Add(-2 * syncWindowSlide)
+
+	full := []*calendar.Event{{Id: "a"}, {Id: "b"}}
+	changes := []*calendar.Event{{Id: "a", Status: "cancelled"}}
+
+	tests := []struct {
+		name        string
+		state       *models.SyncState
+		expired     bool
+		wantTokens  []string
+		wantReplace bool
+		wantWindow  [2]time.Time // zero means fresh window
+		wantToken   string
+	}{
+		{
+			name:        "first sync is full",
+			wantTokens:  []string{""},
+			wantReplace: true,
+			wantToken:   "t1",
+		},
+		{
+			name:       "incremental sync keeps window",
+			state:      &models.SyncState{SyncToken: "t0", WindowStart: &oldStart, WindowEnd: &oldEnd},
+			wantTokens: []string{"t0"},
+			wantWindow: [2]time.Time{oldStart, oldEnd},
+			wantToken:  "t1",
+		},
+		{
+			name:        "410 gone drops mirror and resyncs",
+			state:       &models.SyncState{SyncToken: "t0", WindowStart: &oldStart, WindowEnd: &oldEnd},
+			expired:     true,
+			wantTokens:  []string{"t0", ""},
+			wantReplace: true,
+			wantToken:   "t1",
+		},
+		{
+			name:        "state without window is resynced",
+			state:       &models.SyncState{SyncToken: "t0"},
+			wantTokens:  []string{""},
+			wantReplace: true,
+			wantToken:   "t1",
+		},
+		{
+			name:        "outdated window slides by full sync",
+			state:       &models.SyncState{SyncToken: "t0", WindowStart: &oldStart, WindowEnd: &staleEnd},
+			wantTokens:  []string{""},
+			wantReplace: true,
+			wantToken:   "t1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeSource{
+				answers: map[string][]*calendar.Event{"": full, "t0": changes},
+				next:    map[string]string{"": "t1", "t0": "t1"},
+				expired: map[string]bool{"t0": tt.expired},
+			}
+			mirror := &fakeMirror{state: tt.state}
+			s := NewSyncer(source, mirror, noLocalEvents{}, time.UTC)
+
+			if err := s.SyncCalendar(context.Background(), primaryCalendar); err != nil {
+				t.Fatalf("SyncCalendar: %v", err)
+			}
+
+			if len(source.calls) != len(tt.wantTokens) {
+				t.Fatalf("sync calls = %+v, want tokens %v", source.calls, tt.wantTokens)
+			}
+			for i, token := range tt.wantTokens {
+				if source.calls[i].token != token {
+					t.Fatalf("call %d token = %q, want %q", i, source.calls[i].token, token)
+				}
+			}
+
+			if tt.wantReplace {
+				if len(mirror.replaced) != len(full) || mirror.applied != nil {
+					t.Fatalf("replaced = %d, applied = %d, want full replace", len(mirror.replaced), len(mirror.applied))
+				}
+			} else if len(mirror.applied) != len(changes) || mirror.replaced != nil {
+				t.Fatalf("replaced = %d, applied = %d, want incremental changes", len(mirror.replaced), len(mirror.applied))
+			}
+
+			last := source.calls[len(source.calls)-1]
+			if !tt.wantWindow[0].IsZero() {
+				if !last.windowStart.Equal(tt.wantWindow[0]) || !last.windowEnd.Equal(tt.wantWindow[1]) {
+					t.Fatalf("window = %v..%v, want %v..%v", last.windowStart, last.windowEnd, tt.wantWindow[0], tt.wantWindow[1])
+				}
+			} else if last.windowStart.Sub(freshStart).Abs() > time.Minute || last.windowEnd.Sub(freshEnd).Abs() > time.Minute {
+				t.Fatalf("window = %v..%v, want fresh %v..%v", last.windowStart, last.windowEnd, freshStart, freshEnd)
+			}
+
+			saved := mirror.saved
+			if saved == nil || saved.SyncToken != tt.wantToken || !saved.IsPrimary || saved.LastSyncedAt == nil || saved.Color != primaryCalendar.BackgroundColor {
+				t.Fatalf("saved state = %+v, want token %s and calendar color", saved, tt.wantToken)
+			}
+			if !saved.WindowStart.Equal(last.windowStart) || !saved.WindowEnd.Equal(last.windowEnd) {
+				t.Fatalf("saved window = %v..%v, want %v..%v", saved.WindowStart, saved.WindowEnd, last.windowStart, last.windowEnd)
+			}
+		})
+	}
+}
+
+func TestSyncAllSkipsUnselected(t *testing.T) {
+	source := &fakeSource{
+		calendars: []*calendar.CalendarListEntry{
+			{Id: "me@example.com", Primary: true},
+			{Id: "team", Selected: true},
+			{Id: "holidays"},
+		},
+		next: map[string]string{"": "t1"},
+	}
+
+	if err := NewSyncer(source, &fakeMirror{}, noLocalEvents{}, time.UTC).SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll: %v", err)
+	}
+	if len(source.calls) != 2 {
+		t.Fatalf("sync calls = %d, want primary and selected calendars", len(source.calls))
+	}
+}
+
+func TestSyncCalendarDropsEventsMovedOutOfWindow(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	windowStart := time.Now().UTC().Add(-syncWindowPast).Truncate(time.Hour)
+	// local midnight, so all-day event of that day starts exactly at the end
+	windowEnd := time.Date(2100, 1, 1, 0, 0, 0, 0, moscow)
+
+	timed := func(id string, start time.Time) *calendar.Event {
+		return &calendar.Event{
+			Id:    id,
+			Start: &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:   &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+		}
+	}
+	inside := windowStart.Add(48 * time.Hour)
+
+	source := &fakeSource{
+		answers: map[string][]*calendar.Event{"t0": {
+			timed("kept", inside),
+			timed("moved-to-past", windowStart.Add(-48*time.Hour)),
+			timed("moved-to-future", windowEnd.Add(time.Hour)),
+			{Id: "all-day-after-end", Start: &calendar.EventDateTime{Date: "2100-01-01"}, End: &calendar.EventDateTime{Date: "2100-01-02"}},
+			{Id: "deleted", Status: "cancelled"},
+			{Id: "no-dates"},
+		}},
+		next: map[string]string{"t0": "t1"},
+	}
+	mirror := &fakeMirror{state: &models.SyncState{SyncToken: "t0", WindowStart: &windowStart, WindowEnd: &windowEnd}}
+
+	if err := NewSyncer(source, mirror, noLocalEvents{}, moscow).SyncCalendar(context.Background(), primaryCalendar); err != nil {
+		t.Fatalf("SyncCalendar: %v", err)
+	}
+
+	want := map[string]string{
+		"kept":              "",
+		"moved-to-past":     "cancelled",
+		"moved-to-future":   "cancelled",
+		"all-day-after-end": "cancelled",
+		"deleted":           "cancelled",
+		"no-dates":          "",
+	}
+	if len(mirror.applied) != len(want) {
+		t.Fatalf("applied = %d changes, want %d", len(mirror.applied), len(want))
+	}
+	for _, event := range mirror.applied {
+		if event.Status != want[event.Id] {
+			t.Errorf("event %s status = %q, want %q", event.Id, event.Status, want[event.Id])
+		}
+	}
+}
+
+func TestPushLocalEventsRetryDoesNotDuplicate(t *testing.T) {
+	source := &fakeSource{}
+	local := &fakeLocal{
+		pending:  []*models.EventRequest{{ID: 3, Title: "Dentist"}, {ID: 4, Title: "Gym"}},
+		pushed:   make(map[int]string),
+		failMark: map[int]bool{3: true},
+	}
+	s := NewSyncer(source, &fakeMirror{}, local, time.UTC)
+	ctx := models.WithUserID(context.Background(), 1)
+
+	// event 3 is created in Google, but the link to it is not saved
+	if err := s.PushLocalEvents(ctx); err == nil {
+		t.Fatal("PushLocalEvents succeeded, want MarkPushed error")
+	}
+	if err := s.PushLocalEvents(ctx); err != nil {
+		t.Fatalf("PushLocalEvents: %v", err)
+	}
+
+	if source.inserts != 2 {
+		t.Fatalf("events created in Google = %d (%v), want 2", source.inserts, source.created)
+	}
+	want := map[int]string{3: "lifeforge1u3", 4: "lifeforge1u4"}
+	for id, externalID := range want {
+		if local.pushed[id] != externalID {
+			t.Fatalf("pushed = %v, want %v", local.pushed, want)
+		}
+	}
+
+	now := time.Now().UTC()
+	if local.timeMin.Sub(now.Add(-syncWindowPast)).Abs() > time.Minute || local.timeMax.Sub(now.Add(syncWindowFuture)).Abs() > time.Minute {
+		t.Fatalf("pending events asked for %v..%v, want sync window", local.timeMin, local.timeMax)
+	}
+}
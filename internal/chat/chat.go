@@ -0,0 +1,392 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/ai"
+	"life_forge/internal/goals"
+	"life_forge/internal/habits"
+	"life_forge/internal/journal"
+	"life_forge/internal/models"
+	"life_forge/internal/review"
+	"life_forge/internal/storage"
+	"life_forge/internal/tasks"
+	"life_forge/internal/timetrack"
+	"life_forge/internal/usecases"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	saveWorkers      = 5
+	saveTimeout      = 5 * time.Second
+	previewDays      = 5
+	reviewFailedMsg  = "Не удалось подвести итоги недели, попробуйте позже."
+	planFailedMsg    = "Не удалось составить план цели, попробуйте переформулировать."
+	entryEmptyMsg    = "Что записать в дневник? Например: «запиши в дневник: сегодня был спокойный день»."
+	entryFailedMsg   = "Не удалось сохранить запись в дневник, попробуйте позже."
+	reflectFailedMsg = "Не удалось подготовить рефлексию, попробуйте позже."
+	timerFailedMsg   = "Не удалось запустить таймер, попробуйте позже."
+	noTimerMsg       = "Таймер не запущен."
+	reportFailedMsg  = "Не удалось сравнить план и факт, попробуйте позже."
+	reauthMsg        = "⚠️ Google отозвал доступ к календарю. Подключите его заново: /auth/google"
+)
+
+// Reply is answer of assistant for any front end (web chat, Telegram)
+type Reply struct {
+	Text            string
+	Requests        []*models.EventRequest // events model asked to create
+	Created         []*models.CalendarEvent
+	IsReview        bool
+	Review          *models.WeeklyReview // nil if review failed
+	CalendarPreview bool
+	Context         *models.Context  // goals and progress after model updates, nil if nothing changed
+	Plan            *models.GoalPlan // drafted goal plan waiting for acceptance, nil if not a planning request
+	Tracked         []*models.Habit  // recurring events registered as habits
+	CheckIns        []*models.Habit  // habits checked in from the message, with fresh stats
+	Entry           *models.JournalEntry
+	Reflection      *models.JournalReflection
+	Tasks           []*models.Task    // to-dos the model found in the message, events go to Created
+	TimeEntry       *models.TimeEntry // started or stopped timer
+	PlanReport      *models.PlanActualReport
+	ReauthRequired  bool // Google access was revoked, Text ends with prompt to authorize again
+}
+
+// Assistant is the chat pipeline: calendar prompt, model answer, event creation
+type Assistant struct {
+	contextStorage   *storage.ContextStorage
+	aiClient         *ai.GigaChatClient
+	calendarProvider storage.CalendarProvider
+	eventStorage     *storage.EventStorage
+	reviewer         *review.Reviewer
+	planner          *goals.Planner
+	tracker          *habits.Tracker
+	journal          *journal.Journal
+	taskManager      *tasks.Manager
+	timesheet        *timetrack.Timesheet
+	googleAuth       *storage.GoogleCalendarStorage
+}
+
+func NewAssistant(contextStorage *storage.ContextStorage, aiClient *ai.GigaChatClient, calendarProvider storage.CalendarProvider, eventStorage *storage.EventStorage, reviewer *review.Reviewer, planner *goals.Planner, tracker *habits.Tracker, diary *journal.Journal, taskManager *tasks.Manager, timesheet *timetrack.Timesheet, googleAuth *storage.GoogleCalendarStorage) *Assistant {
+	return &Assistant{
+		contextStorage:   contextStorage,
+		aiClient:         aiClient,
+		calendarProvider: calendarProvider,
+		eventStorage:     eventStorage,
+		reviewer:         reviewer,
+		planner:          planner,
+		tracker:          tracker,
+		journal:          diary,
+		taskManager:      taskManager,
+		timesheet:        timesheet,
+		googleAuth:       googleAuth,
+	}
+}
+
+// Reply answers user message, error means model did not answer at all
+func (a *Assistant) Reply(ctx context.Context, message string) (*Reply, error) {
+	reply, err := a.reply(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// without Google answers come from local calendar, user has to know why
+	if a.googleAuth != nil {
+		status, err := a.googleAuth.AuthStatus(ctx)
+		if err != nil {
+			log.Printf("internal/chat/chat.go Reply: %v", err)
+		} else if status.ReauthRequired {
+			reply.ReauthRequired = true
+			reply.Text = strings.TrimSpace(reply.Text + "\n\n" + reauthMsg)
+		}
+	}
+	return reply, nil
+}
+
+func (a *Assistant) reply(ctx context.Context, message string) (*Reply, error) {
+	op := "internal/chat/chat.go Reply"
+
+	if review.IsReviewRequest(message) {
+		return a.weeklyReview(ctx), nil
+	}
+	if text, ok := journal.ParseJournalRequest(message); ok {
+		return a.journalEntry(ctx, text), nil
+	}
+	if journal.IsReflectionRequest(message) {
+		return a.reflection(ctx), nil
+	}
+	if action, title, ok := timetrack.ParseTimerCommand(message); ok {
+		return a.timer(ctx, action, title), nil
+	}
+	if week, ok := timetrack.ParseReportRequest(message); ok {
+		return a.planReport(ctx, week), nil
+	}
+	if goals.IsPlanRequest(message) {
+		return a.goalPlan(ctx, message), nil
+	}
+
+	calendarData := storage.CalendarPreview(ctx, a.calendarProvider, previewDays)
+	log.Printf("📅 Calendar data: %d symbols", len(calendarData))
+
+	userContext, err := a.contextStorage.GetContextByID(ctx, models.UserID(ctx))
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+	habitList, err := a.tracker.Habits(ctx, false)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+
+	now := time.Now()
+	timeContext := fmt.Sprintf("ВНИМАНИЕ! Сегодня: %s. Завтра: %s. Текущее время: %s. Все даты в JSON должны вычисляться относительно сегодня, используй часовой пояс +03:00 вместо Z!",
+		now.Format("2006-01-02"),
+		now.AddDate(0, 0, 1).Format("2006-01-02"),
+		now.Format("15:04"))
+	//запрос от пользователя (вместе с базовым промтом)
+	promt_calendar := fmt.Sprintf("%s\n%s\n%s\n%s \n запрос от пользователя:%s\n %s", storage.PROMT_CALENDAR, storage.PROMT_TASKS, contextPrompt(userContext, habitList), calendarData, message, timeContext)
+
+	response, err := a.aiClient.Generate(ctx, promt_calendar)
+	if err != nil {
+		log.Printf("%s: AI calendar error: %v", op, err)
+		return nil, fmt.Errorf("%s: AI error: %w", op, err)
+	}
+
+	log.Printf("Answer from AI: %d symbols", len(response))
+
+	checkins := usecases.ParseHabitUpdates(response)
+	response, updates := usecases.ParseAIResponse(response)
+	response, taskRequests := usecases.ParseTaskAIResponse(response)
+	answer, events, err := usecases.ParseCalendarAIResponse(response)
+	if err != nil {
+		log.Printf("%s: parse calendar response error: %v", op, err)
+	}
+
+	log.Printf("Parsing events: %d events", len(events))
+
+	created := a.saveEvents(ctx, events)
+	reply := &Reply{
+		Text:            answer,
+		Requests:        events,
+		Created:         []*models.CalendarEvent{},
+		CalendarPreview: len(calendarData) > 50,
+	}
+	if len(taskRequests) > 0 {
+		reply.Tasks = a.taskManager.CreateFromChat(ctx, taskRequests)
+	}
+	for i, e := range created {
+		if e == nil {
+			continue
+		}
+		reply.Created = append(reply.Created, e)
+		if r := events[i].Recurrence; r != nil && habits.Trackable(*r) {
+			habit, err := a.tracker.Track(ctx, e, *r)
+			if err != nil {
+				log.Printf("%s: track habit %q: %v", op, e.Summary, err)
+				continue
+			}
+			reply.Tracked = append(reply.Tracked, habit)
+		}
+	}
+
+	// context is not rewritten if it failed to load, model updates would wipe saved goals
+	if merged, changed := usecases.MergeContext(userContext, updates); changed && userContext.ID != 0 {
+		if err := a.contextStorage.SaveContext(ctx, &merged); err != nil {
+			log.Printf("%s: %v", op, err)
+		} else {
+			reply.Context = &merged
+		}
+	}
+
+	// after context is saved: check-ins write habit progress into it
+	for title, status := range checkins {
+		habit, err := a.tracker.CheckInToday(ctx, title, status)
+		if err != nil {
+			log.Printf("%s: check in habit %q: %v", op, title, err)
+			continue
+		}
+		reply.CheckIns = append(reply.CheckIns, habit)
+	}
+
+	return reply, nil
+}
+
+// contextPrompt lists goals, progress, habits and recent actions for the model
+func contextPrompt(userContext models.Context, habitList []*models.Habit) string {
+	var b strings.Builder
+	b.WriteString(storage.PROMT_CONTEXT)
+
+	b.WriteString("\n## Текущие цели:\n")
+	if len(userContext.Goals) == 0 {
+		b.WriteString("- не заданы\n")
+	}
+	for _, goal := range userContext.Goals {
+		if progress, ok := userContext.Progress[goal]; ok {
+			fmt.Fprintf(&b, "- %s (прогресс: %s)\n", goal, progress)
+		} else {
+			fmt.Fprintf(&b, "- %s\n", goal)
+		}
+	}
+
+	if len(habitList) > 0 {
+		b.WriteString("\n## Привычки:\n")
+		for _, h := range habitList {
+			fmt.Fprintf(&b, "- %s (%s, %s)\n", h.Title, h.Recurrence, usecases.HabitProgress(h.Stats))
+		}
+	}
+
+	if len(userContext.Recent5) > 0 {
+		b.WriteString("\n## Недавние действия:\n")
+		for _, action := range userContext.Recent5 {
+			fmt.Fprintf(&b, "- %s\n", action)
+		}
+	}
+	return b.String()
+}
+
+// weeklyReview handles "подведи итоги недели" without calendar prompt
+func (a *Assistant) weeklyReview(ctx context.Context) *Reply {
+	op := "internal/chat/chat.go weeklyReview"
+
+	weekly, err := a.reviewer.GenerateWeekly(ctx, time.Now())
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return &Reply{Text: reviewFailedMsg, IsReview: true}
+	}
+	return &Reply{Text: review.Format(weekly), IsReview: true, Review: weekly}
+}
+
+// goalPlan drafts plan for goal, nothing is created until user accepts it
+func (a *Assistant) goalPlan(ctx context.Context, message string) *Reply {
+	op := "internal/chat/chat.go goalPlan"
+
+	plan, err := a.planner.Draft(ctx, message)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return &Reply{Text: planFailedMsg}
+	}
+	return &Reply{Text: goals.FormatPlan(plan), Plan: plan}
+}
+
+// journalEntry saves "запиши в дневник: ..." with mood scored by the model
+func (a *Assistant) journalEntry(ctx context.Context, text string) *Reply {
+	op := "internal/chat/chat.go journalEntry"
+
+	if text == "" {
+		return &Reply{Text: entryEmptyMsg}
+	}
+	entry, err := a.journal.Write(ctx, text, nil, models.JournalSourceChat)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return &Reply{Text: entryFailedMsg}
+	}
+	return &Reply{Text: journal.FormatEntry(entry), Entry: entry}
+}
+
+// reflection looks at mood of the last week against calendar load
+func (a *Assistant) reflection(ctx context.Context) *Reply {
+	op := "internal/chat/chat.go reflection"
+
+	reflection, err := a.journal.Reflect(ctx, time.Now())
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return &Reply{Text: reflectFailedMsg}
+	}
+	return &Reply{Text: journal.FormatReflection(reflection), Reflection: reflection}
+}
+
+// timer starts or stops time tracking, started timer is linked to event going on now
+func (a *Assistant) timer(ctx context.Context, action, title string) *Reply {
+	op := "internal/chat/chat.go timer"
+
+	if action == "stop" {
+		entry, err := a.timesheet.Stop(ctx)
+		if errors.Is(err, storage.ErrNoRunningTimer) {
+			return &Reply{Text: noTimerMsg}
+		}
+		if err != nil {
+			log.Printf("%s: %v", op, err)
+			return &Reply{Text: timerFailedMsg}
+		}
+		return &Reply{Text: timetrack.FormatEntry(entry), TimeEntry: entry}
+	}
+
+	entry, stopped, err := a.timesheet.Start(ctx, &models.TimeEntry{Title: title})
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return &Reply{Text: timerFailedMsg}
+	}
+	text := timetrack.FormatEntry(entry)
+	if stopped != nil {
+		text = timetrack.FormatEntry(stopped) + "\n" + text
+	}
+	return &Reply{Text: text, TimeEntry: entry}
+}
+
+// planReport compares plan with tracked time for today or the current week
+func (a *Assistant) planReport(ctx context.Context, week bool) *Reply {
+	op := "internal/chat/chat.go planReport"
+
+	from, to := a.timesheet.Today()
+	groupBy := models.PlanGroupCalendar
+	if week {
+		from, to = a.timesheet.Week()
+		groupBy = models.PlanGroupDay
+	}
+	report, err := a.timesheet.Report(ctx, from, to, groupBy)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return &Reply{Text: reportFailedMsg}
+	}
+	return &Reply{Text: timetrack.FormatReport(report), PlanReport: report}
+}
+
+// saveEvents creates events in parallel, result is aligned with events, nil where creation failed
+func (a *Assistant) saveEvents(ctx context.Context, events []*models.EventRequest) []*models.CalendarEvent {
+	workers := make(chan struct{}, saveWorkers)
+	created := make([]*models.CalendarEvent, len(events))
+	var wg sync.WaitGroup
+
+	for i, event := range events {
+		if !event.IsEvent || event.Title == "" {
+			continue
+		}
+
+		workers <- struct{}{}
+		wg.Add(1)
+
+		go func(i int, event *models.EventRequest) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			log.Printf("Event %d: %s", i+1, event.Title)
+			// saving outlives the request, but stays on behalf of its user
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveTimeout)
+			defer cancel()
+
+			createdEvent, err := a.calendarProvider.CreateEvent(ctx, "", *event)
+			if err != nil {
+				log.Printf("❌ Error to create event '%s': %v", event.Title, err)
+			} else {
+				log.Printf("✅ Event created: %s (ID: %s)", event.Title, createdEvent.ID)
+				created[i] = createdEvent
+				// local calendar already keeps event in events table
+				if createdEvent.CalendarID == storage.LocalCalendarID {
+					return
+				}
+			}
+
+			err = a.eventStorage.SaveEventInDB(ctx, event)
+			if err != nil {
+				log.Printf("❌ Error to save event in db '%s': %v", event.Title, err)
+			} else {
+				log.Printf("✅ Event saved in db: %s", event.Title)
+			}
+		}(i, event)
+	}
+	wg.Wait()
+	return created
+}
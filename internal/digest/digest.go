@@ -0,0 +1,392 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"life_forge/internal/ai"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/usecases"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// verification link works this long after it was sent
+const verifyLinkTTL = 24 * time.Hour
+
+var (
+	ErrDigestDisabled   = errors.New("digest is disabled")
+	ErrEmailNotVerified = errors.New("email is not verified")
+	// ErrSendTooOften is returned for on-demand send or verification mail within send interval of the previous one
+	ErrSendTooOften = errors.New("digest was sent recently, try again later")
+)
+
+// Store is digest settings and delivery history, implemented by storage.DigestStorage
+type Store interface {
+	GetSettings(ctx context.Context, userID int) (*models.DigestSettings, error)
+	SaveSettings(ctx context.Context, settings *models.DigestSettings) error
+	ListEnabled(ctx context.Context) ([]*models.DigestSettings, error)
+	ClaimDay(ctx context.Context, userID int, date time.Time) (bool, error)
+	ClaimManualSend(ctx context.Context, userID int, sentBefore time.Time) (bool, error)
+	CreateVerifyToken(ctx context.Context, userID int, email string, sentBefore time.Time) (string, error)
+	VerifyEmail(ctx context.Context, token string, sentAfter time.Time) (int, error)
+	SaveDelivery(ctx context.Context, delivery *models.DigestDelivery) error
+}
+
+// Sender delivers mail, implemented by Mailer
+type Sender interface {
+	Send(to, subject, text, html string) error
+}
+
+// Digester emails users their agenda of the day with a short AI summary
+type Digester struct {
+	aiClient         *ai.GigaChatClient
+	calendarProvider storage.CalendarProvider
+	contextStorage   *storage.ContextStorage
+	digestStorage    Store
+	mailer           Sender
+	location         *time.Location
+	publicURL        string        // verification links point here
+	sendInterval     time.Duration // minimal interval between mails a user can trigger
+}
+
+func NewDigester(aiClient *ai.GigaChatClient, cp storage.CalendarProvider, cs *storage.ContextStorage, ds Store, mailer Sender, location *time.Location, publicURL string, sendInterval time.Duration) *Digester {
+	return &Digester{
+		aiClient:         aiClient,
+		calendarProvider: cp,
+		contextStorage:   cs,
+		digestStorage:    ds,
+		mailer:           mailer,
+		location:         location,
+		publicURL:        publicURL,
+		sendInterval:     sendInterval,
+	}
+}
+
+// SaveSettings saves settings of the user and mails verification link to new email,
+// the link is not resent more often than send interval
+func (d *Digester) SaveSettings(ctx context.Context, settings *models.DigestSettings) error {
+	op := "internal/digest/digest.go SaveSettings"
+
+	if err := d.digestStorage.SaveSettings(ctx, settings); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if settings.Email == "" || settings.EmailVerified {
+		return nil
+	}
+
+	token, err := d.digestStorage.CreateVerifyToken(ctx, settings.UserID, settings.Email, time.Now().Add(-d.sendInterval))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if token == "" {
+		return nil
+	}
+
+	link := d.publicURL + "/api/digest/verify?token=" + url.QueryEscape(token)
+	text := fmt.Sprintf("Чтобы получать утреннюю сводку LifeForge на этот адрес, откройте ссылку:\n%s\n\nСсылка действует сутки. Если вы не настраивали сводку, просто удалите письмо.\n", link)
+	html, err := renderVerifyHTML(link)
+	if err != nil {
+		return fmt.Errorf("%s: failed to render mail: %w", op, err)
+	}
+	if err := d.mailer.Send(settings.Email, "Подтвердите адрес для утренней сводки", text, html); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// VerifyEmail confirms email by token from verification link
+func (d *Digester) VerifyEmail(ctx context.Context, token string) error {
+	op := "internal/digest/digest.go VerifyEmail"
+
+	if _, err := d.digestStorage.VerifyEmail(ctx, token, time.Now().Add(-verifyLinkTTL)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SendDue is run by the job scheduler every minute, it sends today's digest to every opted-in user whose send time is past and who did not get it yet
+func (d *Digester) SendDue(ctx context.Context, now time.Time) error {
+	op := "internal/digest/digest.go SendDue"
+
+	settings, err := d.digestStorage.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, s := range settings {
+		loc := d.userLocation(s)
+		day, ok := dueDay(s, now.In(loc))
+		if !ok {
+			continue
+		}
+
+		claimed, err := d.digestStorage.ClaimDay(ctx, s.UserID, day)
+		if err != nil {
+			log.Printf("%s: user %d: %v", op, s.UserID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if _, err := d.deliver(ctx, s, day); err != nil {
+			log.Printf("%s: user %d: %v", op, s.UserID, err)
+		}
+	}
+	return nil
+}
+
+// SendNow sends today's digest right away regardless of schedule, used to check settings.
+// It needs opt-in and verified email and works once per send interval
+func (d *Digester) SendNow(ctx context.Context, userID int) (*models.DigestDelivery, error) {
+	op := "internal/digest/digest.go SendNow"
+
+	s, err := d.digestStorage.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := optedIn(s); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	claimed, err := d.digestStorage.ClaimManualSend(ctx, userID, time.Now().Add(-d.sendInterval))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%s: %w", op, ErrSendTooOften)
+	}
+
+	now := time.Now().In(d.userLocation(s))
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return d.deliver(ctx, s, day)
+}
+
+// optedIn tells why digest of settings must not be sent, nil means it may
+func optedIn(s *models.DigestSettings) error {
+	if !s.Enabled || s.Email == "" {
+		return ErrDigestDisabled
+	}
+	if !s.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// dueDay returns local midnight of today if user opted in, send time has passed and today's digest was not sent
+func dueDay(s *models.DigestSettings, now time.Time) (time.Time, bool) {
+	if optedIn(s) != nil {
+		return time.Time{}, false
+	}
+	sendAt, err := time.Parse("15:04", s.SendTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if now.Before(day.Add(time.Duration(sendAt.Hour())*time.Hour + time.Duration(sendAt.Minute())*time.Minute)) {
+		return time.Time{}, false
+	}
+	if s.LastSentDate != nil && s.LastSentDate.Format("2006-01-02") >= day.Format("2006-01-02") {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+func (d *Digester) userLocation(s *models.DigestSettings) *time.Location {
+	if s.Timezone != "" {
+		if loc, err := time.LoadLocation(s.Timezone); err == nil {
+			return loc
+		}
+	}
+	return d.location
+}
+
+// deliver sends digest of day and records the attempt, failed attempts are recorded too
+func (d *Digester) deliver(ctx context.Context, s *models.DigestSettings, day time.Time) (*models.DigestDelivery, error) {
+	op := "internal/digest/digest.go deliver"
+
+	// calendars and context are read as the digest owner
+	ctx = models.WithUserID(ctx, s.UserID)
+	delivery := &models.DigestDelivery{
+		UserID:     s.UserID,
+		DigestDate: day,
+		Email:      s.Email,
+		Subject:    "Повестка на " + day.Format("02.01.2006"),
+	}
+
+	sendErr := d.send(ctx, s, day, delivery)
+	delivery.Status = models.DeliveryStatusSent
+	if sendErr != nil {
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.Error = sendErr.Error()
+	}
+
+	if err := d.digestStorage.SaveDelivery(ctx, delivery); err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+	if sendErr != nil {
+		return delivery, fmt.Errorf("%s: %w", op, sendErr)
+	}
+	return delivery, nil
+}
+
+func (d *Digester) send(ctx context.Context, s *models.DigestSettings, day time.Time, delivery *models.DigestDelivery) error {
+	events, _, err := d.calendarProvider.ListEvents(ctx, day, day.AddDate(0, 0, 1), s.Calendars...)
+	if err != nil {
+		return fmt.Errorf("failed to load events: %w", err)
+	}
+
+	agenda := make([]*models.CalendarEvent, 0, len(events))
+	for _, e := range events {
+		if e.Status != "cancelled" {
+			agenda = append(agenda, e)
+		}
+	}
+	sort.SliceStable(agenda, func(i, j int) bool {
+		if agenda[i].IsAllDay != agenda[j].IsAllDay {
+			return agenda[i].IsAllDay
+		}
+		return agenda[i].Start.Before(agenda[j].Start)
+	})
+	delivery.Events = len(agenda)
+
+	summary := d.summarize(ctx, agenda, day)
+	text := renderText(agenda, summary, day)
+	html, err := renderHTML(agenda, summary, day)
+	if err != nil {
+		return fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	return d.mailer.Send(s.Email, delivery.Subject, text, html)
+}
+
+// summarize asks model for summary and focus, nil means digest goes out with agenda only
+func (d *Digester) summarize(ctx context.Context, agenda []*models.CalendarEvent, day time.Time) *models.DigestSummary {
+	op := "internal/digest/digest.go summarize"
+
+	userContext, err := d.contextStorage.GetContextByID(ctx, models.UserID(ctx))
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+
+	var b strings.Builder
+	b.WriteString(storage.PROMT_DIGEST)
+	fmt.Fprintf(&b, "\n## Дата: %s\n", day.Format("Mon 02.01.2006"))
+
+	b.WriteString("\n## Цели:\n")
+	if len(userContext.Goals) == 0 {
+		b.WriteString("- не заданы\n")
+	}
+	for _, goal := range userContext.Goals {
+		fmt.Fprintf(&b, "- %s\n", goal)
+	}
+
+	b.WriteString("\n## События:\n")
+	if len(agenda) == 0 {
+		b.WriteString("- событий нет\n")
+	}
+	for _, e := range agenda {
+		fmt.Fprintf(&b, "- %s: %s\n", eventTime(e, day.Location()), e.Summary)
+	}
+
+	response, err := d.aiClient.Generate(ctx, b.String())
+	if err != nil {
+		log.Printf("%s: AI error: %v", op, err)
+		return nil
+	}
+	summary, err := usecases.ParseDigestResponse(response)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return nil
+	}
+	return summary
+}
+
+func eventTime(e *models.CalendarEvent, loc *time.Location) string {
+	if e.IsAllDay {
+		return "весь день"
+	}
+	return e.Start.In(loc).Format("15:04") + "–" + e.End.In(loc).Format("15:04")
+}
+
+func renderText(agenda []*models.CalendarEvent, summary *models.DigestSummary, day time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Доброе утро! Повестка на %s\n", day.Format("02.01.2006"))
+
+	if summary != nil && summary.Summary != "" {
+		fmt.Fprintf(&b, "\n%s\n", summary.Summary)
+	}
+
+	b.WriteString("\nСобытия:\n")
+	if len(agenda) == 0 {
+		b.WriteString("Сегодня событий нет\n")
+	}
+	for _, e := range agenda {
+		fmt.Fprintf(&b, "• %s  %s\n", eventTime(e, day.Location()), e.Summary)
+	}
+
+	if summary != nil && len(summary.Focus) > 0 {
+		b.WriteString("\nФокус дня:\n")
+		for _, f := range summary.Focus {
+			fmt.Fprintf(&b, "• %s\n", f)
+		}
+	}
+	return b.String()
+}
+
+var digestTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif; color: #222;">
+<h2>Повестка на {{.Date}}</h2>
+{{with .Summary}}{{if .Summary}}<p>{{.Summary}}</p>{{end}}{{end}}
+<h3>События</h3>
+{{if .Events}}<table cellpadding="4">
+{{range .Events}}<tr><td style="color: #666; white-space: nowrap;">{{.Time}}</td><td>{{.Name}}</td></tr>
+{{end}}</table>{{else}}<p>Сегодня событий нет</p>{{end}}
+{{with .Summary}}{{if .Focus}}<h3>Фокус дня</h3>
+<ul>{{range .Focus}}<li>{{.}}</li>{{end}}</ul>{{end}}{{end}}
+</body></html>
+`))
+
+var verifyTemplate = template.Must(template.New("verify").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif; color: #222;">
+<p>Чтобы получать утреннюю сводку LifeForge на этот адрес, <a href="{{.}}">подтвердите его</a>.</p>
+<p style="color: #666;">Ссылка действует сутки. Если вы не настраивали сводку, просто удалите письмо.</p>
+</body></html>
+`))
+
+func renderVerifyHTML(link string) (string, error) {
+	var buf bytes.Buffer
+	if err := verifyTemplate.Execute(&buf, link); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderHTML(agenda []*models.CalendarEvent, summary *models.DigestSummary, day time.Time) (string, error) {
+	type row struct{ Time, Name string }
+	data := struct {
+		Date    string
+		Summary *models.DigestSummary
+		Events  []row
+	}{
+		Date:    day.Format("02.01.2006"),
+		Summary: summary,
+	}
+	for _, e := range agenda {
+		data.Events = append(data.Events, row{Time: eventTime(e, day.Location()), Name: e.Summary})
+	}
+
+	var buf bytes.Buffer
+	if err := digestTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
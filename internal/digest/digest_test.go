@@ -0,0 +1,190 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"life_forge/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sentMail struct {
+	to, subject, text string
+}
+
+type fakeMailer struct {
+	sent []sentMail
+}
+
+func (m *fakeMailer) Send(to, subject, text, html string) error {
+	m.sent = append(m.sent, sentMail{to: to, subject: subject, text: text})
+	return nil
+}
+
+// fakeStore keeps settings of one user, claims succeed unless denied
+type fakeStore struct {
+	settings    models.DigestSettings
+	denyManual  bool
+	denyToken   bool
+	manualCalls int
+}
+
+func (s *fakeStore) GetSettings(ctx context.Context, userID int) (*models.DigestSettings, error) {
+	settings := s.settings
+	return &settings, nil
+}
+
+func (s *fakeStore) SaveSettings(ctx context.Context, settings *models.DigestSettings) error {
+	settings.EmailVerified = s.settings.EmailVerified && s.settings.Email == settings.Email
+	s.settings = *settings
+	return nil
+}
+
+func (s *fakeStore) ListEnabled(ctx context.Context) ([]*models.DigestSettings, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) ClaimDay(ctx context.Context, userID int, date time.Time) (bool, error) {
+	return true, nil
+}
+
+func (s *fakeStore) ClaimManualSend(ctx context.Context, userID int, sentBefore time.Time) (bool, error) {
+	s.manualCalls++
+	return !s.denyManual, nil
+}
+
+func (s *fakeStore) CreateVerifyToken(ctx context.Context, userID int, email string, sentBefore time.Time) (string, error) {
+	if s.denyToken {
+		return "", nil
+	}
+	return "tok+en", nil
+}
+
+func (s *fakeStore) VerifyEmail(ctx context.Context, token string, sentAfter time.Time) (int, error) {
+	return 0, errors.New("not expected")
+}
+
+func (s *fakeStore) SaveDelivery(ctx context.Context, delivery *models.DigestDelivery) error {
+	return errors.New("not expected")
+}
+
+func TestDueDay(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("no tzdata: ", err)
+	}
+	now := time.Date(2026, 3, 10, 8, 30, 0, 0, loc)
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, loc)
+	yesterday := today.AddDate(0, 0, -1)
+
+	optedIn := func(sendTime string, lastSent *time.Time) models.DigestSettings {
+		return models.DigestSettings{Enabled: true, Email: "me@example.com", EmailVerified: true, SendTime: sendTime, LastSentDate: lastSent}
+	}
+	disabled := optedIn("08:00", nil)
+	disabled.Enabled = false
+	unverified := optedIn("08:00", nil)
+	unverified.EmailVerified = false
+
+	tests := []struct {
+		name     string
+		settings models.DigestSettings
+		want     bool
+	}{
+		{"send time passed", optedIn("08:00", nil), true},
+		{"send time now", optedIn("08:30", nil), true},
+		{"send time ahead", optedIn("09:00", nil), false},
+		{"sent yesterday", optedIn("08:00", &yesterday), true},
+		{"sent today", optedIn("08:00", &today), false},
+		{"disabled", disabled, false},
+		{"email not verified", unverified, false},
+		{"broken send time", optedIn("8 am", nil), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, ok := dueDay(&tt.settings, now)
+			if ok != tt.want {
+				t.Fatalf("dueDay() ok = %v, want %v", ok, tt.want)
+			}
+			if ok && !day.Equal(today) {
+				t.Fatalf("dueDay() = %v, want %v", day, today)
+			}
+		})
+	}
+}
+
+func TestSendNowRefused(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   models.DigestSettings
+		denyManual bool
+		want       error
+	}{
+		{"disabled", models.DigestSettings{Email: "me@example.com", EmailVerified: true}, false, ErrDigestDisabled},
+		{"no email", models.DigestSettings{Enabled: true}, false, ErrDigestDisabled},
+		{"email not verified", models.DigestSettings{Enabled: true, Email: "me@example.com"}, false, ErrEmailNotVerified},
+		{"sent recently", models.DigestSettings{Enabled: true, Email: "me@example.com", EmailVerified: true}, true, ErrSendTooOften},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{settings: tt.settings, denyManual: tt.denyManual}
+			mailer := &fakeMailer{}
+			d := NewDigester(nil, nil, nil, store, mailer, time.UTC, "http://app", time.Minute)
+
+			delivery, err := d.SendNow(context.Background(), 1)
+			if !errors.Is(err, tt.want) || delivery != nil {
+				t.Fatalf("SendNow() = %v, %v, want %v", delivery, err, tt.want)
+			}
+			if len(mailer.sent) != 0 {
+				t.Fatalf("sent %v, want nothing", mailer.sent)
+			}
+			if tt.want != ErrSendTooOften && store.manualCalls != 0 {
+				t.Fatalf("refused send used up the send interval")
+			}
+		})
+	}
+}
+
+func TestSaveSettingsSendsVerification(t *testing.T) {
+	tests := []struct {
+		name      string
+		stored    models.DigestSettings
+		email     string
+		denyToken bool
+		wantMail  bool
+	}{
+		{"new email", models.DigestSettings{}, "me@example.com", false, true},
+		{"changed verified email", models.DigestSettings{Email: "old@example.com", EmailVerified: true}, "me@example.com", false, true},
+		{"same verified email", models.DigestSettings{Email: "me@example.com", EmailVerified: true}, "me@example.com", false, false},
+		{"link sent recently", models.DigestSettings{}, "me@example.com", true, false},
+		{"no email", models.DigestSettings{}, "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{settings: tt.stored, denyToken: tt.denyToken}
+			mailer := &fakeMailer{}
+			d := NewDigester(nil, nil, nil, store, mailer, time.UTC, "http://app", time.Minute)
+
+			settings := &models.DigestSettings{UserID: 1, Enabled: tt.email != "", Email: tt.email, SendTime: "08:00"}
+			if err := d.SaveSettings(context.Background(), settings); err != nil {
+				t.Fatalf("SaveSettings: %v", err)
+			}
+
+			if !tt.wantMail {
+				if len(mailer.sent) != 0 {
+					t.Fatalf("sent %v, want nothing", mailer.sent)
+				}
+				return
+			}
+			if len(mailer.sent) != 1 || mailer.sent[0].to != tt.email {
+				t.Fatalf("sent %v, want one mail to %s", mailer.sent, tt.email)
+			}
+			if link := "http://app/api/digest/verify?token=tok%2Ben"; !strings.Contains(mailer.sent[0].text, link) {
+				t.Fatalf("mail text %q has no link %s", mailer.sent[0].text, link)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package goals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/ai"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/usecases"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	planPreviewDays = 14
+	createTimeout   = 5 * time.Second
+)
+
+// ErrInvalidPlan is returned when edited plan can not be scheduled
+var ErrInvalidPlan = errors.New("invalid plan")
+
+// planRequest matches "хочу выучить Go за 2 месяца", "составь план ..."
+var planRequest = regexp.MustCompile(`(?i)((хочу|цель|планирую|собираюсь).*за\s+(\d+|пару|полгода|месяц|недел|год))|составь план`)
+
+// Planner breaks goals into milestones and weekly sessions and keeps goal progress in Context
+type Planner struct {
+	aiClient         *ai.GigaChatClient
+	calendarProvider storage.CalendarProvider
+	contextStorage   *storage.ContextStorage
+	goalStorage      *storage.GoalStorage
+	location         *time.Location
+}
+
+func NewPlanner(aiClient *ai.GigaChatClient, cp storage.CalendarProvider, cs *storage.ContextStorage, gs *storage.GoalStorage, location *time.Location) *Planner {
+	return &Planner{
+		aiClient:         aiClient,
+		calendarProvider: cp,
+		contextStorage:   cs,
+		goalStorage:      gs,
+		location:         location,
+	}
+}
+
+// IsPlanRequest recognizes goals with a time frame that should go to planning mode
+func IsPlanRequest(message string) bool {
+	return planRequest.MatchString(message)
+}
+
+// Draft asks model for a plan and stores it for review, nothing is created in calendar yet
+func (p *Planner) Draft(ctx context.Context, goal string) (*models.GoalPlan, error) {
+	op := "internal/goals/planner.go Draft"
+
+	now := time.Now().In(p.location)
+	var b strings.Builder
+	b.WriteString(storage.PROMT_GOAL_PLAN)
+	fmt.Fprintf(&b, "\n## Сегодня: %s\n", now.Format("2006-01-02 (Monday)"))
+	b.WriteString("\n## Календарь на ближайшие две недели:\n")
+	b.WriteString(storage.CalendarPreview(ctx, p.calendarProvider, planPreviewDays))
+	fmt.Fprintf(&b, "\n## Цель пользователя:\n%s\n", goal)
+
+	response, err := p.aiClient.Generate(ctx, b.String())
+	if err != nil {
+		return nil, fmt.Errorf("%s: AI error: %w", op, err)
+	}
+
+	plan, err := usecases.ParseGoalPlanResponse(response)
+	if err != nil {
+		log.Printf("%s: unparsable plan: %v", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	plan.Goal = goal
+	if err := usecases.ExpandSessions(plan, now, p.location); err != nil {
+		return nil, fmt.Errorf("%s: model plan is invalid: %w", op, err)
+	}
+
+	if err := p.goalStorage.SavePlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Revise applies user edits to a drafted plan and expands sessions again
+func (p *Planner) Revise(ctx context.Context, planID int, edit *models.GoalPlan) (*models.GoalPlan, error) {
+	plan, err := p.goalStorage.GetPlan(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.GoalID != nil {
+		return nil, storage.ErrPlanAlreadyAccepted
+	}
+
+	if edit.Title != "" {
+		plan.Title = edit.Title
+	}
+	if !edit.Deadline.IsZero() {
+		plan.Deadline = edit.Deadline
+	}
+	if len(edit.Milestones) > 0 {
+		plan.Milestones = edit.Milestones
+	}
+	if edit.Session.Title != "" {
+		plan.Session.Title = edit.Session.Title
+	}
+	if len(edit.Session.Weekdays) > 0 {
+		plan.Session.Weekdays = edit.Session.Weekdays
+	}
+	if edit.Session.Time != "" {
+		plan.Session.Time = edit.Session.Time
+	}
+	if edit.Session.DurationHours != 0 {
+		plan.Session.DurationHours = edit.Session.DurationHours
+	}
+
+	if err := usecases.ExpandSessions(plan, time.Now(), p.location); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPlan, err)
+	}
+	if err := p.goalStorage.SavePlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Accept turns plan into tracked goal and creates one calendar event per session.
+// Sessions that failed to be created are skipped, they are reported in the log only
+func (p *Planner) Accept(ctx context.Context, planID int, calendarID string) (*models.Goal, error) {
+	op := "internal/goals/planner.go Accept"
+
+	plan, err := p.goalStorage.GetPlan(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.GoalID != nil {
+		return nil, storage.ErrPlanAlreadyAccepted
+	}
+
+	goal, err := p.goalStorage.CreateGoalFromPlan(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range plan.Sessions {
+		milestone := goal.Milestones[min(s.Milestone, len(goal.Milestones)-1)]
+		description := fmt.Sprintf("Цель: %s\nЭтап: %s (до %s)", goal.Title, milestone.Title, milestone.DueDate.Format("02.01.2006"))
+		start, duration := s.Start, s.DurationHours
+
+		createCtx, cancel := context.WithTimeout(ctx, createTimeout)
+		event, err := p.calendarProvider.CreateEvent(createCtx, calendarID, models.EventRequest{
+			IsEvent:       true,
+			Title:         s.Title,
+			StartTime:     &start,
+			DurationHours: &duration,
+			Description:   &description,
+		})
+		cancel()
+		if err != nil {
+			log.Printf("%s: session %s of goal %d: %v", op, start.Format(time.RFC3339), goal.ID, err)
+			continue
+		}
+
+		session := models.GoalSession{
+			GoalID:      goal.ID,
+			MilestoneID: &milestone.ID,
+			Title:       s.Title,
+			CalendarID:  event.CalendarID,
+			EventID:     event.ID,
+			Start:       event.Start,
+			End:         event.End,
+		}
+		if err := p.goalStorage.AddSession(ctx, &session); err != nil {
+			log.Printf("%s: %v", op, err)
+			continue
+		}
+		goal.Sessions = append(goal.Sessions, session)
+	}
+
+	goal.Total = len(goal.Sessions)
+	goal.Progress = usecases.GoalProgress(0, goal.Total)
+	if err := p.syncContext(ctx, goal); err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+	return goal, nil
+}
+
+// Goals returns tracked goals, id 0 means all
+func (p *Planner) Goals(ctx context.Context, id int) ([]*models.Goal, error) {
+	goals, err := p.goalStorage.ListGoals(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range goals {
+		g.Progress = usecases.GoalProgress(g.Completed, g.Total)
+	}
+	return goals, nil
+}
+
+// SetSessionCompleted marks session and recomputes goal progress in Context
+func (p *Planner) SetSessionCompleted(ctx context.Context, sessionID int, completed bool) (*models.Goal, error) {
+	op := "internal/goals/planner.go SetSessionCompleted"
+
+	goalID, err := p.goalStorage.SetSessionCompleted(ctx, sessionID, completed)
+	if err != nil {
+		return nil, err
+	}
+
+	goals, err := p.Goals(ctx, goalID)
+	if err != nil {
+		return nil, err
+	}
+	if len(goals) == 0 {
+		return nil, storage.ErrGoalNotFound
+	}
+	goal := goals[0]
+
+	status := models.GoalStatusActive
+	if goal.Total > 0 && goal.Completed == goal.Total {
+		status = models.GoalStatusCompleted
+	}
+	if status != goal.Status {
+		if err := p.goalStorage.SetGoalStatus(ctx, goal.ID, status); err != nil {
+			return nil, err
+		}
+		goal.Status = status
+	}
+
+	if err := p.syncContext(ctx, goal); err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+	return goal, nil
+}
+
+// Delete removes goal, with deleteEvents its not completed future sessions are removed from calendar too
+func (p *Planner) Delete(ctx context.Context, goalID int, deleteEvents bool) error {
+	op := "internal/goals/planner.go Delete"
+
+	goals, err := p.goalStorage.ListGoals(ctx, goalID)
+	if err != nil {
+		return err
+	}
+	if len(goals) == 0 {
+		return storage.ErrGoalNotFound
+	}
+
+	if deleteEvents {
+		now := time.Now()
+		for _, s := range goals[0].Sessions {
+			if s.Completed || s.Start.Before(now) {
+				continue
+			}
+			if err := p.calendarProvider.DeleteEvent(ctx, s.CalendarID, s.EventID); err != nil {
+				log.Printf("%s: event %s: %v", op, s.EventID, err)
+			}
+		}
+	}
+
+	if err := p.goalStorage.DeleteGoal(ctx, goalID); err != nil {
+		return err
+	}
+
+	userContext, err := p.contextStorage.GetContextByID(ctx, models.UserID(ctx))
+	if err != nil {
+		return err
+	}
+	delete(userContext.Progress, goals[0].Title)
+	return p.contextStorage.SaveContext(ctx, &userContext)
+}
+
+// syncContext keeps goal in Context.Goals and its computed progress in Context.Progress
+func (p *Planner) syncContext(ctx context.Context, goal *models.Goal) error {
+	userContext, err := p.contextStorage.GetContextByID(ctx, models.UserID(ctx))
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(userContext.Goals, goal.Title) {
+		userContext.Goals = append(userContext.Goals, goal.Title)
+	}
+	userContext.Progress[goal.Title] = goal.Progress
+	return p.contextStorage.SaveContext(ctx, &userContext)
+}
+
+// FormatPlan renders plan as plain text for chat
+func FormatPlan(plan *models.GoalPlan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🎯 План: %s (до %s)\n", plan.Title, plan.Deadline.Format("02.01.2006"))
+
+	b.WriteString("\nЭтапы:\n")
+	for i, m := range plan.Milestones {
+		fmt.Fprintf(&b, "%d. %s — до %s\n", i+1, m.Title, m.DueDate.Format("02.01"))
+	}
+
+	weekdays := make([]string, 0, len(plan.Session.Weekdays))
+	for _, wd := range plan.Session.Weekdays {
+		weekdays = append(weekdays, weekdayNames[wd%7])
+	}
+	fmt.Fprintf(&b, "\nЗанятия: «%s» %s в %s по %.1f ч — всего %d\n",
+		plan.Session.Title, strings.Join(weekdays, ", "), plan.Session.Time, plan.Session.DurationHours, len(plan.Sessions))
+
+	if plan.Advice != "" {
+		fmt.Fprintf(&b, "\n💡 %s\n", plan.Advice)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+var weekdayNames = []string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+func (p *Planner) Plan(ctx context.Context, id int) (*models.GoalPlan, error) {
+	return p.goalStorage.GetPlan(ctx, id)
+}
@@ -0,0 +1,236 @@
+package habits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/usecases"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	HistoryWeeks  = 8
+	createTimeout = 5 * time.Second
+)
+
+// ErrInvalidHabit is returned for bad title, recurrence, status or check-in day
+var ErrInvalidHabit = errors.New("invalid habit")
+
+// Tracker links habits to recurring events, records check-ins and keeps habit progress in Context
+type Tracker struct {
+	calendarProvider storage.CalendarProvider
+	contextStorage   *storage.ContextStorage
+	habitStorage     *storage.HabitStorage
+	location         *time.Location
+}
+
+func NewTracker(cp storage.CalendarProvider, cs *storage.ContextStorage, hs *storage.HabitStorage, location *time.Location) *Tracker {
+	return &Tracker{
+		calendarProvider: cp,
+		contextStorage:   cs,
+		habitStorage:     hs,
+		location:         location,
+	}
+}
+
+// Trackable reports whether events with this recurrence can be tracked as habit
+func Trackable(recurrence string) bool {
+	return recurrence == models.HabitDaily || recurrence == models.HabitWeekly
+}
+
+// Create saves habit, with event it first creates the recurring event and links habit to it
+func (t *Tracker) Create(ctx context.Context, habit *models.Habit, event *models.EventRequest) (*models.Habit, error) {
+	op := "internal/habits/tracker.go Create"
+
+	habit.Title = strings.TrimSpace(habit.Title)
+	if habit.Title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidHabit)
+	}
+	if habit.Recurrence == "" {
+		habit.Recurrence = models.HabitDaily
+	}
+	if !Trackable(habit.Recurrence) {
+		return nil, fmt.Errorf("%w: recurrence must be daily or weekly", ErrInvalidHabit)
+	}
+	if habit.StartDate.IsZero() {
+		habit.StartDate = usecases.HabitDay(time.Now(), t.location)
+	}
+
+	if event != nil {
+		event.IsEvent = true
+		event.Title = habit.Title
+		event.Recurrence = &habit.Recurrence
+
+		createCtx, cancel := context.WithTimeout(ctx, createTimeout)
+		created, err := t.calendarProvider.CreateEvent(createCtx, habit.CalendarID, *event)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		habit.CalendarID, habit.EventID = created.CalendarID, created.ID
+		habit.StartDate = usecases.HabitDay(created.Start, t.location)
+	}
+
+	if err := t.habitStorage.CreateHabit(ctx, habit); err != nil {
+		if event != nil {
+			if err := t.calendarProvider.DeleteEvent(ctx, habit.CalendarID, habit.EventID); err != nil {
+				log.Printf("%s: %v", op, err)
+			}
+		}
+		return nil, err
+	}
+	return t.refresh(ctx, habit, HistoryWeeks)
+}
+
+// Track registers recurring event created from chat as habit
+func (t *Tracker) Track(ctx context.Context, event *models.CalendarEvent, recurrence string) (*models.Habit, error) {
+	habit := &models.Habit{
+		Title:      event.Summary,
+		Recurrence: recurrence,
+		CalendarID: event.CalendarID,
+		EventID:    event.ID,
+		StartDate:  usecases.HabitDay(event.Start, t.location),
+	}
+	return t.Create(ctx, habit, nil)
+}
+
+// Habits returns habits with stats and weekly history
+func (t *Tracker) Habits(ctx context.Context, archived bool) ([]*models.Habit, error) {
+	habits, err := t.habitStorage.ListHabits(ctx, archived)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range habits {
+		if err := t.fillStats(ctx, h, HistoryWeeks); err != nil {
+			return nil, err
+		}
+	}
+	return habits, nil
+}
+
+// Habit returns one habit with history of the last weeks
+func (t *Tracker) Habit(ctx context.Context, id, weeks int) (*models.Habit, error) {
+	habit, err := t.habitStorage.GetHabit(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.fillStats(ctx, habit, weeks); err != nil {
+		return nil, err
+	}
+	return habit, nil
+}
+
+// CheckIn marks the day (date in calendar timezone) as done, partial or skipped
+func (t *Tracker) CheckIn(ctx context.Context, habitID int, day time.Time, status, note string) (*models.Habit, error) {
+	if !usecases.ValidCheckinStatus(status) {
+		return nil, fmt.Errorf("%w: status must be done, partial or skipped", ErrInvalidHabit)
+	}
+	if day.After(usecases.HabitDay(time.Now(), t.location)) {
+		return nil, fmt.Errorf("%w: check-in day is in the future", ErrInvalidHabit)
+	}
+
+	habit, err := t.habitStorage.GetHabit(ctx, habitID)
+	if err != nil {
+		return nil, err
+	}
+	if day.Before(habit.StartDate) {
+		return nil, fmt.Errorf("%w: check-in day is before habit start", ErrInvalidHabit)
+	}
+
+	checkin := &models.HabitCheckin{HabitID: habitID, Day: day, Status: status, Note: strings.TrimSpace(note)}
+	if err := t.habitStorage.SaveCheckin(ctx, checkin); err != nil {
+		return nil, err
+	}
+	return t.refresh(ctx, habit, HistoryWeeks)
+}
+
+// CheckInToday marks today for active habit named in chat ("сегодня пробежал")
+func (t *Tracker) CheckInToday(ctx context.Context, title, status string) (*models.Habit, error) {
+	habit, err := t.habitStorage.FindHabit(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+	return t.CheckIn(ctx, habit.ID, usecases.HabitDay(time.Now(), t.location), status, "")
+}
+
+// Uncheck removes mark of the day
+func (t *Tracker) Uncheck(ctx context.Context, habitID int, day time.Time) (*models.Habit, error) {
+	habit, err := t.habitStorage.GetHabit(ctx, habitID)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.habitStorage.DeleteCheckin(ctx, habitID, day); err != nil {
+		return nil, err
+	}
+	return t.refresh(ctx, habit, HistoryWeeks)
+}
+
+func (t *Tracker) Checkins(ctx context.Context, habitID int, from, to time.Time) ([]*models.HabitCheckin, error) {
+	return t.habitStorage.ListCheckins(ctx, habitID, from, to)
+}
+
+// Delete archives habit, with purge it is removed with check-ins, with deleteEvent
+// its recurring event is removed from calendar too
+func (t *Tracker) Delete(ctx context.Context, id int, purge, deleteEvent bool) error {
+	op := "internal/habits/tracker.go Delete"
+
+	habit, err := t.habitStorage.GetHabit(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if deleteEvent && habit.EventID != "" {
+		if err := t.calendarProvider.DeleteEvent(ctx, habit.CalendarID, habit.EventID); err != nil {
+			log.Printf("%s: event %s: %v", op, habit.EventID, err)
+		}
+	}
+
+	if purge {
+		err = t.habitStorage.DeleteHabit(ctx, id)
+	} else {
+		err = t.habitStorage.ArchiveHabit(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	userContext, err := t.contextStorage.GetContextByID(ctx, models.UserID(ctx))
+	if err != nil {
+		return err
+	}
+	delete(userContext.Progress, habit.Title)
+	return t.contextStorage.SaveContext(ctx, &userContext)
+}
+
+// refresh recomputes stats after a change and writes them to Context.Progress
+func (t *Tracker) refresh(ctx context.Context, habit *models.Habit, weeks int) (*models.Habit, error) {
+	op := "internal/habits/tracker.go refresh"
+
+	if err := t.fillStats(ctx, habit, weeks); err != nil {
+		return nil, err
+	}
+
+	userContext, err := t.contextStorage.GetContextByID(ctx, models.UserID(ctx))
+	if err == nil {
+		userContext.Progress[habit.Title] = usecases.HabitProgress(habit.Stats)
+		err = t.contextStorage.SaveContext(ctx, &userContext)
+	}
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+	return habit, nil
+}
+
+func (t *Tracker) fillStats(ctx context.Context, habit *models.Habit, weeks int) error {
+	today := usecases.HabitDay(time.Now(), t.location)
+	checkins, err := t.habitStorage.ListCheckins(ctx, habit.ID, habit.StartDate, today)
+	if err != nil {
+		return err
+	}
+	habit.Stats = usecases.HabitStats(habit, checkins, today, weeks)
+	return nil
+}
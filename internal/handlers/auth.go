@@ -1,37 +1,166 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"life_forge/internal/storage"
+	"log"
 	"net/http"
+	"strconv"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	oauthStateCookie    = "lf_oauth_state"
+	oauthVerifierCookie = "lf_oauth_verifier"
+	oauthCallbackPath   = "/auth/callback"
+	// time user has to pass Google consent screen
+	oauthCookieMaxAge = 10 * 60
 )
 
 type AuthHandler struct {
 	calendarStorage *storage.GoogleCalendarStorage
+	publicURL       string
+}
+
+func NewAuthHandler(cs *storage.GoogleCalendarStorage, publicURL string) *AuthHandler {
+	return &AuthHandler{calendarStorage: cs, publicURL: publicURL}
 }
 
-func NewAuthHandler(cs *storage.GoogleCalendarStorage) *AuthHandler {
-	return &AuthHandler{calendarStorage: cs}
+// OAuthRedirectURL is callback address registered for the OAuth client
+func OAuthRedirectURL(publicURL string) string {
+	return publicURL + oauthCallbackPath
 }
 
-// /auth/google -> redirect to google
+// /auth/google -> redirect to google, state and PKCE verifier are kept in short-lived cookies
 func (h *AuthHandler) HandleGoogleLogin(w http.ResponseWriter, r *http.Request) {
-	url := h.calendarStorage.GetAuthURL()
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		http.Error(w, "Failed to generate state", http.StatusInternalServerError)
+		return
+	}
+	state := base64.RawURLEncoding.EncodeToString(raw)
+	verifier := oauth2.GenerateVerifier()
+
+	setOAuthCookie(w, r, oauthStateCookie, state, oauthCookieMaxAge)
+	setOAuthCookie(w, r, oauthVerifierCookie, verifier, oauthCookieMaxAge)
+
+	http.Redirect(w, r, h.calendarStorage.GetAuthURL(state, verifier), http.StatusTemporaryRedirect)
 }
 
 // /auth/callback -> Google send code here
 func (h *AuthHandler) HandleGoogleCallback(w http.ResponseWriter, r *http.Request) {
-	code := r.URL.Query().Get("code")
+	op := "internal/handlers/auth.go HandleGoogleCallback"
+
+	query := r.URL.Query()
+
+	// login attempt is over whatever the outcome, cookies are single use
+	stateCookie, stateErr := r.Cookie(oauthStateCookie)
+	verifierCookie, verifierErr := r.Cookie(oauthVerifierCookie)
+	setOAuthCookie(w, r, oauthStateCookie, "", -1)
+	setOAuthCookie(w, r, oauthVerifierCookie, "", -1)
+
+	// user denied access or Google failed, e.g. error=access_denied
+	if errCode := query.Get("error"); errCode != "" {
+		log.Printf("Google authorization failed in %s: %s %s", op, errCode, query.Get("error_description"))
+		http.Error(w, "Google authorization failed: "+errCode, http.StatusBadRequest)
+		return
+	}
+
+	state := query.Get("state")
+	if stateErr != nil || verifierErr != nil || state == "" ||
+		subtle.ConstantTimeCompare([]byte(state), []byte(stateCookie.Value)) != 1 {
+		http.Error(w, "Invalid OAuth state, start again from /auth/google", http.StatusBadRequest)
+		return
+	}
+
+	code := query.Get("code")
 	if code == "" {
 		http.Error(w, "Code not found", http.StatusBadRequest)
 		return
 	}
 
-	err := h.calendarStorage.ExchangeCode(code)
+	account, err := h.calendarStorage.ExchangeCode(r.Context(), code, verifierCookie.Value)
 	if err != nil {
 		http.Error(w, "Failed to exchange code: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	log.Printf("Google account %d (%s) is linked", account.ID, account.Email)
+
+	http.Redirect(w, r, h.publicURL+"/", http.StatusFound)
+}
+
+// /api/auth/status -> Google connection of current user, reauth_required asks to pass /auth/google again
+func (h *AuthHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status, err := h.calendarStorage.AuthStatus(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load auth status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// /auth/google/disconnect?account_id= -> revoke and unlink one Google account, without account_id all of them
+func (h *AuthHandler) HandleDisconnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var ids []int
+	if raw := r.URL.Query().Get("account_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "Invalid account_id", http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	} else {
+		status, err := h.calendarStorage.AuthStatus(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to load accounts: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// primary one goes last, so remaining accounts are not promoted on the way
+		for i := len(status.Accounts) - 1; i >= 0; i-- {
+			ids = append(ids, status.Accounts[i].ID)
+		}
+	}
+
+	for _, id := range ids {
+		err := h.calendarStorage.Disconnect(r.Context(), id)
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			http.Error(w, "Google account not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to disconnect account: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	http.Redirect(w, r, "http://localhost:8080", http.StatusPermanentRedirect)
+// setOAuthCookie is scoped to callback path, Lax is enough since Google comes back with top-level GET
+func setOAuthCookie(w http.ResponseWriter, r *http.Request, name, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     oauthCallbackPath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
 }
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/caldav"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CalDAVHandler struct {
+	accountStorage *storage.CalDAVAccountStorage
+	router         *storage.CalendarRouter
+	allowPrivate   bool // servers in local networks, off for public installations
+	location       *time.Location
+}
+
+func NewCalDAVHandler(as *storage.CalDAVAccountStorage, router *storage.CalendarRouter, allowPrivate bool, location *time.Location) *CalDAVHandler {
+	return &CalDAVHandler{accountStorage: as, router: router, allowPrivate: allowPrivate, location: location}
+}
+
+// /api/caldav/accounts GET - list, POST - link new account, DELETE ?id= - unlink
+func (h *CalDAVHandler) HandleAccounts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listAccounts(w, r)
+	case http.MethodPost:
+		h.linkAccount(w, r)
+	case http.MethodDelete:
+		h.unlinkAccount(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CalDAVHandler) listAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.accountStorage.ListAccounts(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load accounts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if accounts == nil {
+		accounts = []models.CalDAVAccount{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+func (h *CalDAVHandler) linkAccount(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/caldav.go linkAccount"
+
+	var req struct {
+		Name     string `json:"name"`
+		URL      string `json:"url"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		req.Name = req.URL
+	}
+
+	account := models.CalDAVAccount{
+		Name:     req.Name,
+		URL:      req.URL,
+		Username: req.Username,
+		Password: req.Password,
+	}
+
+	// check that server answers before saving credentials
+	provider, err := storage.NewCalDAVCalendarStorage(account, h.allowPrivate, h.location)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	calendars, err := provider.ListCalendars(r.Context())
+	if errors.Is(err, caldav.ErrPrivateAddress) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("%s: discovery failed: %v", op, err)
+		http.Error(w, "Failed to connect to CalDAV server: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if err := h.accountStorage.SaveAccount(r.Context(), &account); err != nil {
+		http.Error(w, "Failed to save account: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	provider, err = storage.NewCalDAVCalendarStorage(account, h.allowPrivate, h.location)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.router.SetCalDAVAccount(provider)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"account":   account,
+		"calendars": len(calendars),
+	})
+}
+
+func (h *CalDAVHandler) unlinkAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	err = h.accountStorage.DeleteAccount(r.Context(), id)
+	if errors.Is(err, storage.ErrAccountNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete account: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.router.RemoveCalDAVAccount(id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,59 +2,89 @@ package handlers
 
 import (
 	"encoding/json"
+	"life_forge/internal/models"
 	"life_forge/internal/storage"
+	"life_forge/internal/usecases"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+)
 
-	"google.golang.org/api/calendar/v3"
+const (
+	daysToShowTasks    = 14 // 2 недели
+	workers            = 7
+	descriptionSnippet = 140 // runes of description sent to UI
+	maxLayoutDays      = 62
 )
 
 const (
-	daysToShowTasks = 14 // 2 недели
-	workers         = 7
+	GanttKindEvent     = "event"
+	GanttKindTask      = "task"
+	GanttKindMilestone = "milestone"
 )
 
 type CalendarHandler struct {
-	calendarStorage *storage.GoogleCalendarStorage
+	calendarProvider storage.CalendarProvider
+	projectStorage   *storage.ProjectStorage
+	mirrorStorage    *storage.EventMirrorStorage
+	location         *time.Location
 }
 
+// GanttTask is a calendar event or a project task, calendar fields are empty for tasks and project fields for events
 type GanttTask struct {
-	Name      string    `json:"name"`
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
-	IsAllDay  bool      `json:"is_all_day"`
+	ID               string    `json:"id,omitempty"`
+	Kind             string    `json:"kind"`
+	Name             string    `json:"name"`
+	StartTime        time.Time `json:"start_time"`
+	EndTime          time.Time `json:"end_time"`
+	IsAllDay         bool      `json:"is_all_day"`
+	CalendarID       string    `json:"calendar_id,omitempty"`
+	CalendarColor    string    `json:"calendar_color,omitempty"`
+	ColorID          string    `json:"color_id,omitempty"`
+	Location         string    `json:"location,omitempty"`
+	Description      string    `json:"description,omitempty"`
+	RecurringEventID string    `json:"recurring_event_id,omitempty"`
+	Status           string    `json:"status,omitempty"`
+	ProjectID        int       `json:"project_id,omitempty"`
+	Progress         int       `json:"progress,omitempty"`
+	Critical         bool      `json:"critical,omitempty"`
 }
 
-func NewCalendarHandler(cs *storage.GoogleCalendarStorage) *CalendarHandler {
-	return &CalendarHandler{calendarStorage: cs}
+// GanttDependency is finish-to-start edge between two GanttTask ids
+type GanttDependency struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
 }
 
-func (cal *CalendarHandler) HandleGanttDiagramm(w http.ResponseWriter, r *http.Request) {
-	op := "internal/handlers/calendar.go HandleGranttDiagramm"
+// GanttResponse tasks plus status of every requested calendar, so UI can warn about partial data
+type GanttResponse struct {
+	Tasks        []GanttTask             `json:"tasks"`
+	Dependencies []GanttDependency       `json:"dependencies"`
+	Calendars    []models.CalendarStatus `json:"calendars"`
+}
 
-	timeMin := time.Now().UTC()
-	timeMax := time.Now().AddDate(0, 0, daysToShowTasks).UTC()
+// LayoutResponse is calendar grid split by days with lanes for overlapping events
+type LayoutResponse struct {
+	TimeZone  string                  `json:"time_zone"`
+	Days      []models.LayoutDay      `json:"days"`
+	Calendars []models.CalendarStatus `json:"calendars"`
+}
 
-	if startStr := r.URL.Query().Get("start"); startStr != "" {
-		if t, err := time.Parse(time.RFC3339, startStr); err == nil {
-			timeMin = t
-		}
-	}
-	if endStr := r.URL.Query().Get("end"); endStr != "" {
-		if t, err := time.Parse(time.RFC3339, endStr); err == nil {
-			timeMax = t
-		}
-	}
+func NewCalendarHandler(cp storage.CalendarProvider, ps *storage.ProjectStorage, ms *storage.EventMirrorStorage, location *time.Location) *CalendarHandler {
+	return &CalendarHandler{calendarProvider: cp, projectStorage: ps, mirrorStorage: ms, location: location}
+}
 
-	var calIDs []string
-	if cals := r.URL.Query().Get("calendars"); cals != "" {
-		calIDs = strings.Split(cals, ",")
-	}
+func (cal *CalendarHandler) HandleGanttDiagramm(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/calendar.go HandleGranttDiagramm"
 
-	eventsArr, err := cal.calendarStorage.ListEvents(r.Context(), timeMin, timeMax, calIDs...)
+	timeMin, timeMax, calIDs := parseEventsQuery(r)
+
+	eventsArr, statuses, err := cal.calendarProvider.ListEvents(r.Context(), timeMin, timeMax, calIDs...)
 
 	if err != nil {
 		http.Error(w, "Failed to load user events: "+err.Error(), http.StatusInternalServerError)
@@ -62,7 +92,9 @@ func (cal *CalendarHandler) HandleGanttDiagramm(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	eventsChan := make(chan *calendar.Event, len(eventsArr))
+	colors := cal.calendarColors(r)
+
+	eventsChan := make(chan *models.CalendarEvent, len(eventsArr))
 
 	var wgWorkers sync.WaitGroup
 
@@ -82,37 +114,25 @@ func (cal *CalendarHandler) HandleGanttDiagramm(w http.ResponseWriter, r *http.R
 			defer wgWorkers.Done()
 
 			for val := range eventsChan {
-				var start, end time.Time
-				var err error
-				isAllDay := false
-
-				if val.Start != nil && val.Start.DateTime != "" {
-					start, err = time.Parse(time.RFC3339, val.Start.DateTime)
-				} else if val.Start != nil && val.Start.Date != "" {
-					start, err = time.Parse("2006-01-02", val.Start.Date)
-					isAllDay = true
-				}
-				if err != nil {
-					log.Printf("Failed to parse start time in event '%s': %v", val.Summary, err)
-					continue
-				}
-
-				if val.End != nil && val.End.DateTime != "" {
-					end, err = time.Parse(time.RFC3339, val.End.DateTime)
-				} else if val.End != nil && val.End.Date != "" {
-					end, err = time.Parse("2006-01-02", val.End.Date)
-					isAllDay = true
-				}
-				if err != nil {
-					log.Printf("Failed to parse end time in event '%s': %v", val.Summary, err)
+				if val.Start.IsZero() || val.End.IsZero() {
+					log.Printf("Skip event '%s' without start or end time", val.Summary)
 					continue
 				}
 
 				resEvents <- GanttTask{
-					Name:      val.Summary,
-					StartTime: start,
-					EndTime:   end,
-					IsAllDay:  isAllDay,
+					ID:               val.ID,
+					Kind:             GanttKindEvent,
+					Name:             val.Summary,
+					StartTime:        val.Start,
+					EndTime:          val.End,
+					IsAllDay:         val.IsAllDay,
+					CalendarID:       val.CalendarID,
+					CalendarColor:    colors[val.CalendarID],
+					ColorID:          val.ColorID,
+					Location:         val.Location,
+					Description:      snippet(val.Description, descriptionSnippet),
+					RecurringEventID: val.RecurringEventID,
+					Status:           val.Status,
 				}
 			}
 		}()
@@ -134,7 +154,17 @@ func (cal *CalendarHandler) HandleGanttDiagramm(w http.ResponseWriter, r *http.R
 	close(resEvents)
 
 	wg.Wait()
-	err = json.NewEncoder(w).Encode(events)
+
+	projectTasks, dependencies, err := cal.projectTasks(r, timeMin, timeMax)
+	if err != nil {
+		http.Error(w, "Failed to load project tasks: "+err.Error(), http.StatusInternalServerError)
+		log.Printf("Failed to load project tasks in %s with err: %v", op, err)
+		return
+	}
+	events = append(events, projectTasks...)
+	sortGanttTasks(events)
+
+	err = json.NewEncoder(w).Encode(GanttResponse{Tasks: events, Dependencies: dependencies, Calendars: statuses})
 	if err != nil {
 		http.Error(w, "Failed to encode events: "+err.Error(), http.StatusInternalServerError)
 		log.Printf("Failed to encode events in %s with err: %v", op, err)
@@ -142,6 +172,172 @@ func (cal *CalendarHandler) HandleGanttDiagramm(w http.ResponseWriter, r *http.R
 	}
 }
 
+// /api/layout -> same query as /api/gantt plus optional tz (IANA name), days are cut in that zone
+func (cal *CalendarHandler) HandleLayout(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/calendar.go HandleLayout"
+
+	timeMin, timeMax, calIDs := parseEventsQuery(r)
+	if timeMax.Sub(timeMin) > maxLayoutDays*24*time.Hour {
+		http.Error(w, "Window is too large for layout", http.StatusBadRequest)
+		return
+	}
+
+	loc := cal.location
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			http.Error(w, "Unknown time zone "+tz, http.StatusBadRequest)
+			return
+		}
+		loc = l
+	}
+
+	events, statuses, err := cal.calendarProvider.ListEvents(r.Context(), timeMin, timeMax, calIDs...)
+	if err != nil {
+		http.Error(w, "Failed to load user events: "+err.Error(), http.StatusInternalServerError)
+		log.Printf("Failed to load user events in %s with err: %v", op, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LayoutResponse{
+		TimeZone:  loc.String(),
+		Days:      usecases.LayoutDays(events, timeMin, timeMax, loc, cal.location),
+		Calendars: statuses,
+	})
+}
+
+// projectTasks returns tasks overlapping window and dependencies between them,
+// critical path is computed over whole projects, not only over visible part
+func (cal *CalendarHandler) projectTasks(r *http.Request, timeMin, timeMax time.Time) ([]GanttTask, []GanttDependency, error) {
+	dependencies := []GanttDependency{}
+	if cal.projectStorage == nil {
+		return nil, dependencies, nil
+	}
+
+	tasks, err := cal.projectStorage.ListTasks(r.Context(), 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	critical, err := usecases.CriticalTasks(tasks)
+	if err != nil {
+		log.Printf("Failed to compute critical path: %v", err)
+		critical = map[int]bool{}
+	}
+
+	visible := make(map[int]bool)
+	var result []GanttTask
+	for _, t := range tasks {
+		if t.EndTime.Before(timeMin) || !t.StartTime.Before(timeMax) {
+			continue
+		}
+		visible[t.ID] = true
+
+		kind := GanttKindTask
+		if t.IsMilestone {
+			kind = GanttKindMilestone
+		}
+		result = append(result, GanttTask{
+			ID:        ganttTaskID(t.ID),
+			Kind:      kind,
+			Name:      t.Name,
+			StartTime: t.StartTime,
+			EndTime:   t.EndTime,
+			ProjectID: t.ProjectID,
+			Progress:  t.Progress,
+			Critical:  critical[t.ID],
+		})
+	}
+
+	for _, t := range tasks {
+		if !visible[t.ID] {
+			continue
+		}
+		for _, dep := range t.DependsOn {
+			if visible[dep] {
+				dependencies = append(dependencies, GanttDependency{
+					From: ganttTaskID(dep),
+					To:   ganttTaskID(t.ID),
+					Type: models.DependencyFinishToStart,
+				})
+			}
+		}
+	}
+
+	return result, dependencies, nil
+}
+
+// sortGanttTasks gives stable order regardless of worker pool: by start, end, calendar and id
+func sortGanttTasks(tasks []GanttTask) {
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime)
+		}
+		if !a.EndTime.Equal(b.EndTime) {
+			return a.EndTime.Before(b.EndTime)
+		}
+		if a.CalendarID != b.CalendarID {
+			return a.CalendarID < b.CalendarID
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Name < b.Name
+	})
+}
+
+// calendarColors maps calendar id to its background color, Google primary calendar is also available as "primary".
+// Providers are not asked: Google colors are saved by every sync, CalDAV and other Google accounts get no color here
+func (cal *CalendarHandler) calendarColors(r *http.Request) map[string]string {
+	colors, err := cal.mirrorStorage.CalendarColors(r.Context())
+	if err != nil {
+		log.Printf("Failed to load calendar colors: %v", err)
+		colors = make(map[string]string)
+	}
+	colors[storage.LocalCalendarID] = storage.LocalCalendarColor
+	return colors
+}
+
+// snippet collapses whitespace and cuts text to limit runes
+func snippet(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:limit])) + "…"
+}
+
+func ganttTaskID(id int) string {
+	return "task:" + strconv.Itoa(id)
+}
+
+// parseEventsQuery reads start, end (RFC3339) and comma separated calendars, defaults to next 14 days
+func parseEventsQuery(r *http.Request) (time.Time, time.Time, []string) {
+	timeMin := time.Now().UTC()
+	timeMax := time.Now().AddDate(0, 0, daysToShowTasks).UTC()
+
+	if startStr := r.URL.Query().Get("start"); startStr != "" {
+		if t, err := time.Parse(time.RFC3339, startStr); err == nil {
+			timeMin = t
+		}
+	}
+	if endStr := r.URL.Query().Get("end"); endStr != "" {
+		if t, err := time.Parse(time.RFC3339, endStr); err == nil {
+			timeMax = t
+		}
+	}
+
+	var calIDs []string
+	if cals := r.URL.Query().Get("calendars"); cals != "" {
+		calIDs = strings.Split(cals, ",")
+	}
+
+	return timeMin, timeMax, calIDs
+}
+
 func normalizeToDay(t time.Time) time.Time {
 	return time.Date(
 		t.Year(),
@@ -154,7 +350,7 @@ func normalizeToDay(t time.Time) time.Time {
 
 func (cal *CalendarHandler) HandleGetCalendars(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	calendars, err := cal.calendarStorage.GetUserCalendars(r.Context())
+	calendars, err := cal.calendarProvider.ListCalendars(r.Context())
 	if err != nil {
 		http.Error(w, "Failed to load calendars: "+err.Error(), http.StatusInternalServerError)
 		return
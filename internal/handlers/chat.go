@@ -2,32 +2,26 @@ package handlers
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"html"
 	"io"
-	"life_forge/internal/ai"
+	"life_forge/internal/chat"
 	"life_forge/internal/models"
-	"life_forge/internal/storage"
+	"life_forge/internal/tasks"
 	"life_forge/internal/usecases"
 	"log"
 	"net/http"
 	"strings"
-	"time"
 )
 
 type ChatHandler struct {
-	contextStorage  *storage.ContextStorage
-	aiClient        *ai.GigaChatClient
-	calendarStorage *storage.GoogleCalendarStorage
+	assistant *chat.Assistant
 }
 
-func NewChatHandler(contextStorage *storage.ContextStorage, aiClient *ai.GigaChatClient, calendarStorage *storage.GoogleCalendarStorage) *ChatHandler {
+func NewChatHandler(assistant *chat.Assistant) *ChatHandler {
 	return &ChatHandler{
-		contextStorage:  contextStorage,
-		aiClient:        aiClient,
-		calendarStorage: calendarStorage,
+		assistant: assistant,
 	}
 }
 
@@ -84,33 +78,33 @@ func (ch *ChatHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	calendarData := ch.calendarStorage.GetCalendarPreview(r.Context(), 5)
-	log.Printf("📅 Calendar data: %d symbols", len(calendarData))
-
-	now := time.Now()
-	timeContext := fmt.Sprintf("ВНИМАНИЕ! Сегодня: %s. Завтра: %s. Текущее время: %s. Все даты в JSON должны вычисляться относительно сегодня, используй часовой пояс +03:00 вместо Z!",
-		now.Format("2006-01-02"),
-		now.AddDate(0, 0, 1).Format("2006-01-02"),
-		now.Format("15:04"))
-	//запрос от пользователя (вместе с базовым промтом)
-	promt_calendar := fmt.Sprintf("%s\n%s \n запрос от пользователя:%s\n %s", storage.PROMT_CALENDAR, calendarData, message, timeContext)
-
-	response, err := makeRequestToAIGetResponse(r.Context(), ch, promt_calendar)
+	reply, err := ch.assistant.Reply(r.Context(), message)
 	if err != nil {
-		log.Printf("%s: AI calendar error: %v", op, err)
+		log.Printf("%s: %v", op, err)
 		http.Error(w, `{"error": "AI service error"}`, http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Answer from AI: %d symbols", len(response))
-
-	user_answer_calendar, events, err := usecases.ParseCalendarAIResponse(response)
-	if err != nil {
-		log.Printf("%s: parse calendar response error: %v", op, err)
+	if reply.IsReview {
+		ch.writeReview(w, r, reply)
+		return
+	}
+	// plans, journal and time tracking answers have no events, they are shown as plain text
+	plain := reply.Plan != nil || reply.Entry != nil || reply.Reflection != nil || reply.TimeEntry != nil || reply.PlanReport != nil
+	if plain && r.Header.Get("HX-Request") == "true" {
+		footer := ""
+		if reply.Plan != nil {
+			footer = fmt.Sprintf(`<div class="mt-2 text-xs text-green-600">📝 Plan #%d: accept it with POST /api/goal-plans/accept</div>`, reply.Plan.ID)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `
+            <div class="message p-4 rounded-2xl bg-gradient-to-r from-green-100 to-emerald-50 border border-green-200 max-w-3xl animate-slide-in mb-4">
+                <div class="mb-1 font-semibold text-green-800">🤖 LifeForge AI:</div>
+                <div class="text-gray-800 whitespace-pre-line">%s</div>
+                %s
+            </div>`, html.EscapeString(reply.Text), footer)
+		return
 	}
-
-	log.Printf("Parsing events: %d events", len(events))
-	saveEvents(ch, events)
 
 	// ui
 	if r.Header.Get("HX-Request") == "true" {
@@ -122,8 +116,8 @@ func (ch *ChatHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
                 <div class="text-gray-800">%s</div>
                 %s
             </div>`,
-			html.EscapeString(user_answer_calendar),
-			formatEventsHTML(events))
+			html.EscapeString(reply.Text),
+			formatEventsHTML(reply.Requests)+formatTasksHTML(reply.Tasks)+formatContextHTML(reply.Context)+formatHabitsHTML(reply.CheckIns))
 
 		fmt.Fprint(w, htmlResponse)
 		return
@@ -131,17 +125,45 @@ func (ch *ChatHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	answ := user_answer_calendar
+	answ := reply.Text
 	if answ == "" {
 		answ = "✅ Task completed!"
 	}
 
 	responseData := map[string]interface{}{
 		"response":         answ,
-		"events_count":     len(events),
-		"calendar_preview": len(calendarData) > 50,
+		"events_count":     len(reply.Requests),
+		"calendar_preview": reply.CalendarPreview,
+		"reauth_required":  reply.ReauthRequired,
 		"status":           "success",
 	}
+	if reply.Context != nil {
+		responseData["context"] = reply.Context
+	}
+	if reply.Plan != nil {
+		responseData["goal_plan"] = reply.Plan
+	}
+	if len(reply.Tracked) > 0 {
+		responseData["habits_tracked"] = reply.Tracked
+	}
+	if len(reply.CheckIns) > 0 {
+		responseData["habit_checkins"] = reply.CheckIns
+	}
+	if len(reply.Tasks) > 0 {
+		responseData["tasks"] = reply.Tasks
+	}
+	if reply.Entry != nil {
+		responseData["journal_entry"] = reply.Entry
+	}
+	if reply.Reflection != nil {
+		responseData["reflection"] = reply.Reflection
+	}
+	if reply.TimeEntry != nil {
+		responseData["time_entry"] = reply.TimeEntry
+	}
+	if reply.PlanReport != nil {
+		responseData["plan_report"] = reply.PlanReport
+	}
 
 	if err := json.NewEncoder(w).Encode(responseData); err != nil {
 		log.Printf("%s: encode response error: %v", op, err)
@@ -150,71 +172,26 @@ func (ch *ChatHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func saveEvents(ch *ChatHandler, events []*models.EventRequest) {
-	workers := make(chan struct{}, 5)
-
-	for i, event := range events {
-
-		workers <- struct{}{}
-
-		go func(event *models.EventRequest) {
-			defer func() {
-				<-workers
-			}()
-			if event.IsEvent && event.Title != "" {
-				log.Printf("Event %d: %s", i+1, event.Title)
-				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				defer cancel()
-
-				createdEvent, err := ch.calendarStorage.CreateEvent(ctx, *event)
-				if err != nil {
-					log.Printf("❌ Error to create event '%s': %v", event.Title, err)
-				} else {
-					log.Printf("✅ Event created: %s (ID: %s)", event.Title, createdEvent.Id)
-				}
-
-				err = ch.calendarStorage.SaveEventInDB(ctx, event)
-				if err != nil {
-					log.Printf("❌ Error to save event in db '%s': %v", event.Title, err)
-				} else {
-					log.Printf("✅ Event saved in db: %s", event.Title)
-				}
-			}
-		}(event)
+// writeReview renders weekly review answer, it has no events
+func (ch *ChatHandler) writeReview(w http.ResponseWriter, r *http.Request, reply *chat.Reply) {
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `
+            <div class="message p-4 rounded-2xl bg-gradient-to-r from-green-100 to-emerald-50 border border-green-200 max-w-3xl animate-slide-in mb-4">
+                <div class="mb-1 font-semibold text-green-800">🤖 LifeForge AI:</div>
+                <div class="text-gray-800 whitespace-pre-line">%s</div>
+            </div>`, html.EscapeString(reply.Text))
+		return
 	}
-}
-
-// func mergeContexts(oldContext, newUpdates models.Context) models.Context {
-// 	result := oldContext
-
-// 	if len(newUpdates.Goals) > 0 {
-// 		result.Goals = newUpdates.Goals
-// 	}
-
-// 	if len(newUpdates.Recent5) > 0 {
-// 		result.Recent5 = newUpdates.Recent5
-// 	}
-
-// 	if len(newUpdates.Progress) > 0 {
-// 		if result.Progress == nil {
-// 			result.Progress = make(map[string]string)
-// 		}
-// 		for k, v := range newUpdates.Progress {
-// 			result.Progress[k] = v
-// 		}
-// 	}
-
-// 	return result
-// }
 
-func makeRequestToAIGetResponse(ctx context.Context, ch *ChatHandler, prompt string) (string, error) {
-	op := "handlers.makeRequestToAIGetResponse"
-	response, err := ch.aiClient.Generate(ctx, prompt)
-	if err != nil {
-		log.Printf("%s: AI error: %v", op, err)
-		return "", err
-	}
-	return response, nil
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"response":        reply.Text,
+		"events_count":    0,
+		"review":          reply.Review,
+		"reauth_required": reply.ReauthRequired,
+		"status":          "success",
+	})
 }
 
 // frontend
@@ -260,3 +237,28 @@ func formatEventsHTML(events []*models.EventRequest) string {
 	htmlEvents.WriteString(`</div>`)
 	return htmlEvents.String()
 }
+
+func formatContextHTML(userContext *models.Context) string {
+	if userContext == nil {
+		return ""
+	}
+	return fmt.Sprintf(`<div class="mt-2 text-xs text-green-600">🎯 Goals updated: %s</div>`,
+		html.EscapeString(strings.Join(userContext.Goals, ", ")))
+}
+
+func formatHabitsHTML(checkIns []*models.Habit) string {
+	var b strings.Builder
+	for _, h := range checkIns {
+		fmt.Fprintf(&b, `<div class="mt-2 text-xs text-green-600">🔥 %s: %s</div>`,
+			html.EscapeString(h.Title), html.EscapeString(usecases.HabitProgress(h.Stats)))
+	}
+	return b.String()
+}
+
+func formatTasksHTML(created []*models.Task) string {
+	if len(created) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`<div class="mt-2 text-xs text-green-600 whitespace-pre-line">%s</div>`,
+		html.EscapeString(tasks.FormatTasks(created)))
+}
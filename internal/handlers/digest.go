@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/digest"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultDeliveriesLimit = 20
+	maxDeliveriesLimit     = 100
+)
+
+type DigestHandler struct {
+	digester      *digest.Digester
+	digestStorage *storage.DigestStorage
+}
+
+func NewDigestHandler(digester *digest.Digester, ds *storage.DigestStorage) *DigestHandler {
+	return &DigestHandler{digester: digester, digestStorage: ds}
+}
+
+// /api/digest/settings GET - current settings, PUT - replace settings
+func (h *DigestHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		settings, err := h.digestStorage.GetSettings(r.Context(), models.UserID(r.Context()))
+		if err != nil {
+			http.Error(w, "Failed to load digest settings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings)
+
+	case http.MethodPut:
+		var settings models.DigestSettings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		settings.UserID = models.UserID(r.Context())
+		if msg := validateDigestSettings(&settings); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		if err := h.digester.SaveSettings(r.Context(), &settings); err != nil {
+			http.Error(w, "Failed to save digest settings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/digest/deliveries GET ?limit= - delivery history, newest first
+func (h *DigestHandler) HandleDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, maxDeliveriesLimit)
+	}
+	deliveries, err := h.digestStorage.ListDeliveries(r.Context(), models.UserID(r.Context()), limit)
+	if err != nil {
+		http.Error(w, "Failed to load deliveries: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// /api/digest/verify GET ?token= - confirm digest email, public since the link is opened from mail
+func (h *DigestHandler) HandleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := h.digester.VerifyEmail(r.Context(), r.URL.Query().Get("token"))
+	if errors.Is(err, storage.ErrDigestVerifyNotFound) {
+		http.Error(w, "Ссылка недействительна или устарела", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to verify email: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("Адрес подтверждён, утренняя сводка будет приходить на него\n"))
+}
+
+// /api/digest/send POST - send today's digest now
+func (h *DigestHandler) HandleSend(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/digest.go HandleSend"
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	delivery, err := h.digester.SendNow(r.Context(), models.UserID(r.Context()))
+	if errors.Is(err, digest.ErrSendTooOften) {
+		http.Error(w, "Failed to send digest: "+err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err != nil && delivery == nil {
+		http.Error(w, "Failed to send digest: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to send digest in %s with err: %v", op, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(delivery)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+func validateDigestSettings(settings *models.DigestSettings) string {
+	if settings.SendTime == "" {
+		settings.SendTime = storage.DefaultDigestSendTime
+	}
+	if _, err := time.Parse("15:04", settings.SendTime); err != nil {
+		return "send_time must be HH:MM"
+	}
+	if settings.Timezone != "" {
+		if _, err := time.LoadLocation(settings.Timezone); err != nil {
+			return "unknown timezone"
+		}
+	}
+	if settings.Email != "" {
+		addr, err := mail.ParseAddress(settings.Email)
+		if err != nil {
+			return "invalid email"
+		}
+		settings.Email = addr.Address
+	}
+	if settings.Enabled && settings.Email == "" {
+		return "email is required to enable digest"
+	}
+	return ""
+}
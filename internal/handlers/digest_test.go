@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"life_forge/internal/digest"
+	"life_forge/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeMailer struct {
+	to []string
+}
+
+func (m *fakeMailer) Send(to, subject, text, html string) error {
+	m.to = append(m.to, to)
+	return nil
+}
+
+// fakeDigestStore saves settings in memory, nothing is ever verified
+type fakeDigestStore struct {
+	saved *models.DigestSettings
+}
+
+func (s *fakeDigestStore) GetSettings(ctx context.Context, userID int) (*models.DigestSettings, error) {
+	if s.saved == nil {
+		return &models.DigestSettings{UserID: userID}, nil
+	}
+	return s.saved, nil
+}
+
+func (s *fakeDigestStore) SaveSettings(ctx context.Context, settings *models.DigestSettings) error {
+	s.saved = settings
+	return nil
+}
+
+func (s *fakeDigestStore) ListEnabled(ctx context.Context) ([]*models.DigestSettings, error) {
+	return nil, nil
+}
+
+func (s *fakeDigestStore) ClaimDay(ctx context.Context, userID int, date time.Time) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeDigestStore) ClaimManualSend(ctx context.Context, userID int, sentBefore time.Time) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeDigestStore) CreateVerifyToken(ctx context.Context, userID int, email string, sentBefore time.Time) (string, error) {
+	return "token", nil
+}
+
+func (s *fakeDigestStore) VerifyEmail(ctx context.Context, token string, sentAfter time.Time) (int, error) {
+	return 0, errors.New("not expected")
+}
+
+func (s *fakeDigestStore) SaveDelivery(ctx context.Context, delivery *models.DigestDelivery) error {
+	return errors.New("not expected")
+}
+
+func TestDigestSettingsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantEmail  string // saved email, also the only recipient of verification mail
+	}{
+		{"invalid json", `{"enabled":`, http.StatusBadRequest, ""},
+		{"bad send time", `{"send_time":"25:00"}`, http.StatusBadRequest, ""},
+		{"unknown timezone", `{"timezone":"Mars/Olympus"}`, http.StatusBadRequest, ""},
+		{"invalid email", `{"email":"not an email"}`, http.StatusBadRequest, ""},
+		{"enabled without email", `{"enabled":true}`, http.StatusBadRequest, ""},
+		{"disabled without email", `{"send_time":"07:30"}`, http.StatusOK, ""},
+		{"email with name", `{"enabled":true,"email":"Me <me@example.com>"}`, http.StatusOK, "me@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeDigestStore{}
+			mailer := &fakeMailer{}
+			h := NewDigestHandler(digest.NewDigester(nil, nil, nil, store, mailer, time.UTC, "http://app", time.Minute), nil)
+
+			r := httptest.NewRequest(http.MethodPut, "/api/digest/settings", strings.NewReader(tt.body))
+			r = r.WithContext(models.WithUserID(r.Context(), 7))
+			w := httptest.NewRecorder()
+			h.HandleSettings(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if store.saved != nil || len(mailer.to) != 0 {
+					t.Fatalf("invalid settings saved %+v, mailed %v", store.saved, mailer.to)
+				}
+				return
+			}
+
+			var got models.DigestSettings
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.UserID != 7 || got.Email != tt.wantEmail || got.EmailVerified || got.SendTime == "" {
+				t.Fatalf("settings = %+v, want unverified settings of user 7 with email %q", got, tt.wantEmail)
+			}
+			wantTo := []string{}
+			if tt.wantEmail != "" {
+				wantTo = append(wantTo, tt.wantEmail)
+			}
+			if strings.Join(mailer.to, ",") != strings.Join(wantTo, ",") {
+				t.Fatalf("mailed %v, want %v", mailer.to, wantTo)
+			}
+		})
+	}
+}
+
+func TestDigestSendNeedsVerifiedEmail(t *testing.T) {
+	store := &fakeDigestStore{saved: &models.DigestSettings{UserID: 7, Enabled: true, Email: "me@example.com"}}
+	mailer := &fakeMailer{}
+	h := NewDigestHandler(digest.NewDigester(nil, nil, nil, store, mailer, time.UTC, "http://app", time.Minute), nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/digest/send", nil)
+	r = r.WithContext(models.WithUserID(r.Context(), 7))
+	w := httptest.NewRecorder()
+	h.HandleSend(w, r)
+
+	if w.Code != http.StatusBadRequest || len(mailer.to) != 0 {
+		t.Fatalf("status = %d, mailed %v, want 400 and no mail", w.Code, mailer.to)
+	}
+
+	store.saved.EmailVerified = true
+	w = httptest.NewRecorder()
+	h.HandleSend(w, r)
+	if w.Code != http.StatusTooManyRequests || len(mailer.to) != 0 {
+		t.Fatalf("status = %d, mailed %v, want 429 once send interval is used up", w.Code, mailer.to)
+	}
+}
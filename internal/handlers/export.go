@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"life_forge/internal/ical"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/usecases"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	feedPastDays   = 30
+	feedFutureDays = 180
+)
+
+type ExportHandler struct {
+	calendarProvider storage.CalendarProvider
+	feedStorage      *storage.FeedStorage
+	location         *time.Location
+}
+
+func NewExportHandler(cp storage.CalendarProvider, fs *storage.FeedStorage, location *time.Location) *ExportHandler {
+	return &ExportHandler{calendarProvider: cp, feedStorage: fs, location: location}
+}
+
+// /api/export.ics -> same events as /api/gantt as downloadable file
+func (h *ExportHandler) HandleExportICS(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/export.go HandleExportICS"
+
+	timeMin, timeMax, calIDs := parseEventsQuery(r)
+
+	events, _, err := h.calendarProvider.ListEvents(r.Context(), timeMin, timeMax, calIDs...)
+	if err != nil {
+		http.Error(w, "Failed to load user events: "+err.Error(), http.StatusInternalServerError)
+		log.Printf("Failed to load user events in %s with err: %v", op, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="lifeforge.ics"`)
+	h.writeICS(w, usecases.EventsToICS(events, "LifeForge", h.location))
+}
+
+// /feed/<token>.ics -> read-only subscription for phones and other clients
+func (h *ExportHandler) HandleFeed(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/export.go HandleFeed"
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/feed/"), ".ics")
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	feed, err := h.feedStorage.GetFeedByToken(r.Context(), token)
+	if errors.Is(err, storage.ErrFeedNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load feed", http.StatusInternalServerError)
+		return
+	}
+
+	timeMin := time.Now().AddDate(0, 0, -feedPastDays).UTC()
+	timeMax := time.Now().AddDate(0, 0, feedFutureDays).UTC()
+
+	// feed url has no session, events are read as the feed owner
+	ctx := models.WithUserID(r.Context(), feed.UserID)
+	events, _, err := h.calendarProvider.ListEvents(ctx, timeMin, timeMax, feed.Calendars...)
+	if err != nil {
+		http.Error(w, "Failed to load events", http.StatusInternalServerError)
+		log.Printf("Failed to load feed events in %s with err: %v", op, err)
+		return
+	}
+
+	h.writeICS(w, usecases.EventsToICS(events, "LifeForge", h.location))
+}
+
+// /api/feeds GET - list, POST - new token, DELETE ?id= - revoke
+func (h *ExportHandler) HandleFeeds(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		feeds, err := h.feedStorage.ListFeeds(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to load feeds: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(feeds)
+
+	case http.MethodPost:
+		var req struct {
+			Calendars []string `json:"calendars"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+				return
+			}
+		}
+
+		feed, err := h.feedStorage.CreateFeed(r.Context(), req.Calendars)
+		if err != nil {
+			http.Error(w, "Failed to create feed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"feed": feed,
+			"url":  fmt.Sprintf("%s/feed/%s.ics", requestBaseURL(r), feed.Token),
+		})
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.feedStorage.DeleteFeed(r.Context(), id); err != nil {
+			http.Error(w, "Failed to delete feed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ExportHandler) writeICS(w http.ResponseWriter, cal *ical.Calendar) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if err := ical.Encode(w, cal); err != nil {
+		log.Printf("Failed to encode ics: %v", err)
+	}
+}
+
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if isSecureRequest(r) {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
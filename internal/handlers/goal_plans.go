@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/goals"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type GoalPlanHandler struct {
+	planner *goals.Planner
+}
+
+func NewGoalPlanHandler(planner *goals.Planner) *GoalPlanHandler {
+	return &GoalPlanHandler{planner: planner}
+}
+
+// planEdit is user's correction of drafted plan, dates are YYYY-MM-DD
+type planEdit struct {
+	Title      string `json:"title"`
+	Deadline   string `json:"deadline"`
+	Milestones []struct {
+		Title   string `json:"title"`
+		DueDate string `json:"due_date"`
+	} `json:"milestones"`
+	Session models.SessionPattern `json:"session"`
+}
+
+// /api/goal-plans POST {goal} - draft plan, GET ?id= - plan, PUT ?id= - edit plan before accepting
+func (h *GoalPlanHandler) HandlePlans(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/goal_plans.go HandlePlans"
+
+	switch r.Method {
+	case http.MethodPost:
+		var req struct {
+			Goal string `json:"goal"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.Goal) == "" {
+			http.Error(w, "goal is required", http.StatusBadRequest)
+			return
+		}
+		plan, err := h.planner.Draft(r.Context(), req.Goal)
+		if err != nil {
+			log.Printf("Failed to draft plan in %s with err: %v", op, err)
+			http.Error(w, "Failed to draft plan: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(plan)
+
+	case http.MethodGet, http.MethodPut:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		var plan *models.GoalPlan
+		if r.Method == http.MethodGet {
+			plan, err = h.planner.Plan(r.Context(), id)
+		} else {
+			edit, msg := decodePlanEdit(r)
+			if msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			plan, err = h.planner.Revise(r.Context(), id, edit)
+		}
+		if !writePlanError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(plan)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/goal-plans/accept POST {plan_id, calendar_id} - create goal and its sessions in calendar
+func (h *GoalPlanHandler) HandleAccept(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		PlanID     int    `json:"plan_id"`
+		CalendarID string `json:"calendar_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	goal, err := h.planner.Accept(r.Context(), req.PlanID, req.CalendarID)
+	if !writePlanError(w, err) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(goal)
+}
+
+// /api/tracked-goals GET ?id= - goals with milestones, sessions and progress, DELETE ?id=&delete_events=true - remove
+func (h *GoalPlanHandler) HandleGoals(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		id, _ := strconv.Atoi(r.URL.Query().Get("id"))
+		list, err := h.planner.Goals(r.Context(), id)
+		if err != nil {
+			http.Error(w, "Failed to load goals: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		deleteEvents := r.URL.Query().Get("delete_events") == "true"
+		if !writePlanError(w, h.planner.Delete(r.Context(), id, deleteEvents)) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/tracked-goals/sessions PUT {id, completed} - mark session done, goal progress is recomputed
+func (h *GoalPlanHandler) HandleSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID        int  `json:"id"`
+		Completed bool `json:"completed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	goal, err := h.planner.SetSessionCompleted(r.Context(), req.ID, req.Completed)
+	if !writePlanError(w, err) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(goal)
+}
+
+func decodePlanEdit(r *http.Request) (*models.GoalPlan, string) {
+	var req planEdit
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, "Invalid JSON format"
+	}
+
+	edit := &models.GoalPlan{Title: strings.TrimSpace(req.Title), Session: req.Session}
+	if req.Deadline != "" {
+		deadline, err := time.Parse("2006-01-02", req.Deadline)
+		if err != nil {
+			return nil, "deadline must be YYYY-MM-DD"
+		}
+		edit.Deadline = deadline
+	}
+	for _, m := range req.Milestones {
+		due, err := time.Parse("2006-01-02", m.DueDate)
+		if err != nil {
+			return nil, "milestone due_date must be YYYY-MM-DD"
+		}
+		if strings.TrimSpace(m.Title) == "" {
+			return nil, "milestone title is required"
+		}
+		edit.Milestones = append(edit.Milestones, models.PlannedMilestone{Title: strings.TrimSpace(m.Title), DueDate: due})
+	}
+	return edit, ""
+}
+
+// writePlanError maps planner errors to statuses, false means response is already written
+func writePlanError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, storage.ErrPlanNotFound), errors.Is(err, storage.ErrGoalNotFound), errors.Is(err, storage.ErrSessionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, storage.ErrPlanAlreadyAccepted):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, goals.ErrInvalidPlan):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Goal planning failed: "+err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
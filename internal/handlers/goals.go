@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type GoalHandler struct {
+	contextStorage *storage.ContextStorage
+}
+
+func NewGoalHandler(cs *storage.ContextStorage) *GoalHandler {
+	return &GoalHandler{contextStorage: cs}
+}
+
+type goalRequest struct {
+	Name     string  `json:"name"`
+	NewName  string  `json:"new_name,omitempty"`
+	Progress *string `json:"progress,omitempty"`
+}
+
+// /api/goals GET - goals with progress, POST - add goal, PUT - rename goal or set its progress, DELETE ?name= - remove goal
+func (h *GoalHandler) HandleGoals(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.writeContext(w, r)
+		return
+	}
+
+	userContext, err := h.contextStorage.GetContextByID(r.Context(), models.UserID(r.Context()))
+	if err != nil {
+		http.Error(w, "Failed to load goals: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		var req goalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		req.NewName = strings.TrimSpace(req.NewName)
+		if req.Name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+
+		idx := slices.Index(userContext.Goals, req.Name)
+		if r.Method == http.MethodPost {
+			if idx >= 0 {
+				http.Error(w, "Goal already exists", http.StatusConflict)
+				return
+			}
+			userContext.Goals = append(userContext.Goals, req.Name)
+			status = http.StatusCreated
+		} else {
+			if idx < 0 {
+				http.Error(w, "Goal not found", http.StatusNotFound)
+				return
+			}
+			if req.NewName != "" && req.NewName != req.Name {
+				if slices.Contains(userContext.Goals, req.NewName) {
+					http.Error(w, "Goal already exists", http.StatusConflict)
+					return
+				}
+				userContext.Goals[idx] = req.NewName
+				if progress, ok := userContext.Progress[req.Name]; ok {
+					userContext.Progress[req.NewName] = progress
+					delete(userContext.Progress, req.Name)
+				}
+				req.Name = req.NewName
+			}
+		}
+
+		if req.Progress != nil {
+			setProgress(&userContext, req.Name, *req.Progress)
+		}
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		idx := slices.Index(userContext.Goals, name)
+		if idx < 0 {
+			http.Error(w, "Goal not found", http.StatusNotFound)
+			return
+		}
+		userContext.Goals = slices.Delete(userContext.Goals, idx, idx+1)
+		delete(userContext.Progress, name)
+		status = http.StatusNoContent
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.contextStorage.SaveContext(r.Context(), &userContext); err != nil {
+		http.Error(w, "Failed to save goals: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(userContext)
+}
+
+// /api/progress GET - progress map, PUT {"key": "value"} - merge values, empty value removes key, DELETE ?key= - remove
+func (h *GoalHandler) HandleProgress(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.writeContext(w, r)
+		return
+	}
+
+	userContext, err := h.contextStorage.GetContextByID(r.Context(), models.UserID(r.Context()))
+	if err != nil {
+		http.Error(w, "Failed to load progress: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var updates map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		for key, value := range updates {
+			if strings.TrimSpace(key) == "" {
+				http.Error(w, "progress key must not be empty", http.StatusBadRequest)
+				return
+			}
+			setProgress(&userContext, strings.TrimSpace(key), value)
+		}
+
+	case http.MethodDelete:
+		key := r.URL.Query().Get("key")
+		if _, ok := userContext.Progress[key]; !ok {
+			http.Error(w, "Progress not found", http.StatusNotFound)
+			return
+		}
+		delete(userContext.Progress, key)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.contextStorage.SaveContext(r.Context(), &userContext); err != nil {
+		http.Error(w, "Failed to save progress: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userContext)
+}
+
+func (h *GoalHandler) writeContext(w http.ResponseWriter, r *http.Request) {
+	userContext, err := h.contextStorage.GetContextByID(r.Context(), models.UserID(r.Context()))
+	if err != nil {
+		http.Error(w, "Failed to load goals: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userContext)
+}
+
+func setProgress(userContext *models.Context, key, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		delete(userContext.Progress, key)
+		return
+	}
+	userContext.Progress[key] = value
+}
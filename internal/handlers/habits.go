@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/habits"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type HabitHandler struct {
+	tracker  *habits.Tracker
+	location *time.Location
+}
+
+func NewHabitHandler(tracker *habits.Tracker, location *time.Location) *HabitHandler {
+	return &HabitHandler{
+		tracker:  tracker,
+		location: location,
+	}
+}
+
+// /api/habits GET ?archived=true - habits with streaks, POST - new habit, DELETE ?id= - archive
+func (h *HabitHandler) HandleHabits(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/habits.go HandleHabits"
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := h.tracker.Habits(r.Context(), r.URL.Query().Get("archived") == "true")
+		if err != nil {
+			log.Printf("Failed to list habits in %s with err: %v", op, err)
+			http.Error(w, "Failed to load habits", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+
+	case http.MethodPost:
+		var req struct {
+			Title      string     `json:"title"`
+			Recurrence string     `json:"recurrence"`
+			CalendarID string     `json:"calendar_id"`
+			EventID    string     `json:"event_id"`   // link existing recurring event
+			StartTime  *time.Time `json:"start_time"` // or create new one
+			Duration   *float64   `json:"duration"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		if req.EventID != "" && req.StartTime != nil {
+			http.Error(w, "event_id and start_time can not be used together", http.StatusBadRequest)
+			return
+		}
+
+		habit := &models.Habit{
+			Title:      req.Title,
+			Recurrence: req.Recurrence,
+			CalendarID: req.CalendarID,
+			EventID:    req.EventID,
+		}
+		var event *models.EventRequest
+		if req.StartTime != nil {
+			event = &models.EventRequest{StartTime: req.StartTime, DurationHours: req.Duration}
+		}
+
+		created, err := h.tracker.Create(r.Context(), habit, event)
+		if !writeHabitError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		purge := r.URL.Query().Get("purge") == "true"
+		deleteEvent := r.URL.Query().Get("delete_event") == "true"
+		if !writeHabitError(w, h.tracker.Delete(r.Context(), id, purge, deleteEvent)) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/habits/checkins GET ?habit_id=&from=&to=, POST {habit_id, date, status, note}, DELETE ?habit_id=&date=
+func (h *HabitHandler) HandleCheckins(w http.ResponseWriter, r *http.Request) {
+	today := time.Now().In(h.location).Format("2006-01-02")
+
+	switch r.Method {
+	case http.MethodGet:
+		habitID, err := strconv.Atoi(r.URL.Query().Get("habit_id"))
+		if err != nil {
+			http.Error(w, "habit_id is required", http.StatusBadRequest)
+			return
+		}
+		from, err := parseDay(r.URL.Query().Get("from"), "2000-01-01")
+		if err != nil {
+			http.Error(w, "from must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to, err := parseDay(r.URL.Query().Get("to"), today)
+		if err != nil {
+			http.Error(w, "to must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		checkins, err := h.tracker.Checkins(r.Context(), habitID, from, to)
+		if !writeHabitError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(checkins)
+
+	case http.MethodPost:
+		var req struct {
+			HabitID int    `json:"habit_id"`
+			Date    string `json:"date"`
+			Status  string `json:"status"`
+			Note    string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		day, err := parseDay(req.Date, today)
+		if err != nil {
+			http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		habit, err := h.tracker.CheckIn(r.Context(), req.HabitID, day, req.Status, req.Note)
+		if !writeHabitError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(habit)
+
+	case http.MethodDelete:
+		habitID, err := strconv.Atoi(r.URL.Query().Get("habit_id"))
+		if err != nil {
+			http.Error(w, "habit_id is required", http.StatusBadRequest)
+			return
+		}
+		day, err := parseDay(r.URL.Query().Get("date"), today)
+		if err != nil {
+			http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		habit, err := h.tracker.Uncheck(r.Context(), habitID, day)
+		if !writeHabitError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(habit)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/habits/history GET ?id=&weeks=12 - streaks and weekly completion rate
+func (h *HabitHandler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	weeks := habits.HistoryWeeks
+	if v := r.URL.Query().Get("weeks"); v != "" {
+		weeks, err = strconv.Atoi(v)
+		if err != nil || weeks < 1 || weeks > 104 {
+			http.Error(w, "weeks must be between 1 and 104", http.StatusBadRequest)
+			return
+		}
+	}
+
+	habit, err := h.tracker.Habit(r.Context(), id, weeks)
+	if !writeHabitError(w, err) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(habit)
+}
+
+// parseDay reads YYYY-MM-DD as midnight UTC, the form habit days are stored in
+func parseDay(value, fallback string) (time.Time, error) {
+	if value == "" {
+		value = fallback
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// writeHabitError maps tracker errors to statuses, false means response is already written
+func writeHabitError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, storage.ErrHabitNotFound), errors.Is(err, storage.ErrCheckinNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, storage.ErrHabitExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, habits.ErrInvalidHabit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Habit tracking failed: "+err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"life_forge/internal/ical"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/usecases"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxImportSize = 5 << 20
+
+type ImportHandler struct {
+	calendarProvider storage.CalendarProvider
+	importStorage    *storage.ImportStorage
+	location         *time.Location // all-day events of the file start at midnight here
+}
+
+func NewImportHandler(cp storage.CalendarProvider, is *storage.ImportStorage, location *time.Location) *ImportHandler {
+	return &ImportHandler{calendarProvider: cp, importStorage: is, location: location}
+}
+
+// /api/import/ics POST .ics file (multipart "file" or raw body) -> preview with conflicts
+func (h *ImportHandler) HandleImportPreview(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/import.go HandleImportPreview"
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	cal, err := ical.Parse(body, h.location)
+	if err != nil {
+		http.Error(w, "Invalid ics file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(cal.Events) == 0 {
+		http.Error(w, "No events in file", http.StatusBadRequest)
+		return
+	}
+
+	timeMin, timeMax := usecases.ImportWindow(cal)
+	existing, _, err := h.calendarProvider.ListEvents(r.Context(), timeMin, timeMax)
+	if err != nil {
+		// preview is still useful without conflicts
+		log.Printf("Failed to load events for conflicts in %s with err: %v", op, err)
+	}
+
+	candidates := usecases.BuildImportPreview(cal, existing)
+
+	uids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		uids = append(uids, c.UID)
+	}
+	imported, err := h.importStorage.ImportedCalendars(r.Context(), uids)
+	if err != nil {
+		http.Error(w, "Failed to check imports: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := range candidates {
+		candidates[i].ImportedTo = imported[candidates[i].UID]
+	}
+
+	preview, err := h.importStorage.SavePreview(r.Context(), candidates)
+	if err != nil {
+		http.Error(w, "Failed to save preview: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// /api/import/ics/commit POST {preview_id, calendar_id, uids} -> result per event
+func (h *ImportHandler) HandleImportCommit(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/import.go HandleImportCommit"
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		PreviewID  int      `json:"preview_id"`
+		CalendarID string   `json:"calendar_id"`
+		UIDs       []string `json:"uids"` // empty means all events
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if req.CalendarID == "" {
+		req.CalendarID = "primary"
+	}
+
+	preview, err := h.importStorage.GetPreview(r.Context(), req.PreviewID)
+	if errors.Is(err, storage.ErrPreviewNotFound) {
+		http.Error(w, "Preview not found, upload file again", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load preview: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	selected := make(map[string]bool, len(req.UIDs))
+	for _, uid := range req.UIDs {
+		selected[uid] = true
+	}
+
+	results := []models.ImportResult{}
+	for _, c := range preview.Candidates {
+		if len(selected) > 0 && !selected[c.UID] {
+			continue
+		}
+		result := models.ImportResult{UID: c.UID}
+
+		reserved, err := h.importStorage.ReserveImport(r.Context(), c.UID, req.CalendarID)
+		if err != nil {
+			result.Status = "error"
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if !reserved {
+			result.Status = "duplicate"
+			results = append(results, result)
+			continue
+		}
+
+		created, err := h.calendarProvider.CreateEvent(r.Context(), req.CalendarID, c.Event)
+		if err != nil {
+			log.Printf("Failed to import %s in %s with err: %v", c.UID, op, err)
+			h.importStorage.ReleaseImport(r.Context(), c.UID, req.CalendarID)
+			result.Status = "error"
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if err := h.importStorage.CompleteImport(r.Context(), c.UID, req.CalendarID, created.ID); err != nil {
+			log.Printf("Event %s imported but not recorded: %v", created.ID, err)
+		}
+		result.Status = "imported"
+		result.EventID = created.ID
+		results = append(results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
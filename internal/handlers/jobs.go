@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/models"
+	"life_forge/internal/scheduler"
+	"life_forge/internal/storage"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultJobRunsLimit = 5
+	maxJobRunsLimit     = 50
+)
+
+// JobHandler is admin only: jobs are shared by all users and their errors mention user ids
+type JobHandler struct {
+	scheduler  *scheduler.Scheduler
+	jobStorage *storage.JobStorage
+	admins     map[int]bool
+}
+
+func NewJobHandler(s *scheduler.Scheduler, js *storage.JobStorage, adminIDs []int) *JobHandler {
+	admins := make(map[int]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+	return &JobHandler{scheduler: s, jobStorage: js, admins: admins}
+}
+
+// /api/admin/jobs GET ?runs= - jobs with their last runs, POST ?name= - run job now
+func (h *JobHandler) HandleJobs(w http.ResponseWriter, r *http.Request) {
+	if !h.admins[models.UserID(r.Context())] {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		limit := defaultJobRunsLimit
+		if l, err := strconv.Atoi(r.URL.Query().Get("runs")); err == nil && l > 0 {
+			limit = min(l, maxJobRunsLimit)
+		}
+		jobs, err := h.scheduler.Jobs(r.Context(), limit)
+		if err != nil {
+			http.Error(w, "Failed to load jobs: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jobs)
+
+	case http.MethodPost:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		err := h.jobStorage.TriggerNow(r.Context(), name)
+		if errors.Is(err, storage.ErrJobNotFound) {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to trigger job: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/journal"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultJournalLimit     = 50
+	maxJournalLimit         = 500
+	defaultJournalDays      = 30
+	maxMoodTrendDays        = 366
+	defaultReflectionsLimit = 10
+	maxReflectionsLimit     = 52
+)
+
+type JournalHandler struct {
+	journal  *journal.Journal
+	location *time.Location
+}
+
+func NewJournalHandler(j *journal.Journal, location *time.Location) *JournalHandler {
+	return &JournalHandler{journal: j, location: location}
+}
+
+// /api/journal GET ?id= or ?from=&to=&limit=, POST {text, mood}, PUT ?id= {text, mood}, DELETE ?id=
+func (h *JournalHandler) HandleEntries(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if idParam := r.URL.Query().Get("id"); idParam != "" {
+			id, err := strconv.Atoi(idParam)
+			if err != nil {
+				http.Error(w, "id must be a number", http.StatusBadRequest)
+				return
+			}
+			entry, err := h.journal.Entry(r.Context(), id)
+			if !writeJournalError(w, err) {
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(entry)
+			return
+		}
+
+		from, to, ok := h.period(w, r)
+		if !ok {
+			return
+		}
+		limit := defaultJournalLimit
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+			limit = min(l, maxJournalLimit)
+		}
+		entries, err := h.journal.Entries(r.Context(), from, to, limit)
+		if !writeJournalError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+
+	case http.MethodPost:
+		var req struct {
+			Text string `json:"text"`
+			Mood *int   `json:"mood"` // without mood the model scores the text
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		entry, err := h.journal.Write(r.Context(), req.Text, req.Mood, models.JournalSourceAPI)
+		if !writeJournalError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(entry)
+
+	case http.MethodPut:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Text *string `json:"text"`
+			Mood *int    `json:"mood"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		entry, err := h.journal.Update(r.Context(), id, req.Text, req.Mood)
+		if !writeJournalError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entry)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		if !writeJournalError(w, h.journal.Delete(r.Context(), id)) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/journal/mood GET ?from=&to= - daily mood next to calendar load, with correlation
+func (h *JournalHandler) HandleMood(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, ok := h.period(w, r)
+	if !ok {
+		return
+	}
+	if to.Sub(from) > maxMoodTrendDays*24*time.Hour {
+		http.Error(w, "period is longer than a year", http.StatusBadRequest)
+		return
+	}
+
+	trend, err := h.journal.Trend(r.Context(), from, to)
+	if !writeJournalError(w, err) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trend)
+}
+
+// /api/journal/reflections GET ?limit= - latest reflections, POST - reflect on the last 7 days now
+func (h *JournalHandler) HandleReflections(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/journal.go HandleReflections"
+
+	switch r.Method {
+	case http.MethodGet:
+		limit := defaultReflectionsLimit
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+			limit = min(l, maxReflectionsLimit)
+		}
+		reflections, err := h.journal.Reflections(r.Context(), limit)
+		if err != nil {
+			http.Error(w, "Failed to load reflections: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reflections)
+
+	case http.MethodPost:
+		reflection, err := h.journal.Reflect(r.Context(), time.Now())
+		if err != nil {
+			log.Printf("Failed to generate reflection in %s with err: %v", op, err)
+			http.Error(w, "Failed to generate reflection: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(reflection)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// period reads from and to as YYYY-MM-DD days in calendar timezone, to is inclusive.
+// Default is the last 30 days
+func (h *JournalHandler) period(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	now := time.Now().In(h.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, h.location)
+	from, to := today.AddDate(0, 0, 1-defaultJournalDays), today
+
+	for _, p := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", v, h.location)
+		if err != nil {
+			http.Error(w, p.name+" must be YYYY-MM-DD", http.StatusBadRequest)
+			return time.Time{}, time.Time{}, false
+		}
+		*p.value = day
+	}
+
+	to = to.AddDate(0, 0, 1)
+	if !from.Before(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// writeJournalError maps journal errors to statuses, false means response is already written
+func writeJournalError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, storage.ErrJournalEntryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, journal.ErrInvalidEntry):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Journal failed: "+err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
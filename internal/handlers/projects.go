@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"net/http"
+	"strconv"
+)
+
+type ProjectHandler struct {
+	projectStorage *storage.ProjectStorage
+}
+
+func NewProjectHandler(ps *storage.ProjectStorage) *ProjectHandler {
+	return &ProjectHandler{projectStorage: ps}
+}
+
+// /api/projects GET - list, POST - create, DELETE ?id= - delete with all tasks
+func (h *ProjectHandler) HandleProjects(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		projects, err := h.projectStorage.ListProjects(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to load projects: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(projects)
+
+	case http.MethodPost:
+		var project models.Project
+		if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		if project.Name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.projectStorage.CreateProject(r.Context(), &project); err != nil {
+			http.Error(w, "Failed to create project: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(project)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.projectStorage.DeleteProject(r.Context(), id); err != nil {
+			http.Error(w, "Failed to delete project: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/projects/tasks GET ?project_id= - list, POST - create, PUT - update by id, DELETE ?id= - delete
+func (h *ProjectHandler) HandleTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		projectID, _ := strconv.Atoi(r.URL.Query().Get("project_id"))
+		tasks, err := h.projectStorage.ListTasks(r.Context(), projectID)
+		if err != nil {
+			http.Error(w, "Failed to load tasks: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tasks)
+
+	case http.MethodPost, http.MethodPut:
+		var task models.ProjectTask
+		if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		if msg := validateTask(&task); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		status := http.StatusCreated
+		var err error
+		if r.Method == http.MethodPut {
+			status = http.StatusOK
+			err = h.projectStorage.UpdateTask(r.Context(), &task)
+		} else {
+			err = h.projectStorage.CreateTask(r.Context(), &task)
+		}
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, storage.ErrProjectNotFound) {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to save task: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(task)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.projectStorage.DeleteTask(r.Context(), id); err != nil {
+			http.Error(w, "Failed to delete task: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/projects/dependencies POST {task_id, depends_on} - add, DELETE ?task_id=&depends_on= - remove
+func (h *ProjectHandler) HandleDependencies(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TaskID    int `json:"task_id"`
+		DependsOn int `json:"depends_on"`
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		err := h.projectStorage.AddDependency(r.Context(), req.TaskID, req.DependsOn)
+		switch {
+		case errors.Is(err, storage.ErrTaskNotFound):
+			http.Error(w, "Task not found", http.StatusNotFound)
+		case errors.Is(err, storage.ErrDependencyCycle):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err != nil:
+			http.Error(w, "Failed to add dependency: "+err.Error(), http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+
+	case http.MethodDelete:
+		taskID, err1 := strconv.Atoi(r.URL.Query().Get("task_id"))
+		dependsOn, err2 := strconv.Atoi(r.URL.Query().Get("depends_on"))
+		if err1 != nil || err2 != nil {
+			http.Error(w, "task_id and depends_on are required", http.StatusBadRequest)
+			return
+		}
+		if err := h.projectStorage.RemoveDependency(r.Context(), taskID, dependsOn); err != nil {
+			http.Error(w, "Failed to delete dependency: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func validateTask(task *models.ProjectTask) string {
+	if task.Name == "" {
+		return "name is required"
+	}
+	if task.ProjectID == 0 && task.ID == 0 {
+		return "project_id is required"
+	}
+	if task.StartTime.IsZero() {
+		return "start_time is required"
+	}
+	if task.IsMilestone || task.EndTime.IsZero() {
+		task.EndTime = task.StartTime
+	}
+	if task.EndTime.Before(task.StartTime) {
+		return "end_time must not be before start_time"
+	}
+	if task.Progress < 0 || task.Progress > 100 {
+		return "progress must be between 0 and 100"
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"life_forge/internal/review"
+	"life_forge/internal/storage"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultReviewsLimit = 10
+	maxReviewsLimit     = 52
+)
+
+type ReviewHandler struct {
+	reviewer      *review.Reviewer
+	reviewStorage *storage.ReviewStorage
+}
+
+func NewReviewHandler(reviewer *review.Reviewer, rs *storage.ReviewStorage) *ReviewHandler {
+	return &ReviewHandler{reviewer: reviewer, reviewStorage: rs}
+}
+
+// /api/reviews GET ?limit= - latest reviews, POST - review the last 7 days now
+func (h *ReviewHandler) HandleReviews(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/reviews.go HandleReviews"
+
+	switch r.Method {
+	case http.MethodGet:
+		limit := defaultReviewsLimit
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+			limit = min(l, maxReviewsLimit)
+		}
+		reviews, err := h.reviewStorage.ListReviews(r.Context(), limit)
+		if err != nil {
+			http.Error(w, "Failed to load reviews: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reviews)
+
+	case http.MethodPost:
+		weekly, err := h.reviewer.GenerateWeekly(r.Context(), time.Now())
+		if err != nil {
+			log.Printf("Failed to generate review in %s with err: %v", op, err)
+			http.Error(w, "Failed to generate review: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(weekly)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"life_forge/internal/models"
+	"life_forge/internal/usecases"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	statsDefaultDays = 7
+	statsMaxDays     = 366
+)
+
+// StatsResponse is stats plus load status of every calendar, like in GanttResponse.
+// A calendar that failed to load is missing from totals, so UI has to warn about it
+type StatsResponse struct {
+	*models.Stats
+	Calendars []models.CalendarStatus `json:"calendars"`
+}
+
+// /api/stats?start=&end=&group_by=calendar|category|weekday|hour&calendars= -> time analytics,
+// default window is the last 7 days
+func (cal *CalendarHandler) HandleStats(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/stats.go HandleStats"
+
+	timeMin, timeMax, calIDs := parseEventsQuery(r)
+	if r.URL.Query().Get("start") == "" && r.URL.Query().Get("end") == "" {
+		timeMax = time.Now().UTC()
+		timeMin = timeMax.AddDate(0, 0, -statsDefaultDays)
+	}
+	if !timeMax.After(timeMin) || timeMax.Sub(timeMin) > statsMaxDays*24*time.Hour {
+		http.Error(w, "Invalid time window", http.StatusBadRequest)
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = models.StatsGroupCalendar
+	}
+
+	events, statuses, err := cal.calendarProvider.ListEvents(r.Context(), timeMin, timeMax, calIDs...)
+	if err != nil {
+		http.Error(w, "Failed to load user events: "+err.Error(), http.StatusInternalServerError)
+		log.Printf("Failed to load user events in %s with err: %v", op, err)
+		return
+	}
+
+	names := make(map[string]string)
+	if groupBy == models.StatsGroupCalendar {
+		if calendars, err := cal.calendarProvider.ListCalendars(r.Context()); err == nil {
+			for _, c := range calendars {
+				names[c.ID] = c.Summary
+				if c.Primary && c.Provider == models.ProviderGoogle {
+					names["primary"] = c.Summary
+				}
+			}
+		}
+	}
+
+	stats, err := usecases.ComputeStats(events, timeMin, timeMax, groupBy, cal.location, names)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(StatsResponse{Stats: stats, Calendars: statuses})
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/tasks"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type TaskHandler struct {
+	manager *tasks.Manager
+}
+
+func NewTaskHandler(manager *tasks.Manager) *TaskHandler {
+	return &TaskHandler{manager: manager}
+}
+
+// /api/tasks GET ?id= or ?status=&tag=&due_before=, POST task, PUT ?id= partial task, DELETE ?id=
+func (h *TaskHandler) HandleTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		if idParam := query.Get("id"); idParam != "" {
+			id, err := strconv.Atoi(idParam)
+			if err != nil {
+				http.Error(w, "id must be a number", http.StatusBadRequest)
+				return
+			}
+			task, err := h.manager.Get(r.Context(), id)
+			if !writeTaskError(w, err) {
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(task)
+			return
+		}
+
+		filter := models.TaskFilter{Status: query.Get("status"), Tag: query.Get("tag")}
+		if v := query.Get("due_before"); v != "" {
+			day, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				http.Error(w, "due_before must be YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			filter.DueBefore = &day
+		}
+		list, err := h.manager.List(r.Context(), filter)
+		if !writeTaskError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+
+	case http.MethodPost:
+		var req struct {
+			Title    string   `json:"title"`
+			Notes    string   `json:"notes"`
+			Due      string   `json:"due"` // YYYY-MM-DD
+			Priority string   `json:"priority"`
+			Estimate *float64 `json:"estimate"`
+			Status   string   `json:"status"`
+			Tags     []string `json:"tags"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		task := &models.Task{
+			Title:         req.Title,
+			Notes:         req.Notes,
+			Priority:      req.Priority,
+			EstimateHours: req.Estimate,
+			Status:        req.Status,
+			Tags:          req.Tags,
+		}
+		if req.Due != "" {
+			due, err := time.Parse("2006-01-02", req.Due)
+			if err != nil {
+				http.Error(w, "due must be YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			task.Due = &due
+		}
+		task, err := h.manager.Create(r.Context(), task)
+		if !writeTaskError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(task)
+
+	case http.MethodPut:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		var patch models.TaskPatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		task, err := h.manager.Update(r.Context(), id, patch)
+		if !writeTaskError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(task)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		if !writeTaskError(w, h.manager.Delete(r.Context(), id)) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/tasks/sync POST - sync with Google Tasks now
+func (h *TaskHandler) HandleSync(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/tasks.go HandleSync"
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result, err := h.manager.Sync(r.Context())
+	if errors.Is(err, tasks.ErrSyncDisabled) {
+		http.Error(w, "Google Tasks sync is disabled, set GOOGLE_TASKS_SYNC=true", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to sync tasks in %s with err: %v", op, err)
+		http.Error(w, "Failed to sync tasks: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// writeTaskError maps task errors to statuses, false means response is already written
+func writeTaskError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, storage.ErrTodoNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, tasks.ErrInvalidTask):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Tasks failed: "+err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"net/http"
+	"strconv"
+)
+
+type TelegramHandler struct {
+	telegramStorage *storage.TelegramStorage
+}
+
+func NewTelegramHandler(ts *storage.TelegramStorage) *TelegramHandler {
+	return &TelegramHandler{telegramStorage: ts}
+}
+
+// /api/telegram/link GET - linked Telegram accounts, POST - new link code, DELETE ?telegram_id= - unlink
+func (h *TelegramHandler) HandleLink(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		links, err := h.telegramStorage.ListLinks(r.Context(), models.UserID(r.Context()))
+		if err != nil {
+			http.Error(w, "Failed to load Telegram accounts: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(links)
+
+	case http.MethodPost:
+		code, err := h.telegramStorage.CreateLinkCode(r.Context(), models.UserID(r.Context()))
+		if err != nil {
+			http.Error(w, "Failed to create link code: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(code)
+
+	case http.MethodDelete:
+		telegramID, err := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "telegram_id is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.telegramStorage.Unlink(r.Context(), models.UserID(r.Context()), telegramID); err != nil {
+			http.Error(w, "Failed to unlink Telegram: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
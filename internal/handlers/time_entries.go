@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/timetrack"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxTimeReportDays = 366
+
+type TimeHandler struct {
+	timesheet *timetrack.Timesheet
+	location  *time.Location
+}
+
+func NewTimeHandler(timesheet *timetrack.Timesheet, location *time.Location) *TimeHandler {
+	return &TimeHandler{timesheet: timesheet, location: location}
+}
+
+type timeEntryRequest struct {
+	Title      string     `json:"title"`
+	CalendarID string     `json:"calendar_id"`
+	EventID    string     `json:"event_id"`
+	TaskID     *int       `json:"task_id"`
+	Start      time.Time  `json:"start"`
+	End        *time.Time `json:"end"`
+	Note       string     `json:"note"`
+}
+
+func (req timeEntryRequest) entry() *models.TimeEntry {
+	return &models.TimeEntry{
+		Title:      req.Title,
+		CalendarID: req.CalendarID,
+		EventID:    req.EventID,
+		TaskID:     req.TaskID,
+		Start:      req.Start,
+		End:        req.End,
+		Note:       req.Note,
+	}
+}
+
+// /api/time/entries GET ?id= or ?from=&to= (days, default this week), POST manual log {title, start, end, event_id, task_id},
+// PUT ?id= partial entry, DELETE ?id=
+func (h *TimeHandler) HandleEntries(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if idParam := r.URL.Query().Get("id"); idParam != "" {
+			id, err := strconv.Atoi(idParam)
+			if err != nil {
+				http.Error(w, "id must be a number", http.StatusBadRequest)
+				return
+			}
+			entry, err := h.timesheet.Entry(r.Context(), id)
+			if !writeTimeError(w, err) {
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(entry)
+			return
+		}
+
+		from, to, ok := h.period(w, r)
+		if !ok {
+			return
+		}
+		entries, err := h.timesheet.Entries(r.Context(), from, to)
+		if !writeTimeError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+
+	case http.MethodPost:
+		var req timeEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		entry, err := h.timesheet.Log(r.Context(), req.entry())
+		if !writeTimeError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(entry)
+
+	case http.MethodPut:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		var patch models.TimeEntryPatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		entry, err := h.timesheet.Update(r.Context(), id, patch)
+		if !writeTimeError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entry)
+
+	case http.MethodDelete:
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		if !writeTimeError(w, h.timesheet.Delete(r.Context(), id)) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/time/timer GET - running timer or null, POST {title, event_id, task_id} - start, DELETE - stop
+func (h *TimeHandler) HandleTimer(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		entry, err := h.timesheet.Running(r.Context())
+		if !writeTimeError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entry)
+
+	case http.MethodPost:
+		var req timeEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		entry := req.entry()
+		entry.End = nil
+		started, stopped, err := h.timesheet.Start(r.Context(), entry)
+		if !writeTimeError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]*models.TimeEntry{
+			"started": started,
+			"stopped": stopped,
+		})
+
+	case http.MethodDelete:
+		entry, err := h.timesheet.Stop(r.Context())
+		if !writeTimeError(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entry)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /api/time/report GET ?from=&to=&group_by=day|week|calendar&calendars= - planned events against tracked time
+func (h *TimeHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/time_entries.go HandleReport"
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, ok := h.period(w, r)
+	if !ok {
+		return
+	}
+	if to.Sub(from) > maxTimeReportDays*24*time.Hour {
+		http.Error(w, "period is longer than a year", http.StatusBadRequest)
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	switch groupBy {
+	case "":
+		groupBy = models.PlanGroupDay
+	case models.PlanGroupDay, models.PlanGroupWeek, models.PlanGroupCalendar:
+	default:
+		http.Error(w, "group_by must be day, week or calendar", http.StatusBadRequest)
+		return
+	}
+
+	var calIDs []string
+	if cals := r.URL.Query().Get("calendars"); cals != "" {
+		calIDs = strings.Split(cals, ",")
+	}
+
+	report, err := h.timesheet.Report(r.Context(), from, to, groupBy, calIDs...)
+	if err != nil {
+		log.Printf("Failed to build report in %s with err: %v", op, err)
+		http.Error(w, "Failed to build report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// period reads from and to as YYYY-MM-DD days in calendar timezone, to is inclusive.
+// Default is the current week
+func (h *TimeHandler) period(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	from, to := h.timesheet.Week()
+	to = to.AddDate(0, 0, -1)
+
+	for _, p := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", v, h.location)
+		if err != nil {
+			http.Error(w, p.name+" must be YYYY-MM-DD", http.StatusBadRequest)
+			return time.Time{}, time.Time{}, false
+		}
+		*p.value = day
+	}
+
+	to = to.AddDate(0, 0, 1)
+	if !from.Before(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// writeTimeError maps time tracking errors to statuses, false means response is already written
+func writeTimeError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, storage.ErrTimeEntryNotFound), errors.Is(err, storage.ErrNoRunningTimer):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, timetrack.ErrInvalidEntry):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Time tracking failed: "+err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookieName = "lf_session"
+	minPasswordLength = 8
+)
+
+// paths available without login, feed urls carry their own token
+var publicPaths = []string{"/auth/login", "/auth/register", "/static/", "/feed/", "/api/digest/verify"}
+
+type UserHandler struct {
+	userStorage    *storage.UserStorage
+	contextStorage *storage.ContextStorage
+	sessionTTL     time.Duration
+}
+
+func NewUserHandler(us *storage.UserStorage, cs *storage.ContextStorage, sessionTTL time.Duration) *UserHandler {
+	return &UserHandler{userStorage: us, contextStorage: cs, sessionTTL: sessionTTL}
+}
+
+type credentials struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// /auth/register POST {email, name, password} -> new account with started session
+func (h *UserHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	op := "internal/handlers/users.go HandleRegister"
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req credentials
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		http.Error(w, "invalid email", http.StatusBadRequest)
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		http.Error(w, "password must be at least 8 characters", http.StatusBadRequest)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		// bcrypt rejects passwords longer than 72 bytes
+		http.Error(w, "password is too long", http.StatusBadRequest)
+		return
+	}
+
+	user := &models.User{Email: req.Email, Name: strings.TrimSpace(req.Name)}
+	err = h.userStorage.CreateUser(r.Context(), user, string(hash))
+	if errors.Is(err, storage.ErrEmailTaken) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.contextStorage.EnsureContext(r.Context(), user.ID); err != nil {
+		log.Printf("Failed to init context of user %d in %s with err: %v", user.ID, op, err)
+	}
+
+	if !h.startSession(w, r, user.ID) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// /auth/login POST {email, password} -> session cookie
+func (h *UserHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req credentials
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	user, hash, err := h.userStorage.GetUserByEmail(r.Context(), strings.TrimSpace(req.Email))
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+		http.Error(w, "Failed to load user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// unknown email and wrong password look the same
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)) != nil {
+		http.Error(w, "invalid email or password", http.StatusUnauthorized)
+		return
+	}
+
+	if !h.startSession(w, r, user.ID) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// /auth/logout POST -> drops current session
+func (h *UserHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		if err := h.userStorage.DeleteSession(r.Context(), cookie.Value); err != nil {
+			http.Error(w, "Failed to logout: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// /api/me -> current user
+func (h *UserHandler) HandleMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, err := h.userStorage.GetUser(r.Context(), models.UserID(r.Context()))
+	if errors.Is(err, storage.ErrUserNotFound) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// Middleware puts session user into request context, everything except index page and public paths requires login
+func (h *UserHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(sessionCookieName)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		user, err := h.userStorage.GetSessionUser(r.Context(), cookie.Value)
+		if errors.Is(err, storage.ErrLoginSessionNotFound) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to check session", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(models.WithUserID(r.Context(), user.ID)))
+	})
+}
+
+func (h *UserHandler) startSession(w http.ResponseWriter, r *http.Request, userID int) bool {
+	expiresAt := time.Now().Add(h.sessionTTL)
+	token, err := h.userStorage.CreateSession(r.Context(), userID, expiresAt)
+	if err != nil {
+		http.Error(w, "Failed to start session: "+err.Error(), http.StatusInternalServerError)
+		return false
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return true
+}
+
+func isPublicPath(path string) bool {
+	if path == "/" {
+		return true
+	}
+	for _, p := range publicPaths {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecureRequest(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
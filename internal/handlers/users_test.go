@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"life_forge/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPublicPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/auth/login", true},
+		{"/auth/register", true},
+		{"/static/app.js", true},
+		{"/feed/abc.ics", true},
+		{"/api/digest/verify", true},
+		{"/auth/logout", false},
+		{"/auth/login/extra", false},
+		{"/api/me", false},
+		{"/staticfile", false},
+		{"/api/digest/settings", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicPath(tt.path); got != tt.want {
+			t.Errorf("isPublicPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsSecureRequest(t *testing.T) {
+	plain := httptest.NewRequest(http.MethodGet, "/", nil)
+	if isSecureRequest(plain) {
+		t.Fatal("plain request is secure")
+	}
+	proxied := httptest.NewRequest(http.MethodGet, "/", nil)
+	proxied.Header.Set("X-Forwarded-Proto", "https")
+	direct := httptest.NewRequest(http.MethodGet, "/", nil)
+	direct.TLS = &tls.ConnectionState{}
+	if !isSecureRequest(proxied) || !isSecureRequest(direct) {
+		t.Fatal("https request is not secure")
+	}
+}
+
+func TestMiddlewareWithoutSession(t *testing.T) {
+	h := NewUserHandler(nil, nil, time.Hour)
+	var reached []string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = append(reached, r.URL.Path)
+	})
+
+	for _, path := range []string{"/auth/login", "/api/me"} {
+		w := httptest.NewRecorder()
+		h.Middleware(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if path == "/api/me" && w.Code != http.StatusUnauthorized {
+			t.Fatalf("%s status = %d, want 401", path, w.Code)
+		}
+	}
+	if len(reached) != 1 || reached[0] != "/auth/login" {
+		t.Fatalf("reached %v, want public path only", reached)
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{"email":`},
+		{"invalid email", `{"email":"me","password":"long enough"}`},
+		{"short password", `{"email":"me@example.com","password":"short"}`},
+		{"too long password", `{"email":"me@example.com","password":"` + strings.Repeat("x", 73) + `"}`},
+	}
+
+	// storage is nil, invalid requests must not get that far
+	h := NewUserHandler(nil, nil, time.Hour)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.HandleRegister(w, httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d (%s), want 400", w.Code, w.Body.String())
+			}
+			if len(w.Result().Cookies()) != 0 {
+				t.Fatal("session cookie set for rejected registration")
+			}
+		})
+	}
+}
+
+func TestJobsAdminOnly(t *testing.T) {
+	h := NewJobHandler(nil, nil, []int{1})
+
+	r := httptest.NewRequest(http.MethodGet, "/api/admin/jobs", nil)
+	r = r.WithContext(models.WithUserID(r.Context(), 2))
+	w := httptest.NewRecorder()
+	h.HandleJobs(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403 for user not in ADMIN_USER_IDS", w.Code)
+	}
+}
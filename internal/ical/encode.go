@@ -0,0 +1,123 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	prodID       = "-//LifeForge AI//LifeForge//RU"
+	maxLineOctet = 75
+)
+
+// Encode writes calendar as VCALENDAR, timed events are written in UTC unless cal.Location is set
+func Encode(w io.Writer, cal *Calendar) error {
+	bw := bufio.NewWriter(w)
+	enc := &encoder{w: bw, loc: cal.Location}
+	if enc.loc == time.UTC {
+		enc.loc = nil
+	}
+
+	enc.line("BEGIN:VCALENDAR")
+	enc.line("VERSION:2.0")
+	enc.line("PRODID:" + prodID)
+	enc.line("CALSCALE:GREGORIAN")
+	if cal.Name != "" {
+		enc.line("X-WR-CALNAME:" + escapeText(cal.Name))
+	}
+	if enc.loc != nil {
+		enc.line("X-WR-TIMEZONE:" + enc.loc.String())
+		from, to := eventsRange(cal.Events)
+		enc.vtimezone(from, to)
+	}
+
+	stamp := time.Now().UTC().Format(utcLayout)
+	for _, e := range cal.Events {
+		enc.event(e, stamp)
+	}
+
+	enc.line("END:VCALENDAR")
+
+	if enc.err != nil {
+		return enc.err
+	}
+	return bw.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	loc *time.Location
+	err error
+}
+
+func (enc *encoder) event(e Event, stamp string) {
+	enc.line("BEGIN:VEVENT")
+	enc.line("UID:" + e.UID)
+	enc.line("DTSTAMP:" + stamp)
+	enc.line(enc.dateProp("DTSTART", e.Start, e.AllDay))
+	if !e.End.IsZero() {
+		enc.line(enc.dateProp("DTEND", e.End, e.AllDay))
+	}
+	if !e.RecurrenceID.IsZero() {
+		enc.line(enc.dateProp("RECURRENCE-ID", e.RecurrenceID, e.AllDay))
+	}
+	if e.RRule != "" {
+		enc.line("RRULE:" + e.RRule)
+	}
+	for _, ex := range e.ExDates {
+		enc.line(enc.dateProp("EXDATE", ex, e.AllDay))
+	}
+	enc.line("SUMMARY:" + escapeText(e.Summary))
+	if e.Description != "" {
+		enc.line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	if e.Location != "" {
+		enc.line("LOCATION:" + escapeText(e.Location))
+	}
+	if e.Status != "" {
+		enc.line("STATUS:" + e.Status)
+	}
+	enc.line("END:VEVENT")
+}
+
+// dateProp writes DATE for all-day events, local time with TZID when location is set, UTC otherwise.
+// Dates are taken in location too, all-day events start at midnight there
+func (enc *encoder) dateProp(name string, t time.Time, allDay bool) string {
+	if allDay {
+		if enc.loc != nil {
+			t = t.In(enc.loc)
+		}
+		return fmt.Sprintf("%s;VALUE=DATE:%s", name, t.Format(dateLayout))
+	}
+	if enc.loc != nil {
+		return fmt.Sprintf("%s;TZID=%s:%s", name, enc.loc.String(), t.In(enc.loc).Format(dateTimeLayout))
+	}
+	return fmt.Sprintf("%s:%s", name, t.UTC().Format(utcLayout))
+}
+
+// line writes content line folded to 75 octets without splitting utf-8 runes
+func (enc *encoder) line(s string) {
+	if enc.err != nil {
+		return
+	}
+
+	limit := maxLineOctet
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		if _, enc.err = enc.w.WriteString(s[:cut] + "\r\n "); enc.err != nil {
+			return
+		}
+		s = s[cut:]
+		limit = maxLineOctet - 1
+	}
+	_, enc.err = enc.w.WriteString(s + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
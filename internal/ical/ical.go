@@ -0,0 +1,325 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+	utcLayout      = "20060102T150405Z"
+)
+
+// Calendar is a parsed VCALENDAR, only VEVENT components are kept.
+// When Location is set, Encode writes timed events with TZID and adds matching VTIMEZONE
+type Calendar struct {
+	Name     string
+	Location *time.Location
+	Events   []Event
+}
+
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Status       string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string // value without "RRULE:" prefix
+	ExDates      []time.Time
+	RecurrenceID time.Time
+}
+
+// Property is one content line: NAME;PARAM=VALUE:value
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Parse reads VCALENDAR stream and returns its events, DATE values (all-day events) are midnight in location
+func Parse(r io.Reader, location *time.Location) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &Calendar{}
+	var stack []string
+	var current *Event
+	var props []Property
+
+	for n, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			stack = append(stack, strings.ToUpper(prop.Value))
+			if strings.EqualFold(prop.Value, "VEVENT") {
+				current = &Event{}
+				props = nil
+			}
+			continue
+		case "END":
+			if len(stack) == 0 || !strings.EqualFold(stack[len(stack)-1], prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", n+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+			if strings.EqualFold(prop.Value, "VEVENT") && current != nil {
+				if err := fillEvent(current, props, location); err != nil {
+					return nil, err
+				}
+				cal.Events = append(cal.Events, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if len(stack) == 0 {
+			continue
+		}
+		switch stack[len(stack)-1] {
+		case "VEVENT":
+			props = append(props, prop)
+		case "VCALENDAR":
+			if prop.Name == "X-WR-CALNAME" {
+				cal.Name = unescapeText(prop.Value)
+			}
+		}
+	}
+
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("unterminated component %s", stack[len(stack)-1])
+	}
+
+	return cal, nil
+}
+
+func fillEvent(e *Event, props []Property, location *time.Location) error {
+	var duration time.Duration
+	hasEnd := false
+
+	for _, p := range props {
+		var err error
+		switch p.Name {
+		case "UID":
+			e.UID = p.Value
+		case "SUMMARY":
+			e.Summary = unescapeText(p.Value)
+		case "DESCRIPTION":
+			e.Description = unescapeText(p.Value)
+		case "LOCATION":
+			e.Location = unescapeText(p.Value)
+		case "STATUS":
+			e.Status = strings.ToUpper(p.Value)
+		case "DTSTART":
+			e.Start, e.AllDay, err = ParseDateTime(p, location)
+		case "DTEND":
+			e.End, _, err = ParseDateTime(p, location)
+			hasEnd = true
+		case "DURATION":
+			duration, err = ParseDuration(p.Value)
+		case "RRULE":
+			e.RRule = p.Value
+		case "EXDATE":
+			for _, v := range strings.Split(p.Value, ",") {
+				var t time.Time
+				t, _, err = ParseDateTime(Property{Name: p.Name, Params: p.Params, Value: v}, location)
+				if err != nil {
+					break
+				}
+				e.ExDates = append(e.ExDates, t)
+			}
+		case "RECURRENCE-ID":
+			e.RecurrenceID, _, err = ParseDateTime(p, location)
+		}
+		if err != nil {
+			return fmt.Errorf("event %q: %s: %w", e.UID, p.Name, err)
+		}
+	}
+
+	if e.Start.IsZero() {
+		return fmt.Errorf("event %q: DTSTART is required", e.UID)
+	}
+
+	if !hasEnd {
+		switch {
+		case duration > 0:
+			e.End = e.Start.Add(duration)
+		case e.AllDay:
+			e.End = e.Start.AddDate(0, 0, 1)
+		default:
+			e.End = e.Start
+		}
+	}
+
+	return nil
+}
+
+// ParseDateTime understands DATE, floating, UTC and TZID forms. DATE is midnight in dateLocation,
+// the same way all-day events of Google are stored, nil means UTC
+func ParseDateTime(p Property, dateLocation *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.Value)
+
+	if strings.EqualFold(p.Params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		if dateLocation == nil {
+			dateLocation = time.UTC
+		}
+		t, err := time.ParseInLocation(dateLayout, value, dateLocation)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t, false, err
+	}
+
+	loc := time.Local
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.Trim(tzid, "/")); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+	return t, false, err
+}
+
+// ParseDuration parses RFC 5545 duration like PT1H30M or P1D
+func ParseDuration(value string) (time.Duration, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+	s = strings.TrimPrefix(s, "+")
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("bad duration %q", value)
+	}
+	s = s[1:]
+
+	var total time.Duration
+	inTime := false
+	num := 0
+	hasNum := false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			num = num*10 + int(r-'0')
+			hasNum = true
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		if !hasNum {
+			return 0, fmt.Errorf("bad duration %q", value)
+		}
+		unit := time.Duration(num)
+		switch {
+		case r == 'W' && !inTime:
+			total += unit * 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			total += unit * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += unit * time.Hour
+		case r == 'M' && inTime:
+			total += unit * time.Minute
+		case r == 'S' && inTime:
+			total += unit * time.Second
+		default:
+			return 0, fmt.Errorf("bad duration %q", value)
+		}
+		num = 0
+		hasNum = false
+	}
+
+	return sign * total, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (Property, error) {
+	prop := Property{Params: map[string]string{}}
+
+	// name and params end at first ':' outside of quotes
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return prop, fmt.Errorf("no ':' in %q", line)
+	}
+
+	head := line[:colon]
+	prop.Value = line[colon+1:]
+
+	parts := splitOutsideQuotes(head, ';')
+	prop.Name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		if idx := strings.Index(param, "="); idx > 0 {
+			prop.Params[strings.ToUpper(param[:idx])] = strings.Trim(param[idx+1:], `"`)
+		}
+	}
+
+	return prop, nil
+}
+
+func splitOutsideQuotes(s string, sep rune) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i, r := range s {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == sep && !inQuotes {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescapeText(s string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return replacer.Replace(s)
+}
+
+func escapeText(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`, "\r", "")
+	return replacer.Replace(s)
+}
@@ -0,0 +1,172 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"X-WR-CALNAME:Work\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:Europe/Moscow\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:19700101T000000\r\n" +
+	"TZOFFSETFROM:+0300\r\n" +
+	"TZOFFSETTO:+0300\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"SUMMARY:Standup\\, daily\r\n" +
+	"DESCRIPTION:first line\\nsecond \r\n" +
+	" line\r\n" +
+	"DTSTART;TZID=Europe/Moscow:20260105T100000\r\n" +
+	"DURATION:PT15M\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR\r\n" +
+	"EXDATE;TZID=Europe/Moscow:20260107T100000,20260109T100000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"DTSTART;VALUE=DATE:20260308\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:call\r\n" +
+	"SUMMARY:Call\r\n" +
+	"STATUS:cancelled\r\n" +
+	"DTSTART:20260110T120000Z\r\n" +
+	"DTEND:20260110T130000Z\r\n" +
+	"RECURRENCE-ID:20260110T120000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	cal, err := Parse(strings.NewReader(sample), time.UTC)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cal.Name != "Work" {
+		t.Fatalf("name = %q", cal.Name)
+	}
+	if len(cal.Events) != 3 {
+		t.Fatalf("events = %d, want 3", len(cal.Events))
+	}
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("no tzdata: ", err)
+	}
+
+	standup := cal.Events[0]
+	if standup.Summary != "Standup, daily" || standup.Description != "first line\nsecond line" {
+		t.Fatalf("text = %q / %q, want unescaped and unfolded", standup.Summary, standup.Description)
+	}
+	wantStart := time.Date(2026, 1, 5, 10, 0, 0, 0, moscow)
+	if !standup.Start.Equal(wantStart) || !standup.End.Equal(wantStart.Add(15*time.Minute)) || standup.AllDay {
+		t.Fatalf("standup = %v..%v allDay=%v", standup.Start, standup.End, standup.AllDay)
+	}
+	if standup.RRule != "FREQ=WEEKLY;BYDAY=MO,WE,FR" || len(standup.ExDates) != 2 {
+		t.Fatalf("rrule = %q, exdates = %v", standup.RRule, standup.ExDates)
+	}
+
+	holiday := cal.Events[1]
+	if !holiday.AllDay || holiday.End.Sub(holiday.Start) != 24*time.Hour {
+		t.Fatalf("holiday = %v..%v allDay=%v, want one whole day", holiday.Start, holiday.End, holiday.AllDay)
+	}
+
+	call := cal.Events[2]
+	if call.Status != "CANCELLED" || !call.RecurrenceID.Equal(time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("call = %+v", call)
+	}
+}
+
+func TestParseAllDayInLocation(t *testing.T) {
+	// west of UTC midnight of UTC is the previous local day, so a UTC reading would shift every date
+	newYork := time.FixedZone("EST", -5*60*60)
+	input := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:gym\r\n" +
+		"DTSTART;VALUE=DATE:20260302\r\n" +
+		"DTEND;VALUE=DATE:20260303\r\n" +
+		"RRULE:FREQ=DAILY;UNTIL=20260305\r\n" +
+		"EXDATE;VALUE=DATE:20260303\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := Parse(strings.NewReader(input), newYork)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	gym := cal.Events[0]
+	wantStart := time.Date(2026, 3, 2, 0, 0, 0, 0, newYork)
+	if !gym.AllDay || !gym.Start.Equal(wantStart) || !gym.End.Equal(wantStart.AddDate(0, 0, 1)) {
+		t.Fatalf("gym = %v..%v allDay=%v, want local midnight %v", gym.Start, gym.End, gym.AllDay, wantStart)
+	}
+
+	occurrences, err := gym.Expand(wantStart, wantStart.AddDate(0, 0, 10))
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	var days []int
+	for _, occ := range occurrences {
+		days = append(days, occ.Start.In(newYork).Day())
+	}
+	// UNTIL date includes its own day, EXDATE date drops the 3rd
+	if len(days) != 3 || days[0] != 2 || days[1] != 4 || days[2] != 5 {
+		t.Fatalf("instances on days %v, want 2, 4 and 5 of March", days)
+	}
+
+	var buf strings.Builder
+	if err := Encode(&buf, &Calendar{Location: newYork, Events: []Event{{UID: "gym", Start: gym.Start.UTC(), End: gym.End.UTC(), AllDay: true}}}); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if !strings.Contains(buf.String(), "DTSTART;VALUE=DATE:20260302") || !strings.Contains(buf.String(), "DTEND;VALUE=DATE:20260303") {
+		t.Fatalf("encoded dates are not local:\n%s", buf.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "no colon", input: "BEGIN:VCALENDAR\nGARBAGE\nEND:VCALENDAR\n"},
+		{name: "unterminated", input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20260101T100000Z\n"},
+		{name: "mismatched end", input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VCALENDAR\n"},
+		{name: "no dtstart", input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:x\nEND:VEVENT\nEND:VCALENDAR\n"},
+		{name: "bad duration", input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20260101T100000Z\nDURATION:1H\nEND:VEVENT\nEND:VCALENDAR\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.input), time.UTC); err == nil {
+				t.Fatal("Parse succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "PT1H30M", want: 90 * time.Minute},
+		{value: "P1D", want: 24 * time.Hour},
+		{value: "P1W", want: 7 * 24 * time.Hour},
+		{value: "P1DT2H", want: 26 * time.Hour},
+		{value: "-PT15M", want: -15 * time.Minute},
+		{value: "PT45S", want: 45 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDuration(tt.value)
+			if err != nil || got != tt.want {
+				t.Fatalf("ParseDuration = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
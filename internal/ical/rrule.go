@@ -0,0 +1,372 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRulePeriods bounds one Expand call, counted from the first period that can reach the window
+const maxRulePeriods = 10000
+
+// Rule is a subset of RFC 5545 RRULE: FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+
+	untilDate bool // UNTIL is DATE, it is read in location of DTSTART by Expand
+}
+
+// WeekdayNum is BYDAY item like MO, 2TU or -1FR
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Occurrence is a single instance of (possibly recurring) event
+type Occurrence struct {
+	Start time.Time
+	End   time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+func ParseRule(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	rule := &Rule{Interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			rule.Freq = val
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad INTERVAL %q", val)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad COUNT %q", val)
+			}
+			rule.Count = n
+		case "UNTIL":
+			t, isDate, err := ParseDateTime(Property{Value: val}, nil)
+			if err != nil {
+				return nil, fmt.Errorf("bad UNTIL %q: %w", val, err)
+			}
+			rule.Until, rule.untilDate = t, isDate
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				if len(d) < 2 {
+					return nil, fmt.Errorf("bad BYDAY %q", d)
+				}
+				day, ok := weekdays[d[len(d)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("bad BYDAY %q", d)
+				}
+				n := 0
+				if num := d[:len(d)-2]; num != "" {
+					var err error
+					if n, err = strconv.Atoi(num); err != nil {
+						return nil, fmt.Errorf("bad BYDAY %q", d)
+					}
+				}
+				rule.ByDay = append(rule.ByDay, WeekdayNum{N: n, Day: day})
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("bad BYMONTHDAY %q", d)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, m := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(m)
+				if err != nil || n < 1 || n > 12 {
+					return nil, fmt.Errorf("bad BYMONTH %q", m)
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(n))
+			}
+		}
+	}
+
+	switch rule.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %q", rule.Freq)
+	}
+
+	return rule, nil
+}
+
+// Expand returns occurrences of event overlapping [from, to), EXDATE instances are dropped
+func (e Event) Expand(from, to time.Time) ([]Occurrence, error) {
+	duration := e.End.Sub(e.Start)
+
+	if e.RRule == "" {
+		if e.Start.Before(to) && (e.End.After(from) || (duration == 0 && !e.Start.Before(from))) {
+			return []Occurrence{{Start: e.Start, End: e.End}}, nil
+		}
+		return nil, nil
+	}
+
+	rule, err := ParseRule(e.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	until := rule.Until
+	if rule.untilDate {
+		until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, e.Start.Location())
+	}
+
+	excluded := make(map[int64]bool, len(e.ExDates))
+	for _, ex := range e.ExDates {
+		excluded[ex.Unix()] = true
+		if e.AllDay {
+			excluded[dateKey(ex)] = true
+		}
+	}
+
+	// COUNT is counted from DTSTART, so only rules without it may skip periods before the window
+	first := 0
+	if rule.Count == 0 {
+		first = rule.firstPeriod(e.Start, from.Add(-duration))
+	}
+
+	var result []Occurrence
+	count := 0
+
+	for period := first; period < first+maxRulePeriods; period++ {
+		candidates := rule.periodCandidates(e.Start, period)
+
+		for _, start := range candidates {
+			if start.Before(e.Start) {
+				continue
+			}
+			if !until.IsZero() && start.After(until) {
+				return result, nil
+			}
+			if !start.Before(to) {
+				return result, nil
+			}
+
+			count++
+			if rule.Count > 0 && count > rule.Count {
+				return result, nil
+			}
+
+			if excluded[start.Unix()] || (e.AllDay && excluded[dateKey(start)]) {
+				continue
+			}
+
+			end := start.Add(duration)
+			if end.After(from) || (duration == 0 && !start.Before(from)) {
+				result = append(result, Occurrence{Start: start, End: end})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func dateKey(t time.Time) int64 {
+	y, m, d := t.Date()
+	return -int64(y*10000 + int(m)*100 + d)
+}
+
+// firstPeriod returns number of the period just before the one containing t,
+// instances of earlier periods start before t
+func (r *Rule) firstPeriod(dtstart, t time.Time) int {
+	if !t.After(dtstart) {
+		return 0
+	}
+	t = t.In(dtstart.Location())
+
+	var elapsed int
+	switch r.Freq {
+	case "DAILY":
+		elapsed = daysBetween(dtstart, t)
+	case "WEEKLY":
+		elapsed = daysBetween(dtstart, t) / 7
+	case "MONTHLY":
+		elapsed = (t.Year()-dtstart.Year())*12 + int(t.Month()-dtstart.Month())
+	case "YEARLY":
+		elapsed = t.Year() - dtstart.Year()
+	}
+
+	// one period back covers week boundaries and DST shifts
+	return max(elapsed/r.Interval-1, 0)
+}
+
+// daysBetween counts calendar days, so DST days of 23 or 25 hours count as one
+func daysBetween(from, to time.Time) int {
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	a := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	b := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// periodCandidates returns sorted instance starts inside n-th period of the rule
+func (r *Rule) periodCandidates(dtstart time.Time, n int) []time.Time {
+	loc := dtstart.Location()
+	h, mi, s := dtstart.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, h, mi, s, 0, loc)
+	}
+
+	var out []time.Time
+	switch r.Freq {
+	case "DAILY":
+		day := dtstart.AddDate(0, 0, n*r.Interval)
+		if r.matchesDay(day) {
+			out = append(out, day)
+		}
+
+	case "WEEKLY":
+		if len(r.ByDay) == 0 {
+			day := dtstart.AddDate(0, 0, 7*n*r.Interval)
+			if len(r.ByMonth) == 0 || containsMonth(r.ByMonth, day.Month()) {
+				out = append(out, day)
+			}
+			break
+		}
+		offset := (int(dtstart.Weekday()) + 6) % 7 // monday based
+		monday := dtstart.AddDate(0, 0, -offset+7*n*r.Interval)
+		for _, wd := range r.ByDay {
+			day := monday.AddDate(0, 0, (int(wd.Day)+6)%7)
+			if len(r.ByMonth) == 0 || containsMonth(r.ByMonth, day.Month()) {
+				out = append(out, day)
+			}
+		}
+
+	case "MONTHLY":
+		first := time.Date(dtstart.Year(), dtstart.Month(), 1, 0, 0, 0, 0, loc).AddDate(0, n*r.Interval, 0)
+		if len(r.ByMonth) == 0 || containsMonth(r.ByMonth, first.Month()) {
+			out = r.monthCandidates(first.Year(), first.Month(), dtstart.Day(), at)
+		}
+
+	case "YEARLY":
+		year := dtstart.Year() + n*r.Interval
+		months := r.ByMonth
+		if len(months) == 0 {
+			if len(r.ByDay) > 0 && len(r.ByMonthDay) == 0 {
+				months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+			} else {
+				months = []time.Month{dtstart.Month()}
+			}
+		}
+		for _, m := range months {
+			out = append(out, r.monthCandidates(year, m, dtstart.Day(), at)...)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+func (r *Rule) monthCandidates(year int, month time.Month, defaultDay int, at func(int, time.Month, int) time.Time) []time.Time {
+	daysIn := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	var out []time.Time
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, d := range r.ByMonthDay {
+			if d < 0 {
+				d = daysIn + d + 1
+			}
+			if d >= 1 && d <= daysIn {
+				out = append(out, at(year, month, d))
+			}
+		}
+
+	case len(r.ByDay) > 0:
+		for _, wd := range r.ByDay {
+			var days []int
+			for d := 1; d <= daysIn; d++ {
+				if time.Date(year, month, d, 0, 0, 0, 0, time.UTC).Weekday() == wd.Day {
+					days = append(days, d)
+				}
+			}
+			switch {
+			case wd.N == 0:
+				for _, d := range days {
+					out = append(out, at(year, month, d))
+				}
+			case wd.N > 0 && wd.N <= len(days):
+				out = append(out, at(year, month, days[wd.N-1]))
+			case wd.N < 0 && -wd.N <= len(days):
+				out = append(out, at(year, month, days[len(days)+wd.N]))
+			}
+		}
+
+	default:
+		if defaultDay <= daysIn {
+			out = append(out, at(year, month, defaultDay))
+		}
+	}
+
+	return out
+}
+
+func (r *Rule) matchesDay(day time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsMonth(r.ByMonth, day.Month()) {
+		return false
+	}
+	if len(r.ByDay) > 0 {
+		found := false
+		for _, wd := range r.ByDay {
+			if wd.Day == day.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.ByMonthDay) > 0 {
+		daysIn := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		found := false
+		for _, d := range r.ByMonthDay {
+			if d == day.Day() || daysIn+d+1 == day.Day() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsMonth(months []time.Month, m time.Month) bool {
+	for _, v := range months {
+		if v == m {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package ical
+
+import (
+	"testing"
+	"time"
+)
+
+func utc(y int, m time.Month, d, h int) time.Time {
+	return time.Date(y, m, d, h, 0, 0, 0, time.UTC)
+}
+
+func TestExpand(t *testing.T) {
+	start := utc(2026, 1, 5, 10) // monday
+
+	tests := []struct {
+		name     string
+		event    Event
+		from, to time.Time
+		want     []time.Time
+	}{
+		{
+			name:  "single event inside window",
+			event: Event{Start: start, End: start.Add(time.Hour)},
+			from:  utc(2026, 1, 1, 0), to: utc(2026, 2, 1, 0),
+			want: []time.Time{start},
+		},
+		{
+			name:  "single event outside window",
+			event: Event{Start: start, End: start.Add(time.Hour)},
+			from:  start.Add(time.Hour), to: utc(2026, 2, 1, 0),
+		},
+		{
+			name:  "daily with count",
+			event: Event{Start: start, End: start.Add(time.Hour), RRule: "FREQ=DAILY;COUNT=3"},
+			from:  utc(2026, 1, 1, 0), to: utc(2026, 2, 1, 0),
+			want: []time.Time{start, start.AddDate(0, 0, 1), start.AddDate(0, 0, 2)},
+		},
+		{
+			name:  "count is counted from dtstart",
+			event: Event{Start: start, End: start.Add(time.Hour), RRule: "FREQ=DAILY;COUNT=3"},
+			from:  utc(2026, 1, 6, 12), to: utc(2026, 2, 1, 0),
+			want: []time.Time{start.AddDate(0, 0, 2)},
+		},
+		{
+			name:  "daily until is inclusive",
+			event: Event{Start: start, End: start.Add(time.Hour), RRule: "FREQ=DAILY;INTERVAL=2;UNTIL=20260109T100000Z"},
+			from:  utc(2026, 1, 1, 0), to: utc(2026, 2, 1, 0),
+			want: []time.Time{start, start.AddDate(0, 0, 2), start.AddDate(0, 0, 4)},
+		},
+		{
+			name: "weekly byday with exdate",
+			event: Event{
+				Start: start, End: start.Add(15 * time.Minute),
+				RRule:   "FREQ=WEEKLY;BYDAY=MO,WE",
+				ExDates: []time.Time{utc(2026, 1, 7, 10)},
+			},
+			from: utc(2026, 1, 5, 0), to: utc(2026, 1, 15, 0),
+			want: []time.Time{start, utc(2026, 1, 12, 10), utc(2026, 1, 14, 10)},
+		},
+		{
+			name: "all-day exdate matches by date",
+			event: Event{
+				Start: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC), AllDay: true,
+				RRule:   "FREQ=DAILY;COUNT=3",
+				ExDates: []time.Time{time.Date(2026, 1, 6, 0, 0, 0, 0, time.FixedZone("X", 3600))},
+			},
+			from: utc(2026, 1, 1, 0), to: utc(2026, 2, 1, 0),
+			want: []time.Time{time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:  "weekly without byday respects bymonth",
+			event: Event{Start: utc(2026, 1, 26, 10), End: utc(2026, 1, 26, 11), RRule: "FREQ=WEEKLY;BYMONTH=1,3"},
+			from:  utc(2026, 1, 1, 0), to: utc(2026, 3, 10, 0),
+			want: []time.Time{utc(2026, 1, 26, 10), utc(2026, 3, 2, 10), utc(2026, 3, 9, 10)},
+		},
+		{
+			name:  "monthly last friday",
+			event: Event{Start: utc(2026, 1, 30, 9), End: utc(2026, 1, 30, 10), RRule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3"},
+			from:  utc(2026, 1, 1, 0), to: utc(2027, 1, 1, 0),
+			want: []time.Time{utc(2026, 1, 30, 9), utc(2026, 2, 27, 9), utc(2026, 3, 27, 9)},
+		},
+		{
+			name:  "monthly on 31st skips short months",
+			event: Event{Start: utc(2026, 1, 31, 9), End: utc(2026, 1, 31, 10), RRule: "FREQ=MONTHLY"},
+			from:  utc(2026, 1, 1, 0), to: utc(2026, 6, 1, 0),
+			want: []time.Time{utc(2026, 1, 31, 9), utc(2026, 3, 31, 9), utc(2026, 5, 31, 9)},
+		},
+		{
+			name:  "yearly birthday",
+			event: Event{Start: utc(1990, 4, 12, 0), End: utc(1990, 4, 13, 0), AllDay: true, RRule: "FREQ=YEARLY"},
+			from:  utc(2026, 1, 1, 0), to: utc(2027, 1, 1, 0),
+			want: []time.Time{utc(2026, 4, 12, 0)},
+		},
+		{
+			name:  "daily series started decades ago",
+			event: Event{Start: utc(1970, 1, 1, 8), End: utc(1970, 1, 1, 9), RRule: "FREQ=DAILY"},
+			from:  utc(2026, 3, 1, 0), to: utc(2026, 3, 4, 0),
+			want: []time.Time{utc(2026, 3, 1, 8), utc(2026, 3, 2, 8), utc(2026, 3, 3, 8)},
+		},
+		{
+			name:  "weekly series started decades ago",
+			event: Event{Start: utc(1950, 1, 2, 8), End: utc(1950, 1, 2, 9), RRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO"},
+			from:  utc(2026, 3, 1, 0), to: utc(2026, 3, 31, 0),
+			want: []time.Time{utc(2026, 3, 2, 8), utc(2026, 3, 16, 8), utc(2026, 3, 30, 8)},
+		},
+		{
+			name:  "long instance started before window",
+			event: Event{Start: utc(2000, 1, 1, 0), End: utc(2000, 1, 3, 0), RRule: "FREQ=DAILY;INTERVAL=3"},
+			from:  utc(2026, 3, 1, 12), to: utc(2026, 3, 2, 0),
+			want: []time.Time{utc(2026, 2, 28, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.event.Expand(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Expand: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("occurrences = %v, want %v", got, tt.want)
+			}
+			for i, occ := range got {
+				if !occ.Start.Equal(tt.want[i]) {
+					t.Fatalf("occurrence %d = %v, want %v", i, occ.Start, tt.want[i])
+				}
+				if occ.End.Sub(occ.Start) != tt.event.End.Sub(tt.event.Start) {
+					t.Fatalf("occurrence %d lasts %v", i, occ.End.Sub(occ.Start))
+				}
+			}
+		})
+	}
+}
+
+func TestExpandKeepsWallClockAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata: ", err)
+	}
+	e := Event{
+		Start: time.Date(2026, 3, 27, 9, 0, 0, 0, berlin),
+		End:   time.Date(2026, 3, 27, 10, 0, 0, 0, berlin),
+		RRule: "FREQ=DAILY;COUNT=4",
+	}
+
+	got, err := e.Expand(e.Start, e.Start.AddDate(0, 0, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("occurrences = %d, want 4", len(got))
+	}
+	for _, occ := range got {
+		if h := occ.Start.In(berlin).Hour(); h != 9 {
+			t.Fatalf("occurrence %v starts at %d:00 local, want 9:00", occ.Start, h)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,2TU,-1FR"},
+		{value: "RRULE:FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=10"},
+		{value: "FREQ=YEARLY;BYMONTH=3;UNTIL=20301231"},
+		{value: "FREQ=HOURLY", wantErr: true},
+		{value: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{value: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := ParseRule(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRule error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// vtimezone writes VTIMEZONE for encoder location with every offset change inside [from, to].
+// Observances use explicit DTSTART instead of RRULE, so they stay correct even for zones whose rules changed
+func (enc *encoder) vtimezone(from, to time.Time) {
+	loc := enc.loc
+	if from.IsZero() || to.IsZero() {
+		from = time.Now()
+		to = from
+	}
+	// a year around the range covers clients that render nearby weeks
+	from = from.AddDate(-1, 0, 0).In(loc)
+	to = to.AddDate(1, 0, 0).In(loc)
+
+	enc.line("BEGIN:VTIMEZONE")
+	enc.line("TZID:" + loc.String())
+
+	name, offset := from.Zone()
+	enc.observance(from.IsDST(), from, offset, offset, name)
+
+	for _, tr := range transitions(from, to) {
+		enc.observance(tr.at.IsDST(), tr.at, tr.fromOffset, tr.toOffset, tr.name)
+	}
+
+	enc.line("END:VTIMEZONE")
+}
+
+// observance DTSTART is wall clock time in the offset that was in effect before the change
+func (enc *encoder) observance(dst bool, at time.Time, fromOffset, toOffset int, name string) {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+	local := at.UTC().Add(time.Duration(fromOffset) * time.Second)
+
+	enc.line("BEGIN:" + kind)
+	enc.line("DTSTART:" + local.Format(dateTimeLayout))
+	enc.line("TZOFFSETFROM:" + formatOffset(fromOffset))
+	enc.line("TZOFFSETTO:" + formatOffset(toOffset))
+	if name != "" {
+		enc.line("TZNAME:" + name)
+	}
+	enc.line("END:" + kind)
+}
+
+type transition struct {
+	at         time.Time
+	fromOffset int
+	toOffset   int
+	name       string
+}
+
+// transitions scans day by day and narrows every offset change down to the second
+func transitions(from, to time.Time) []transition {
+	var result []transition
+
+	_, prevOffset := from.Zone()
+	prev := from
+	for day := from.AddDate(0, 0, 1); !day.After(to); day = day.AddDate(0, 0, 1) {
+		_, offset := day.Zone()
+		if offset != prevOffset {
+			lo, hi := prev, day
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.Zone(); o == prevOffset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			name, _ := hi.Zone()
+			result = append(result, transition{at: hi, fromOffset: prevOffset, toOffset: offset, name: name})
+			prevOffset = offset
+		}
+		prev = day
+	}
+
+	return result
+}
+
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	h := seconds / 3600
+	m := (seconds % 3600) / 60
+	s := seconds % 60
+	if s != 0 {
+		return fmt.Sprintf("%c%02d%02d%02d", sign, h, m, s)
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, h, m)
+}
+
+func eventsRange(events []Event) (time.Time, time.Time) {
+	var from, to time.Time
+	for _, e := range events {
+		if e.AllDay {
+			continue
+		}
+		if from.IsZero() || e.Start.Before(from) {
+			from = e.Start
+		}
+		end := e.End
+		if end.IsZero() {
+			end = e.Start
+		}
+		if to.IsZero() || end.After(to) {
+			to = end
+		}
+	}
+	return from, to
+}
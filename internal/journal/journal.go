@@ -0,0 +1,299 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/ai"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/usecases"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	reflectionDays    = 7
+	trendEntries      = 1000
+	maxPromptEntries  = 50
+	maxPromptEntryLen = 500
+)
+
+// ErrInvalidEntry is returned for empty text or mood out of 1..10
+var ErrInvalidEntry = errors.New("invalid journal entry")
+
+// journalRequest matches "запиши в дневник: ..." and keeps the entry text
+var journalRequest = regexp.MustCompile(`(?is)^\s*(?:запиши|добавь|занеси|сохрани)\s+в\s+дневник\s*[:,.\-—]?\s*(.*)$`)
+
+// Journal writes diary entries, scores their mood and reflects on mood against calendar load
+type Journal struct {
+	aiClient         *ai.GigaChatClient
+	calendarProvider storage.CalendarProvider
+	journalStorage   *storage.JournalStorage
+	location         *time.Location
+}
+
+func NewJournal(aiClient *ai.GigaChatClient, cp storage.CalendarProvider, js *storage.JournalStorage, location *time.Location) *Journal {
+	return &Journal{
+		aiClient:         aiClient,
+		calendarProvider: cp,
+		journalStorage:   js,
+		location:         location,
+	}
+}
+
+// ParseJournalRequest returns entry text of chat commands like "запиши в дневник: ..."
+func ParseJournalRequest(message string) (string, bool) {
+	m := journalRequest.FindStringSubmatch(message)
+	if m == nil {
+		return "", false
+	}
+	return strings.TrimSpace(m[1]), true
+}
+
+// IsReflectionRequest recognizes chat commands like "рефлексия за неделю", "как моё настроение"
+func IsReflectionRequest(message string) bool {
+	text := strings.ToLower(message)
+	return strings.Contains(text, "рефлекси") || strings.Contains(text, "настроение за неделю") ||
+		strings.Contains(text, "как моё настроение") || strings.Contains(text, "как мое настроение")
+}
+
+// Write saves entry, without mood it is scored by the model. A failed score leaves mood empty
+func (j *Journal) Write(ctx context.Context, text string, mood *int, source string) (*models.JournalEntry, error) {
+	entry := &models.JournalEntry{Text: strings.TrimSpace(text), Source: source}
+	if err := j.setMood(ctx, entry, mood); err != nil {
+		return nil, err
+	}
+	if err := j.journalStorage.CreateEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Update replaces text and/or mood, changed text of AI scored entry is scored again
+func (j *Journal) Update(ctx context.Context, id int, text *string, mood *int) (*models.JournalEntry, error) {
+	entry, err := j.journalStorage.GetEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	textChanged := text != nil && strings.TrimSpace(*text) != entry.Text
+	if text != nil {
+		entry.Text = strings.TrimSpace(*text)
+	}
+	switch {
+	case mood != nil:
+		err = j.setMood(ctx, entry, mood)
+	case textChanged && entry.MoodSource != models.MoodSourceUser:
+		err = j.setMood(ctx, entry, nil)
+	case entry.Text == "":
+		err = fmt.Errorf("%w: text is required", ErrInvalidEntry)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := j.journalStorage.UpdateEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (j *Journal) Entry(ctx context.Context, id int) (*models.JournalEntry, error) {
+	return j.journalStorage.GetEntry(ctx, id)
+}
+
+func (j *Journal) Entries(ctx context.Context, from, to time.Time, limit int) ([]*models.JournalEntry, error) {
+	return j.journalStorage.ListEntries(ctx, from, to, limit)
+}
+
+func (j *Journal) Delete(ctx context.Context, id int) error {
+	return j.journalStorage.DeleteEntry(ctx, id)
+}
+
+// Trend is daily mood of [from, to) next to busy hours of the calendar
+func (j *Journal) Trend(ctx context.Context, from, to time.Time) (*models.MoodTrend, error) {
+	trend, _, err := j.trend(ctx, from, to)
+	return trend, err
+}
+
+// Reflect looks at 7 days before periodEnd: entries, mood trend and calendar load go to the model
+func (j *Journal) Reflect(ctx context.Context, periodEnd time.Time) (*models.JournalReflection, error) {
+	op := "internal/journal/journal.go Reflect"
+
+	periodStart := periodEnd.AddDate(0, 0, -reflectionDays)
+	trend, entries, err := j.trend(ctx, periodStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := j.aiClient.Generate(ctx, j.reflectionPrompt(entries, trend))
+	if err != nil {
+		return nil, fmt.Errorf("%s: AI error: %w", op, err)
+	}
+
+	reflection, err := usecases.ParseReflectionResponse(response)
+	if err != nil {
+		log.Printf("%s: unparsable reflection: %v", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	reflection.PeriodStart = periodStart
+	reflection.PeriodEnd = periodEnd
+	reflection.Trend = trend
+
+	if err := j.journalStorage.SaveReflection(ctx, reflection); err != nil {
+		return nil, err
+	}
+	return reflection, nil
+}
+
+// ReflectWeekly is the scheduled job, a week without entries needs no reflection
+func (j *Journal) ReflectWeekly(ctx context.Context) error {
+	now := time.Now()
+	entries, err := j.journalStorage.ListEntries(ctx, now.AddDate(0, 0, -reflectionDays), now, 1)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	_, err = j.Reflect(ctx, now)
+	return err
+}
+
+func (j *Journal) Reflections(ctx context.Context, limit int) ([]models.JournalReflection, error) {
+	return j.journalStorage.ListReflections(ctx, limit)
+}
+
+func (j *Journal) trend(ctx context.Context, from, to time.Time) (*models.MoodTrend, []*models.JournalEntry, error) {
+	op := "internal/journal/journal.go trend"
+
+	entries, err := j.journalStorage.ListEntries(ctx, from, to, trendEntries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, _, err := j.calendarProvider.ListEvents(ctx, from, to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to load events: %w", op, err)
+	}
+	stats, err := usecases.ComputeStats(events, from, to, models.StatsGroupWeekday, j.location, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return usecases.MoodTrend(entries, stats.Days, from, to, j.location), entries, nil
+}
+
+// setMood validates mood given by user or asks the model to score text
+func (j *Journal) setMood(ctx context.Context, entry *models.JournalEntry, mood *int) error {
+	op := "internal/journal/journal.go setMood"
+
+	if entry.Text == "" {
+		return fmt.Errorf("%w: text is required", ErrInvalidEntry)
+	}
+	if mood != nil {
+		if *mood < models.MoodMin || *mood > models.MoodMax {
+			return fmt.Errorf("%w: mood must be between %d and %d", ErrInvalidEntry, models.MoodMin, models.MoodMax)
+		}
+		entry.Mood, entry.MoodSource = mood, models.MoodSourceUser
+		return nil
+	}
+
+	entry.Mood, entry.MoodSource = nil, ""
+	response, err := j.aiClient.Generate(ctx, storage.PROMT_JOURNAL_MOOD+"\n## Запись:\n"+entry.Text)
+	if err != nil {
+		log.Printf("%s: AI error: %v", op, err)
+		return nil
+	}
+	score, err := usecases.ParseMoodResponse(response)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return nil
+	}
+	entry.Mood, entry.MoodSource = &score, models.MoodSourceAI
+	return nil
+}
+
+func (j *Journal) reflectionPrompt(entries []*models.JournalEntry, trend *models.MoodTrend) string {
+	var b strings.Builder
+	b.WriteString(storage.PROMT_JOURNAL_REFLECTION)
+
+	fmt.Fprintf(&b, "\n## Период: %s — %s\n",
+		trend.Start.In(j.location).Format("02.01.2006"),
+		trend.End.In(j.location).Format("02.01.2006"))
+
+	b.WriteString("\n## По дням:\n")
+	for _, d := range trend.Days {
+		mood := "нет записей"
+		if d.Mood != nil {
+			mood = fmt.Sprintf("настроение %.1f", *d.Mood)
+		}
+		fmt.Fprintf(&b, "- %s: %s, занято %.1f ч, событий %d\n", d.Date, mood, d.BusyHours, d.Events)
+	}
+	if trend.Correlation != nil {
+		fmt.Fprintf(&b, "\nКорреляция настроения и загрузки: %.2f\n", *trend.Correlation)
+	} else {
+		b.WriteString("\nКорреляция настроения и загрузки: недостаточно данных\n")
+	}
+
+	b.WriteString("\n## Записи дневника:\n")
+	if len(entries) == 0 {
+		b.WriteString("- записей не было\n")
+	}
+	sorted := append([]*models.JournalEntry(nil), entries...)
+	sort.Slice(sorted, func(a, c int) bool { return sorted[a].CreatedAt.Before(sorted[c].CreatedAt) })
+	for i, e := range sorted {
+		if i == maxPromptEntries {
+			fmt.Fprintf(&b, "- ... и ещё %d записей\n", len(sorted)-i)
+			break
+		}
+		mood := "?"
+		if e.Mood != nil {
+			mood = fmt.Sprintf("%d", *e.Mood)
+		}
+		text := []rune(e.Text)
+		if len(text) > maxPromptEntryLen {
+			text = append(text[:maxPromptEntryLen], '…')
+		}
+		fmt.Fprintf(&b, "- %s (настроение %s): %s\n", e.CreatedAt.In(j.location).Format("Mon 02.01 15:04"), mood, string(text))
+	}
+	return b.String()
+}
+
+// FormatEntry is chat confirmation of saved entry
+func FormatEntry(entry *models.JournalEntry) string {
+	if entry.Mood == nil {
+		return "📓 Записал в дневник."
+	}
+	return fmt.Sprintf("📓 Записал в дневник. Настроение: %d/10", *entry.Mood)
+}
+
+// FormatReflection renders reflection as plain text for chat
+func FormatReflection(r *models.JournalReflection) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📓 Рефлексия %s — %s\n", r.PeriodStart.Format("02.01"), r.PeriodEnd.Format("02.01"))
+	if r.Trend != nil && r.Trend.Average != nil {
+		fmt.Fprintf(&b, "Среднее настроение: %.1f/10", *r.Trend.Average)
+		if r.Trend.Correlation != nil {
+			fmt.Fprintf(&b, ", связь с загрузкой: %.2f", *r.Trend.Correlation)
+		}
+		b.WriteString("\n")
+	}
+	if r.Summary != "" {
+		fmt.Fprintf(&b, "\n%s\n", r.Summary)
+	}
+	writeList(&b, "🔍 Наблюдения:", r.Insights)
+	writeList(&b, "💡 На следующую неделю:", r.Suggestions)
+	return strings.TrimSpace(b.String())
+}
+
+func writeList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "• %s\n", item)
+	}
+}
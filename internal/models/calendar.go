@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ProviderGoogle = "google"
+	ProviderCalDAV = "caldav"
+	ProviderLocal  = "local"
+)
+
+// Calendar json keys match Google CalendarListEntry, UI reads them as is
+type Calendar struct {
+	ID              string `json:"id"`
+	Summary         string `json:"summary"`
+	BackgroundColor string `json:"backgroundColor,omitempty"`
+	Primary         bool   `json:"primary"`
+	Provider        string `json:"provider"`
+	Account         string `json:"account,omitempty"` // email of linked Google account that is not primary
+}
+
+// CalendarEvent is provider independent event instance
+type CalendarEvent struct {
+	ID               string    `json:"id"`
+	CalendarID       string    `json:"calendar_id"`
+	UID              string    `json:"uid,omitempty"`
+	Summary          string    `json:"summary"`
+	Description      string    `json:"description,omitempty"`
+	Location         string    `json:"location,omitempty"`
+	Status           string    `json:"status,omitempty"`
+	ColorID          string    `json:"color_id,omitempty"`
+	RecurringEventID string    `json:"recurring_event_id,omitempty"`
+	Recurrence       []string  `json:"recurrence,omitempty"`
+	HTMLLink         string    `json:"html_link,omitempty"`
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	IsAllDay         bool      `json:"is_all_day"`
+}
+
+type BusyPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type CalDAVAccount struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"-" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	URL       string    `json:"url" db:"url"`
+	Username  string    `json:"username" db:"username"`
+	Password  string    `json:"-" db:"password"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
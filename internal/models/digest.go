@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	DeliveryStatusSent   = "sent"
+	DeliveryStatusFailed = "failed"
+)
+
+// DigestSettings is per-user opt-in for morning agenda email, empty Timezone means server default.
+// Digest is sent only after owner of Email follows the link from verification mail
+type DigestSettings struct {
+	UserID        int        `json:"user_id"`
+	Enabled       bool       `json:"enabled"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	SendTime      string     `json:"send_time"` // HH:MM in Timezone
+	Timezone      string     `json:"timezone"`
+	Calendars     []string   `json:"calendars"`
+	LastSentDate  *time.Time `json:"last_sent_date,omitempty"`
+}
+
+type DigestDelivery struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	DigestDate time.Time `json:"digest_date"`
+	Email      string    `json:"email"`
+	Subject    string    `json:"subject"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Events     int       `json:"events"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// DigestSummary is AI part of digest, digest is sent without it if model fails
+type DigestSummary struct {
+	Summary string   `json:"summary"`
+	Focus   []string `json:"focus"`
+}
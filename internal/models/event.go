@@ -5,11 +5,14 @@ import (
 )
 
 type EventRequest struct {
-	ID            int        `json:"id" db:"id"`
-	IsEvent       bool       `json:"is_event" db:"is_event"`
-	Title         string     `json:"title" db:"title"`
-	StartTime     *time.Time `json:"start_time,omitempty" db:"start_time"`
-	DurationHours *float64   `json:"duration,omitempty" db:"duration_hours"`
-	Recurrence    *string    `json:"recurrence,omitempty" db:"recurrence"`
-	Description   *string    `json:"description,omitempty" db:"description"`
+	ID            int         `json:"id" db:"id"`
+	IsEvent       bool        `json:"is_event" db:"is_event"`
+	Title         string      `json:"title" db:"title"`
+	StartTime     *time.Time  `json:"start_time,omitempty" db:"start_time"`
+	DurationHours *float64    `json:"duration,omitempty" db:"duration_hours"`
+	Recurrence    *string     `json:"recurrence,omitempty" db:"recurrence"`
+	Description   *string     `json:"description,omitempty" db:"description"`
+	IsAllDay      *bool       `json:"is_all_day,omitempty" db:"-"`
+	ExDates       []time.Time `json:"exdates,omitempty" db:"-"`
+	UID           *string     `json:"uid,omitempty" db:"-"`
 }
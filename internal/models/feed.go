@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// FeedToken gives read-only access to ICS feed, the raw token is shown only once on creation
+type FeedToken struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"-" db:"user_id"`
+	Token      string     `json:"token,omitempty" db:"-"`
+	Calendars  []string   `json:"calendars" db:"calendars"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	GoalStatusActive    = "active"
+	GoalStatusCompleted = "completed"
+)
+
+// GoalPlan is model's breakdown of a goal, user reviews and may edit it before accepting
+type GoalPlan struct {
+	ID         int                `json:"id"`
+	Goal       string             `json:"goal"` // what user said
+	Title      string             `json:"title"`
+	Deadline   time.Time          `json:"deadline"`
+	Milestones []PlannedMilestone `json:"milestones"`
+	Session    SessionPattern     `json:"session"`
+	Sessions   []PlannedSession   `json:"sessions"` // expanded from Session, one calendar event each
+	Advice     string             `json:"advice,omitempty"`
+	GoalID     *int               `json:"goal_id,omitempty"` // set after accept
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type PlannedMilestone struct {
+	Title   string    `json:"title"`
+	DueDate time.Time `json:"due_date"`
+}
+
+// SessionPattern is weekly practice, Weekdays are 1 (Monday) .. 7 (Sunday)
+type SessionPattern struct {
+	Title         string  `json:"title"`
+	Weekdays      []int   `json:"weekdays"`
+	Time          string  `json:"time"` // HH:MM
+	DurationHours float64 `json:"duration"`
+}
+
+type PlannedSession struct {
+	Title         string    `json:"title"`
+	Start         time.Time `json:"start"`
+	DurationHours float64   `json:"duration"`
+	Milestone     int       `json:"milestone"` // index in Milestones
+}
+
+// Goal is accepted plan, its progress is share of completed sessions
+type Goal struct {
+	ID          int             `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Deadline    time.Time       `json:"deadline"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Milestones  []GoalMilestone `json:"milestones"`
+	Sessions    []GoalSession   `json:"sessions"`
+	Completed   int             `json:"completed"`
+	Total       int             `json:"total"`
+	Progress    string          `json:"progress"`
+}
+
+type GoalMilestone struct {
+	ID        int       `json:"id"`
+	GoalID    int       `json:"goal_id"`
+	Title     string    `json:"title"`
+	DueDate   time.Time `json:"due_date"`
+	Position  int       `json:"position"`
+	Completed bool      `json:"completed"` // all its sessions are done
+}
+
+type GoalSession struct {
+	ID          int        `json:"id"`
+	GoalID      int        `json:"goal_id"`
+	MilestoneID *int       `json:"milestone_id,omitempty"`
+	Title       string     `json:"title"`
+	CalendarID  string     `json:"calendar_id"`
+	EventID     string     `json:"event_id"`
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// GoogleAccount is Google account linked by user. Calendars of primary account keep plain ids,
+// calendars of others are prefixed with "google:<id>:"
+type GoogleAccount struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"-"`
+	Email          string     `json:"email"`
+	Scopes         []string   `json:"scopes"`
+	Primary        bool       `json:"primary"`
+	ReauthRequired bool       `json:"reauth_required"`
+	Expiry         *time.Time `json:"expiry,omitempty"` // access token expiry, it is refreshed automatically
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+}
+
+// GoogleAuthStatus is state of user's Google connection, ReauthRequired means Google revoked access
+// to some account and user has to pass AuthURL again
+type GoogleAuthStatus struct {
+	Connected      bool            `json:"connected"`
+	ReauthRequired bool            `json:"reauth_required"`
+	Email          string          `json:"email,omitempty"` // primary account
+	AuthURL        string          `json:"auth_url"`
+	Accounts       []GoogleAccount `json:"accounts"`
+}
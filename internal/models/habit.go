@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	HabitDaily  = "daily"
+	HabitWeekly = "weekly"
+
+	CheckinDone    = "done"
+	CheckinPartial = "partial"
+	CheckinSkipped = "skipped"
+)
+
+// Habit is recurring event user checks in, Recurrence is daily or weekly
+type Habit struct {
+	ID         int         `json:"id"`
+	Title      string      `json:"title"`
+	Recurrence string      `json:"recurrence"`
+	CalendarID string      `json:"calendar_id,omitempty"`
+	EventID    string      `json:"event_id,omitempty"`
+	StartDate  time.Time   `json:"start_date"`
+	Archived   bool        `json:"archived"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Stats      *HabitStats `json:"stats,omitempty"`
+}
+
+// HabitCheckin is one mark per habit and day, Day is a date without time
+type HabitCheckin struct {
+	HabitID   int       `json:"habit_id"`
+	Day       time.Time `json:"day"`
+	Status    string    `json:"status"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HabitStats counts streaks in days for daily habits and in weeks for weekly ones.
+// Done and partial keep the streak, skipped or missing period breaks it
+type HabitStats struct {
+	Unit           string        `json:"unit"` // day or week
+	Streak         int           `json:"streak"`
+	LongestStreak  int           `json:"longest_streak"`
+	CompletionRate float64       `json:"completion_rate"` // last 30 days, partial counts as half
+	History        []HabitPeriod `json:"history"`         // weeks, oldest first
+}
+
+type HabitPeriod struct {
+	Start    time.Time `json:"start"`
+	Done     int       `json:"done"`
+	Partial  int       `json:"partial"`
+	Skipped  int       `json:"skipped"`
+	Expected int       `json:"expected"`
+	Rate     float64   `json:"rate"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// ImportCandidate is one VEVENT from uploaded .ics shown in preview
+type ImportCandidate struct {
+	UID         string           `json:"uid"`
+	Event       EventRequest     `json:"event"`
+	Occurrences int              `json:"occurrences"`
+	Conflicts   []ImportConflict `json:"conflicts"`
+	ImportedTo  []string         `json:"imported_to,omitempty"`
+}
+
+// ImportConflict is existing event overlapping with imported one
+type ImportConflict struct {
+	CalendarID string    `json:"calendar_id"`
+	EventID    string    `json:"event_id"`
+	Summary    string    `json:"summary"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+}
+
+type ImportPreview struct {
+	ID         int               `json:"id"`
+	Candidates []ImportCandidate `json:"candidates"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type ImportResult struct {
+	UID     string `json:"uid"`
+	Status  string `json:"status"` // imported, duplicate, error
+	EventID string `json:"event_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
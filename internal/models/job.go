@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	JobStatusRunning = "running"
+	JobStatusSuccess = "success"
+	JobStatusFailed  = "failed"
+	JobStatusRetry   = "retry" // failed, another attempt is scheduled
+)
+
+// Job is persistent state of scheduled job, shared by all app instances
+type Job struct {
+	Name       string     `json:"name"`
+	Schedule   string     `json:"schedule"`
+	NextRunAt  time.Time  `json:"next_run_at"`
+	Attempts   int        `json:"attempts"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`
+	LastStatus string     `json:"last_status"`
+	LastError  string     `json:"last_error,omitempty"`
+	Running    bool       `json:"running"`
+	Runs       []JobRun   `json:"runs"`
+}
+
+type JobRun struct {
+	ID         int64      `json:"id"`
+	JobName    string     `json:"job_name"`
+	Attempt    int        `json:"attempt"`
+	Instance   string     `json:"instance"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
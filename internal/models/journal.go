@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	MoodMin = 1
+	MoodMax = 10
+
+	MoodSourceUser = "user"
+	MoodSourceAI   = "ai"
+
+	JournalSourceAPI  = "api"
+	JournalSourceChat = "chat"
+)
+
+// JournalEntry is a diary note, Mood is 1..10 given by user or scored by the model, nil if unknown
+type JournalEntry struct {
+	ID         int       `json:"id"`
+	Text       string    `json:"text"`
+	Mood       *int      `json:"mood,omitempty"`
+	MoodSource string    `json:"mood_source,omitempty"`
+	Source     string    `json:"source"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// MoodDay is average mood of a day next to calendar load of that day
+type MoodDay struct {
+	Date      string   `json:"date"`
+	Mood      *float64 `json:"mood,omitempty"`
+	Entries   int      `json:"entries"`
+	BusyHours float64  `json:"busy_hours"`
+	Events    int      `json:"events"`
+}
+
+// MoodTrend describes mood over period, nil fields mean there is not enough data
+type MoodTrend struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Average     *float64  `json:"average,omitempty"`
+	Change      *float64  `json:"change,omitempty"`      // second half average minus first half average
+	Correlation *float64  `json:"correlation,omitempty"` // Pearson between daily mood and busy hours
+	Days        []MoodDay `json:"days"`
+}
+
+// JournalReflection is AI written look at the week of journal entries and calendar load
+type JournalReflection struct {
+	ID          int        `json:"id"`
+	PeriodStart time.Time  `json:"period_start"`
+	PeriodEnd   time.Time  `json:"period_end"`
+	Summary     string     `json:"summary"`
+	Insights    []string   `json:"insights"`
+	Suggestions []string   `json:"suggestions"`
+	Trend       *MoodTrend `json:"trend,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// LayoutDay is one column of calendar grid in user's time zone
+type LayoutDay struct {
+	Date   string       `json:"date"` // YYYY-MM-DD
+	AllDay []LayoutItem `json:"all_day"`
+	Events []LayoutItem `json:"events"`
+}
+
+// LayoutItem is part of event that falls into one day, events crossing midnight get one item per day.
+// Lane is 0-based column inside overlap cluster of Lanes columns
+type LayoutItem struct {
+	EventID         string    `json:"event_id"`
+	CalendarID      string    `json:"calendar_id"`
+	Name            string    `json:"name"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	EventStart      time.Time `json:"event_start"`
+	EventEnd        time.Time `json:"event_end"`
+	StartMinute     int       `json:"start_minute"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Lane            int       `json:"lane"`
+	Lanes           int       `json:"lanes"`
+	ContinuesBefore bool      `json:"continues_before"`
+	ContinuesAfter  bool      `json:"continues_after"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+const DependencyFinishToStart = "finish_to_start"
+
+type Project struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProjectTask is a planned piece of work shown on Gantt chart, milestone is a task with zero length
+type ProjectTask struct {
+	ID          int       `json:"id"`
+	ProjectID   int       `json:"project_id"`
+	Name        string    `json:"name"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Progress    int       `json:"progress"`
+	IsMilestone bool      `json:"is_milestone"`
+	DependsOn   []int     `json:"depends_on"`
+}
+
+// Dependency is an edge between two tasks: To can start when From is finished
+type Dependency struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Type string `json:"type"`
+}
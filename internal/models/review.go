@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// WeeklyReview is AI written summary of a week, Stats keeps numbers the review was based on
+type WeeklyReview struct {
+	ID          int         `json:"id"`
+	PeriodStart time.Time   `json:"period_start"`
+	PeriodEnd   time.Time   `json:"period_end"`
+	Summary     string      `json:"summary"`
+	WentWell    []string    `json:"went_well"`
+	Slipped     []string    `json:"slipped"`
+	Suggestions []string    `json:"suggestions"`
+	Stats       StatsTotals `json:"stats"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	StatsGroupCalendar = "calendar"
+	StatsGroupCategory = "category"
+	StatsGroupWeekday  = "weekday"
+	StatsGroupHour     = "hour"
+)
+
+// Stats is time analytics over a window, hours never count double-booked time twice
+type Stats struct {
+	Start       time.Time    `json:"start"`
+	End         time.Time    `json:"end"`
+	TimeZone    string       `json:"time_zone"`
+	GroupBy     string       `json:"group_by"`
+	Totals      StatsTotals  `json:"totals"`
+	Groups      []StatsGroup `json:"groups"`
+	Days        []DayLoad    `json:"days"`
+	FreeStreaks []FreeStreak `json:"free_streaks"`
+}
+
+type StatsTotals struct {
+	Events         int     `json:"events"`
+	BusyHours      float64 `json:"busy_hours"`      // union of all events
+	ScheduledHours float64 `json:"scheduled_hours"` // plain sum of durations
+	OverlapHours   float64 `json:"overlap_hours"`   // scheduled minus busy
+	FreeHours      float64 `json:"free_hours"`
+	MeetingLoad    float64 `json:"meeting_load"` // busy share of working hours, 0..1
+}
+
+// StatsGroup is one bar of chart, Key is stable (calendar id, color id, 0-6, 0-23), Label is for humans
+type StatsGroup struct {
+	Key    string  `json:"key"`
+	Label  string  `json:"label"`
+	Hours  float64 `json:"hours"`
+	Events int     `json:"events"`
+}
+
+type DayLoad struct {
+	Date        string  `json:"date"`
+	BusyHours   float64 `json:"busy_hours"`
+	WorkHours   float64 `json:"work_hours"`
+	MeetingLoad float64 `json:"meeting_load"`
+	Events      int     `json:"events"`
+}
+
+type FreeStreak struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Hours float64   `json:"hours"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+type SyncState struct {
+	CalendarID   string     `json:"calendar_id" db:"calendar_id"`
+	IsPrimary    bool       `json:"is_primary" db:"is_primary"`
+	SyncToken    string     `json:"sync_token" db:"sync_token"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty" db:"last_synced_at"`
+	// mirror is complete only between WindowStart and WindowEnd
+	WindowStart *time.Time `json:"window_start,omitempty" db:"window_start"`
+	WindowEnd   *time.Time `json:"window_end,omitempty" db:"window_end"`
+	Color       string     `json:"color,omitempty" db:"color"`
+}
+
+const (
+	CalendarStatusOK        = "ok"
+	CalendarStatusError     = "error"
+	CalendarStatusForbidden = "forbidden"
+)
+
+// CalendarStatus tells how loading of one calendar went
+type CalendarStatus struct {
+	CalendarID string `json:"calendar_id"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	Events     int    `json:"events"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	TaskStatusTodo       = "todo"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusDone       = "done"
+
+	TaskPriorityLow    = "low"
+	TaskPriorityMedium = "medium"
+	TaskPriorityHigh   = "high"
+)
+
+// Task is a to-do without fixed time, Due is a date. Priority, estimate and tags are local only,
+// Google Tasks keeps title, notes, due and completion
+type Task struct {
+	ID            int        `json:"id"`
+	Title         string     `json:"title"`
+	Notes         string     `json:"notes,omitempty"`
+	Due           *time.Time `json:"due,omitempty"`
+	Priority      string     `json:"priority"`
+	EstimateHours *float64   `json:"estimate,omitempty"`
+	Status        string     `json:"status"`
+	Tags          []string   `json:"tags"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	GoogleTaskID  string     `json:"google_task_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	Dirty     bool       `json:"-"`
+	DeletedAt *time.Time `json:"-"`
+}
+
+// TaskPatch is partial update, nil fields are kept. Empty Due clears the date
+type TaskPatch struct {
+	Title         *string   `json:"title"`
+	Notes         *string   `json:"notes"`
+	Due           *string   `json:"due"` // YYYY-MM-DD
+	Priority      *string   `json:"priority"`
+	EstimateHours *float64  `json:"estimate"`
+	Status        *string   `json:"status"`
+	Tags          *[]string `json:"tags"`
+}
+
+type TaskFilter struct {
+	Status    string
+	Tag       string
+	DueBefore *time.Time
+}
+
+// RemoteTask is task as a remote to-do service sees it
+type RemoteTask struct {
+	ID          string
+	Title       string
+	Notes       string
+	Due         *time.Time
+	Completed   bool
+	CompletedAt *time.Time
+	Deleted     bool
+	Updated     time.Time
+}
+
+type TaskSyncResult struct {
+	Pushed   int       `json:"pushed"`
+	Pulled   int       `json:"pulled"`
+	Deleted  int       `json:"deleted"`
+	Failed   int       `json:"failed"`
+	SyncedAt time.Time `json:"synced_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// TelegramLink maps Telegram account to LifeForge user
+type TelegramLink struct {
+	TelegramID int64     `json:"telegram_id"`
+	UserID     int       `json:"user_id"`
+	ChatID     int64     `json:"chat_id"`
+	Username   string    `json:"username"`
+	LinkedAt   time.Time `json:"linked_at"`
+}
+
+type TelegramLinkCode struct {
+	Code      string    `json:"code"`
+	Command   string    `json:"command"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TelegramEventAction is event behind inline button of bot message
+type TelegramEventAction struct {
+	ID         int
+	UserID     int
+	CalendarID string
+	EventID    string
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	TimeSourceTimer  = "timer"
+	TimeSourceManual = "manual"
+
+	PlanGroupDay      = "day"
+	PlanGroupWeek     = "week"
+	PlanGroupCalendar = "calendar"
+
+	PlanStatusDone     = "done"     // tracked at least 80% of planned time
+	PlanStatusPartial  = "partial"  // tracked something
+	PlanStatusMissed   = "missed"   // event is over and nothing was tracked
+	PlanStatusUpcoming = "upcoming" // event has not ended yet and nothing was tracked
+)
+
+// TimeEntry is tracked time, End is nil while the timer runs. Hours of running entry count up to now
+type TimeEntry struct {
+	ID         int        `json:"id"`
+	Title      string     `json:"title"`
+	CalendarID string     `json:"calendar_id,omitempty"`
+	EventID    string     `json:"event_id,omitempty"`
+	TaskID     *int       `json:"task_id,omitempty"`
+	Start      time.Time  `json:"start"`
+	End        *time.Time `json:"end"`
+	Note       string     `json:"note,omitempty"`
+	Source     string     `json:"source"`
+	Hours      float64    `json:"hours"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TimeEntryPatch is partial update, nil fields are kept
+type TimeEntryPatch struct {
+	Title      *string    `json:"title"`
+	CalendarID *string    `json:"calendar_id"`
+	EventID    *string    `json:"event_id"`
+	TaskID     *int       `json:"task_id"` // 0 unlinks the task
+	Start      *time.Time `json:"start"`
+	End        *time.Time `json:"end"`
+	Note       *string    `json:"note"`
+}
+
+// PlanActualReport compares calendar events (plan) with tracked time (fact) over a window
+type PlanActualReport struct {
+	Start    time.Time         `json:"start"`
+	End      time.Time         `json:"end"`
+	TimeZone string            `json:"time_zone"`
+	GroupBy  string            `json:"group_by"`
+	Totals   PlanActualTotals  `json:"totals"`
+	Groups   []PlanActualGroup `json:"groups"`
+	Events   []EventActual     `json:"events"`
+}
+
+type PlanActualTotals struct {
+	PlannedHours   float64 `json:"planned_hours"`
+	ActualHours    float64 `json:"actual_hours"`
+	OnPlanHours    float64 `json:"on_plan_hours"`   // tracked on planned events
+	UnplannedHours float64 `json:"unplanned_hours"` // tracked on tasks or without link
+	Adherence      float64 `json:"adherence"`       // on plan share of planned, 0..1 and more
+}
+
+// PlanActualGroup Key is YYYY-MM-DD for day, monday of the week for week, calendar id for calendar
+type PlanActualGroup struct {
+	Key          string  `json:"key"`
+	Label        string  `json:"label"`
+	PlannedHours float64 `json:"planned_hours"`
+	ActualHours  float64 `json:"actual_hours"`
+	DiffHours    float64 `json:"diff_hours"` // actual minus planned
+	Events       int     `json:"events"`
+	Entries      int     `json:"entries"`
+}
+
+type EventActual struct {
+	CalendarID   string    `json:"calendar_id"`
+	EventID      string    `json:"event_id"`
+	Summary      string    `json:"summary"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	PlannedHours float64   `json:"planned_hours"`
+	ActualHours  float64   `json:"actual_hours"`
+	Status       string    `json:"status"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type User struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type userIDKey struct{}
+
+// WithUserID returns context of request made by user, storages scope their queries with it
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserID of context, 0 means no user and matches no rows
+func UserID(ctx context.Context) int {
+	id, _ := ctx.Value(userIDKey{}).(int)
+	return id
+}
@@ -0,0 +1,219 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"life_forge/internal/ai"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/usecases"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	reviewDays        = 7
+	maxPromptEvents   = 150
+	moodEntries       = 1000
+	maxPromptEntries  = 30
+	maxPromptEntryLen = 300
+)
+
+// Reviewer writes weekly reviews from calendar events, journal, user goals and time stats
+type Reviewer struct {
+	aiClient         *ai.GigaChatClient
+	calendarProvider storage.CalendarProvider
+	contextStorage   *storage.ContextStorage
+	journalStorage   *storage.JournalStorage
+	reviewStorage    *storage.ReviewStorage
+	location         *time.Location
+}
+
+func NewReviewer(aiClient *ai.GigaChatClient, cp storage.CalendarProvider, cs *storage.ContextStorage, js *storage.JournalStorage, rs *storage.ReviewStorage, location *time.Location) *Reviewer {
+	return &Reviewer{
+		aiClient:         aiClient,
+		calendarProvider: cp,
+		contextStorage:   cs,
+		journalStorage:   js,
+		reviewStorage:    rs,
+		location:         location,
+	}
+}
+
+// GenerateWeekly reviews 7 days before periodEnd and stores the result
+func (rv *Reviewer) GenerateWeekly(ctx context.Context, periodEnd time.Time) (*models.WeeklyReview, error) {
+	op := "internal/review/review.go GenerateWeekly"
+
+	periodStart := periodEnd.AddDate(0, 0, -reviewDays)
+
+	events, _, err := rv.calendarProvider.ListEvents(ctx, periodStart, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to load events: %w", op, err)
+	}
+
+	userContext, err := rv.contextStorage.GetContextByID(ctx, models.UserID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stats, err := usecases.ComputeStats(events, periodStart, periodEnd, models.StatsGroupWeekday, rv.location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	entries, err := rv.journalStorage.ListEntries(ctx, periodStart, periodEnd, moodEntries)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	trend := usecases.MoodTrend(entries, stats.Days, periodStart, periodEnd, rv.location)
+
+	prompt := rv.buildPrompt(events, entries, trend, userContext, stats)
+	response, err := rv.aiClient.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: AI error: %w", op, err)
+	}
+
+	review, err := usecases.ParseWeeklyReviewResponse(response)
+	if err != nil {
+		log.Printf("%s: unparsable review: %v", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	review.PeriodStart = periodStart
+	review.PeriodEnd = periodEnd
+	review.Stats = stats.Totals
+
+	if err := rv.reviewStorage.SaveReview(ctx, review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (rv *Reviewer) buildPrompt(events []*models.CalendarEvent, entries []*models.JournalEntry, trend *models.MoodTrend, userContext models.Context, stats *models.Stats) string {
+	var b strings.Builder
+	b.WriteString(storage.PROMT_WEEKLY_REVIEW)
+
+	fmt.Fprintf(&b, "\n## Период: %s — %s\n",
+		stats.Start.In(rv.location).Format("02.01.2006"),
+		stats.End.In(rv.location).Format("02.01.2006"))
+
+	b.WriteString("\n## Цели:\n")
+	if len(userContext.Goals) == 0 {
+		b.WriteString("- не заданы\n")
+	}
+	for _, goal := range userContext.Goals {
+		fmt.Fprintf(&b, "- %s\n", goal)
+	}
+
+	if len(userContext.Progress) > 0 {
+		b.WriteString("\n## Прогресс:\n")
+		keys := make([]string, 0, len(userContext.Progress))
+		for k := range userContext.Progress {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "- %s: %s\n", k, userContext.Progress[k])
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Статистика:\n- событий: %d\n- занято часов: %.1f\n- пересечений (часов): %.1f\n- загрузка рабочего времени: %.0f%%\n",
+		stats.Totals.Events, stats.Totals.BusyHours, stats.Totals.OverlapHours, stats.Totals.MeetingLoad*100)
+
+	b.WriteString("\n## События:\n")
+	sort.Slice(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	written := 0
+	for _, e := range events {
+		if e.Status == "cancelled" {
+			continue
+		}
+		if written == maxPromptEvents {
+			fmt.Fprintf(&b, "- ... и ещё %d событий\n", len(events)-written)
+			break
+		}
+		start := e.Start.In(rv.location)
+		if e.IsAllDay {
+			fmt.Fprintf(&b, "- %s (весь день): %s\n", start.Format("Mon 02.01"), e.Summary)
+		} else {
+			fmt.Fprintf(&b, "- %s %s–%s: %s\n", start.Format("Mon 02.01"), start.Format("15:04"), e.End.In(rv.location).Format("15:04"), e.Summary)
+		}
+		written++
+	}
+	if written == 0 {
+		b.WriteString("- событий не было\n")
+	}
+
+	rv.writeJournal(&b, entries, trend)
+	return b.String()
+}
+
+// writeJournal adds mood by days and the week's diary, entries are cut so they do not crowd out events
+func (rv *Reviewer) writeJournal(b *strings.Builder, entries []*models.JournalEntry, trend *models.MoodTrend) {
+	b.WriteString("\n## Настроение (1-10):\n")
+	if trend.Average == nil {
+		b.WriteString("- оценок настроения нет\n")
+	} else {
+		fmt.Fprintf(b, "- среднее: %.1f\n", *trend.Average)
+		if trend.Change != nil {
+			fmt.Fprintf(b, "- изменение ко второй половине недели: %+.1f\n", *trend.Change)
+		}
+		for _, d := range trend.Days {
+			if d.Mood != nil {
+				fmt.Fprintf(b, "- %s: %.1f, занято %.1f ч\n", d.Date, *d.Mood, d.BusyHours)
+			}
+		}
+	}
+
+	b.WriteString("\n## Дневник:\n")
+	if len(entries) == 0 {
+		b.WriteString("- записей не было\n")
+		return
+	}
+	sorted := append([]*models.JournalEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+	for i, e := range sorted {
+		if i == maxPromptEntries {
+			fmt.Fprintf(b, "- ... и ещё %d записей\n", len(sorted)-i)
+			break
+		}
+		mood := "?"
+		if e.Mood != nil {
+			mood = fmt.Sprintf("%d", *e.Mood)
+		}
+		text := []rune(e.Text)
+		if len(text) > maxPromptEntryLen {
+			text = append(text[:maxPromptEntryLen], '…')
+		}
+		fmt.Fprintf(b, "- %s (настроение %s): %s\n", e.CreatedAt.In(rv.location).Format("Mon 02.01 15:04"), mood, string(text))
+	}
+}
+
+// Format renders review as plain text for chat
+func Format(review *models.WeeklyReview) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 Итоги недели %s — %s\n", review.PeriodStart.Format("02.01"), review.PeriodEnd.Format("02.01"))
+	if review.Summary != "" {
+		fmt.Fprintf(&b, "\n%s\n", review.Summary)
+	}
+	writeList(&b, "✅ Получилось:", review.WentWell)
+	writeList(&b, "⚠️ Не получилось:", review.Slipped)
+	writeList(&b, "💡 На следующую неделю:", review.Suggestions)
+	return strings.TrimSpace(b.String())
+}
+
+func writeList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "• %s\n", item)
+	}
+}
+
+// IsReviewRequest recognizes chat commands like "подведи итоги недели"
+func IsReviewRequest(message string) bool {
+	text := strings.ToLower(message)
+	return strings.Contains(text, "итоги недели") || strings.Contains(text, "итог недели") || strings.Contains(text, "обзор недели")
+}
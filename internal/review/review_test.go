@@ -0,0 +1,68 @@
+package review
+
+import (
+	"life_forge/internal/models"
+	"life_forge/internal/usecases"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildPromptIncludesJournal(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	end := time.Date(2026, 3, 16, 0, 0, 0, 0, moscow)
+	start := end.AddDate(0, 0, -reviewDays)
+	rv := &Reviewer{location: moscow}
+
+	events := []*models.CalendarEvent{{
+		ID: "a", CalendarID: "primary", Summary: "Демо",
+		Start: start.Add(34 * time.Hour), End: start.Add(36 * time.Hour),
+	}}
+	stats, err := usecases.ComputeStats(events, start, end, models.StatsGroupWeekday, moscow, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	low, high := 3, 8
+	entries := []*models.JournalEntry{
+		{Text: "Отличный день, демо прошло", Mood: &high, CreatedAt: start.Add(40 * time.Hour)},
+		{Text: "Устал от созвонов", Mood: &low, CreatedAt: start.Add(20 * time.Hour)},
+		{Text: strings.Repeat("я", maxPromptEntryLen+50), CreatedAt: start.Add(60 * time.Hour)},
+	}
+	trend := usecases.MoodTrend(entries, stats.Days, start, end, moscow)
+
+	prompt := rv.buildPrompt(events, entries, trend, models.Context{}, stats)
+
+	for _, want := range []string{
+		"## Настроение (1-10):\n- среднее: 5.5\n",
+		"- 2026-03-09: 3.0",
+		"- 2026-03-10: 8.0",
+		"(настроение 3): Устал от созвонов",
+		"(настроение ?): ",
+		"…\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt has no %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Index(prompt, "Устал") > strings.Index(prompt, "Отличный") {
+		t.Fatal("journal entries must go in chronological order")
+	}
+}
+
+func TestBuildPromptWithoutJournal(t *testing.T) {
+	end := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -reviewDays)
+	rv := &Reviewer{location: time.UTC}
+
+	stats, err := usecases.ComputeStats(nil, start, end, models.StatsGroupWeekday, time.UTC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trend := usecases.MoodTrend(nil, stats.Days, start, end, time.UTC)
+
+	prompt := rv.buildPrompt(nil, nil, trend, models.Context{}, stats)
+	if !strings.Contains(prompt, "- оценок настроения нет\n") || !strings.Contains(prompt, "## Дневник:\n- записей не было\n") {
+		t.Fatalf("prompt without journal:\n%s", prompt)
+	}
+}
@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "*/15 * * * *"},
+		{spec: "0 9 * * 1-5"},
+		{spec: "0,30 8-18/2 1,15 * *"},
+		{spec: "0 0 * * 7"},
+		{spec: " @daily "},
+		{spec: "@every 90s"},
+		{spec: "@every 10m"},
+		{spec: "* * * *", wantErr: true},
+		{spec: "* * * * * *", wantErr: true},
+		{spec: "60 * * * *", wantErr: true},
+		{spec: "* 24 * * *", wantErr: true},
+		{spec: "* * 0 * *", wantErr: true},
+		{spec: "* * * 13 *", wantErr: true},
+		{spec: "* * * * 8", wantErr: true},
+		{spec: "5-1 * * * *", wantErr: true},
+		{spec: "*/0 * * * *", wantErr: true},
+		{spec: "a * * * *", wantErr: true},
+		{spec: "1-x * * * *", wantErr: true},
+		{spec: "@every 10", wantErr: true},
+		{spec: "@every 500ms", wantErr: true},
+		{spec: "@sometimes", wantErr: true},
+		{spec: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseSchedule(tt.spec, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSchedule error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	// wednesday
+	after := time.Date(2026, 3, 11, 10, 7, 30, 0, moscow)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "*/15 * * * *", want: time.Date(2026, 3, 11, 10, 15, 0, 0, moscow)},
+		{spec: "* * * * *", want: time.Date(2026, 3, 11, 10, 8, 0, 0, moscow)},
+		{spec: "7 10 * * *", want: time.Date(2026, 3, 12, 10, 7, 0, 0, moscow)},
+		{spec: "@hourly", want: time.Date(2026, 3, 11, 11, 0, 0, 0, moscow)},
+		{spec: "@daily", want: time.Date(2026, 3, 12, 0, 0, 0, 0, moscow)},
+		{spec: "@weekly", want: time.Date(2026, 3, 15, 0, 0, 0, 0, moscow)},
+		{spec: "@monthly", want: time.Date(2026, 4, 1, 0, 0, 0, 0, moscow)},
+		{spec: "@yearly", want: time.Date(2027, 1, 1, 0, 0, 0, 0, moscow)},
+		{spec: "0 9 * * 1-5", want: time.Date(2026, 3, 12, 9, 0, 0, 0, moscow)},
+		{spec: "0 9 * * 6,7", want: time.Date(2026, 3, 14, 9, 0, 0, 0, moscow)},
+		{spec: "0 0 * * 7", want: time.Date(2026, 3, 15, 0, 0, 0, 0, moscow)},
+		{spec: "30 8 29 2 *", want: time.Date(2028, 2, 29, 8, 30, 0, 0, moscow)},
+		// both day fields restricted: either of them matches
+		{spec: "0 12 20 * 5", want: time.Date(2026, 3, 13, 12, 0, 0, 0, moscow)},
+		{spec: "0 0 31 2 *", want: time.Time{}},
+		{spec: "@every 90s", want: after.Add(90 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec, moscow)
+			if err != nil {
+				t.Fatalf("ParseSchedule: %v", err)
+			}
+			if got := s.Next(after); !got.Equal(tt.want) {
+				t.Fatalf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata: ", err)
+	}
+	cest := time.FixedZone("CEST", 2*60*60)
+	cet := time.FixedZone("CET", 60*60)
+
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "job inside skipped hour runs right after the gap",
+			spec:  "30 2 * * *",
+			after: time.Date(2026, 3, 28, 12, 0, 0, 0, berlin),
+			want: []time.Time{
+				time.Date(2026, 3, 29, 3, 0, 0, 0, cest),
+				time.Date(2026, 3, 30, 2, 30, 0, 0, cest),
+			},
+		},
+		{
+			name:  "job inside repeated hour runs once",
+			spec:  "30 2 * * *",
+			after: time.Date(2026, 10, 24, 12, 0, 0, 0, berlin),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 2, 30, 0, 0, cest),
+				time.Date(2026, 10, 26, 2, 30, 0, 0, cet),
+			},
+		},
+		{
+			name:  "hourly job runs in both copies of repeated hour",
+			spec:  "0 * * * *",
+			after: time.Date(2026, 10, 25, 1, 30, 0, 0, cest),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 2, 0, 0, 0, cest),
+				time.Date(2026, 10, 25, 2, 0, 0, 0, cet),
+				time.Date(2026, 10, 25, 3, 0, 0, 0, cet),
+			},
+		},
+		{
+			name:  "daily job keeps local time across the change",
+			spec:  "0 9 * * *",
+			after: time.Date(2026, 3, 28, 12, 0, 0, 0, berlin),
+			want: []time.Time{
+				time.Date(2026, 3, 29, 9, 0, 0, 0, cest),
+				time.Date(2026, 3, 30, 9, 0, 0, 0, cest),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec, berlin)
+			if err != nil {
+				t.Fatalf("ParseSchedule: %v", err)
+			}
+			next := tt.after
+			for i, want := range tt.want {
+				next = s.Next(next)
+				if !next.Equal(want) {
+					t.Fatalf("run %d = %v, want %v", i, next, want)
+				}
+			}
+		})
+	}
+}
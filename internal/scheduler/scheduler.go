@@ -0,0 +1,319 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	tickInterval   = 5 * time.Second
+	defaultTimeout = 10 * time.Minute
+	retryBaseDelay = 30 * time.Second
+	// leader looks for runs of crashed instances this often, see failInterrupted
+	interruptedCheckInterval = time.Minute
+)
+
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name       string
+	spec       string
+	schedule   Schedule
+	run        JobFunc
+	maxRetries int
+	timeout    time.Duration
+}
+
+type Option func(*job)
+
+// WithRetries lets failed run be retried n times with exponential backoff before waiting for the next schedule
+func WithRetries(n int) Option {
+	return func(j *job) { j.maxRetries = n }
+}
+
+func WithTimeout(d time.Duration) Option {
+	return func(j *job) { j.timeout = d }
+}
+
+// Scheduler runs registered jobs by their schedules. Job state lives in Postgres, only the instance
+// holding advisory lock runs jobs, and every run is claimed atomically, so replicas never double-run
+type Scheduler struct {
+	jobStorage *storage.JobStorage
+	location   *time.Location
+	instance   string
+
+	jobs map[string]*job
+	lock *storage.LeaderLock // touched by Run only, released in Shutdown after drain
+
+	interruptedCheckedAt time.Time // touched by Run only
+
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+
+	// runCtx outlives Run ctx, so jobs in progress can finish while draining
+	runCtx    context.Context
+	cancelRun context.CancelFunc
+}
+
+func New(js *storage.JobStorage, location *time.Location) *Scheduler {
+	host, _ := os.Hostname()
+	runCtx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		jobStorage: js,
+		location:   location,
+		instance:   fmt.Sprintf("%s/%d", host, os.Getpid()),
+		jobs:       make(map[string]*job),
+		running:    make(map[string]bool),
+		runCtx:     runCtx,
+		cancelRun:  cancel,
+	}
+}
+
+// Register adds job before Run is started. Spec is cron ("*/5 * * * *", "@daily", "@every 10m") evaluated in scheduler location
+func (s *Scheduler) Register(ctx context.Context, name, spec string, run JobFunc, opts ...Option) error {
+	op := "internal/scheduler/scheduler.go Register"
+
+	schedule, err := ParseSchedule(spec, s.location)
+	if err != nil {
+		return fmt.Errorf("%s: job %s: %w", op, name, err)
+	}
+	next := schedule.Next(time.Now())
+	if next.IsZero() {
+		return fmt.Errorf("%s: job %s: schedule %q never fires", op, name, spec)
+	}
+
+	j := &job{name: name, spec: spec, schedule: schedule, run: run, timeout: defaultTimeout}
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	if err := s.jobStorage.RegisterJob(ctx, name, spec, next); err != nil {
+		return err
+	}
+	s.jobs[name] = j
+	return nil
+}
+
+// Run dispatches due jobs until ctx is done. It does not wait for jobs in progress and keeps
+// leadership, so other replicas do not start the same jobs while this one drains, see Shutdown
+func (s *Scheduler) Run(ctx context.Context) {
+	op := "internal/scheduler/scheduler.go Run"
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		lock := s.ensureLeader(ctx, s.lock)
+		if lock != s.lock {
+			// new leadership checks runs of the previous leader right away
+			s.interruptedCheckedAt = time.Time{}
+		}
+		s.lock = lock
+		if s.lock != nil {
+			if time.Since(s.interruptedCheckedAt) >= interruptedCheckInterval {
+				s.failInterrupted(ctx)
+				s.interruptedCheckedAt = time.Now()
+			}
+			if err := s.dispatchDue(ctx); err != nil {
+				log.Printf("%s: %v", op, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ensureLeader keeps or takes leadership, nil means another instance leads
+func (s *Scheduler) ensureLeader(ctx context.Context, lock *storage.LeaderLock) *storage.LeaderLock {
+	op := "internal/scheduler/scheduler.go ensureLeader"
+
+	if lock != nil {
+		if err := lock.Alive(ctx); err == nil {
+			return lock
+		}
+		log.Printf("%s: lost scheduler leadership", op)
+		lock.Release(ctx)
+	}
+
+	lock, err := s.jobStorage.TryLeaderLock(ctx)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return nil
+	}
+	if lock == nil {
+		return nil
+	}
+
+	log.Printf("Scheduler %s is the leader", s.instance)
+	return lock
+}
+
+// failInterrupted closes runs of other instances that outlived the longest job timeout: their
+// instance died, a former leader that is still alive has cancelled them by then and is not touched
+func (s *Scheduler) failInterrupted(ctx context.Context) {
+	op := "internal/scheduler/scheduler.go failInterrupted"
+
+	// one more check interval lets the former leader record result of a cancelled run
+	n, err := s.jobStorage.FailInterrupted(ctx, s.instance, time.Now().Add(-s.maxTimeout()-interruptedCheckInterval))
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+	} else if n > 0 {
+		log.Printf("%s: %d interrupted runs marked failed", op, n)
+	}
+}
+
+// maxTimeout bounds run of any job, jobs removed from code had the default one
+func (s *Scheduler) maxTimeout() time.Duration {
+	timeout := defaultTimeout
+	for _, j := range s.jobs {
+		if j.timeout > timeout {
+			timeout = j.timeout
+		}
+	}
+	return timeout
+}
+
+func (s *Scheduler) dispatchDue(ctx context.Context) error {
+	states, err := s.jobStorage.ListJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, state := range states {
+		j, ok := s.jobs[state.Name]
+		if !ok || state.NextRunAt.After(now) || s.isRunning(j.name) {
+			continue
+		}
+
+		claimed, err := s.jobStorage.ClaimRun(ctx, j.name, state.NextRunAt, j.schedule.Next(now))
+		if err != nil {
+			log.Printf("Failed to claim job %s: %v", j.name, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		s.setRunning(j.name, true)
+		s.wg.Add(1)
+		go func(j *job, attempt int) {
+			defer s.wg.Done()
+			defer s.setRunning(j.name, false)
+			s.execute(j, attempt)
+		}(j, state.Attempts+1)
+	}
+	return nil
+}
+
+// execute runs job once and records result, failure schedules retry while attempts are left
+func (s *Scheduler) execute(j *job, attempt int) {
+	op := "internal/scheduler/scheduler.go execute"
+
+	// bookkeeping must succeed even when jobs are being cancelled
+	bg := context.WithoutCancel(s.runCtx)
+
+	run := &models.JobRun{JobName: j.name, Attempt: attempt, Instance: s.instance}
+	if err := s.jobStorage.StartRun(bg, run); err != nil {
+		log.Printf("%s: %v", op, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.runCtx, j.timeout)
+	err := runSafely(ctx, j.run)
+	cancel()
+
+	var retryAt *time.Time
+	run.Status = models.JobStatusSuccess
+	if err != nil {
+		run.Status = models.JobStatusFailed
+		run.Error = err.Error()
+		if attempt <= j.maxRetries && s.runCtx.Err() == nil {
+			at := time.Now().Add(retryBaseDelay << (attempt - 1))
+			retryAt = &at
+			run.Status = models.JobStatusRetry
+		}
+		log.Printf("Job %s attempt %d failed: %v", j.name, attempt, err)
+	}
+
+	if err := s.jobStorage.FinishRun(bg, run, retryAt); err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+}
+
+// runSafely turns panic of job into error, so one bad job does not take the server down
+func runSafely(ctx context.Context, run JobFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+// Shutdown must be called after Run returned. It waits for jobs in progress,
+// when ctx expires jobs are cancelled and given a moment to record it. Leadership is released at the end
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.cancelRun()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+		err = errors.New("scheduler: jobs did not finish before shutdown deadline")
+	}
+	s.cancelRun()
+
+	if s.lock != nil {
+		s.lock.Release(context.Background())
+		s.lock = nil
+	}
+	return err
+}
+
+// Jobs returns state of registered jobs with their last runs for admin page
+func (s *Scheduler) Jobs(ctx context.Context, runsLimit int) ([]*models.Job, error) {
+	jobs, err := s.jobStorage.ListJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range jobs {
+		if j.Runs, err = s.jobStorage.ListRuns(ctx, j.Name, runsLimit); err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+func (s *Scheduler) isRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[name]
+}
+
+func (s *Scheduler) setRunning(name string, running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running[name] = running
+}
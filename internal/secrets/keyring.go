@@ -0,0 +1,173 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+const keySize = 32 // AES-256
+
+// GigaChatKey is name of GigaChat auth key in secret store, used when GIGACHAT_AUTH_KEY is empty
+const GigaChatKey = "gigachat_auth_key"
+
+var (
+	// ErrNoKey is returned when neither environment nor key file has a master key
+	ErrNoKey = errors.New("no master key configured")
+	// ErrUnknownKey means value was encrypted with a key that is no longer in the keyring
+	ErrUnknownKey = errors.New("value is encrypted with unknown key")
+)
+
+// Keyring encrypts with its current key and decrypts with any key it holds, old keys stay
+// in the keyring until everything is re-encrypted with the current one
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// Parse reads keyring entries "<id>:<base64 key>" separated by commas or new lines,
+// the first entry is the current key, lines starting with # are comments
+func Parse(value string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+
+	entries := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' })
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid key entry, want <id>:<base64 key>")
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != keySize {
+			return nil, fmt.Errorf("key %s must be %d bytes in base64", id, keySize)
+		}
+		if _, ok := k.keys[id]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", id)
+		}
+
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		k.keys[id] = aead
+		if k.current == "" {
+			k.current = id
+		}
+	}
+
+	if k.current == "" {
+		return nil, ErrNoKey
+	}
+	return k, nil
+}
+
+// Load takes keyring from environment value, or from key file when value is empty
+func Load(value, path string) (*Keyring, error) {
+	if strings.TrimSpace(value) != "" {
+		return Parse(value)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	return Parse(string(data))
+}
+
+// LoadOrCreate is Load that creates key file with new key on first start, so single-node setup
+// works without configuration. Losing this file means users have to connect Google again
+func LoadOrCreate(value, path string) (*Keyring, error) {
+	k, err := Load(value, path)
+	if !errors.Is(err, ErrNoKey) || strings.TrimSpace(value) != "" {
+		return k, err
+	}
+
+	id, err := AddKeyToFile(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Created master key %s in %s, keep it safe", id, path)
+	return Load("", path)
+}
+
+// GenerateKey returns new keyring entry with random id and key
+func GenerateKey() (string, error) {
+	id := make([]byte, 4)
+	key := make([]byte, keySize)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id) + ":" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// AddKeyToFile puts new key first in key file, so it becomes current while old keys still decrypt
+func AddKeyToFile(path string) (string, error) {
+	entry, err := GenerateKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	old, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read key file: %w", err)
+	}
+	if err := os.WriteFile(path, append([]byte(entry+"\n"), old...), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	id, _, _ := strings.Cut(entry, ":")
+	return id, nil
+}
+
+func (k *Keyring) CurrentKeyID() string {
+	return k.current
+}
+
+// Encrypt seals value with current key, aad binds ciphertext to its owner (row) so it can't be swapped
+func (k *Keyring) Encrypt(plaintext, aad []byte) (string, []byte, error) {
+	aead := k.keys[k.current]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return k.current, aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func (k *Keyring) Decrypt(keyID string, ciphertext, aad []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownKey, keyID)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with key %s: %w", keyID, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,246 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustKey(t *testing.T) string {
+	t.Helper()
+	entry, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return entry
+}
+
+func mustParse(t *testing.T, value string) *Keyring {
+	t.Helper()
+	k, err := Parse(value)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return k
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	oldKey, newKey := mustKey(t), mustKey(t)
+	old := mustParse(t, oldKey)
+	rotated := mustParse(t, newKey+"\n"+oldKey)
+	other := mustParse(t, mustKey(t))
+
+	tests := []struct {
+		name       string
+		encrypt    *Keyring
+		decrypt    *Keyring
+		aad        string
+		decryptAAD string
+		wantErr    error
+		anyErr     bool
+	}{
+		{name: "round trip", encrypt: old, decrypt: old, aad: "google_account:1", decryptAAD: "google_account:1"},
+		{name: "empty aad", encrypt: old, decrypt: old},
+		{name: "retired key after rotation", encrypt: old, decrypt: rotated, aad: "secret:x", decryptAAD: "secret:x"},
+		{name: "current key after rotation", encrypt: rotated, decrypt: rotated, aad: "secret:x", decryptAAD: "secret:x"},
+		{name: "wrong aad", encrypt: old, decrypt: old, aad: "google_account:1", decryptAAD: "google_account:2", anyErr: true},
+		{name: "missing aad", encrypt: old, decrypt: old, aad: "caldav_password:1", anyErr: true},
+		{name: "removed key", encrypt: old, decrypt: other, wantErr: ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext := []byte(`{"access_token":"a","refresh_token":"r"}`)
+
+			keyID, ciphertext, err := tt.encrypt.Encrypt(plaintext, []byte(tt.aad))
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if keyID != tt.encrypt.CurrentKeyID() {
+				t.Fatalf("key id = %s, want current %s", keyID, tt.encrypt.CurrentKeyID())
+			}
+			if bytes.Contains(ciphertext, plaintext) {
+				t.Fatal("ciphertext contains plaintext")
+			}
+
+			got, err := tt.decrypt.Decrypt(keyID, ciphertext, []byte(tt.decryptAAD))
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Decrypt error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.anyErr:
+				if err == nil {
+					t.Fatal("Decrypt succeeded, want error")
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Decrypt: %v", err)
+				}
+				if !bytes.Equal(got, plaintext) {
+					t.Fatalf("Decrypt = %q, want %q", got, plaintext)
+				}
+			}
+		})
+	}
+}
+
+func TestEncryptUsesFreshNonce(t *testing.T) {
+	k := mustParse(t, mustKey(t))
+
+	_, first, err := k.Encrypt([]byte("value"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, err := k.Encrypt([]byte("value"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) {
+		t.Fatal("same plaintext encrypted to the same ciphertext")
+	}
+}
+
+func TestDecryptTamperedCiphertext(t *testing.T) {
+	k := mustParse(t, mustKey(t))
+	keyID, ciphertext, err := k.Encrypt([]byte("value"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		ciphertext []byte
+	}{
+		{name: "flipped bit", ciphertext: func() []byte {
+			c := bytes.Clone(ciphertext)
+			c[len(c)-1] ^= 1
+			return c
+		}()},
+		{name: "too short", ciphertext: ciphertext[:4]},
+		{name: "empty", ciphertext: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := k.Decrypt(keyID, tt.ciphertext, nil); err == nil {
+				t.Fatal("Decrypt succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	first, second := mustKey(t), mustKey(t)
+	firstID, _, _ := strings.Cut(first, ":")
+
+	tests := []struct {
+		name      string
+		value     string
+		wantID    string
+		wantErr   error
+		anyErr    bool
+		wantCount int
+	}{
+		{name: "single", value: first, wantID: firstID, wantCount: 1},
+		{name: "comma separated, first is current", value: first + "," + second, wantID: firstID, wantCount: 2},
+		{name: "file with comments", value: "# current\n" + first + "\n\n# old\n" + second + "\n", wantID: firstID, wantCount: 2},
+		{name: "empty", value: "", wantErr: ErrNoKey},
+		{name: "only comments", value: "# nothing here\n", wantErr: ErrNoKey},
+		{name: "no id", value: ":" + strings.SplitN(first, ":", 2)[1], anyErr: true},
+		{name: "no separator", value: "abc", anyErr: true},
+		{name: "short key", value: "k1:c2hvcnQ=", anyErr: true},
+		{name: "not base64", value: "k1:!!!", anyErr: true},
+		{name: "duplicate id", value: first + "," + first, anyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := Parse(tt.value)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Parse error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.anyErr:
+				if err == nil {
+					t.Fatal("Parse succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if k.CurrentKeyID() != tt.wantID {
+				t.Fatalf("current key = %s, want %s", k.CurrentKeyID(), tt.wantID)
+			}
+			if len(k.keys) != tt.wantCount {
+				t.Fatalf("keys = %d, want %d", len(k.keys), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRotationWithKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.key")
+
+	k, err := LoadOrCreate("", path)
+	if err != nil {
+		t.Fatalf("LoadOrCreate: %v", err)
+	}
+	oldID := k.CurrentKeyID()
+	keyID, ciphertext, err := k.Encrypt([]byte("token"), []byte("google_account:7"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newID, err := AddKeyToFile(path)
+	if err != nil {
+		t.Fatalf("AddKeyToFile: %v", err)
+	}
+	rotated, err := Load("", path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if rotated.CurrentKeyID() != newID || newID == oldID {
+		t.Fatalf("current key = %s, want new key %s", rotated.CurrentKeyID(), newID)
+	}
+
+	plain, err := rotated.Decrypt(keyID, ciphertext, []byte("google_account:7"))
+	if err != nil || string(plain) != "token" {
+		t.Fatalf("Decrypt with retired key = %q, %v", plain, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestLoadPrefersValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.key")
+	if _, err := AddKeyToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	entry := mustKey(t)
+	id, _, _ := strings.Cut(entry, ":")
+
+	k, err := Load(entry, path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if k.CurrentKeyID() != id {
+		t.Fatalf("current key = %s, want key from value %s", k.CurrentKeyID(), id)
+	}
+
+	if _, err := Load("", filepath.Join(t.TempDir(), "missing.key")); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Load of missing file error = %v, want ErrNoKey", err)
+	}
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"life_forge/internal/secrets"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrAccountNotFound is returned for unknown account or account of another user
+var ErrAccountNotFound = errors.New("caldav account not found")
+
+// CalDAVAccountStorage keeps linked CalDAV accounts, passwords are encrypted with keyring
+type CalDAVAccountStorage struct {
+	pool    *pgxpool.Pool
+	keyring *secrets.Keyring
+}
+
+func NewCalDAVAccountStorage(pool *pgxpool.Pool, keyring *secrets.Keyring) *CalDAVAccountStorage {
+	return &CalDAVAccountStorage{
+		pool:    pool,
+		keyring: keyring,
+	}
+}
+
+// ListAccounts returns accounts of the user from context
+func (s *CalDAVAccountStorage) ListAccounts(ctx context.Context) ([]models.CalDAVAccount, error) {
+	return s.listAccounts(ctx, "internal/storage/caldav_accounts.go ListAccounts", `WHERE user_id = $1`, models.UserID(ctx))
+}
+
+// ListAllAccounts returns accounts of every user, router is filled with them on start
+func (s *CalDAVAccountStorage) ListAllAccounts(ctx context.Context) ([]models.CalDAVAccount, error) {
+	return s.listAccounts(ctx, "internal/storage/caldav_accounts.go ListAllAccounts", "")
+}
+
+func (s *CalDAVAccountStorage) listAccounts(ctx context.Context, op, where string, args ...interface{}) ([]models.CalDAVAccount, error) {
+	sql_query := `
+	SELECT id, user_id, name, url, COALESCE(username, ''), password, password_encrypted, key_id, created_at FROM caldav_accounts
+	` + where + `
+	ORDER BY id
+	`
+
+	rows, err := s.pool.Query(ctx, sql_query, args...)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list accounts: %w", op, err)
+	}
+	defer rows.Close()
+
+	var accounts []models.CalDAVAccount
+	for rows.Next() {
+		var a models.CalDAVAccount
+		var plain, keyID *string
+		var encrypted []byte
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Name, &a.URL, &a.Username, &plain, &encrypted, &keyID, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan account: %w", op, err)
+		}
+
+		// account with undecryptable password is still listed, so it can be unlinked
+		password, err := s.decodePassword(a.ID, plain, encrypted, keyID)
+		if err != nil {
+			log.Printf("Failed to read password of CalDAV account %d: %v", a.ID, err)
+		}
+		a.Password = password
+		accounts = append(accounts, a)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (s *CalDAVAccountStorage) SaveAccount(ctx context.Context, account *models.CalDAVAccount) error {
+	op := "internal/storage/caldav_accounts.go SaveAccount"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	sql_query := `
+	INSERT INTO caldav_accounts (user_id, name, url, username) VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at
+	`
+
+	account.UserID = models.UserID(ctx)
+	err = tx.QueryRow(ctx, sql_query,
+		account.UserID,
+		account.Name,
+		account.URL,
+		account.Username,
+	).Scan(&account.ID, &account.CreatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save account: %w", op, err)
+	}
+
+	// password is bound to account id, so it is encrypted once the id is known
+	keyID, encrypted, err := s.keyring.Encrypt([]byte(account.Password), caldavPasswordAAD(account.ID))
+	if err != nil {
+		return fmt.Errorf("%s: failed to encrypt password: %w", op, err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE caldav_accounts SET password_encrypted = $2, key_id = $3 WHERE id = $1`, account.ID, encrypted, keyID); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save password: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: failed to commit: %w", op, err)
+	}
+	return nil
+}
+
+func (s *CalDAVAccountStorage) DeleteAccount(ctx context.Context, id int) error {
+	op := "internal/storage/caldav_accounts.go DeleteAccount"
+
+	tag, err := s.pool.Exec(ctx, `DELETE FROM caldav_accounts WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete account: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+// ReencryptPasswords moves passwords encrypted with old keys or stored in plaintext to current key,
+// returns number of updated accounts
+func (s *CalDAVAccountStorage) ReencryptPasswords(ctx context.Context) (int, error) {
+	op := "internal/storage/caldav_accounts.go ReencryptPasswords"
+
+	sql_query := `
+	SELECT id, password, password_encrypted, key_id FROM caldav_accounts
+	WHERE key_id IS DISTINCT FROM $1 AND (password IS NOT NULL OR password_encrypted IS NOT NULL)
+	`
+
+	rows, err := s.pool.Query(ctx, sql_query, s.keyring.CurrentKeyID())
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return 0, fmt.Errorf("%s: failed to list accounts: %w", op, err)
+	}
+
+	stale := make(map[int]string)
+	var ids []int
+	for rows.Next() {
+		var id int
+		var plain, keyID *string
+		var encrypted []byte
+		if err := rows.Scan(&id, &plain, &encrypted, &keyID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: failed to scan account: %w", op, err)
+		}
+		// password under removed key stays as is, account has to be linked again
+		password, err := s.decodePassword(id, plain, encrypted, keyID)
+		if err != nil {
+			log.Printf("Skip CalDAV account %d in %s: %v", id, op, err)
+			continue
+		}
+		stale[id] = password
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: failed to list accounts: %w", op, err)
+	}
+
+	var updated int
+	for _, id := range ids {
+		keyID, encrypted, err := s.keyring.Encrypt([]byte(stale[id]), caldavPasswordAAD(id))
+		if err != nil {
+			return updated, fmt.Errorf("%s: account %d: %w", op, id, err)
+		}
+
+		sql_query := `
+		UPDATE caldav_accounts SET password = NULL, password_encrypted = $2, key_id = $3
+		WHERE id = $1
+		`
+		if _, err := s.pool.Exec(ctx, sql_query, id, encrypted, keyID); err != nil {
+			log.Println("Error with Exec method in ", op, " with error: ", err)
+			return updated, fmt.Errorf("%s: failed to update password: %w", op, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// decodePassword reads encrypted password, plaintext one is left from before encryption
+func (s *CalDAVAccountStorage) decodePassword(accountID int, plain *string, encrypted []byte, keyID *string) (string, error) {
+	if encrypted != nil && keyID != nil {
+		data, err := s.keyring.Decrypt(*keyID, encrypted, caldavPasswordAAD(accountID))
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	if plain != nil {
+		return *plain, nil
+	}
+	return "", nil
+}
+
+func caldavPasswordAAD(accountID int) []byte {
+	return []byte(fmt.Sprintf("caldav_password:%d", accountID))
+}
@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"life_forge/internal/caldav"
+	"life_forge/internal/ical"
+	"life_forge/internal/models"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const caldavIDPrefix = "caldav:"
+
+// CalDAVCalendarStorage is CalendarProvider for one linked CalDAV account (Yandex, Nextcloud, Radicale...)
+type CalDAVCalendarStorage struct {
+	account  models.CalDAVAccount
+	client   *caldav.Client
+	location *time.Location // all-day events start at midnight here
+}
+
+// NewCalDAVCalendarStorage allowPrivate permits servers in local networks, see caldav.NewClient
+func NewCalDAVCalendarStorage(account models.CalDAVAccount, allowPrivate bool, location *time.Location) (*CalDAVCalendarStorage, error) {
+	client, err := caldav.NewClient(account.URL, account.Username, account.Password, allowPrivate)
+	if err != nil {
+		return nil, err
+	}
+	return &CalDAVCalendarStorage{account: account, client: client, location: location}, nil
+}
+
+func (s *CalDAVCalendarStorage) AccountID() int {
+	return s.account.ID
+}
+
+// UserID is owner of the account, router hides account from other users
+func (s *CalDAVCalendarStorage) UserID() int {
+	return s.account.UserID
+}
+
+// calendar ids look like caldav:<account id>:<collection href>
+func (s *CalDAVCalendarStorage) calendarID(href string) string {
+	return fmt.Sprintf("%s%d:%s", caldavIDPrefix, s.account.ID, href)
+}
+
+func (s *CalDAVCalendarStorage) hrefOf(calendarID string) (string, error) {
+	prefix := fmt.Sprintf("%s%d:", caldavIDPrefix, s.account.ID)
+	if !strings.HasPrefix(calendarID, prefix) {
+		return "", fmt.Errorf("calendar %s does not belong to caldav account %d", calendarID, s.account.ID)
+	}
+	return strings.TrimPrefix(calendarID, prefix), nil
+}
+
+func parseCalDAVAccountID(calendarID string) (int, bool) {
+	if !strings.HasPrefix(calendarID, caldavIDPrefix) {
+		return 0, false
+	}
+	rest := strings.TrimPrefix(calendarID, caldavIDPrefix)
+	idx := strings.Index(rest, ":")
+	if idx <= 0 {
+		return 0, false
+	}
+	id, err := strconv.Atoi(rest[:idx])
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *CalDAVCalendarStorage) ListCalendars(ctx context.Context) ([]models.Calendar, error) {
+	infos, err := s.client.FindCalendars(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("caldav account %s: %w", s.account.Name, err)
+	}
+
+	calendars := make([]models.Calendar, 0, len(infos))
+	for _, info := range infos {
+		color := info.Color
+		if len(color) == 9 { // #RRGGBBAA from Apple namespace
+			color = color[:7]
+		}
+		calendars = append(calendars, models.Calendar{
+			ID:              s.calendarID(info.Href),
+			Summary:         fmt.Sprintf("%s (%s)", info.Name, s.account.Name),
+			BackgroundColor: color,
+			Provider:        models.ProviderCalDAV,
+		})
+	}
+	return calendars, nil
+}
+
+func (s *CalDAVCalendarStorage) ListEvents(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]*models.CalendarEvent, []models.CalendarStatus, error) {
+	if len(calendarIDs) == 0 {
+		calendars, err := s.ListCalendars(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, cal := range calendars {
+			calendarIDs = append(calendarIDs, cal.ID)
+		}
+	}
+
+	var events []*models.CalendarEvent
+	statuses := make([]models.CalendarStatus, 0, len(calendarIDs))
+
+	for _, cid := range calendarIDs {
+		calEvents, err := s.listCalendarEvents(ctx, cid, timeMin, timeMax)
+		status := models.CalendarStatus{CalendarID: cid, Status: models.CalendarStatusOK, Events: len(calEvents)}
+		if err != nil {
+			log.Printf("failed to list caldav events for %s: %v", cid, err)
+			status.Status = models.CalendarStatusError
+			status.Error = err.Error()
+
+			var statusErr *caldav.StatusError
+			if errors.As(err, &statusErr) && (statusErr.Code == http.StatusForbidden || statusErr.Code == http.StatusUnauthorized) {
+				status.Status = models.CalendarStatusForbidden
+			}
+		}
+		statuses = append(statuses, status)
+		events = append(events, calEvents...)
+	}
+
+	return events, statuses, nil
+}
+
+func (s *CalDAVCalendarStorage) listCalendarEvents(ctx context.Context, calendarID string, timeMin, timeMax time.Time) ([]*models.CalendarEvent, error) {
+	href, err := s.hrefOf(calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := s.client.QueryEvents(ctx, href, timeMin, timeMax)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*models.CalendarEvent
+	for _, obj := range objects {
+		cal, err := ical.Parse(strings.NewReader(obj.Data), s.location)
+		if err != nil {
+			log.Printf("Skip broken caldav object %s: %v", obj.Href, err)
+			continue
+		}
+		events = append(events, expandObject(calendarID, obj.Href, cal.Events, timeMin, timeMax)...)
+	}
+	return events, nil
+}
+
+// expandObject expands master event of calendar object, overridden instances replace generated ones
+func expandObject(calendarID, href string, vevents []ical.Event, timeMin, timeMax time.Time) []*models.CalendarEvent {
+	overridden := make(map[int64]bool)
+	for _, e := range vevents {
+		if !e.RecurrenceID.IsZero() {
+			overridden[e.RecurrenceID.Unix()] = true
+		}
+	}
+
+	var result []*models.CalendarEvent
+	for _, e := range vevents {
+		if e.Status == "CANCELLED" {
+			continue
+		}
+
+		if !e.RecurrenceID.IsZero() {
+			if e.Start.Before(timeMax) && e.End.After(timeMin) {
+				result = append(result, icalToModel(calendarID, href, e, e.Start, e.End, true))
+			}
+			continue
+		}
+
+		occurrences, err := e.Expand(timeMin, timeMax)
+		if err != nil {
+			log.Printf("Failed to expand %s: %v", href, err)
+			continue
+		}
+		for _, occ := range occurrences {
+			if overridden[occ.Start.Unix()] {
+				continue
+			}
+			result = append(result, icalToModel(calendarID, href, e, occ.Start, occ.End, e.RRule != ""))
+		}
+	}
+	return result
+}
+
+func icalToModel(calendarID, href string, e ical.Event, start, end time.Time, instance bool) *models.CalendarEvent {
+	event := &models.CalendarEvent{
+		ID:          href,
+		CalendarID:  calendarID,
+		UID:         e.UID,
+		Summary:     e.Summary,
+		Description: e.Description,
+		Location:    e.Location,
+		Status:      strings.ToLower(e.Status),
+		Start:       start,
+		End:         end,
+		IsAllDay:    e.AllDay,
+	}
+	if e.RRule != "" {
+		event.Recurrence = []string{"RRULE:" + e.RRule}
+	}
+	if instance {
+		event.ID = fmt.Sprintf("%s#%d", href, start.Unix())
+		event.RecurringEventID = href
+	}
+	return event
+}
+
+func (s *CalDAVCalendarStorage) CreateEvent(ctx context.Context, calendarID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	collection, err := s.hrefOf(calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	uid := newUID()
+	if event.UID != nil && *event.UID != "" {
+		uid = *event.UID
+	}
+	vevent, err := icalEventFromRequest(uid, event, s.location)
+	if err != nil {
+		return nil, err
+	}
+
+	href := strings.TrimSuffix(collection, "/") + "/" + url.PathEscape(uid) + ".ics"
+	if err := s.putEvent(ctx, href, vevent, true); err != nil {
+		return nil, err
+	}
+
+	return icalToModel(calendarID, href, vevent, vevent.Start, vevent.End, false), nil
+}
+
+func (s *CalDAVCalendarStorage) UpdateEvent(ctx context.Context, calendarID, eventID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	href := objectHref(eventID)
+
+	existing, err := s.client.GetObject(ctx, href)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := ical.Parse(strings.NewReader(existing.Data), s.location)
+	if err != nil || len(cal.Events) == 0 {
+		return nil, fmt.Errorf("caldav object %s is not a valid event", href)
+	}
+
+	// patch keeps all-day flag of the stored event
+	if event.IsAllDay == nil {
+		event.IsAllDay = &cal.Events[0].AllDay
+	}
+	vevent, err := icalEventFromRequest(cal.Events[0].UID, event, s.location)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.putEvent(ctx, href, vevent, false); err != nil {
+		return nil, err
+	}
+
+	return icalToModel(calendarID, href, vevent, vevent.Start, vevent.End, false), nil
+}
+
+func (s *CalDAVCalendarStorage) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	return s.client.DeleteObject(ctx, objectHref(eventID))
+}
+
+// FreeBusy is computed from events, not every server supports free-busy-query
+func (s *CalDAVCalendarStorage) FreeBusy(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]models.BusyPeriod, error) {
+	events, _, err := s.ListEvents(ctx, timeMin, timeMax, calendarIDs...)
+	if err != nil {
+		return nil, err
+	}
+	return busyFromEvents(events, timeMin, timeMax), nil
+}
+
+func (s *CalDAVCalendarStorage) putEvent(ctx context.Context, href string, vevent ical.Event, create bool) error {
+	var body strings.Builder
+	if err := ical.Encode(&body, &ical.Calendar{Events: []ical.Event{vevent}}); err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	_, err := s.client.PutObject(ctx, href, body.String(), create)
+	return err
+}
+
+// objectHref drops instance suffix from event id
+func objectHref(eventID string) string {
+	if idx := strings.LastIndex(eventID, "#"); idx > 0 {
+		return eventID[:idx]
+	}
+	return eventID
+}
+
+// icalEventFromRequest moves all-day event to midnight in location, so its dates are written as user sees them
+func icalEventFromRequest(uid string, event models.EventRequest, location *time.Location) (ical.Event, error) {
+	if event.StartTime == nil {
+		return ical.Event{}, fmt.Errorf("start time is required")
+	}
+
+	start := *event.StartTime
+	duration := time.Hour
+	allDay := isAllDay(event)
+	if allDay {
+		day := start.In(location)
+		start = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+		duration = 24 * time.Hour
+	}
+	if event.DurationHours != nil {
+		duration = time.Duration(*event.DurationHours * float64(time.Hour))
+	}
+	end := start.Add(duration)
+
+	vevent := ical.Event{
+		UID:     uid,
+		Summary: event.Title,
+		Start:   start,
+		End:     end,
+		AllDay:  allDay,
+	}
+	if event.Recurrence != nil && *event.Recurrence != "" {
+		vevent.RRule = strings.TrimPrefix(formatRecurrenceRule(*event.Recurrence), "RRULE:")
+		vevent.ExDates = event.ExDates
+	}
+	if event.Description != nil {
+		vevent.Description = *event.Description
+	}
+	return vevent, nil
+}
+
+func newUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d@lifeforge", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b) + "@lifeforge"
+}
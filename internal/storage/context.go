@@ -3,7 +3,9 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"life_forge/internal/models"
 	"log"
@@ -38,15 +40,14 @@ func (db_ct *ContextStorage) GetContextByID(ctx context.Context, id int) (models
 		&progressJSON,
 	)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Context{ID: id, Goals: []string{}, Recent5: []string{}, Progress: map[string]string{}}, nil
+	}
 	if err != nil {
 		log.Println("Error with QueryRow method in ", op, " with error: ", err)
-		return models.Context{}, nil
+		return models.Context{}, fmt.Errorf("%s: failed to get context: %w", op, err)
 	}
 
-	context.Goals = []string{}
-	context.Recent5 = []string{}
-	context.Progress = map[string]string{}
-
 	if context.Goals == nil {
 		context.Goals = []string{}
 	}
@@ -96,3 +97,19 @@ func (db_ct *ContextStorage) SaveContext(ctx context.Context, contextData *model
 
 	return nil
 }
+
+// EnsureContext creates empty context if user has none, existing data is kept
+func (db_ct *ContextStorage) EnsureContext(ctx context.Context, id int) error {
+	op := "internal/storage/context.go EnsureContext"
+
+	sql_query := `
+	INSERT INTO Context (id, goals, recent5, progress) VALUES ($1, '{}', '{}', '{}')
+	ON CONFLICT (id) DO NOTHING
+	`
+
+	if _, err := db_ct.pool.Exec(ctx, sql_query, id); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to create context: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const DefaultDigestSendTime = "08:00"
+
+// ErrDigestVerifyNotFound is returned for unknown, used or expired verification link
+var ErrDigestVerifyNotFound = errors.New("verification link not found or expired")
+
+type DigestStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewDigestStorage(pool *pgxpool.Pool) *DigestStorage {
+	return &DigestStorage{
+		pool: pool,
+	}
+}
+
+// GetSettings returns disabled defaults if user never saved settings
+func (ds *DigestStorage) GetSettings(ctx context.Context, userID int) (*models.DigestSettings, error) {
+	op := "internal/storage/digest.go GetSettings"
+
+	sql_query := `
+	SELECT user_id, enabled, email, email_verified, send_time, timezone, calendars, last_sent_date
+	FROM digest_settings
+	WHERE user_id = $1
+	`
+
+	settings, err := scanDigestSettings(ds.pool.QueryRow(ctx, sql_query, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &models.DigestSettings{UserID: userID, SendTime: DefaultDigestSendTime, Calendars: []string{}}, nil
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to load settings: %w", op, err)
+	}
+	return settings, nil
+}
+
+// SaveSettings upserts settings, last sent date is kept so changing send time does not resend today,
+// changed email has to be verified again
+func (ds *DigestStorage) SaveSettings(ctx context.Context, settings *models.DigestSettings) error {
+	op := "internal/storage/digest.go SaveSettings"
+
+	if settings.Calendars == nil {
+		settings.Calendars = []string{}
+	}
+
+	sql_query := `
+	INSERT INTO digest_settings (user_id, enabled, email, send_time, timezone, calendars)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (user_id) DO UPDATE SET
+		enabled = EXCLUDED.enabled,
+		email = EXCLUDED.email,
+		send_time = EXCLUDED.send_time,
+		timezone = EXCLUDED.timezone,
+		calendars = EXCLUDED.calendars,
+		email_verified = digest_settings.email_verified AND digest_settings.email = EXCLUDED.email,
+		verify_token_hash = CASE WHEN digest_settings.email = EXCLUDED.email THEN digest_settings.verify_token_hash END,
+		updated_at = NOW()
+	RETURNING email_verified, last_sent_date
+	`
+
+	err := ds.pool.QueryRow(ctx, sql_query,
+		settings.UserID,
+		settings.Enabled,
+		settings.Email,
+		settings.SendTime,
+		settings.Timezone,
+		settings.Calendars,
+	).Scan(&settings.EmailVerified, &settings.LastSentDate)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save settings: %w", op, err)
+	}
+	return nil
+}
+
+// ListEnabled returns users who opted in to the digest and verified their email
+func (ds *DigestStorage) ListEnabled(ctx context.Context) ([]*models.DigestSettings, error) {
+	op := "internal/storage/digest.go ListEnabled"
+
+	sql_query := `
+	SELECT user_id, enabled, email, email_verified, send_time, timezone, calendars, last_sent_date
+	FROM digest_settings
+	WHERE enabled AND email <> '' AND email_verified
+	ORDER BY user_id
+	`
+
+	rows, err := ds.pool.Query(ctx, sql_query)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list settings: %w", op, err)
+	}
+	defer rows.Close()
+
+	var result []*models.DigestSettings
+	for rows.Next() {
+		settings, err := scanDigestSettings(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan settings: %w", op, err)
+		}
+		result = append(result, settings)
+	}
+	return result, rows.Err()
+}
+
+// ClaimDay marks digest of date as sent, false means it was already claimed,
+// so several app instances never send the same digest twice
+func (ds *DigestStorage) ClaimDay(ctx context.Context, userID int, date time.Time) (bool, error) {
+	op := "internal/storage/digest.go ClaimDay"
+
+	sql_query := `
+	UPDATE digest_settings
+	SET last_sent_date = $2
+	WHERE user_id = $1 AND (last_sent_date IS NULL OR last_sent_date < $2)
+	`
+
+	tag, err := ds.pool.Exec(ctx, sql_query, userID, date.Format("2006-01-02"))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return false, fmt.Errorf("%s: failed to claim digest: %w", op, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ClaimManualSend records on-demand send of opted-in user, false means the digest is disabled,
+// email is not verified or the previous on-demand send was after sentBefore
+func (ds *DigestStorage) ClaimManualSend(ctx context.Context, userID int, sentBefore time.Time) (bool, error) {
+	op := "internal/storage/digest.go ClaimManualSend"
+
+	sql_query := `
+	UPDATE digest_settings
+	SET manual_sent_at = NOW()
+	WHERE user_id = $1 AND enabled AND email <> '' AND email_verified
+		AND (manual_sent_at IS NULL OR manual_sent_at < $2)
+	`
+
+	tag, err := ds.pool.Exec(ctx, sql_query, userID, sentBefore)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return false, fmt.Errorf("%s: failed to claim send: %w", op, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CreateVerifyToken generates verification token for unverified email of user, only its hash is stored.
+// Empty token means email has changed, is already verified or the previous link was sent after sentBefore
+func (ds *DigestStorage) CreateVerifyToken(ctx context.Context, userID int, email string, sentBefore time.Time) (string, error) {
+	op := "internal/storage/digest.go CreateVerifyToken"
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("%s: failed to generate token: %w", op, err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	sql_query := `
+	UPDATE digest_settings
+	SET verify_token_hash = $3, verify_sent_at = NOW()
+	WHERE user_id = $1 AND email = $2 AND email <> '' AND NOT email_verified
+		AND (verify_sent_at IS NULL OR verify_sent_at < $4)
+	`
+
+	tag, err := ds.pool.Exec(ctx, sql_query, userID, email, hashToken(token), sentBefore)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return "", fmt.Errorf("%s: failed to save token: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return "", nil
+	}
+	return token, nil
+}
+
+// VerifyEmail marks email of token as verified, the token works once and only if it was sent after sentAfter
+func (ds *DigestStorage) VerifyEmail(ctx context.Context, token string, sentAfter time.Time) (int, error) {
+	op := "internal/storage/digest.go VerifyEmail"
+
+	sql_query := `
+	UPDATE digest_settings
+	SET email_verified = TRUE, verify_token_hash = NULL
+	WHERE verify_token_hash = $1 AND verify_sent_at > $2
+	RETURNING user_id
+	`
+
+	var userID int
+	err := ds.pool.QueryRow(ctx, sql_query, hashToken(token), sentAfter).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrDigestVerifyNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return 0, fmt.Errorf("%s: failed to verify email: %w", op, err)
+	}
+	return userID, nil
+}
+
+func (ds *DigestStorage) SaveDelivery(ctx context.Context, delivery *models.DigestDelivery) error {
+	op := "internal/storage/digest.go SaveDelivery"
+
+	sql_query := `
+	INSERT INTO digest_deliveries (user_id, digest_date, email, subject, status, error, events)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+	RETURNING id, sent_at
+	`
+
+	err := ds.pool.QueryRow(ctx, sql_query,
+		delivery.UserID,
+		delivery.DigestDate.Format("2006-01-02"),
+		delivery.Email,
+		delivery.Subject,
+		delivery.Status,
+		delivery.Error,
+		delivery.Events,
+	).Scan(&delivery.ID, &delivery.SentAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save delivery: %w", op, err)
+	}
+	return nil
+}
+
+// ListDeliveries returns newest deliveries first
+func (ds *DigestStorage) ListDeliveries(ctx context.Context, userID, limit int) ([]models.DigestDelivery, error) {
+	op := "internal/storage/digest.go ListDeliveries"
+
+	sql_query := `
+	SELECT id, user_id, digest_date, email, subject, status, COALESCE(error, ''), events, sent_at
+	FROM digest_deliveries
+	WHERE user_id = $1
+	ORDER BY sent_at DESC
+	LIMIT $2
+	`
+
+	rows, err := ds.pool.Query(ctx, sql_query, userID, limit)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list deliveries: %w", op, err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.DigestDelivery{}
+	for rows.Next() {
+		var d models.DigestDelivery
+		if err := rows.Scan(&d.ID, &d.UserID, &d.DigestDate, &d.Email, &d.Subject, &d.Status, &d.Error, &d.Events, &d.SentAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan delivery: %w", op, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func scanDigestSettings(row pgx.Row) (*models.DigestSettings, error) {
+	var s models.DigestSettings
+	if err := row.Scan(&s.UserID, &s.Enabled, &s.Email, &s.EmailVerified, &s.SendTime, &s.Timezone, &s.Calendars, &s.LastSentDate); err != nil {
+		return nil, err
+	}
+	if s.Calendars == nil {
+		s.Calendars = []string{}
+	}
+	return &s, nil
+}
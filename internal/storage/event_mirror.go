@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/api/calendar/v3"
+)
+
+// EventMirrorStorage keeps a local copy of Google calendars in Postgres, every user has own copy.
+// All-day events are stored from midnight in location
+type EventMirrorStorage struct {
+	pool     *pgxpool.Pool
+	location *time.Location
+}
+
+func NewEventMirrorStorage(pool *pgxpool.Pool, location *time.Location) *EventMirrorStorage {
+	return &EventMirrorStorage{
+		pool:     pool,
+		location: location,
+	}
+}
+
+// GetSyncState returns saved state for calendar, found=false if calendar was never synced
+func (m *EventMirrorStorage) GetSyncState(ctx context.Context, calendarID string) (models.SyncState, bool, error) {
+	op := "internal/storage/event_mirror.go GetSyncState"
+
+	sql_query := `
+	SELECT calendar_id, is_primary, COALESCE(sync_token, ''), last_synced_at, window_start, window_end, color FROM calendar_sync_state
+	WHERE user_id = $1 AND calendar_id = $2
+	`
+
+	var state models.SyncState
+	err := m.pool.QueryRow(ctx, sql_query, models.UserID(ctx), calendarID).Scan(
+		&state.CalendarID,
+		&state.IsPrimary,
+		&state.SyncToken,
+		&state.LastSyncedAt,
+		&state.WindowStart,
+		&state.WindowEnd,
+		&state.Color,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.SyncState{CalendarID: calendarID}, false, nil
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return models.SyncState{}, false, fmt.Errorf("%s: failed to get sync state: %w", op, err)
+	}
+
+	return state, true, nil
+}
+
+func (m *EventMirrorStorage) SaveSyncState(ctx context.Context, state *models.SyncState) error {
+	op := "internal/storage/event_mirror.go SaveSyncState"
+
+	sql_query := `
+	INSERT INTO calendar_sync_state (user_id, calendar_id, is_primary, sync_token, last_synced_at, window_start, window_end, color)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (user_id, calendar_id) DO UPDATE SET
+	is_primary = EXCLUDED.is_primary,
+	sync_token = EXCLUDED.sync_token,
+	last_synced_at = EXCLUDED.last_synced_at,
+	window_start = EXCLUDED.window_start,
+	window_end = EXCLUDED.window_end,
+	color = EXCLUDED.color
+	`
+
+	_, err := m.pool.Exec(ctx, sql_query,
+		models.UserID(ctx),
+		state.CalendarID,
+		state.IsPrimary,
+		state.SyncToken,
+		state.LastSyncedAt,
+		state.WindowStart,
+		state.WindowEnd,
+		state.Color,
+	)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save sync state: %w", op, err)
+	}
+
+	return nil
+}
+
+// CalendarColors maps synced calendars to their colors as of the last sync, primary calendar is also available as "primary"
+func (m *EventMirrorStorage) CalendarColors(ctx context.Context) (map[string]string, error) {
+	op := "internal/storage/event_mirror.go CalendarColors"
+
+	sql_query := `
+	SELECT calendar_id, is_primary, color FROM calendar_sync_state
+	WHERE user_id = $1 AND color <> ''
+	`
+
+	rows, err := m.pool.Query(ctx, sql_query, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to load colors: %w", op, err)
+	}
+	defer rows.Close()
+
+	colors := make(map[string]string)
+	for rows.Next() {
+		var (
+			calendarID, color string
+			isPrimary         bool
+		)
+		if err := rows.Scan(&calendarID, &isPrimary, &color); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan color: %w", op, err)
+		}
+		colors[calendarID] = color
+		if isPrimary {
+			colors[whereSaveEvent] = color
+		}
+	}
+	return colors, rows.Err()
+}
+
+// ResolveCalendarID turns "primary" alias into real id of primary calendar
+func (m *EventMirrorStorage) ResolveCalendarID(ctx context.Context, calendarID string) (string, error) {
+	if calendarID != whereSaveEvent {
+		return calendarID, nil
+	}
+
+	var id string
+	err := m.pool.QueryRow(ctx, `SELECT calendar_id FROM calendar_sync_state WHERE user_id = $1 AND is_primary LIMIT 1`, models.UserID(ctx)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return calendarID, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve primary calendar: %w", err)
+	}
+	return id, nil
+}
+
+// ReplaceEvents drops mirrored calendar and stores events from full sync
+func (m *EventMirrorStorage) ReplaceEvents(ctx context.Context, calendarID string, events []*calendar.Event) error {
+	op := "internal/storage/event_mirror.go ReplaceEvents"
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin tx: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM calendar_events WHERE user_id = $1 AND calendar_id = $2`, models.UserID(ctx), calendarID); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to clear calendar: %w", op, err)
+	}
+
+	if err := m.applyEvents(ctx, tx, calendarID, events); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Reset drops mirror and sync tokens of the user from context, next sync starts from full one
+func (m *EventMirrorStorage) Reset(ctx context.Context) error {
+	op := "internal/storage/event_mirror.go Reset"
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin tx: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	userID := models.UserID(ctx)
+	if _, err := tx.Exec(ctx, `DELETE FROM calendar_events WHERE user_id = $1`, userID); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to clear events: %w", op, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM calendar_sync_state WHERE user_id = $1`, userID); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to clear sync state: %w", op, err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ApplyChanges upserts changed events and deletes cancelled ones
+func (m *EventMirrorStorage) ApplyChanges(ctx context.Context, calendarID string, events []*calendar.Event) error {
+	op := "internal/storage/event_mirror.go ApplyChanges"
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin tx: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := m.applyEvents(ctx, tx, calendarID, events); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (m *EventMirrorStorage) applyEvents(ctx context.Context, tx pgx.Tx, calendarID string, events []*calendar.Event) error {
+	userID := models.UserID(ctx)
+	for _, event := range events {
+		if event == nil || event.Id == "" {
+			continue
+		}
+
+		if event.Status == "cancelled" {
+			_, err := tx.Exec(ctx, `DELETE FROM calendar_events WHERE user_id = $1 AND calendar_id = $2 AND event_id = $3`, userID, calendarID, event.Id)
+			if err != nil {
+				return fmt.Errorf("failed to delete event %s: %w", event.Id, err)
+			}
+			continue
+		}
+
+		start, end, err := EventBounds(event, m.location)
+		if err != nil {
+			log.Printf("Skip event '%s' in mirror: %v", event.Summary, err)
+			continue
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event %s: %w", event.Id, err)
+		}
+
+		_, err = tx.Exec(ctx, `
+		INSERT INTO calendar_events (user_id, calendar_id, event_id, start_time, end_time, data, updated_at) VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (user_id, calendar_id, event_id) DO UPDATE SET
+		start_time = EXCLUDED.start_time,
+		end_time = EXCLUDED.end_time,
+		data = EXCLUDED.data,
+		updated_at = NOW()
+		`, userID, calendarID, event.Id, start, end, data)
+		if err != nil {
+			return fmt.Errorf("failed to upsert event %s: %w", event.Id, err)
+		}
+	}
+
+	return nil
+}
+
+// ListEvents reads events of synced calendar, synced=false means caller should ask Google:
+// calendar is not synced yet or range goes beyond the sync window
+func (m *EventMirrorStorage) ListEvents(ctx context.Context, calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, bool, error) {
+	op := "internal/storage/event_mirror.go ListEvents"
+
+	calendarID, err := m.ResolveCalendarID(ctx, calendarID)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	state, found, err := m.GetSyncState(ctx, calendarID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !found || state.LastSyncedAt == nil || state.WindowStart == nil || state.WindowEnd == nil {
+		return nil, false, nil
+	}
+	if timeMin.Before(*state.WindowStart) || timeMax.After(*state.WindowEnd) {
+		return nil, false, nil
+	}
+
+	sql_query := `
+	SELECT data FROM calendar_events
+	WHERE user_id = $1 AND calendar_id = $2 AND start_time < $4 AND end_time > $3
+	ORDER BY start_time
+	`
+
+	rows, err := m.pool.Query(ctx, sql_query, models.UserID(ctx), calendarID, timeMin, timeMax)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, false, fmt.Errorf("%s: failed to list events: %w", op, err)
+	}
+	defer rows.Close()
+
+	var events []*calendar.Event
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, false, fmt.Errorf("%s: failed to scan event: %w", op, err)
+		}
+
+		event := &calendar.Event{}
+		if err := json.Unmarshal(data, event); err != nil {
+			log.Println("Failed to unmarshal event in ", op, "with error: ", err)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return events, true, nil
+}
+
+// EventBounds returns start and end of event, all-day events start at midnight in location
+func EventBounds(event *calendar.Event, location *time.Location) (time.Time, time.Time, error) {
+	start, err := parseEventDateTime(event.Start, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("bad start: %w", err)
+	}
+	end, err := parseEventDateTime(event.End, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("bad end: %w", err)
+	}
+	return start, end, nil
+}
+
+// parseEventDateTime reads all-day date in location, so day boundaries match what user sees
+func parseEventDateTime(dt *calendar.EventDateTime, location *time.Location) (time.Time, error) {
+	if dt == nil {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+	if dt.DateTime != "" {
+		return time.Parse(time.RFC3339, dt.DateTime)
+	}
+	if dt.Date != "" {
+		return time.ParseInLocation("2006-01-02", dt.Date, location)
+	}
+	return time.Time{}, fmt.Errorf("empty date")
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"life_forge/internal/models"
+	"testing"
+	"time"
+)
+
+func TestCalendarColors(t *testing.T) {
+	m := NewEventMirrorStorage(testPool(t), time.UTC)
+	ctx := models.WithUserID(context.Background(), 1)
+
+	for _, state := range []models.SyncState{
+		{CalendarID: "me@example.com", IsPrimary: true, Color: "#039be5"},
+		{CalendarID: "team", Color: "#33b679"},
+		{CalendarID: "no-color"},
+	} {
+		if err := m.SaveSyncState(ctx, &state); err != nil {
+			t.Fatalf("SaveSyncState: %v", err)
+		}
+	}
+	// color changed in Google is picked up by the next sync
+	if err := m.SaveSyncState(ctx, &models.SyncState{CalendarID: "team", Color: "#f4511e"}); err != nil {
+		t.Fatalf("SaveSyncState: %v", err)
+	}
+	if err := m.SaveSyncState(models.WithUserID(context.Background(), 2), &models.SyncState{CalendarID: "other", Color: "#000000"}); err != nil {
+		t.Fatalf("SaveSyncState: %v", err)
+	}
+
+	colors, err := m.CalendarColors(ctx)
+	if err != nil {
+		t.Fatalf("CalendarColors: %v", err)
+	}
+	want := map[string]string{"me@example.com": "#039be5", "primary": "#039be5", "team": "#f4511e"}
+	if len(colors) != len(want) {
+		t.Fatalf("colors = %v, want %v", colors, want)
+	}
+	for id, color := range want {
+		if colors[id] != color {
+			t.Fatalf("colors = %v, want %v", colors, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EventStorage keeps history of events created through the assistant, rows belong to the user from context
+type EventStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewEventStorage(pool *pgxpool.Pool) *EventStorage {
+	return &EventStorage{
+		pool: pool,
+	}
+}
+
+func (es *EventStorage) SaveEventInDB(ctx context.Context, event *models.EventRequest) error {
+	op := "internal/storage/events.go SaveEvent"
+
+	sql_query := `
+	INSERT INTO events (user_id, is_event, title, start_time, duration_hours, recurrence, description) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := es.pool.Exec(ctx, sql_query,
+		models.UserID(ctx),
+		event.IsEvent,
+		event.Title,
+		event.StartTime,
+		event.DurationHours,
+		event.Recurrence,
+		event.Description,
+	)
+
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save context data: %w", op, err)
+	}
+
+	return nil
+}
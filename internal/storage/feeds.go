@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrFeedNotFound is returned for unknown or revoked feed token
+var ErrFeedNotFound = errors.New("feed not found")
+
+type FeedStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewFeedStorage(pool *pgxpool.Pool) *FeedStorage {
+	return &FeedStorage{
+		pool: pool,
+	}
+}
+
+// CreateFeed generates new token, only its hash is stored
+func (fs *FeedStorage) CreateFeed(ctx context.Context, calendars []string) (*models.FeedToken, error) {
+	op := "internal/storage/feeds.go CreateFeed"
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("%s: failed to generate token: %w", op, err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	feed := &models.FeedToken{UserID: models.UserID(ctx), Token: token, Calendars: calendars}
+	if feed.Calendars == nil {
+		feed.Calendars = []string{}
+	}
+
+	sql_query := `
+	INSERT INTO feed_tokens (user_id, token_hash, calendars) VALUES ($1, $2, $3)
+	RETURNING id, created_at
+	`
+
+	err := fs.pool.QueryRow(ctx, sql_query, feed.UserID, hashToken(token), feed.Calendars).Scan(&feed.ID, &feed.CreatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to save feed: %w", op, err)
+	}
+
+	return feed, nil
+}
+
+func (fs *FeedStorage) ListFeeds(ctx context.Context) ([]models.FeedToken, error) {
+	op := "internal/storage/feeds.go ListFeeds"
+
+	rows, err := fs.pool.Query(ctx, `SELECT id, user_id, calendars, created_at, last_used_at FROM feed_tokens WHERE user_id = $1 ORDER BY id`, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list feeds: %w", op, err)
+	}
+	defer rows.Close()
+
+	feeds := []models.FeedToken{}
+	for rows.Next() {
+		var f models.FeedToken
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Calendars, &f.CreatedAt, &f.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan feed: %w", op, err)
+		}
+		feeds = append(feeds, f)
+	}
+
+	return feeds, rows.Err()
+}
+
+// GetFeedByToken checks token and marks feed as used, feed is read on behalf of its owner
+func (fs *FeedStorage) GetFeedByToken(ctx context.Context, token string) (*models.FeedToken, error) {
+	op := "internal/storage/feeds.go GetFeedByToken"
+
+	sql_query := `
+	UPDATE feed_tokens SET last_used_at = NOW()
+	WHERE token_hash = $1
+	RETURNING id, user_id, calendars, created_at, last_used_at
+	`
+
+	var f models.FeedToken
+	err := fs.pool.QueryRow(ctx, sql_query, hashToken(token)).Scan(&f.ID, &f.UserID, &f.Calendars, &f.CreatedAt, &f.LastUsedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFeedNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get feed: %w", op, err)
+	}
+
+	return &f, nil
+}
+
+func (fs *FeedStorage) DeleteFeed(ctx context.Context, id int) error {
+	op := "internal/storage/feeds.go DeleteFeed"
+
+	if _, err := fs.pool.Exec(ctx, `DELETE FROM feed_tokens WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx)); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete feed: %w", op, err)
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
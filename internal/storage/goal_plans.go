@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrPlanNotFound        = errors.New("goal plan not found")
+	ErrPlanAlreadyAccepted = errors.New("goal plan is already accepted")
+	ErrGoalNotFound        = errors.New("goal not found")
+	ErrSessionNotFound     = errors.New("goal session not found")
+)
+
+// GoalStorage keeps drafted plans and accepted goals with their milestones and calendar sessions,
+// milestones and sessions belong to the user through their goal
+type GoalStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewGoalStorage(pool *pgxpool.Pool) *GoalStorage {
+	return &GoalStorage{
+		pool: pool,
+	}
+}
+
+// SavePlan inserts new plan or replaces edited one, accepted plans can not be changed
+func (gs *GoalStorage) SavePlan(ctx context.Context, plan *models.GoalPlan) error {
+	op := "internal/storage/goal_plans.go SavePlan"
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("%s: failed to marshal plan: %w", op, err)
+	}
+
+	if plan.ID == 0 {
+		err = gs.pool.QueryRow(ctx, `INSERT INTO goal_plans (user_id, plan) VALUES ($1, $2) RETURNING id, created_at`, models.UserID(ctx), data).
+			Scan(&plan.ID, &plan.CreatedAt)
+		if err != nil {
+			log.Println("Error with QueryRow method in ", op, " with error: ", err)
+			return fmt.Errorf("%s: failed to save plan: %w", op, err)
+		}
+		return nil
+	}
+
+	tag, err := gs.pool.Exec(ctx, `UPDATE goal_plans SET plan = $2 WHERE id = $1 AND user_id = $3 AND goal_id IS NULL`, plan.ID, data, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to update plan: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPlanAlreadyAccepted
+	}
+	return nil
+}
+
+func (gs *GoalStorage) GetPlan(ctx context.Context, id int) (*models.GoalPlan, error) {
+	op := "internal/storage/goal_plans.go GetPlan"
+
+	var data []byte
+	var goalID *int
+	var plan models.GoalPlan
+	err := gs.pool.QueryRow(ctx, `SELECT plan, goal_id, created_at FROM goal_plans WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx)).
+		Scan(&data, &goalID, &plan.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get plan: %w", op, err)
+	}
+
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("%s: failed to unmarshal plan: %w", op, err)
+	}
+	plan.ID = id
+	plan.GoalID = goalID
+	return &plan, nil
+}
+
+// CreateGoalFromPlan creates goal with milestones and binds plan to it in one transaction,
+// so a plan accepted twice in parallel produces one goal
+func (gs *GoalStorage) CreateGoalFromPlan(ctx context.Context, plan *models.GoalPlan) (*models.Goal, error) {
+	op := "internal/storage/goal_plans.go CreateGoalFromPlan"
+
+	tx, err := gs.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var acceptedGoal *int
+	err = tx.QueryRow(ctx, `SELECT goal_id FROM goal_plans WHERE id = $1 AND user_id = $2 FOR UPDATE`, plan.ID, models.UserID(ctx)).Scan(&acceptedGoal)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to lock plan: %w", op, err)
+	}
+	if acceptedGoal != nil {
+		return nil, ErrPlanAlreadyAccepted
+	}
+
+	goal := &models.Goal{
+		Title:       plan.Title,
+		Description: plan.Goal,
+		Deadline:    plan.Deadline,
+		Status:      models.GoalStatusActive,
+		Milestones:  []models.GoalMilestone{},
+		Sessions:    []models.GoalSession{},
+	}
+
+	sql_query := `
+	INSERT INTO goals (user_id, title, description, deadline, status)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(ctx, sql_query, models.UserID(ctx), goal.Title, goal.Description, goal.Deadline, goal.Status).Scan(&goal.ID, &goal.CreatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to create goal: %w", op, err)
+	}
+
+	for i, m := range plan.Milestones {
+		milestone := models.GoalMilestone{GoalID: goal.ID, Title: m.Title, DueDate: m.DueDate, Position: i}
+		err = tx.QueryRow(ctx, `INSERT INTO goal_milestones (goal_id, title, due_date, position) VALUES ($1, $2, $3, $4) RETURNING id`,
+			goal.ID, m.Title, m.DueDate, i).Scan(&milestone.ID)
+		if err != nil {
+			log.Println("Error with QueryRow method in ", op, " with error: ", err)
+			return nil, fmt.Errorf("%s: failed to create milestone: %w", op, err)
+		}
+		goal.Milestones = append(goal.Milestones, milestone)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE goal_plans SET goal_id = $2 WHERE id = $1`, plan.ID, goal.ID); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to bind plan: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit: %w", op, err)
+	}
+	plan.GoalID = &goal.ID
+	return goal, nil
+}
+
+func (gs *GoalStorage) AddSession(ctx context.Context, session *models.GoalSession) error {
+	op := "internal/storage/goal_plans.go AddSession"
+
+	sql_query := `
+	INSERT INTO goal_sessions (goal_id, milestone_id, title, calendar_id, event_id, start_time, end_time)
+	SELECT id, $2, $3, $4, $5, $6, $7 FROM goals WHERE id = $1 AND user_id = $8
+	RETURNING id
+	`
+
+	err := gs.pool.QueryRow(ctx, sql_query,
+		session.GoalID,
+		session.MilestoneID,
+		session.Title,
+		session.CalendarID,
+		session.EventID,
+		session.Start,
+		session.End,
+		models.UserID(ctx),
+	).Scan(&session.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrGoalNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save session: %w", op, err)
+	}
+	return nil
+}
+
+// ListGoals returns goals with milestones and sessions, id 0 means all goals
+func (gs *GoalStorage) ListGoals(ctx context.Context, id int) ([]*models.Goal, error) {
+	op := "internal/storage/goal_plans.go ListGoals"
+
+	rows, err := gs.pool.Query(ctx, `
+	SELECT id, title, description, deadline, status, created_at
+	FROM goals
+	WHERE user_id = $2 AND ($1 = 0 OR id = $1)
+	ORDER BY created_at DESC
+	`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list goals: %w", op, err)
+	}
+
+	goals := []*models.Goal{}
+	byID := make(map[int]*models.Goal)
+	for rows.Next() {
+		g := &models.Goal{Milestones: []models.GoalMilestone{}, Sessions: []models.GoalSession{}}
+		if err := rows.Scan(&g.ID, &g.Title, &g.Description, &g.Deadline, &g.Status, &g.CreatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: failed to scan goal: %w", op, err)
+		}
+		goals = append(goals, g)
+		byID[g.ID] = g
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(goals) == 0 {
+		return goals, nil
+	}
+
+	rows, err = gs.pool.Query(ctx, `
+	SELECT id, goal_id, title, due_date, position
+	FROM goal_milestones
+	WHERE goal_id IN (SELECT id FROM goals WHERE user_id = $2) AND ($1 = 0 OR goal_id = $1)
+	ORDER BY goal_id, position
+	`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list milestones: %w", op, err)
+	}
+	for rows.Next() {
+		var m models.GoalMilestone
+		if err := rows.Scan(&m.ID, &m.GoalID, &m.Title, &m.DueDate, &m.Position); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: failed to scan milestone: %w", op, err)
+		}
+		if g, ok := byID[m.GoalID]; ok {
+			g.Milestones = append(g.Milestones, m)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = gs.pool.Query(ctx, `
+	SELECT id, goal_id, milestone_id, title, calendar_id, event_id, start_time, end_time, completed, completed_at
+	FROM goal_sessions
+	WHERE goal_id IN (SELECT id FROM goals WHERE user_id = $2) AND ($1 = 0 OR goal_id = $1)
+	ORDER BY goal_id, start_time
+	`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list sessions: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s models.GoalSession
+		if err := rows.Scan(&s.ID, &s.GoalID, &s.MilestoneID, &s.Title, &s.CalendarID, &s.EventID, &s.Start, &s.End, &s.Completed, &s.CompletedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan session: %w", op, err)
+		}
+		if g, ok := byID[s.GoalID]; ok {
+			g.Sessions = append(g.Sessions, s)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, g := range goals {
+		fillGoalProgress(g)
+	}
+	return goals, nil
+}
+
+// SetSessionCompleted marks session done or not done and returns its goal
+func (gs *GoalStorage) SetSessionCompleted(ctx context.Context, sessionID int, completed bool) (int, error) {
+	op := "internal/storage/goal_plans.go SetSessionCompleted"
+
+	sql_query := `
+	UPDATE goal_sessions
+	SET completed = $2, completed_at = CASE WHEN $2 THEN COALESCE(completed_at, NOW()) END
+	WHERE id = $1 AND goal_id IN (SELECT id FROM goals WHERE user_id = $3)
+	RETURNING goal_id
+	`
+
+	var goalID int
+	err := gs.pool.QueryRow(ctx, sql_query, sessionID, completed, models.UserID(ctx)).Scan(&goalID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrSessionNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return 0, fmt.Errorf("%s: failed to update session: %w", op, err)
+	}
+	return goalID, nil
+}
+
+func (gs *GoalStorage) SetGoalStatus(ctx context.Context, goalID int, status string) error {
+	op := "internal/storage/goal_plans.go SetGoalStatus"
+
+	if _, err := gs.pool.Exec(ctx, `UPDATE goals SET status = $2 WHERE id = $1 AND user_id = $3`, goalID, status, models.UserID(ctx)); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to update goal: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteGoal removes goal with its milestones and sessions, calendar events are left to the caller
+func (gs *GoalStorage) DeleteGoal(ctx context.Context, goalID int) error {
+	op := "internal/storage/goal_plans.go DeleteGoal"
+
+	tag, err := gs.pool.Exec(ctx, `DELETE FROM goals WHERE id = $1 AND user_id = $2`, goalID, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete goal: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGoalNotFound
+	}
+	return nil
+}
+
+// fillGoalProgress counts completed sessions of goal and of each milestone
+func fillGoalProgress(g *models.Goal) {
+	total := make(map[int]int)
+	done := make(map[int]int)
+	g.Total, g.Completed = len(g.Sessions), 0
+	for _, s := range g.Sessions {
+		if s.Completed {
+			g.Completed++
+		}
+		if s.MilestoneID != nil {
+			total[*s.MilestoneID]++
+			if s.Completed {
+				done[*s.MilestoneID]++
+			}
+		}
+	}
+	for i := range g.Milestones {
+		id := g.Milestones[i].ID
+		g.Milestones[i].Completed = total[id] > 0 && done[id] == total[id]
+	}
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"life_forge/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const googleIDPrefix = "google:"
+
+// googleAccountProvider is CalendarProvider for linked Google account that is not primary.
+// Its calendars are "google:<account id>:<calendar id>" and are read from Google directly, without mirror.
+// It is a comparable value, so router groups calendars of one account into one call
+type googleAccountProvider struct {
+	gcs       *GoogleCalendarStorage
+	accountID int
+	email     string
+}
+
+func (p googleAccountProvider) calendarID(id string) string {
+	return fmt.Sprintf("%s%d:%s", googleIDPrefix, p.accountID, id)
+}
+
+func (p googleAccountProvider) googleID(calendarID string) (string, error) {
+	prefix := fmt.Sprintf("%s%d:", googleIDPrefix, p.accountID)
+	if !strings.HasPrefix(calendarID, prefix) {
+		return "", fmt.Errorf("calendar %s does not belong to google account %d", calendarID, p.accountID)
+	}
+	return strings.TrimPrefix(calendarID, prefix), nil
+}
+
+func (p googleAccountProvider) context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, googleAccountKey{}, p.accountID)
+}
+
+func parseGoogleAccountID(calendarID string) (int, bool) {
+	if !strings.HasPrefix(calendarID, googleIDPrefix) {
+		return 0, false
+	}
+	rest := strings.TrimPrefix(calendarID, googleIDPrefix)
+	idx := strings.Index(rest, ":")
+	if idx <= 0 {
+		return 0, false
+	}
+	id, err := strconv.Atoi(rest[:idx])
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// ListCalendars marks none of them primary, "primary" always means calendar of primary account
+func (p googleAccountProvider) ListCalendars(ctx context.Context) ([]models.Calendar, error) {
+	calendars, err := p.gcs.ListCalendars(p.context(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("google account %s: %w", p.email, err)
+	}
+	for i := range calendars {
+		calendars[i].ID = p.calendarID(calendars[i].ID)
+		calendars[i].Primary = false
+		calendars[i].Account = p.email
+	}
+	return calendars, nil
+}
+
+func (p googleAccountProvider) ListEvents(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]*models.CalendarEvent, []models.CalendarStatus, error) {
+	ids := make([]string, 0, len(calendarIDs))
+	for _, calendarID := range calendarIDs {
+		id, err := p.googleID(calendarID)
+		if err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		ids = append(ids, whereSaveEvent)
+	}
+
+	events, statuses, err := p.gcs.ListEvents(p.context(ctx), timeMin, timeMax, ids...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, event := range events {
+		event.CalendarID = p.calendarID(event.CalendarID)
+	}
+	for i := range statuses {
+		statuses[i].CalendarID = p.calendarID(statuses[i].CalendarID)
+	}
+	return events, statuses, nil
+}
+
+func (p googleAccountProvider) CreateEvent(ctx context.Context, calendarID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	id, err := p.googleID(calendarID)
+	if err != nil {
+		return nil, err
+	}
+	created, err := p.gcs.CreateEvent(p.context(ctx), id, event)
+	if err != nil {
+		return nil, err
+	}
+	created.CalendarID = p.calendarID(created.CalendarID)
+	return created, nil
+}
+
+func (p googleAccountProvider) UpdateEvent(ctx context.Context, calendarID, eventID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	id, err := p.googleID(calendarID)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := p.gcs.UpdateEvent(p.context(ctx), id, eventID, event)
+	if err != nil {
+		return nil, err
+	}
+	updated.CalendarID = p.calendarID(updated.CalendarID)
+	return updated, nil
+}
+
+func (p googleAccountProvider) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	id, err := p.googleID(calendarID)
+	if err != nil {
+		return err
+	}
+	return p.gcs.DeleteEvent(p.context(ctx), id, eventID)
+}
+
+func (p googleAccountProvider) FreeBusy(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]models.BusyPeriod, error) {
+	ids := make([]string, 0, len(calendarIDs))
+	for _, calendarID := range calendarIDs {
+		id, err := p.googleID(calendarID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return p.gcs.FreeBusy(p.context(ctx), timeMin, timeMax, ids...)
+}
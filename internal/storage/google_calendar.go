@@ -3,96 +3,410 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"life_forge/internal/models"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
+	"google.golang.org/api/tasks/v1"
 )
 
 const (
 	whereSaveEvent = "primary"
+	syncPageSize   = 250
+
+	listEventsWorkers = 4
 )
 
+// ErrSyncTokenExpired is returned when Google answers 410 Gone and full resync is needed
+var ErrSyncTokenExpired = errors.New("sync token expired")
+
+// legacyTokenFile is token of single-user setup, see ImportLegacyToken
+const legacyTokenFile = "token.json"
+
+const googleRevokeURL = "https://oauth2.googleapis.com/revoke"
+
+// revokeTimeout bounds revoke request, Disconnect waits for it
+const revokeTimeout = 10 * time.Second
+
+const notAuthorizedMessage = "Календарь не подключен. Перейдите по /auth/google для авторизации."
+
+// GoogleCalendarStorage works with Google accounts of the user from context: primary one by default,
+// another linked account when context carries it (see googleAccountProvider).
+// Tokens live in Postgres and services are cached per account
 type GoogleCalendarStorage struct {
+	config    *oauth2.Config
+	tokens    *TokenStorage
+	mirror    *EventMirrorStorage
+	endpoint  string
+	revokeURL string
+	revoker   *http.Client
+	location  *time.Location // all-day events start at midnight here
+
+	mu       sync.Mutex
+	services map[int]accountService // by account id
+	primary  map[int]int            // user id -> primary account id
+}
+
+type accountService struct {
+	userID  int
 	service *calendar.Service
-	config  *oauth2.Config
-	pool    *pgxpool.Pool
 }
 
-func NewGoogleCalendarStorage(pool *pgxpool.Pool) (*GoogleCalendarStorage, error) {
+// googleAccountKey in context selects linked account that is not primary
+type googleAccountKey struct{}
+
+// NewGoogleCalendarStorage endpoint overrides Calendar API base url (fake server in tests), empty means Google,
+// redirectURL overrides the one from credentials.json and must be registered for the OAuth client
+func NewGoogleCalendarStorage(tokens *TokenStorage, mirror *EventMirrorStorage, endpoint, redirectURL string, location *time.Location) (*GoogleCalendarStorage, error) {
 	data, err := os.ReadFile("credentials.json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read credentials.json: %w", err)
 	}
 
-	config, err := google.ConfigFromJSON(data, calendar.CalendarScope)
+	// tasks scope is for optional Google Tasks sync, tokens issued before it need /auth/google again
+	config, err := google.ConfigFromJSON(data, calendar.CalendarScope, tasks.TasksScope)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create config: %w", err)
 	}
+	if redirectURL != "" {
+		config.RedirectURL = redirectURL
+	}
+
+	return &GoogleCalendarStorage{
+		config:    config,
+		tokens:    tokens,
+		mirror:    mirror,
+		endpoint:  endpoint,
+		revokeURL: googleRevokeURL,
+		revoker:   &http.Client{Timeout: revokeTimeout},
+		location:  location,
+		services:  make(map[int]accountService),
+		primary:   make(map[int]int),
+	}, nil
+}
+
+func (gcs *GoogleCalendarStorage) newService(client *http.Client) (*calendar.Service, error) {
+	opts := []option.ClientOption{option.WithHTTPClient(client)}
+	if gcs.endpoint != "" {
+		opts = append(opts, option.WithEndpoint(gcs.endpoint))
+	}
+	return calendar.NewService(context.Background(), opts...)
+}
 
-	client, err := getClient(config)
+// serviceFor returns service of the account from context, nil if user has not connected it
+func (gcs *GoogleCalendarStorage) serviceFor(ctx context.Context) *calendar.Service {
+	userID := models.UserID(ctx)
+	if userID == 0 {
+		return nil
+	}
+	accountID, _ := ctx.Value(googleAccountKey{}).(int)
+
+	gcs.mu.Lock()
+	if accountID == 0 {
+		accountID = gcs.primary[userID]
+	}
+	if cached, ok := gcs.services[accountID]; ok && cached.userID == userID {
+		gcs.mu.Unlock()
+		return cached.service
+	}
+	gcs.mu.Unlock()
+
+	account, tok, err := gcs.tokens.GetToken(ctx, userID, accountID)
+	if err != nil {
+		return nil
+	}
+	service, err := gcs.newService(gcs.newClient(userID, account.ID, tok))
 	if err != nil {
-		return &GoogleCalendarStorage{config: config, pool: pool}, nil
+		log.Printf("Failed to create Calendar service for Google account %d: %v", account.ID, err)
+		return nil
 	}
 
-	service, err := calendar.NewService(context.Background(), option.WithHTTPClient(client))
+	// accounts linked before emails were stored learn it on first use
+	if account.Email == "" {
+		gcs.saveEmail(ctx, account.ID, service)
+	}
+
+	gcs.mu.Lock()
+	gcs.services[account.ID] = accountService{userID: userID, service: service}
+	if account.Primary {
+		gcs.primary[userID] = account.ID
+	}
+	gcs.mu.Unlock()
+	return service
+}
+
+func (gcs *GoogleCalendarStorage) IsAuthorized(ctx context.Context) bool {
+	return gcs.serviceFor(ctx) != nil
+}
+
+// HTTPClient is authorized client of user's primary account for other Google APIs sharing the same token
+func (gcs *GoogleCalendarStorage) HTTPClient(ctx context.Context) (*http.Client, error) {
+	userID := models.UserID(ctx)
+	_, tok, err := gcs.tokens.GetToken(ctx, userID, 0)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Calendar service: %w", err)
+		return nil, err
+	}
+	return gcs.newClient(userID, 0, tok), nil
+}
+
+// newClient refreshes token through storage, see dbTokenSource
+func (gcs *GoogleCalendarStorage) newClient(userID, accountID int, tok *oauth2.Token) *http.Client {
+	return oauth2.NewClient(context.Background(), &dbTokenSource{
+		config:    gcs.config,
+		tokens:    gcs.tokens,
+		userID:    userID,
+		accountID: accountID,
+		onRevoked: gcs.forget,
+		tok:       tok,
+	})
+}
+
+// forget drops cached service of account, next call reads it from storage again
+func (gcs *GoogleCalendarStorage) forget(accountID int) {
+	gcs.mu.Lock()
+	defer gcs.mu.Unlock()
+
+	delete(gcs.services, accountID)
+	for userID, id := range gcs.primary {
+		if id == accountID {
+			delete(gcs.primary, userID)
+		}
+	}
+}
+
+// AuthStatus lists Google accounts of the user from context, ReauthRequired is set when Google revoked any of them
+func (gcs *GoogleCalendarStorage) AuthStatus(ctx context.Context) (*models.GoogleAuthStatus, error) {
+	accounts, err := gcs.tokens.ListAccounts(ctx, models.UserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.GoogleAuthStatus{AuthURL: "/auth/google", Accounts: accounts}
+	for _, account := range accounts {
+		if account.Primary {
+			status.Connected = !account.ReauthRequired
+			status.Email = account.Email
+		}
+		if account.ReauthRequired {
+			status.ReauthRequired = true
+		}
+	}
+	return status, nil
+}
+
+// AccountProviders are linked accounts except primary one, their calendars are merged by CalendarRouter
+func (gcs *GoogleCalendarStorage) AccountProviders(ctx context.Context) []CalendarProvider {
+	accounts, err := gcs.tokens.ListAccounts(ctx, models.UserID(ctx))
+	if err != nil {
+		log.Printf("Failed to list Google accounts: %v", err)
+		return nil
 	}
 
-	return &GoogleCalendarStorage{service: service, config: config, pool: pool}, nil
+	var providers []CalendarProvider
+	for _, account := range accounts {
+		if account.Primary || account.ReauthRequired {
+			continue
+		}
+		providers = append(providers, gcs.AccountProvider(account.ID, account.Email))
+	}
+	return providers
 }
 
-func (gcs *GoogleCalendarStorage) IsAuthorized() bool {
-	return gcs.service != nil
+// AccountProvider serves calendars "google:<accountID>:<calendar id>", calls fail if account is not user's
+func (gcs *GoogleCalendarStorage) AccountProvider(accountID int, email string) CalendarProvider {
+	return googleAccountProvider{gcs: gcs, accountID: accountID, email: email}
+}
+
+// ImportLegacyToken moves token.json of single-user setup to the given user, who owns the old data.
+// Missing file is not an error; file is kept when the user has already linked Google
+func (gcs *GoogleCalendarStorage) ImportLegacyToken(ctx context.Context, userID int) error {
+	tok, err := tokenFromFile(legacyTokenFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", legacyTokenFile, err)
+	}
+
+	_, err = gcs.tokens.ImportToken(ctx, userID, tok)
+	if errors.Is(err, ErrAccountExists) {
+		return fmt.Errorf("user %d already has Google account, %s is not imported", userID, legacyTokenFile)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(legacyTokenFile); err != nil {
+		log.Printf("Failed to remove %s: %v", legacyTokenFile, err)
+	}
+	log.Printf("Token from %s is moved to user %d", legacyTokenFile, userID)
+	return nil
 }
 
-// GetAuthURL returns url for login
-func (gcs *GoogleCalendarStorage) GetAuthURL() string {
+// GetAuthURL returns url for login, state and PKCE verifier must be kept by caller until callback
+func (gcs *GoogleCalendarStorage) GetAuthURL(state, verifier string) string {
 	return gcs.config.AuthCodeURL(
-		"state-token",
+		state,
 		oauth2.AccessTypeOffline,
 		oauth2.SetAuthURLParam("prompt", "consent"), // принудительный запрос согласия
+		oauth2.S256ChallengeOption(verifier),
 	)
 }
 
-// ExchangeCode change code from Google on token and save it
-func (gcs *GoogleCalendarStorage) ExchangeCode(code string) error {
-	tok, err := gcs.config.Exchange(context.Background(), code)
+// ExchangeCode change code from Google on token and links the account to the user from context,
+// authorizing already linked account again just replaces its token
+func (gcs *GoogleCalendarStorage) ExchangeCode(ctx context.Context, code, verifier string) (*models.GoogleAccount, error) {
+	userID := models.UserID(ctx)
+	if userID == 0 {
+		return nil, fmt.Errorf("no user to save token for")
+	}
+
+	tok, err := gcs.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 	if err != nil {
-		return fmt.Errorf("unable to retrieve token from web: %w", err)
+		return nil, fmt.Errorf("unable to retrieve token from web: %w", err)
 	}
-	saveToken("token.json", tok)
 
-	client := gcs.config.Client(context.Background(), tok)
-	service, err := calendar.NewService(context.Background(), option.WithHTTPClient(client))
+	service, err := gcs.newService(gcs.config.Client(ctx, tok))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service: %w", err)
+	}
+	email, err := accountEmail(ctx, service)
 	if err != nil {
-		return fmt.Errorf("failed to create service: %w", err)
+		return nil, fmt.Errorf("failed to get account email: %w", err)
+	}
+
+	// account without email (token.json) must learn it first, otherwise the same account is linked twice
+	gcs.learnMissingEmails(ctx, userID)
+
+	scopes := gcs.config.Scopes
+	if granted, ok := tok.Extra("scope").(string); ok && granted != "" {
+		scopes = strings.Fields(granted)
+	}
+
+	account, err := gcs.tokens.SaveAccount(ctx, userID, email, scopes, tok)
+	if err != nil {
+		return nil, err
+	}
+	gcs.forget(account.ID)
+	return account, nil
+}
+
+// learnMissingEmails asks Google for emails of user's accounts linked before emails were stored
+func (gcs *GoogleCalendarStorage) learnMissingEmails(ctx context.Context, userID int) {
+	accounts, err := gcs.tokens.ListAccounts(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to list Google accounts: %v", err)
+		return
+	}
+
+	for _, account := range accounts {
+		if account.Email != "" || account.ReauthRequired {
+			continue
+		}
+		_, tok, err := gcs.tokens.GetToken(ctx, userID, account.ID)
+		if err != nil {
+			continue
+		}
+		service, err := gcs.newService(gcs.newClient(userID, account.ID, tok))
+		if err != nil {
+			log.Printf("Failed to create Calendar service for Google account %d: %v", account.ID, err)
+			continue
+		}
+		gcs.saveEmail(ctx, account.ID, service)
+	}
+}
+
+// saveEmail stores email of the account, failures are only logged and retried on next use
+func (gcs *GoogleCalendarStorage) saveEmail(ctx context.Context, accountID int, service *calendar.Service) {
+	email, err := accountEmail(ctx, service)
+	if err != nil {
+		return
+	}
+	if err := gcs.tokens.SetEmail(ctx, accountID, email); err != nil {
+		log.Printf("Failed to save email of Google account %d: %v", accountID, err)
+	}
+}
+
+// Disconnect revokes account token with Google and unlinks it. Primary account owns the mirror,
+// so it is dropped and the next primary account is synced from scratch
+func (gcs *GoogleCalendarStorage) Disconnect(ctx context.Context, accountID int) error {
+	account, tok, err := gcs.tokens.DeleteAccount(ctx, models.UserID(ctx), accountID)
+	if err != nil {
+		return err
+	}
+	gcs.forget(account.ID)
+
+	// local state is cleared anyway, failed revoke only leaves grant in user's Google settings
+	if tok != nil {
+		if err := gcs.revoke(ctx, tok); err != nil {
+			log.Printf("Failed to revoke token of Google account %d: %v", account.ID, err)
+		}
+	}
+
+	if account.Primary && gcs.mirror != nil {
+		if err := gcs.mirror.Reset(ctx); err != nil {
+			return err
+		}
 	}
-	gcs.service = service
 	return nil
 }
 
-//==========================================
+// revoke invalidates refresh token and with it every access token of the grant
+func (gcs *GoogleCalendarStorage) revoke(ctx context.Context, tok *oauth2.Token) error {
+	value := tok.RefreshToken
+	if value == "" {
+		value = tok.AccessToken
+	}
 
-func getClient(config *oauth2.Config) (*http.Client, error) {
-	tokFile := "token.json"
-	tok, err := tokenFromFile(tokFile)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, gcs.revokeURL, strings.NewReader(url.Values{"token": {value}}.Encode()))
 	if err != nil {
-		return nil, err
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := gcs.revoker.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 400 invalid_token means it is already revoked
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("revoke answered %s", resp.Status)
 	}
-	return config.Client(context.Background(), tok), nil
+	return nil
+}
+
+// accountEmail is id of primary calendar, it equals account email and needs no extra scope
+func accountEmail(ctx context.Context, service *calendar.Service) (string, error) {
+	entry, err := service.CalendarList.Get(whereSaveEvent).Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+	return entry.Id, nil
+}
+
+// mirrorFor is mirror of primary account, other accounts are always read from Google
+func (gcs *GoogleCalendarStorage) mirrorFor(ctx context.Context) *EventMirrorStorage {
+	if _, ok := ctx.Value(googleAccountKey{}).(int); ok {
+		return nil
+	}
+	return gcs.mirror
 }
 
+//==========================================
+
 func tokenFromFile(file string) (*oauth2.Token, error) {
 	f, err := os.Open(file)
 	if err != nil {
@@ -104,19 +418,117 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
 	return tok, err
 }
 
-func saveToken(path string, token *oauth2.Token) {
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+func (gcs *GoogleCalendarStorage) CreateEvent(ctx context.Context, calendarID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	service := gcs.serviceFor(ctx)
+	if service == nil {
+		return nil, fmt.Errorf(notAuthorizedMessage)
+	}
+	if calendarID == "" {
+		calendarID = whereSaveEvent
+	}
+
+	googleEvent, err := toGoogleEvent(event)
 	if err != nil {
-		log.Printf("Unable to cache oauth token: %v", err)
+		return nil, err
+	}
+
+	created, err := service.Events.Insert(calendarID, googleEvent).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	gcs.updateMirror(ctx, calendarID, created)
+	return googleEventToModel(calendarID, created, gcs.location), nil
+}
+
+// CreateEventWithID creates event under the given id, so repeating it after a failure never duplicates the event:
+// when the id is already taken, the event stored under it is returned. Google ids are made of base32hex characters
+func (gcs *GoogleCalendarStorage) CreateEventWithID(ctx context.Context, calendarID, eventID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	service := gcs.serviceFor(ctx)
+	if service == nil {
+		return nil, fmt.Errorf(notAuthorizedMessage)
+	}
+	if calendarID == "" {
+		calendarID = whereSaveEvent
+	}
+
+	googleEvent, err := toGoogleEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	googleEvent.Id = eventID
+
+	created, err := service.Events.Insert(calendarID, googleEvent).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict {
+		created, err = service.Events.Get(calendarID, eventID).Context(ctx).Do()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gcs.updateMirror(ctx, calendarID, created)
+	return googleEventToModel(calendarID, created, gcs.location), nil
+}
+
+func (gcs *GoogleCalendarStorage) UpdateEvent(ctx context.Context, calendarID, eventID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	service := gcs.serviceFor(ctx)
+	if service == nil {
+		return nil, fmt.Errorf(notAuthorizedMessage)
+	}
+	if calendarID == "" {
+		calendarID = whereSaveEvent
+	}
+
+	googleEvent, err := toGoogleEvent(event)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := service.Events.Patch(calendarID, eventID, googleEvent).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	gcs.updateMirror(ctx, calendarID, updated)
+	return googleEventToModel(calendarID, updated, gcs.location), nil
+}
+
+func (gcs *GoogleCalendarStorage) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	service := gcs.serviceFor(ctx)
+	if service == nil {
+		return fmt.Errorf(notAuthorizedMessage)
+	}
+	if calendarID == "" {
+		calendarID = whereSaveEvent
+	}
+
+	if err := service.Events.Delete(calendarID, eventID).Context(ctx).Do(); err != nil {
+		return err
+	}
+
+	gcs.updateMirror(ctx, calendarID, &calendar.Event{Id: eventID, Status: "cancelled"})
+	return nil
+}
+
+// updateMirror keeps mirror fresh until next sync
+func (gcs *GoogleCalendarStorage) updateMirror(ctx context.Context, calendarID string, event *calendar.Event) {
+	mirror := gcs.mirrorFor(ctx)
+	if mirror == nil {
 		return
 	}
-	defer f.Close()
-	json.NewEncoder(f).Encode(token)
+	calendarID, err := mirror.ResolveCalendarID(ctx, calendarID)
+	if err == nil && calendarID != whereSaveEvent {
+		err = mirror.ApplyChanges(ctx, calendarID, []*calendar.Event{event})
+	}
+	if err != nil {
+		log.Printf("Failed to put event in mirror: %v", err)
+	}
 }
 
-func (gcs *GoogleCalendarStorage) CreateEvent(ctx context.Context, event models.EventRequest) (*calendar.Event, error) {
+func toGoogleEvent(event models.EventRequest) (*calendar.Event, error) {
 	if event.StartTime == nil {
-		log.Println("start time is required")
+		return nil, fmt.Errorf("start time is required")
 	}
 
 	startTime := *event.StartTime
@@ -140,19 +552,68 @@ func (gcs *GoogleCalendarStorage) CreateEvent(ctx context.Context, event models.
 		},
 	}
 
+	if isAllDay(event) {
+		days := int(endTime.Sub(startTime).Hours()/24 + 0.5)
+		if days < 1 {
+			days = 1
+		}
+		googleEvent.Start = &calendar.EventDateTime{Date: startTime.Format("2006-01-02")}
+		googleEvent.End = &calendar.EventDateTime{Date: startTime.AddDate(0, 0, days).Format("2006-01-02")}
+	}
+
 	if event.Recurrence != nil && *event.Recurrence != "" {
 		recurrenceRule := formatRecurrenceRule(*event.Recurrence)
 		if recurrenceRule != "" {
 			googleEvent.Recurrence = []string{recurrenceRule}
 			log.Printf("📅 Устанавливаем рекуррентность: %s", recurrenceRule)
 		}
+
+		for _, ex := range event.ExDates {
+			if isAllDay(event) {
+				googleEvent.Recurrence = append(googleEvent.Recurrence, "EXDATE;VALUE=DATE:"+ex.Format("20060102"))
+			} else {
+				googleEvent.Recurrence = append(googleEvent.Recurrence, "EXDATE:"+ex.UTC().Format("20060102T150405Z"))
+			}
+		}
+	}
+
+	if event.UID != nil {
+		googleEvent.ICalUID = *event.UID
 	}
 
 	if event.Description != nil {
 		googleEvent.Description = *event.Description
 	}
 
-	return gcs.service.Events.Insert("primary", googleEvent).Context(ctx).Do()
+	return googleEvent, nil
+}
+
+// googleEventToModel converts API event, times that fail to parse stay zero
+func googleEventToModel(calendarID string, e *calendar.Event, location *time.Location) *models.CalendarEvent {
+	event := &models.CalendarEvent{
+		ID:               e.Id,
+		CalendarID:       calendarID,
+		UID:              e.ICalUID,
+		Summary:          e.Summary,
+		Description:      e.Description,
+		Location:         e.Location,
+		Status:           e.Status,
+		ColorID:          e.ColorId,
+		RecurringEventID: e.RecurringEventId,
+		Recurrence:       e.Recurrence,
+		HTMLLink:         e.HtmlLink,
+	}
+
+	if start, err := parseEventDateTime(e.Start, location); err == nil {
+		event.Start = start
+	}
+	if end, err := parseEventDateTime(e.End, location); err == nil {
+		event.End = end
+	}
+	event.IsAllDay = (e.Start != nil && e.Start.DateTime == "" && e.Start.Date != "") ||
+		(e.End != nil && e.End.DateTime == "" && e.End.Date != "")
+
+	return event
 }
 
 func formatRecurrenceRule(recurrence string) string {
@@ -173,96 +634,202 @@ func formatRecurrenceRule(recurrence string) string {
 	}
 }
 
-func (gcs *GoogleCalendarStorage) SaveEventInDB(ctx context.Context, event *models.EventRequest) error {
-	op := "internal/storage/google_calendar.go SaveEvent"
+// ListEvents loads events of every calendar concurrently and reports status of each calendar,
+// error is returned only when calendar is not connected at all
+func (gcs *GoogleCalendarStorage) ListEvents(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]*models.CalendarEvent, []models.CalendarStatus, error) {
+	service := gcs.serviceFor(ctx)
+	if service == nil {
+		return nil, nil, fmt.Errorf(notAuthorizedMessage)
+	}
 
-	sql_query := `
-	INSERT INTO events (is_event, title, start_time, duration_hours, recurrence, description) VALUES ($1, $2, $3, $4, $5, $6)
-	`
+	if len(calendarIDs) == 0 {
+		calendarIDs = []string{"primary"}
+	}
 
-	_, err := gcs.pool.Exec(ctx, sql_query,
-		event.IsEvent,
-		event.Title,
-		event.StartTime,
-		event.DurationHours,
-		event.Recurrence,
-		event.Description,
-	)
+	results := make([][]*models.CalendarEvent, len(calendarIDs))
+	statuses := make([]models.CalendarStatus, len(calendarIDs))
+
+	sem := make(chan struct{}, listEventsWorkers)
+	var wg sync.WaitGroup
+
+	for i, cid := range calendarIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, cid string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			events, err := gcs.listCalendarEvents(ctx, service, cid, timeMin, timeMax)
+			statuses[i] = models.CalendarStatus{CalendarID: cid, Status: models.CalendarStatusOK, Events: len(events)}
+
+			if err != nil {
+				log.Printf("failed to list events for %s: %v", cid, err)
+				statuses[i].Status = models.CalendarStatusError
+				statuses[i].Error = err.Error()
+
+				var apiErr *googleapi.Error
+				if errors.As(err, &apiErr) && (apiErr.Code == http.StatusForbidden || apiErr.Code == http.StatusUnauthorized) {
+					statuses[i].Status = models.CalendarStatusForbidden
+				}
+				return
+			}
+			for _, e := range events {
+				results[i] = append(results[i], googleEventToModel(cid, e, gcs.location))
+			}
+		}(i, cid)
+	}
+	wg.Wait()
 
-	if err != nil {
-		log.Println("Error with Exec method in ", op, " with error: ", err)
-		return fmt.Errorf("%s: failed to save context data: %w", op, err)
+	var allEvents []*models.CalendarEvent
+	for _, events := range results {
+		allEvents = append(allEvents, events...)
 	}
 
-	return nil
+	return allEvents, statuses, nil
 }
 
-func (gcs *GoogleCalendarStorage) ListEvents(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]*calendar.Event, error) {
-	if gcs.service == nil {
-		return nil, fmt.Errorf("Календарь не подключен. Перейдите по /auth/google для авторизации.")
+// listCalendarEvents reads calendar from mirror if it is synced, otherwise fetches every page from Google
+func (gcs *GoogleCalendarStorage) listCalendarEvents(ctx context.Context, service *calendar.Service, calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	if mirror := gcs.mirrorFor(ctx); mirror != nil {
+		mirrored, synced, err := mirror.ListEvents(ctx, calendarID, timeMin, timeMax)
+		if err != nil {
+			log.Printf("failed to read mirror for %s: %v", calendarID, err)
+		} else if synced {
+			return mirrored, nil
+		}
 	}
-	timeMinStr := timeMin.Format(time.RFC3339)
-	timeMaxStr := timeMax.Format(time.RFC3339)
 
-	if len(calendarIDs) == 0 {
-		calendarIDs = []string{"primary"}
+	var events []*calendar.Event
+	err := service.Events.List(calendarID).
+		TimeMin(timeMin.Format(time.RFC3339)).
+		TimeMax(timeMax.Format(time.RFC3339)).
+		SingleEvents(true).
+		OrderBy("startTime").
+		MaxResults(syncPageSize).
+		Pages(ctx, func(page *calendar.Events) error {
+			events = append(events, page.Items...)
+			return nil
+		})
+	if err != nil {
+		return nil, err
 	}
 
-	var allEvents []*calendar.Event
+	return events, nil
+}
 
-	for _, cid := range calendarIDs {
-		events, err := gcs.service.Events.List(cid).
-			TimeMin(timeMinStr).
-			TimeMax(timeMaxStr).
-			SingleEvents(true).
-			OrderBy("startTime").
-			Context(ctx).
-			Do()
+// SyncEvents fetches every page of changes since syncToken (full sync when token is empty)
+// and returns them with the token for the next call. Full sync asks Google for instances in
+// [windowStart, windowEnd) only (series without end would never finish otherwise), incremental
+// changes can't be limited by Google and are returned as is, see calsync.Syncer
+func (gcs *GoogleCalendarStorage) SyncEvents(ctx context.Context, calendarID, syncToken string, windowStart, windowEnd time.Time) ([]*calendar.Event, string, error) {
+	service := gcs.serviceFor(ctx)
+	if service == nil {
+		return nil, "", fmt.Errorf("Календарь не авторизован")
+	}
 
-		if err != nil {
-			log.Printf("failed to list events for %s: %v", cid, err)
-			continue
+	call := service.Events.List(calendarID).
+		SingleEvents(true).
+		MaxResults(syncPageSize)
+	if syncToken != "" {
+		// Google rejects timeMin/timeMax together with syncToken
+		call = call.SyncToken(syncToken)
+	} else {
+		call = call.TimeMin(windowStart.Format(time.RFC3339)).TimeMax(windowEnd.Format(time.RFC3339))
+	}
+
+	var events []*calendar.Event
+	var nextSyncToken string
+
+	err := call.Pages(ctx, func(page *calendar.Events) error {
+		events = append(events, page.Items...)
+		if page.NextSyncToken != "" {
+			nextSyncToken = page.NextSyncToken
 		}
-		allEvents = append(allEvents, events.Items...)
+		return nil
+	})
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusGone {
+		return nil, "", ErrSyncTokenExpired
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to sync events for %s: %w", calendarID, err)
 	}
 
-	return allEvents, nil
+	return events, nextSyncToken, nil
 }
 
 // GetUserCalendars returns user's calendar list
 func (gcs *GoogleCalendarStorage) GetUserCalendars(ctx context.Context) ([]*calendar.CalendarListEntry, error) {
-	if gcs.service == nil {
+	service := gcs.serviceFor(ctx)
+	if service == nil {
 		return nil, fmt.Errorf("Календарь не авторизован")
 	}
-	list, err := gcs.service.CalendarList.List().Context(ctx).Do()
+	list, err := service.CalendarList.List().Context(ctx).Do()
 	if err != nil {
 		return nil, err
 	}
 	return list.Items, nil
 }
 
-func (gcs *GoogleCalendarStorage) GetCalendarPreview(ctx context.Context, days int) string {
-	timeMin := time.Now().UTC()
-	timeMax := time.Now().AddDate(0, 0, days).UTC()
-	events, err := gcs.ListEvents(ctx, timeMin, timeMax)
+func (gcs *GoogleCalendarStorage) ListCalendars(ctx context.Context) ([]models.Calendar, error) {
+	entries, err := gcs.GetUserCalendars(ctx)
 	if err != nil {
-		return fmt.Sprintf("Error to load calendar: %v", err)
+		return nil, err
+	}
+
+	calendars := make([]models.Calendar, 0, len(entries))
+	for _, entry := range entries {
+		calendars = append(calendars, models.Calendar{
+			ID:              entry.Id,
+			Summary:         entry.Summary,
+			BackgroundColor: entry.BackgroundColor,
+			Primary:         entry.Primary,
+			Provider:        models.ProviderGoogle,
+		})
+	}
+	return calendars, nil
+}
+
+func (gcs *GoogleCalendarStorage) FreeBusy(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]models.BusyPeriod, error) {
+	service := gcs.serviceFor(ctx)
+	if service == nil {
+		return nil, fmt.Errorf("Календарь не авторизован")
+	}
+	if len(calendarIDs) == 0 {
+		calendarIDs = []string{whereSaveEvent}
 	}
 
-	if len(events) == 0 {
-		return "No events"
+	req := &calendar.FreeBusyRequest{
+		TimeMin: timeMin.Format(time.RFC3339),
+		TimeMax: timeMax.Format(time.RFC3339),
+	}
+	for _, id := range calendarIDs {
+		req.Items = append(req.Items, &calendar.FreeBusyRequestItem{Id: id})
 	}
 
-	var preview strings.Builder
-	preview.WriteString("Closest events:\n")
+	resp, err := service.Freebusy.Query(req).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, event := range events {
-		start := event.Start.DateTime
-		if start == "" {
-			start = event.Start.Date
+	var busy []models.BusyPeriod
+	for id, cal := range resp.Calendars {
+		for _, e := range cal.Errors {
+			log.Printf("freebusy error for %s: %s", id, e.Reason)
+		}
+		for _, period := range cal.Busy {
+			start, err1 := time.Parse(time.RFC3339, period.Start)
+			end, err2 := time.Parse(time.RFC3339, period.End)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			busy = append(busy, models.BusyPeriod{Start: start, End: end})
 		}
-		preview.WriteString(fmt.Sprintf("- %s: %s\n", start, event.Summary))
 	}
 
-	return preview.String()
+	return MergeBusy(busy), nil
 }
@@ -0,0 +1,398 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"life_forge/internal/models"
+	"life_forge/internal/secrets"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+)
+
+// fakeCalendarAPI serves events.list of "primary" with pagination and sync tokens
+type fakeCalendarAPI struct {
+	queries []map[string]string
+	// pages by sync token, "" is full sync
+	pages   map[string][][]*calendar.Event
+	expired map[string]bool
+}
+
+func (f *fakeCalendarAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/calendars/primary/events" {
+		http.NotFound(w, r)
+		return
+	}
+	q := r.URL.Query()
+	f.queries = append(f.queries, map[string]string{
+		"syncToken":    q.Get("syncToken"),
+		"pageToken":    q.Get("pageToken"),
+		"timeMin":      q.Get("timeMin"),
+		"timeMax":      q.Get("timeMax"),
+		"singleEvents": q.Get("singleEvents"),
+	})
+
+	token := q.Get("syncToken")
+	if f.expired[token] {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": 410, "message": "Sync token is no longer valid"}})
+		return
+	}
+
+	pages := f.pages[token]
+	page := 0
+	if q.Get("pageToken") == "p2" {
+		page = 1
+	}
+	resp := &calendar.Events{Items: pages[page]}
+	if page+1 < len(pages) {
+		resp.NextPageToken = "p2"
+	} else {
+		resp.NextSyncToken = "next-" + token
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newFakeGoogle(t *testing.T, api http.Handler) (*GoogleCalendarStorage, context.Context) {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	moscow := time.FixedZone("MSK", 3*60*60)
+	gcs := &GoogleCalendarStorage{
+		endpoint: srv.URL + "/",
+		location: moscow,
+		services: make(map[int]accountService),
+		primary:  make(map[int]int),
+	}
+	service, err := gcs.newService(srv.Client())
+	if err != nil {
+		t.Fatalf("newService: %v", err)
+	}
+	// authorized account without token storage
+	gcs.services[1] = accountService{userID: 1, service: service}
+	gcs.primary[1] = 1
+
+	return gcs, models.WithUserID(context.Background(), 1)
+}
+
+func timedEvent(id string, start time.Time) *calendar.Event {
+	return &calendar.Event{
+		Id:    id,
+		Start: &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:   &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+	}
+}
+
+func TestSyncEvents(t *testing.T) {
+	windowStart := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	windowEnd := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	inside := windowStart.AddDate(0, 3, 0)
+	outside := windowEnd.AddDate(5, 0, 0)
+
+	api := &fakeCalendarAPI{
+		pages: map[string][][]*calendar.Event{
+			"": {
+				{timedEvent("a", inside)},
+				{timedEvent("b", inside.Add(24*time.Hour))},
+			},
+			"t1": {
+				{
+					timedEvent("a", inside.Add(time.Hour)),
+					timedEvent("far", outside),
+					{Id: "b", Status: "cancelled"},
+				},
+			},
+		},
+		expired: map[string]bool{"old": true},
+	}
+	gcs, ctx := newFakeGoogle(t, api)
+
+	t.Run("full sync reads window page by page", func(t *testing.T) {
+		api.queries = nil
+		events, next, err := gcs.SyncEvents(ctx, "primary", "", windowStart, windowEnd)
+		if err != nil {
+			t.Fatalf("SyncEvents: %v", err)
+		}
+		if len(events) != 2 || events[0].Id != "a" || events[1].Id != "b" {
+			t.Fatalf("events = %+v, want a and b", events)
+		}
+		if next != "next-" {
+			t.Fatalf("next sync token = %q", next)
+		}
+		if len(api.queries) != 2 || api.queries[1]["pageToken"] != "p2" {
+			t.Fatalf("queries = %+v, want two pages", api.queries)
+		}
+		q := api.queries[0]
+		if q["timeMin"] != windowStart.Format(time.RFC3339) || q["timeMax"] != windowEnd.Format(time.RFC3339) || q["singleEvents"] != "true" {
+			t.Fatalf("full sync query = %+v, want window and single events", q)
+		}
+	})
+
+	t.Run("incremental sync returns every change", func(t *testing.T) {
+		api.queries = nil
+		events, next, err := gcs.SyncEvents(ctx, "primary", "t1", windowStart, windowEnd)
+		if err != nil {
+			t.Fatalf("SyncEvents: %v", err)
+		}
+		if len(events) != 3 || events[0].Id != "a" || events[1].Id != "far" || events[2].Status != "cancelled" {
+			t.Fatalf("events = %+v, want changes of a and far and cancellation of b", events)
+		}
+		if next != "next-t1" {
+			t.Fatalf("next sync token = %q", next)
+		}
+		if q := api.queries[0]; q["syncToken"] != "t1" || q["timeMin"] != "" || q["timeMax"] != "" {
+			t.Fatalf("incremental query = %+v, want sync token without window", q)
+		}
+	})
+
+	t.Run("410 gone means expired token", func(t *testing.T) {
+		_, _, err := gcs.SyncEvents(ctx, "primary", "old", windowStart, windowEnd)
+		if !errors.Is(err, ErrSyncTokenExpired) {
+			t.Fatalf("error = %v, want ErrSyncTokenExpired", err)
+		}
+	})
+}
+
+func TestListEventsPagesAndStatuses(t *testing.T) {
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	primary := &fakeCalendarAPI{pages: map[string][][]*calendar.Event{"": {
+		{timedEvent("a", start), timedEvent("b", start.Add(time.Hour))},
+		{timedEvent("c", start.Add(2*time.Hour))},
+	}}}
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/calendars/primary/events":
+			primary.ServeHTTP(w, r)
+		case "/calendars/shared/events":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":403,"message":"Forbidden"}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":{"code":500,"message":"Backend Error"}}`))
+		}
+	})
+	gcs, ctx := newFakeGoogle(t, api)
+
+	events, statuses, err := gcs.ListEvents(ctx, start, start.Add(24*time.Hour), "primary", "shared", "broken")
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("events = %d, want all 3 from both pages", len(events))
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		if events[i].ID != id || events[i].CalendarID != "primary" {
+			t.Fatalf("event %d = %s of %s, want %s of primary", i, events[i].ID, events[i].CalendarID, id)
+		}
+	}
+	if len(primary.queries) != 2 || primary.queries[1]["pageToken"] != "p2" || primary.queries[0]["singleEvents"] != "true" {
+		t.Fatalf("queries = %v, want two pages of expanded instances", primary.queries)
+	}
+
+	want := []models.CalendarStatus{
+		{CalendarID: "primary", Status: models.CalendarStatusOK, Events: 3},
+		{CalendarID: "shared", Status: models.CalendarStatusForbidden},
+		{CalendarID: "broken", Status: models.CalendarStatusError},
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("statuses = %+v, want %+v", statuses, want)
+	}
+	for i, w := range want {
+		got := statuses[i]
+		if got.CalendarID != w.CalendarID || got.Status != w.Status || got.Events != w.Events || (w.Status != models.CalendarStatusOK) != (got.Error != "") {
+			t.Fatalf("status %d = %+v, want %+v with error text for failed calendar", i, got, w)
+		}
+	}
+}
+
+func TestEventBoundsAllDayInLocation(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	event := &calendar.Event{
+		Start: &calendar.EventDateTime{Date: "2026-03-10"},
+		End:   &calendar.EventDateTime{Date: "2026-03-11"},
+	}
+
+	start, end, err := EventBounds(event, moscow)
+	if err != nil {
+		t.Fatalf("EventBounds: %v", err)
+	}
+	wantStart := time.Date(2026, 3, 9, 21, 0, 0, 0, time.UTC)
+	if !start.Equal(wantStart) || !end.Equal(wantStart.Add(24*time.Hour)) {
+		t.Fatalf("bounds = %v..%v, want local midnight %v", start.UTC(), end.UTC(), wantStart)
+	}
+}
+
+// fakeInsertAPI stores inserted events of "primary" by id and answers 409 for a taken id, as Google does
+type fakeInsertAPI struct {
+	events  map[string]*calendar.Event
+	inserts int
+}
+
+func (f *fakeInsertAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const prefix = "/calendars/primary/events"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == prefix:
+		var event calendar.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := f.events[event.Id]; ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":{"code":409,"message":"The requested identifier already exists."}}`))
+			return
+		}
+		f.inserts++
+		event.Status = "confirmed"
+		f.events[event.Id] = &event
+		json.NewEncoder(w).Encode(&event)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, prefix+"/"):
+		event, ok := f.events[strings.TrimPrefix(r.URL.Path, prefix+"/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(event)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestCreateEventWithIDRepeated(t *testing.T) {
+	api := &fakeInsertAPI{events: make(map[string]*calendar.Event)}
+	gcs, ctx := newFakeGoogle(t, api)
+
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		created, err := gcs.CreateEventWithID(ctx, "primary", "lifeforge1u3", models.EventRequest{Title: "Dentist", StartTime: &start})
+		if err != nil {
+			t.Fatalf("attempt %d: CreateEventWithID: %v", i+1, err)
+		}
+		if created.ID != "lifeforge1u3" || created.Summary != "Dentist" {
+			t.Fatalf("attempt %d: created = %+v, want Dentist with given id", i+1, created)
+		}
+	}
+	if api.inserts != 1 {
+		t.Fatalf("inserted %d events, want 1", api.inserts)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("token") {
+		case "hanging":
+			<-release
+		case "invalid":
+			w.WriteHeader(http.StatusBadRequest)
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	gcs := &GoogleCalendarStorage{
+		revokeURL: srv.URL,
+		revoker:   &http.Client{Timeout: 50 * time.Millisecond},
+	}
+
+	tests := []struct {
+		name    string
+		tok     *oauth2.Token
+		wantErr bool
+	}{
+		{"refresh token is revoked", &oauth2.Token{RefreshToken: "ok", AccessToken: "broken"}, false},
+		{"access token without refresh one", &oauth2.Token{AccessToken: "ok"}, false},
+		{"already revoked", &oauth2.Token{RefreshToken: "invalid"}, false},
+		{"server error", &oauth2.Token{RefreshToken: "broken"}, true},
+		{"hanging server times out", &oauth2.Token{RefreshToken: "hanging"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := gcs.revoke(context.Background(), tt.tok)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("revoke error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImportLegacyToken(t *testing.T) {
+	pool := testPool(t)
+	entry, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := secrets.Parse(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := NewTokenStorage(pool, keyring)
+	gcs := &GoogleCalendarStorage{tokens: tokens}
+	ctx := context.Background()
+	owner := testUser(t, pool, "owner@example.com")
+	other := testUser(t, pool, "other@example.com")
+
+	t.Chdir(t.TempDir())
+	writeToken := func(access string) {
+		t.Helper()
+		data, _ := json.Marshal(&oauth2.Token{AccessToken: access, RefreshToken: "refresh"})
+		if err := os.WriteFile(legacyTokenFile, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := gcs.ImportLegacyToken(ctx, owner); err != nil {
+		t.Fatalf("import without file: %v", err)
+	}
+
+	writeToken("first")
+	if err := gcs.ImportLegacyToken(ctx, owner); err != nil {
+		t.Fatalf("first import: %v", err)
+	}
+	if _, err := os.Stat(legacyTokenFile); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("%s is not removed after import: %v", legacyTokenFile, err)
+	}
+	_, tok, err := tokens.GetToken(ctx, owner, 0)
+	if err != nil || tok.AccessToken != "first" {
+		t.Fatalf("GetToken = %v, %v, want imported token", tok, err)
+	}
+
+	// token.json restored from backup on the next start, or migrate-token run after it
+	writeToken("second")
+	if err := gcs.ImportLegacyToken(ctx, owner); err == nil {
+		t.Fatal("second import succeeded, want error")
+	}
+	if _, err := tokens.ImportToken(ctx, owner, &oauth2.Token{AccessToken: "third"}); !errors.Is(err, ErrAccountExists) {
+		t.Fatalf("ImportToken err = %v, want ErrAccountExists", err)
+	}
+	if _, err := os.Stat(legacyTokenFile); err != nil {
+		t.Fatalf("%s is not kept after refused import: %v", legacyTokenFile, err)
+	}
+	accounts, err := tokens.ListAccounts(ctx, owner)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("ListAccounts = %v, %v, want the imported account only", accounts, err)
+	}
+
+	// user who linked Google through OAuth keeps the account
+	if _, err := tokens.SaveAccount(ctx, other, "me@example.com", nil, &oauth2.Token{AccessToken: "oauth"}); err != nil {
+		t.Fatalf("SaveAccount: %v", err)
+	}
+	if err := gcs.ImportLegacyToken(ctx, other); err == nil {
+		t.Fatal("import to user with linked account succeeded, want error")
+	}
+	if accounts, err := tokens.ListAccounts(ctx, other); err != nil || len(accounts) != 1 || accounts[0].Email != "me@example.com" {
+		t.Fatalf("ListAccounts = %v, %v, want the linked account only", accounts, err)
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/tasks/v1"
+)
+
+const (
+	tasksPageSize = 100
+	// Google Tasks keeps date only, time part of due is always midnight UTC
+	googleTaskDueLayout = "2006-01-02T00:00:00.000Z"
+)
+
+// GoogleTasksStorage is TaskRemote on top of a Google Tasks list. Services are created on first use
+// per user, so sync starts working as soon as user's calendar is authorized
+type GoogleTasksStorage struct {
+	client   func(ctx context.Context) (*http.Client, error)
+	endpoint string
+	listID   string
+
+	mu       sync.Mutex
+	services map[int]*tasks.Service
+}
+
+// NewGoogleTasksStorage client usually is GoogleCalendarStorage.HTTPClient, endpoint overrides
+// Tasks API base url (fake server in tests), empty means Google
+func NewGoogleTasksStorage(client func(ctx context.Context) (*http.Client, error), endpoint, listID string) *GoogleTasksStorage {
+	return &GoogleTasksStorage{
+		client:   client,
+		endpoint: endpoint,
+		listID:   listID,
+		services: make(map[int]*tasks.Service),
+	}
+}
+
+func (gts *GoogleTasksStorage) getService(ctx context.Context) (*tasks.Service, error) {
+	userID := models.UserID(ctx)
+
+	gts.mu.Lock()
+	defer gts.mu.Unlock()
+
+	if service, ok := gts.services[userID]; ok {
+		return service, nil
+	}
+
+	client, err := gts.client(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Google не авторизован, перейдите по /auth/google: %w", err)
+	}
+	opts := []option.ClientOption{option.WithHTTPClient(client)}
+	if gts.endpoint != "" {
+		opts = append(opts, option.WithEndpoint(gts.endpoint))
+	}
+	service, err := tasks.NewService(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Tasks service: %w", err)
+	}
+	gts.services[userID] = service
+	return service, nil
+}
+
+func (gts *GoogleTasksStorage) ListTasks(ctx context.Context, updatedMin time.Time) ([]*models.RemoteTask, error) {
+	service, err := gts.getService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	call := service.Tasks.List(gts.listID).
+		ShowCompleted(true).
+		ShowDeleted(true).
+		ShowHidden(true).
+		MaxResults(tasksPageSize)
+	if !updatedMin.IsZero() {
+		call = call.UpdatedMin(updatedMin.UTC().Format(time.RFC3339))
+	}
+
+	var remote []*models.RemoteTask
+	err = call.Pages(ctx, func(page *tasks.Tasks) error {
+		for _, item := range page.Items {
+			remote = append(remote, googleTaskToModel(item))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks of %s: %w", gts.listID, err)
+	}
+	return remote, nil
+}
+
+func (gts *GoogleTasksStorage) CreateTask(ctx context.Context, task *models.Task) (string, error) {
+	service, err := gts.getService(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	created, err := service.Tasks.Insert(gts.listID, modelToGoogleTask(task)).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to create task: %w", err)
+	}
+	return created.Id, nil
+}
+
+func (gts *GoogleTasksStorage) UpdateTask(ctx context.Context, remoteID string, task *models.Task) error {
+	service, err := gts.getService(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := service.Tasks.Patch(gts.listID, remoteID, modelToGoogleTask(task)).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to update task %s: %w", remoteID, err)
+	}
+	return nil
+}
+
+// DeleteTask treats already deleted task as success
+func (gts *GoogleTasksStorage) DeleteTask(ctx context.Context, remoteID string) error {
+	service, err := gts.getService(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = service.Tasks.Delete(gts.listID, remoteID).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete task %s: %w", remoteID, err)
+	}
+	return nil
+}
+
+func modelToGoogleTask(task *models.Task) *tasks.Task {
+	gt := &tasks.Task{
+		Title:           task.Title,
+		Notes:           task.Notes,
+		Status:          "needsAction",
+		ForceSendFields: []string{"Notes"},
+	}
+	if task.Due != nil {
+		gt.Due = task.Due.Format(googleTaskDueLayout)
+	} else {
+		gt.NullFields = append(gt.NullFields, "Due")
+	}
+	if task.Status == models.TaskStatusDone {
+		gt.Status = "completed"
+		if task.CompletedAt != nil {
+			completed := task.CompletedAt.UTC().Format(time.RFC3339)
+			gt.Completed = &completed
+		}
+	} else {
+		gt.NullFields = append(gt.NullFields, "Completed")
+	}
+	return gt
+}
+
+func googleTaskToModel(gt *tasks.Task) *models.RemoteTask {
+	remote := &models.RemoteTask{
+		ID:        gt.Id,
+		Title:     gt.Title,
+		Notes:     gt.Notes,
+		Completed: gt.Status == "completed",
+		Deleted:   gt.Deleted,
+	}
+	if due, err := time.Parse(time.RFC3339, gt.Due); err == nil {
+		day := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+		remote.Due = &day
+	}
+	if gt.Completed != nil {
+		if completed, err := time.Parse(time.RFC3339, *gt.Completed); err == nil {
+			remote.CompletedAt = &completed
+		}
+	}
+	if updated, err := time.Parse(time.RFC3339, gt.Updated); err == nil {
+		remote.Updated = updated
+	}
+	return remote
+}
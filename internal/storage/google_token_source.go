@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const tokenRefreshTimeout = 10 * time.Second
+
+// dbTokenSource refreshes account token through TokenStorage: refreshed tokens are written back,
+// token from a newer authorization or another instance is picked up, and invalid_grant marks
+// the account revoked so the user is asked to authorize again. accountID 0 follows user's
+// primary account, so clients of other Google APIs survive switching the primary one
+type dbTokenSource struct {
+	config    *oauth2.Config
+	tokens    *TokenStorage
+	userID    int
+	accountID int
+	onRevoked func(accountID int)
+
+	mu  sync.Mutex
+	tok *oauth2.Token
+}
+
+func (s *dbTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tok.Valid() {
+		return s.tok, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRefreshTimeout)
+	defer cancel()
+
+	account, tok, err := s.tokens.GetToken(ctx, s.userID, s.accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tok.Valid() {
+		refreshed, err := s.config.TokenSource(ctx, tok).Token()
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			log.Printf("Google account %d of user %d is revoked: %v", account.ID, s.userID, err)
+			if err := s.tokens.MarkRevoked(ctx, account.ID); err != nil {
+				log.Printf("Failed to mark Google account %d revoked: %v", account.ID, err)
+			}
+			if s.onRevoked != nil {
+				s.onRevoked(account.ID)
+			}
+			return nil, ErrTokenRevoked
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to refresh token: %w", err)
+		}
+
+		// failed save only costs an extra refresh next time
+		if err := s.tokens.SaveToken(ctx, s.userID, account.ID, refreshed); err != nil {
+			log.Printf("Failed to save refreshed token of Google account %d: %v", account.ID, err)
+		}
+		tok = refreshed
+	}
+
+	s.tok = tok
+	return tok, nil
+}
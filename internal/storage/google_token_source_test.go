@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"life_forge/internal/secrets"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestDBTokenSource(t *testing.T) {
+	pool := testPool(t)
+	entry, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := secrets.Parse(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := NewTokenStorage(pool, keyring)
+	ctx := context.Background()
+	userID := testUser(t, pool, "me@example.com")
+
+	// refresh token "good" gets a new access token, anything else is revoked
+	refreshes := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshes++
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("refresh_token") != "good" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"fresh","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer srv.Close()
+	config := &oauth2.Config{ClientID: "id", Endpoint: oauth2.Endpoint{TokenURL: srv.URL, AuthStyle: oauth2.AuthStyleInParams}}
+
+	expired := &oauth2.Token{AccessToken: "stale", RefreshToken: "good", Expiry: time.Now().Add(-time.Hour)}
+	account, err := tokens.SaveAccount(ctx, userID, "me@example.com", nil, expired)
+	if err != nil {
+		t.Fatalf("SaveAccount: %v", err)
+	}
+	source := func(onRevoked func(int)) *dbTokenSource {
+		return &dbTokenSource{config: config, tokens: tokens, userID: userID, onRevoked: onRevoked, tok: expired}
+	}
+
+	src := source(nil)
+	tok, err := src.Token()
+	if err != nil || tok.AccessToken != "fresh" || refreshes != 1 {
+		t.Fatalf("Token() = %v, %v after %d refreshes, want one refresh", tok, err, refreshes)
+	}
+	if _, saved, err := tokens.GetToken(ctx, userID, 0); err != nil || saved.AccessToken != "fresh" || saved.RefreshToken != "good" {
+		t.Fatalf("stored token = %v, %v, want refreshed one with refresh token kept", saved, err)
+	}
+	if _, err := src.Token(); err != nil || refreshes != 1 {
+		t.Fatalf("cached Token() err = %v, refreshes = %d, want no refresh", err, refreshes)
+	}
+
+	// another instance with stale copy picks the stored token up
+	if tok, err := source(nil).Token(); err != nil || tok.AccessToken != "fresh" || refreshes != 1 {
+		t.Fatalf("Token() of other instance = %v, %v after %d refreshes, want stored token", tok, err, refreshes)
+	}
+
+	if err := tokens.SaveToken(ctx, userID, account.ID, &oauth2.Token{AccessToken: "stale", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	var revoked []int
+	if _, err := source(func(id int) { revoked = append(revoked, id) }).Token(); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("Token() of revoked account = %v, want ErrTokenRevoked", err)
+	}
+	if len(revoked) != 1 || revoked[0] != account.ID {
+		t.Fatalf("onRevoked called with %v, want account %d", revoked, account.ID)
+	}
+	if _, _, err := tokens.GetToken(ctx, userID, 0); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("GetToken() after revocation = %v, want ErrTokenRevoked", err)
+	}
+	accounts, err := tokens.ListAccounts(ctx, userID)
+	if err != nil || len(accounts) != 1 || accounts[0].RevokedAt == nil {
+		t.Fatalf("ListAccounts() = %+v, %v, want revoked account kept for status page", accounts, err)
+	}
+}
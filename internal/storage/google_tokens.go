@@ -0,0 +1,424 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"life_forge/internal/secrets"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrTokenNotFound is returned when user has not connected Google yet
+	ErrTokenNotFound = errors.New("google token not found")
+	// ErrTokenRevoked is returned when Google rejected refresh token and user has to authorize again
+	ErrTokenRevoked = errors.New("google access revoked, authorize again via /auth/google")
+	// ErrAccountExists is returned by ImportToken when user has already linked Google
+	ErrAccountExists = errors.New("user already has google account")
+)
+
+// TokenStorage keeps OAuth token of every user's Google account, encrypted with keyring
+type TokenStorage struct {
+	pool    *pgxpool.Pool
+	keyring *secrets.Keyring
+}
+
+func NewTokenStorage(pool *pgxpool.Pool, keyring *secrets.Keyring) *TokenStorage {
+	return &TokenStorage{
+		pool:    pool,
+		keyring: keyring,
+	}
+}
+
+// accountColumns are read by scanAccount
+const accountColumns = `id, user_id, email, scopes, is_primary, created_at, updated_at, revoked_at, token, token_encrypted, key_id, account_bound`
+
+// GetToken returns token of user's account, accountID 0 means primary account
+func (ts *TokenStorage) GetToken(ctx context.Context, userID, accountID int) (*models.GoogleAccount, *oauth2.Token, error) {
+	op := "internal/storage/google_tokens.go GetToken"
+
+	sql_query := `SELECT ` + accountColumns + ` FROM google_tokens
+	WHERE user_id = $1 AND (id = $2 OR ($2 = 0 AND is_primary))
+	`
+
+	account, tok, err := ts.scanAccount(ts.pool.QueryRow(ctx, sql_query, userID, accountID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrTokenNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, nil, fmt.Errorf("%s: failed to get token: %w", op, err)
+	}
+	if account.RevokedAt != nil {
+		return account, nil, ErrTokenRevoked
+	}
+	if tok == nil {
+		return account, nil, fmt.Errorf("%s: token of account %d can't be decrypted", op, account.ID)
+	}
+	return account, tok, nil
+}
+
+// ListAccounts returns linked accounts, primary first; tokens are read only for expiry
+func (ts *TokenStorage) ListAccounts(ctx context.Context, userID int) ([]models.GoogleAccount, error) {
+	op := "internal/storage/google_tokens.go ListAccounts"
+
+	sql_query := `SELECT ` + accountColumns + ` FROM google_tokens
+	WHERE user_id = $1
+	ORDER BY is_primary DESC, id
+	`
+
+	rows, err := ts.pool.Query(ctx, sql_query, userID)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list accounts: %w", op, err)
+	}
+	defer rows.Close()
+
+	accounts := []models.GoogleAccount{}
+	for rows.Next() {
+		account, tok, err := ts.scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan account: %w", op, err)
+		}
+		if tok != nil && !tok.Expiry.IsZero() {
+			account.Expiry = &tok.Expiry
+		}
+		accounts = append(accounts, *account)
+	}
+	return accounts, rows.Err()
+}
+
+// SaveAccount links account or replaces token of already linked one with the same email,
+// first account of the user becomes primary. Empty email is token of single-user setup
+func (ts *TokenStorage) SaveAccount(ctx context.Context, userID int, email string, scopes []string, tok *oauth2.Token) (*models.GoogleAccount, error) {
+	op := "internal/storage/google_tokens.go SaveAccount"
+
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	tx, err := ts.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// token is bound to account id, so it is written once the row exists
+	sql_query := `
+	INSERT INTO google_tokens (user_id, email, scopes, is_primary, updated_at)
+	VALUES ($1, $2, $3, NOT EXISTS (SELECT 1 FROM google_tokens WHERE user_id = $1 AND is_primary), NOW())
+	ON CONFLICT (user_id, LOWER(email)) WHERE email <> '' DO UPDATE SET
+	scopes = EXCLUDED.scopes,
+	updated_at = NOW(),
+	revoked_at = NULL
+	RETURNING ` + accountColumns
+
+	account, _, err := ts.scanAccount(tx.QueryRow(ctx, sql_query, userID, email, scopes))
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to save account: %w", op, err)
+	}
+
+	if err := ts.writeToken(ctx, tx, account.ID, tok); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit: %w", op, err)
+	}
+	return account, nil
+}
+
+// ImportToken saves token of single-user setup as primary account of user who has no Google accounts yet,
+// so repeated import never creates a second account
+func (ts *TokenStorage) ImportToken(ctx context.Context, userID int, tok *oauth2.Token) (*models.GoogleAccount, error) {
+	op := "internal/storage/google_tokens.go ImportToken"
+
+	tx, err := ts.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	sql_query := `
+	INSERT INTO google_tokens (user_id, email, scopes, is_primary, updated_at)
+	SELECT $1, '', '{}', TRUE, NOW()
+	WHERE NOT EXISTS (SELECT 1 FROM google_tokens WHERE user_id = $1)
+	RETURNING ` + accountColumns
+
+	account, _, err := ts.scanAccount(tx.QueryRow(ctx, sql_query, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAccountExists
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to save account: %w", op, err)
+	}
+
+	if err := ts.writeToken(ctx, tx, account.ID, tok); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit: %w", op, err)
+	}
+	return account, nil
+}
+
+// SaveToken stores refreshed token of account, saving also clears revocation mark
+func (ts *TokenStorage) SaveToken(ctx context.Context, userID, accountID int, tok *oauth2.Token) error {
+	op := "internal/storage/google_tokens.go SaveToken"
+
+	keyID, encrypted, err := ts.encodeToken(accountID, tok)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	sql_query := `
+	UPDATE google_tokens SET
+	token = NULL,
+	token_encrypted = $3,
+	key_id = $4,
+	account_bound = TRUE,
+	updated_at = NOW(),
+	revoked_at = NULL
+	WHERE id = $1 AND user_id = $2
+	`
+
+	if _, err := ts.pool.Exec(ctx, sql_query, accountID, userID, encrypted, keyID); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save token: %w", op, err)
+	}
+	return nil
+}
+
+// SetEmail fills email of account linked before emails were stored
+func (ts *TokenStorage) SetEmail(ctx context.Context, accountID int, email string) error {
+	op := "internal/storage/google_tokens.go SetEmail"
+
+	if _, err := ts.pool.Exec(ctx, `UPDATE google_tokens SET email = $2 WHERE id = $1 AND email = ''`, accountID, email); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save email: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteAccount unlinks account and returns its token for revocation,
+// when primary account goes away the oldest remaining one takes its place
+func (ts *TokenStorage) DeleteAccount(ctx context.Context, userID, accountID int) (*models.GoogleAccount, *oauth2.Token, error) {
+	op := "internal/storage/google_tokens.go DeleteAccount"
+
+	tx, err := ts.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	sql_query := `DELETE FROM google_tokens WHERE id = $1 AND user_id = $2 RETURNING ` + accountColumns
+
+	account, tok, err := ts.scanAccount(tx.QueryRow(ctx, sql_query, accountID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrTokenNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, nil, fmt.Errorf("%s: failed to delete account: %w", op, err)
+	}
+
+	if account.Primary {
+		sql_query := `
+		UPDATE google_tokens SET is_primary = TRUE
+		WHERE id = (SELECT id FROM google_tokens WHERE user_id = $1 ORDER BY id LIMIT 1)
+		`
+		if _, err := tx.Exec(ctx, sql_query, userID); err != nil {
+			log.Println("Error with Exec method in ", op, " with error: ", err)
+			return nil, nil, fmt.Errorf("%s: failed to promote account: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to commit: %w", op, err)
+	}
+	return account, tok, nil
+}
+
+// ReencryptTokens moves tokens encrypted with old keys, bound to user id or stored in plaintext
+// to current key, returns number of updated rows
+func (ts *TokenStorage) ReencryptTokens(ctx context.Context) (int, error) {
+	op := "internal/storage/google_tokens.go ReencryptTokens"
+
+	sql_query := `SELECT ` + accountColumns + ` FROM google_tokens
+	WHERE key_id IS DISTINCT FROM $1 OR NOT account_bound
+	`
+
+	rows, err := ts.pool.Query(ctx, sql_query, ts.keyring.CurrentKeyID())
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return 0, fmt.Errorf("%s: failed to list tokens: %w", op, err)
+	}
+
+	type staleToken struct {
+		id  int
+		tok *oauth2.Token
+	}
+	var stale []staleToken
+	for rows.Next() {
+		account, tok, err := ts.scanAccount(rows)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: failed to read token: %w", op, err)
+		}
+		if tok == nil {
+			continue
+		}
+		stale = append(stale, staleToken{id: account.ID, tok: tok})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: failed to list tokens: %w", op, err)
+	}
+
+	tx, err := ts.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// revocation mark and updated_at stay, only the storage form changes
+	for _, t := range stale {
+		if err := ts.writeToken(ctx, tx, t.id, t.tok); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit: %w", op, err)
+	}
+	return len(stale), nil
+}
+
+// scanAccount reads accountColumns and decrypts token, token is nil when it can't be decrypted
+// (key removed from keyring), so such account can still be listed and unlinked
+func (ts *TokenStorage) scanAccount(row pgx.Row) (*models.GoogleAccount, *oauth2.Token, error) {
+	var account models.GoogleAccount
+	var plain, encrypted []byte
+	var keyID *string
+	var bound bool
+	err := row.Scan(&account.ID, &account.UserID, &account.Email, &account.Scopes, &account.Primary,
+		&account.CreatedAt, &account.UpdatedAt, &account.RevokedAt, &plain, &encrypted, &keyID, &bound)
+	if err != nil {
+		return nil, nil, err
+	}
+	account.ReauthRequired = account.RevokedAt != nil
+
+	aad := tokenAAD(account.ID)
+	if !bound {
+		aad = legacyTokenAAD(account.UserID)
+	}
+	tok, err := ts.decodeToken(aad, plain, encrypted, keyID)
+	if err != nil {
+		log.Printf("Failed to read token of Google account %d: %v", account.ID, err)
+		return &account, nil, nil
+	}
+	return &account, tok, nil
+}
+
+// writeToken encrypts token bound to account
+func (ts *TokenStorage) writeToken(ctx context.Context, tx pgx.Tx, accountID int, tok *oauth2.Token) error {
+	keyID, encrypted, err := ts.encodeToken(accountID, tok)
+	if err != nil {
+		return err
+	}
+
+	sql_query := `
+	UPDATE google_tokens SET token = NULL, token_encrypted = $2, key_id = $3, account_bound = TRUE
+	WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, sql_query, accountID, encrypted, keyID); err != nil {
+		log.Println("Error with Exec method in internal/storage/google_tokens.go writeToken with error: ", err)
+		return fmt.Errorf("failed to save token of account %d: %w", accountID, err)
+	}
+	return nil
+}
+
+func (ts *TokenStorage) encodeToken(accountID int, tok *oauth2.Token) (string, []byte, error) {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal token: %w", err)
+	}
+	keyID, encrypted, err := ts.keyring.Encrypt(data, tokenAAD(accountID))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	return keyID, encrypted, nil
+}
+
+// decodeToken reads encrypted token, plaintext one is left from before encryption
+func (ts *TokenStorage) decodeToken(aad, plain, encrypted []byte, keyID *string) (*oauth2.Token, error) {
+	data := plain
+	if encrypted != nil && keyID != nil {
+		var err error
+		data, err = ts.keyring.Decrypt(*keyID, encrypted, aad)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tok := &oauth2.Token{}
+	if err := json.Unmarshal(data, tok); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
+	}
+	return tok, nil
+}
+
+// tokenAAD binds ciphertext to account row, so it can't be moved even between accounts of one user
+func tokenAAD(accountID int) []byte {
+	return []byte(fmt.Sprintf("google_account:%d", accountID))
+}
+
+// legacyTokenAAD is binding of tokens encrypted before account_bound, they are re-encrypted on start
+func legacyTokenAAD(userID int) []byte {
+	return []byte(fmt.Sprintf("google_token:%d", userID))
+}
+
+// MarkRevoked keeps the account for status page but stops using it until user authorizes again
+func (ts *TokenStorage) MarkRevoked(ctx context.Context, accountID int) error {
+	op := "internal/storage/google_tokens.go MarkRevoked"
+
+	sql_query := `
+	UPDATE google_tokens SET revoked_at = NOW()
+	WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	if _, err := ts.pool.Exec(ctx, sql_query, accountID); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to mark token revoked: %w", op, err)
+	}
+	return nil
+}
+
+// ListUserIDs returns users with working primary account, background sync runs only for them
+func (ts *TokenStorage) ListUserIDs(ctx context.Context) ([]int, error) {
+	op := "internal/storage/google_tokens.go ListUserIDs"
+
+	rows, err := ts.pool.Query(ctx, `SELECT user_id FROM google_tokens WHERE is_primary AND revoked_at IS NULL ORDER BY user_id`)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list users: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan user: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrHabitNotFound   = errors.New("habit not found")
+	ErrHabitExists     = errors.New("habit with this title already exists")
+	ErrCheckinNotFound = errors.New("habit check-in not found")
+)
+
+// HabitStorage keeps habits and their daily check-ins, check-ins belong to the user through their habit
+type HabitStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewHabitStorage(pool *pgxpool.Pool) *HabitStorage {
+	return &HabitStorage{
+		pool: pool,
+	}
+}
+
+// CreateHabit saves habit, titles of active habits are unique ignoring case
+func (hs *HabitStorage) CreateHabit(ctx context.Context, habit *models.Habit) error {
+	op := "internal/storage/habits.go CreateHabit"
+
+	sql_query := `
+	INSERT INTO habits (user_id, title, recurrence, calendar_id, event_id, start_date)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT DO NOTHING
+	RETURNING id, created_at
+	`
+
+	err := hs.pool.QueryRow(ctx, sql_query,
+		models.UserID(ctx),
+		habit.Title,
+		habit.Recurrence,
+		habit.CalendarID,
+		habit.EventID,
+		habit.StartDate,
+	).Scan(&habit.ID, &habit.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrHabitExists
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save habit: %w", op, err)
+	}
+	return nil
+}
+
+// ListHabits returns active habits, with archived also the archived ones
+func (hs *HabitStorage) ListHabits(ctx context.Context, archived bool) ([]*models.Habit, error) {
+	op := "internal/storage/habits.go ListHabits"
+
+	rows, err := hs.pool.Query(ctx, `
+	SELECT id, title, recurrence, calendar_id, event_id, start_date, archived, created_at
+	FROM habits
+	WHERE user_id = $2 AND ($1 OR NOT archived)
+	ORDER BY created_at
+	`, archived, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list habits: %w", op, err)
+	}
+	defer rows.Close()
+
+	habits := []*models.Habit{}
+	for rows.Next() {
+		h, err := scanHabit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan habit: %w", op, err)
+		}
+		habits = append(habits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return habits, nil
+}
+
+func (hs *HabitStorage) GetHabit(ctx context.Context, id int) (*models.Habit, error) {
+	op := "internal/storage/habits.go GetHabit"
+
+	row := hs.pool.QueryRow(ctx, `
+	SELECT id, title, recurrence, calendar_id, event_id, start_date, archived, created_at
+	FROM habits
+	WHERE id = $1 AND user_id = $2
+	`, id, models.UserID(ctx))
+	h, err := scanHabit(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrHabitNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get habit: %w", op, err)
+	}
+	return h, nil
+}
+
+// FindHabit looks for active habit by title ignoring case, chat check-ins name habits this way
+func (hs *HabitStorage) FindHabit(ctx context.Context, title string) (*models.Habit, error) {
+	op := "internal/storage/habits.go FindHabit"
+
+	row := hs.pool.QueryRow(ctx, `
+	SELECT id, title, recurrence, calendar_id, event_id, start_date, archived, created_at
+	FROM habits
+	WHERE user_id = $2 AND LOWER(title) = LOWER($1) AND NOT archived
+	`, title, models.UserID(ctx))
+	h, err := scanHabit(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrHabitNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to find habit: %w", op, err)
+	}
+	return h, nil
+}
+
+// ArchiveHabit hides habit from lists and chat, check-ins are kept for history
+func (hs *HabitStorage) ArchiveHabit(ctx context.Context, id int) error {
+	op := "internal/storage/habits.go ArchiveHabit"
+
+	tag, err := hs.pool.Exec(ctx, `UPDATE habits SET archived = TRUE WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to archive habit: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrHabitNotFound
+	}
+	return nil
+}
+
+// DeleteHabit removes habit with its check-ins, calendar event is left to the caller
+func (hs *HabitStorage) DeleteHabit(ctx context.Context, id int) error {
+	op := "internal/storage/habits.go DeleteHabit"
+
+	tag, err := hs.pool.Exec(ctx, `DELETE FROM habits WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete habit: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrHabitNotFound
+	}
+	return nil
+}
+
+// SaveCheckin sets mark of the day, second check-in of the same day replaces the first
+func (hs *HabitStorage) SaveCheckin(ctx context.Context, checkin *models.HabitCheckin) error {
+	op := "internal/storage/habits.go SaveCheckin"
+
+	sql_query := `
+	INSERT INTO habit_checkins (habit_id, day, status, note)
+	SELECT id, $2, $3, $4 FROM habits WHERE id = $1 AND user_id = $5
+	ON CONFLICT (habit_id, day) DO UPDATE SET status = EXCLUDED.status, note = EXCLUDED.note, created_at = NOW()
+	RETURNING created_at
+	`
+
+	err := hs.pool.QueryRow(ctx, sql_query, checkin.HabitID, checkin.Day, checkin.Status, checkin.Note, models.UserID(ctx)).
+		Scan(&checkin.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrHabitNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save check-in: %w", op, err)
+	}
+	return nil
+}
+
+func (hs *HabitStorage) DeleteCheckin(ctx context.Context, habitID int, day time.Time) error {
+	op := "internal/storage/habits.go DeleteCheckin"
+
+	tag, err := hs.pool.Exec(ctx, `DELETE FROM habit_checkins WHERE habit_id = $1 AND day = $2 AND habit_id IN (SELECT id FROM habits WHERE user_id = $3)`, habitID, day, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete check-in: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCheckinNotFound
+	}
+	return nil
+}
+
+// ListCheckins returns check-ins of habit between from and to inclusive, oldest first
+func (hs *HabitStorage) ListCheckins(ctx context.Context, habitID int, from, to time.Time) ([]*models.HabitCheckin, error) {
+	op := "internal/storage/habits.go ListCheckins"
+
+	rows, err := hs.pool.Query(ctx, `
+	SELECT habit_id, day, status, note, created_at
+	FROM habit_checkins
+	WHERE habit_id = $1 AND day BETWEEN $2 AND $3 AND habit_id IN (SELECT id FROM habits WHERE user_id = $4)
+	ORDER BY day
+	`, habitID, from, to, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list check-ins: %w", op, err)
+	}
+	defer rows.Close()
+
+	checkins := []*models.HabitCheckin{}
+	for rows.Next() {
+		c := &models.HabitCheckin{}
+		if err := rows.Scan(&c.HabitID, &c.Day, &c.Status, &c.Note, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan check-in: %w", op, err)
+		}
+		checkins = append(checkins, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return checkins, nil
+}
+
+func scanHabit(row pgx.Row) (*models.Habit, error) {
+	h := &models.Habit{}
+	err := row.Scan(&h.ID, &h.Title, &h.Recurrence, &h.CalendarID, &h.EventID, &h.StartDate, &h.Archived, &h.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrPreviewNotFound is returned for unknown or expired import preview
+var ErrPreviewNotFound = errors.New("import preview not found")
+
+// ImportStorage keeps uploaded .ics previews until commit and remembers imported UIDs
+type ImportStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewImportStorage(pool *pgxpool.Pool) *ImportStorage {
+	return &ImportStorage{
+		pool: pool,
+	}
+}
+
+// SavePreview stores candidates and drops previews older than a day
+func (is *ImportStorage) SavePreview(ctx context.Context, candidates []models.ImportCandidate) (*models.ImportPreview, error) {
+	op := "internal/storage/ics_import.go SavePreview"
+
+	data, err := json.Marshal(candidates)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to marshal candidates: %w", op, err)
+	}
+
+	if _, err := is.pool.Exec(ctx, `DELETE FROM ics_import_previews WHERE created_at < NOW() - INTERVAL '1 day'`); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+	}
+
+	preview := &models.ImportPreview{Candidates: candidates}
+	err = is.pool.QueryRow(ctx, `INSERT INTO ics_import_previews (user_id, candidates) VALUES ($1, $2) RETURNING id, created_at`, models.UserID(ctx), data).
+		Scan(&preview.ID, &preview.CreatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to save preview: %w", op, err)
+	}
+
+	return preview, nil
+}
+
+func (is *ImportStorage) GetPreview(ctx context.Context, id int) (*models.ImportPreview, error) {
+	op := "internal/storage/ics_import.go GetPreview"
+
+	var data []byte
+	preview := &models.ImportPreview{ID: id}
+	err := is.pool.QueryRow(ctx, `SELECT candidates, created_at FROM ics_import_previews WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx)).
+		Scan(&data, &preview.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPreviewNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get preview: %w", op, err)
+	}
+
+	if err := json.Unmarshal(data, &preview.Candidates); err != nil {
+		return nil, fmt.Errorf("%s: failed to unmarshal candidates: %w", op, err)
+	}
+
+	return preview, nil
+}
+
+// ImportedCalendars returns calendars every given UID was already imported to
+func (is *ImportStorage) ImportedCalendars(ctx context.Context, uids []string) (map[string][]string, error) {
+	op := "internal/storage/ics_import.go ImportedCalendars"
+
+	rows, err := is.pool.Query(ctx, `SELECT uid, calendar_id FROM ics_imports WHERE user_id = $1 AND uid = ANY($2)`, models.UserID(ctx), uids)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list imports: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := make(map[string][]string)
+	for rows.Next() {
+		var uid, calendarID string
+		if err := rows.Scan(&uid, &calendarID); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan import: %w", op, err)
+		}
+		result[uid] = append(result[uid], calendarID)
+	}
+
+	return result, rows.Err()
+}
+
+// ReserveImport marks UID as imported to calendar, false means it was imported before
+func (is *ImportStorage) ReserveImport(ctx context.Context, uid, calendarID string) (bool, error) {
+	op := "internal/storage/ics_import.go ReserveImport"
+
+	tag, err := is.pool.Exec(ctx, `
+	INSERT INTO ics_imports (user_id, uid, calendar_id) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, uid, calendar_id) DO NOTHING
+	`, models.UserID(ctx), uid, calendarID)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return false, fmt.Errorf("%s: failed to reserve import: %w", op, err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (is *ImportStorage) CompleteImport(ctx context.Context, uid, calendarID, eventID string) error {
+	op := "internal/storage/ics_import.go CompleteImport"
+
+	_, err := is.pool.Exec(ctx, `UPDATE ics_imports SET event_id = $3 WHERE uid = $1 AND calendar_id = $2 AND user_id = $4`, uid, calendarID, eventID, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to complete import: %w", op, err)
+	}
+	return nil
+}
+
+// ReleaseImport forgets reservation when event could not be created
+func (is *ImportStorage) ReleaseImport(ctx context.Context, uid, calendarID string) error {
+	op := "internal/storage/ics_import.go ReleaseImport"
+
+	_, err := is.pool.Exec(ctx, `DELETE FROM ics_imports WHERE uid = $1 AND calendar_id = $2 AND user_id = $3 AND event_id IS NULL`, uid, calendarID, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to release import: %w", op, err)
+	}
+	return nil
+}
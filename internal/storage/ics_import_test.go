@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"life_forge/internal/models"
+	"testing"
+)
+
+func TestImportReservationByUID(t *testing.T) {
+	is := NewImportStorage(testPool(t))
+	ctx := models.WithUserID(context.Background(), 1)
+
+	reserve := func(ctx context.Context, uid, calendarID string) bool {
+		t.Helper()
+		ok, err := is.ReserveImport(ctx, uid, calendarID)
+		if err != nil {
+			t.Fatalf("ReserveImport: %v", err)
+		}
+		return ok
+	}
+
+	if !reserve(ctx, "talks@conf", "primary") {
+		t.Fatal("first import is reported as duplicate")
+	}
+	if err := is.CompleteImport(ctx, "talks@conf", "primary", "ev1"); err != nil {
+		t.Fatalf("CompleteImport: %v", err)
+	}
+	if reserve(ctx, "talks@conf", "primary") {
+		t.Fatal("second import to the same calendar is not a duplicate")
+	}
+	if !reserve(ctx, "talks@conf", "local") {
+		t.Fatal("import to another calendar is reported as duplicate")
+	}
+	if !reserve(models.WithUserID(context.Background(), 2), "talks@conf", "primary") {
+		t.Fatal("import of another user is reported as duplicate")
+	}
+
+	// failed import is released and can be repeated, completed one is kept
+	if err := is.ReleaseImport(ctx, "talks@conf", "local"); err != nil {
+		t.Fatalf("ReleaseImport: %v", err)
+	}
+	if err := is.ReleaseImport(ctx, "talks@conf", "primary"); err != nil {
+		t.Fatalf("ReleaseImport: %v", err)
+	}
+	imported, err := is.ImportedCalendars(ctx, []string{"talks@conf", "other@conf"})
+	if err != nil {
+		t.Fatalf("ImportedCalendars: %v", err)
+	}
+	if len(imported) != 1 || len(imported["talks@conf"]) != 1 || imported["talks@conf"][0] != "primary" {
+		t.Fatalf("imported = %v, want talks@conf in primary only", imported)
+	}
+}
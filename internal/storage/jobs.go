@@ -0,0 +1,278 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// schedulerLockKey is pg advisory lock id held by the leading scheduler instance
+const schedulerLockKey int64 = 0x6c665f6a6f6273 // "lf_jobs"
+
+var ErrJobNotFound = errors.New("job not found")
+
+type JobStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewJobStorage(pool *pgxpool.Pool) *JobStorage {
+	return &JobStorage{
+		pool: pool,
+	}
+}
+
+// LeaderLock is session advisory lock, it lives as long as its connection
+type LeaderLock struct {
+	conn *pgxpool.Conn
+}
+
+// TryLeaderLock returns nil lock if another instance is the leader
+func (js *JobStorage) TryLeaderLock(ctx context.Context) (*LeaderLock, error) {
+	op := "internal/storage/jobs.go TryLeaderLock"
+
+	conn, err := js.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to acquire connection: %w", op, err)
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, schedulerLockKey).Scan(&locked); err != nil {
+		conn.Release()
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to take lock: %w", op, err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, nil
+	}
+	return &LeaderLock{conn: conn}, nil
+}
+
+// Alive checks that session holding the lock is still up
+func (l *LeaderLock) Alive(ctx context.Context) error {
+	return l.conn.Ping(ctx)
+}
+
+func (l *LeaderLock) Release(ctx context.Context) {
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, schedulerLockKey); err != nil {
+		// broken session already lost the lock, do not return it to the pool
+		l.conn.Conn().Close(ctx)
+	}
+	l.conn.Release()
+}
+
+// RegisterJob creates job state, changed schedule resets next run
+func (js *JobStorage) RegisterJob(ctx context.Context, name, schedule string, nextRunAt time.Time) error {
+	op := "internal/storage/jobs.go RegisterJob"
+
+	sql_query := `
+	INSERT INTO scheduled_jobs (name, schedule, next_run_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (name) DO UPDATE SET
+		schedule = EXCLUDED.schedule,
+		next_run_at = CASE WHEN scheduled_jobs.schedule = EXCLUDED.schedule
+			THEN scheduled_jobs.next_run_at ELSE EXCLUDED.next_run_at END,
+		updated_at = NOW()
+	`
+
+	if _, err := js.pool.Exec(ctx, sql_query, name, schedule, nextRunAt); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to register job %s: %w", op, name, err)
+	}
+	return nil
+}
+
+func (js *JobStorage) ListJobs(ctx context.Context) ([]*models.Job, error) {
+	op := "internal/storage/jobs.go ListJobs"
+
+	sql_query := `
+	SELECT j.name, j.schedule, j.next_run_at, j.attempts, j.last_run_at, j.last_status, j.last_error,
+		EXISTS (SELECT 1 FROM job_runs r WHERE r.job_name = j.name AND r.status = 'running')
+	FROM scheduled_jobs j
+	ORDER BY j.name
+	`
+
+	rows, err := js.pool.Query(ctx, sql_query)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list jobs: %w", op, err)
+	}
+	defer rows.Close()
+
+	jobs := []*models.Job{}
+	for rows.Next() {
+		j := &models.Job{Runs: []models.JobRun{}}
+		if err := rows.Scan(&j.Name, &j.Schedule, &j.NextRunAt, &j.Attempts, &j.LastRunAt, &j.LastStatus, &j.LastError, &j.Running); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan job: %w", op, err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// ListRuns returns newest runs of job first
+func (js *JobStorage) ListRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	op := "internal/storage/jobs.go ListRuns"
+
+	sql_query := `
+	SELECT id, job_name, attempt, instance, status, error, started_at, finished_at
+	FROM job_runs
+	WHERE job_name = $1
+	ORDER BY started_at DESC
+	LIMIT $2
+	`
+
+	rows, err := js.pool.Query(ctx, sql_query, name, limit)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list runs: %w", op, err)
+	}
+	defer rows.Close()
+
+	runs := []models.JobRun{}
+	for rows.Next() {
+		var r models.JobRun
+		if err := rows.Scan(&r.ID, &r.JobName, &r.Attempt, &r.Instance, &r.Status, &r.Error, &r.StartedAt, &r.FinishedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan run: %w", op, err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// ClaimRun moves next run from dueAt to nextRunAt, false means someone else already claimed this run
+func (js *JobStorage) ClaimRun(ctx context.Context, name string, dueAt, nextRunAt time.Time) (bool, error) {
+	op := "internal/storage/jobs.go ClaimRun"
+
+	sql_query := `
+	UPDATE scheduled_jobs
+	SET next_run_at = $3, updated_at = NOW()
+	WHERE name = $1 AND next_run_at = $2
+	`
+
+	tag, err := js.pool.Exec(ctx, sql_query, name, dueAt, nextRunAt)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return false, fmt.Errorf("%s: failed to claim run: %w", op, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (js *JobStorage) StartRun(ctx context.Context, run *models.JobRun) error {
+	op := "internal/storage/jobs.go StartRun"
+
+	sql_query := `
+	INSERT INTO job_runs (job_name, attempt, instance, status)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, started_at
+	`
+
+	err := js.pool.QueryRow(ctx, sql_query, run.JobName, run.Attempt, run.Instance, models.JobStatusRunning).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to start run: %w", op, err)
+	}
+	run.Status = models.JobStatusRunning
+	return nil
+}
+
+// FinishRun stores result of run. retryAt is set when failed run gets another attempt,
+// it brings next run closer but never pushes it later than the regular schedule
+func (js *JobStorage) FinishRun(ctx context.Context, run *models.JobRun, retryAt *time.Time) error {
+	op := "internal/storage/jobs.go FinishRun"
+
+	tx, err := js.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	sql_query := `
+	UPDATE job_runs SET status = $2, error = $3, finished_at = NOW()
+	WHERE id = $1
+	RETURNING finished_at
+	`
+
+	if err := tx.QueryRow(ctx, sql_query, run.ID, run.Status, run.Error).Scan(&run.FinishedAt); err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to finish run: %w", op, err)
+	}
+
+	attempts := 0
+	if retryAt != nil {
+		attempts = run.Attempt
+	}
+
+	sql_query = `
+	UPDATE scheduled_jobs SET
+		last_run_at = $2,
+		last_status = $3,
+		last_error = $4,
+		attempts = $5,
+		next_run_at = CASE WHEN $6::timestamptz IS NOT NULL AND $6 < next_run_at THEN $6 ELSE next_run_at END,
+		updated_at = NOW()
+	WHERE name = $1
+	`
+
+	if _, err := tx.Exec(ctx, sql_query, run.JobName, run.StartedAt, run.Status, run.Error, attempts, retryAt); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to update job: %w", op, err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FailInterrupted closes runs left "running" by other instances that started before the time.
+// Caller passes the run timeout, so runs a former leader may still be finishing are not touched
+func (js *JobStorage) FailInterrupted(ctx context.Context, instance string, startedBefore time.Time) (int64, error) {
+	op := "internal/storage/jobs.go FailInterrupted"
+
+	sql_query := `
+	UPDATE job_runs SET status = $1, error = 'interrupted', finished_at = NOW()
+	WHERE status = $2 AND instance <> $3 AND started_at < $4
+	`
+
+	tag, err := js.pool.Exec(ctx, sql_query, models.JobStatusFailed, models.JobStatusRunning, instance, startedBefore)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return 0, fmt.Errorf("%s: failed to close runs: %w", op, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// PruneRuns deletes history of runs finished before the time, runs in progress are kept
+func (js *JobStorage) PruneRuns(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	op := "internal/storage/jobs.go PruneRuns"
+
+	sql_query := `
+	DELETE FROM job_runs
+	WHERE status <> $1 AND finished_at < $2
+	`
+
+	tag, err := js.pool.Exec(ctx, sql_query, models.JobStatusRunning, finishedBefore)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return 0, fmt.Errorf("%s: failed to prune runs: %w", op, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// TriggerNow makes job due right away, leader picks it up on the next tick
+func (js *JobStorage) TriggerNow(ctx context.Context, name string) error {
+	op := "internal/storage/jobs.go TriggerNow"
+
+	tag, err := js.pool.Exec(ctx, `UPDATE scheduled_jobs SET next_run_at = NOW(), updated_at = NOW() WHERE name = $1`, name)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to trigger job: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"life_forge/internal/models"
+	"testing"
+	"time"
+)
+
+// startTestRun starts run of job and moves its start back by age
+func startTestRun(t *testing.T, js *JobStorage, name, instance string, age time.Duration) *models.JobRun {
+	t.Helper()
+	ctx := context.Background()
+	run := &models.JobRun{JobName: name, Attempt: 1, Instance: instance}
+	if err := js.StartRun(ctx, run); err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	if _, err := js.pool.Exec(ctx, `UPDATE job_runs SET started_at = NOW() - $2::float8 * INTERVAL '1 second' WHERE id = $1`, run.ID, age.Seconds()); err != nil {
+		t.Fatalf("age run: %v", err)
+	}
+	return run
+}
+
+func runStatuses(t *testing.T, js *JobStorage, name string) map[int64]string {
+	t.Helper()
+	runs, err := js.ListRuns(context.Background(), name, 100)
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	statuses := make(map[int64]string, len(runs))
+	for _, r := range runs {
+		statuses[r.ID] = r.Status
+	}
+	return statuses
+}
+
+func TestPruneRuns(t *testing.T) {
+	js := NewJobStorage(testPool(t))
+	ctx := context.Background()
+	if err := js.RegisterJob(ctx, "digest", "* * * * *", time.Now()); err != nil {
+		t.Fatalf("RegisterJob: %v", err)
+	}
+
+	old := startTestRun(t, js, "digest", "a", 0)
+	old.Status = models.JobStatusSuccess
+	if err := js.FinishRun(ctx, old, nil); err != nil {
+		t.Fatalf("FinishRun: %v", err)
+	}
+	if _, err := js.pool.Exec(ctx, `UPDATE job_runs SET finished_at = NOW() - INTERVAL '30 days' WHERE id = $1`, old.ID); err != nil {
+		t.Fatalf("age run: %v", err)
+	}
+	fresh := startTestRun(t, js, "digest", "a", 0)
+	fresh.Status = models.JobStatusFailed
+	if err := js.FinishRun(ctx, fresh, nil); err != nil {
+		t.Fatalf("FinishRun: %v", err)
+	}
+	running := startTestRun(t, js, "digest", "a", 40*24*time.Hour)
+
+	n, err := js.PruneRuns(ctx, time.Now().Add(-14*24*time.Hour))
+	if err != nil {
+		t.Fatalf("PruneRuns: %v", err)
+	}
+	statuses := runStatuses(t, js, "digest")
+	if n != 1 || len(statuses) != 2 || statuses[fresh.ID] == "" || statuses[running.ID] != models.JobStatusRunning {
+		t.Fatalf("pruned %d, left %v, want only old finished run deleted", n, statuses)
+	}
+}
+
+func TestFailInterrupted(t *testing.T) {
+	js := NewJobStorage(testPool(t))
+	ctx := context.Background()
+	if err := js.RegisterJob(ctx, "sync", "@every 5m", time.Now()); err != nil {
+		t.Fatalf("RegisterJob: %v", err)
+	}
+
+	crashed := startTestRun(t, js, "sync", "old-leader", time.Hour)
+	// former leader lost the lock but may still finish this run
+	recent := startTestRun(t, js, "sync", "old-leader", time.Minute)
+	own := startTestRun(t, js, "sync", "new-leader", time.Hour)
+
+	n, err := js.FailInterrupted(ctx, "new-leader", time.Now().Add(-10*time.Minute))
+	if err != nil {
+		t.Fatalf("FailInterrupted: %v", err)
+	}
+
+	statuses := runStatuses(t, js, "sync")
+	want := map[int64]string{
+		crashed.ID: models.JobStatusFailed,
+		recent.ID:  models.JobStatusRunning,
+		own.ID:     models.JobStatusRunning,
+	}
+	if n != 1 {
+		t.Fatalf("failed %d runs, want 1", n)
+	}
+	for id, status := range want {
+		if statuses[id] != status {
+			t.Fatalf("run statuses = %v, want %v", statuses, want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrJournalEntryNotFound = errors.New("journal entry not found")
+
+// JournalStorage keeps diary entries with mood scores and weekly reflections
+type JournalStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewJournalStorage(pool *pgxpool.Pool) *JournalStorage {
+	return &JournalStorage{
+		pool: pool,
+	}
+}
+
+func (js *JournalStorage) CreateEntry(ctx context.Context, entry *models.JournalEntry) error {
+	op := "internal/storage/journal.go CreateEntry"
+
+	sql_query := `
+	INSERT INTO journal_entries (entry_text, mood_score, mood_source, source, user_id)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, created_at, updated_at
+	`
+
+	err := js.pool.QueryRow(ctx, sql_query, entry.Text, entry.Mood, entry.MoodSource, entry.Source, models.UserID(ctx)).
+		Scan(&entry.ID, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save entry: %w", op, err)
+	}
+	return nil
+}
+
+func (js *JournalStorage) GetEntry(ctx context.Context, id int) (*models.JournalEntry, error) {
+	op := "internal/storage/journal.go GetEntry"
+
+	row := js.pool.QueryRow(ctx, `
+	SELECT id, entry_text, mood_score, mood_source, source, created_at, updated_at
+	FROM journal_entries
+	WHERE id = $1 AND user_id = $2
+	`, id, models.UserID(ctx))
+	entry, err := scanJournalEntry(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrJournalEntryNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get entry: %w", op, err)
+	}
+	return entry, nil
+}
+
+// ListEntries returns entries written in [from, to), newest first
+func (js *JournalStorage) ListEntries(ctx context.Context, from, to time.Time, limit int) ([]*models.JournalEntry, error) {
+	op := "internal/storage/journal.go ListEntries"
+
+	rows, err := js.pool.Query(ctx, `
+	SELECT id, entry_text, mood_score, mood_source, source, created_at, updated_at
+	FROM journal_entries
+	WHERE user_id = $4 AND created_at >= $1 AND created_at < $2
+	ORDER BY created_at DESC
+	LIMIT $3
+	`, from, to, limit, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list entries: %w", op, err)
+	}
+	defer rows.Close()
+
+	entries := []*models.JournalEntry{}
+	for rows.Next() {
+		entry, err := scanJournalEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan entry: %w", op, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
+
+func (js *JournalStorage) UpdateEntry(ctx context.Context, entry *models.JournalEntry) error {
+	op := "internal/storage/journal.go UpdateEntry"
+
+	sql_query := `
+	UPDATE journal_entries
+	SET entry_text = $2, mood_score = $3, mood_source = $4, updated_at = NOW()
+	WHERE id = $1 AND user_id = $5
+	RETURNING updated_at
+	`
+
+	err := js.pool.QueryRow(ctx, sql_query, entry.ID, entry.Text, entry.Mood, entry.MoodSource, models.UserID(ctx)).Scan(&entry.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrJournalEntryNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to update entry: %w", op, err)
+	}
+	return nil
+}
+
+func (js *JournalStorage) DeleteEntry(ctx context.Context, id int) error {
+	op := "internal/storage/journal.go DeleteEntry"
+
+	tag, err := js.pool.Exec(ctx, `DELETE FROM journal_entries WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete entry: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrJournalEntryNotFound
+	}
+	return nil
+}
+
+func (js *JournalStorage) SaveReflection(ctx context.Context, reflection *models.JournalReflection) error {
+	op := "internal/storage/journal.go SaveReflection"
+
+	trendJSON, err := json.Marshal(reflection.Trend)
+	if err != nil {
+		return fmt.Errorf("%s: failed to marshal trend: %w", op, err)
+	}
+
+	sql_query := `
+	INSERT INTO journal_reflections (user_id, period_start, period_end, summary, insights, suggestions, trend)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, created_at
+	`
+
+	err = js.pool.QueryRow(ctx, sql_query,
+		models.UserID(ctx),
+		reflection.PeriodStart,
+		reflection.PeriodEnd,
+		reflection.Summary,
+		reflection.Insights,
+		reflection.Suggestions,
+		trendJSON,
+	).Scan(&reflection.ID, &reflection.CreatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save reflection: %w", op, err)
+	}
+	return nil
+}
+
+// ListReflections returns newest reflections first
+func (js *JournalStorage) ListReflections(ctx context.Context, limit int) ([]models.JournalReflection, error) {
+	op := "internal/storage/journal.go ListReflections"
+
+	rows, err := js.pool.Query(ctx, `
+	SELECT id, period_start, period_end, summary, insights, suggestions, trend, created_at
+	FROM journal_reflections
+	WHERE user_id = $2
+	ORDER BY created_at DESC
+	LIMIT $1
+	`, limit, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list reflections: %w", op, err)
+	}
+	defer rows.Close()
+
+	reflections := []models.JournalReflection{}
+	for rows.Next() {
+		var r models.JournalReflection
+		var trendJSON []byte
+		if err := rows.Scan(&r.ID, &r.PeriodStart, &r.PeriodEnd, &r.Summary, &r.Insights, &r.Suggestions, &trendJSON, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan reflection: %w", op, err)
+		}
+		if len(trendJSON) > 0 {
+			if err := json.Unmarshal(trendJSON, &r.Trend); err != nil {
+				log.Println("Failed to unmarshal trend in ", op, "with error: ", err)
+			}
+		}
+		reflections = append(reflections, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return reflections, nil
+}
+
+func scanJournalEntry(row pgx.Row) (*models.JournalEntry, error) {
+	e := &models.JournalEntry{}
+	err := row.Scan(&e.ID, &e.Text, &e.Mood, &e.MoodSource, &e.Source, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/ical"
+	"life_forge/internal/models"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LocalCalendarID is the id of built-in calendar stored in events table
+const LocalCalendarID = "local"
+
+// LocalCalendarColor is background color of built-in calendar
+const LocalCalendarColor = "#7986cb"
+
+// ErrEventNotFound is returned for unknown local event
+var ErrEventNotFound = errors.New("event not found")
+
+// LocalCalendarStorage is CalendarProvider backed by Postgres, it works without any external account.
+// Events pushed to Google keep their row with external_id set and are no longer listed here
+type LocalCalendarStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewLocalCalendarStorage(pool *pgxpool.Pool) *LocalCalendarStorage {
+	return &LocalCalendarStorage{
+		pool: pool,
+	}
+}
+
+const localEventColumns = `id, title, start_time, duration_hours, recurrence, description, uid, is_all_day, exdates`
+
+func (ls *LocalCalendarStorage) ListCalendars(ctx context.Context) ([]models.Calendar, error) {
+	return []models.Calendar{{
+		ID:              LocalCalendarID,
+		Summary:         "LifeForge",
+		BackgroundColor: LocalCalendarColor,
+		Provider:        models.ProviderLocal,
+	}}, nil
+}
+
+func (ls *LocalCalendarStorage) ListEvents(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]*models.CalendarEvent, []models.CalendarStatus, error) {
+	op := "internal/storage/local_calendar.go ListEvents"
+
+	sql_query := `
+	SELECT ` + localEventColumns + ` FROM events
+	WHERE user_id = $4 AND calendar_id = $1 AND external_id IS NULL AND start_time < $3
+	  AND (recurrence IS NOT NULL OR start_time + COALESCE(duration_hours, 1) * INTERVAL '1 hour' > $2)
+	ORDER BY start_time
+	`
+
+	rows, err := ls.pool.Query(ctx, sql_query, LocalCalendarID, timeMin, timeMax, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		status := models.CalendarStatus{CalendarID: LocalCalendarID, Status: models.CalendarStatusError, Error: err.Error()}
+		return nil, []models.CalendarStatus{status}, nil
+	}
+	defer rows.Close()
+
+	var events []*models.CalendarEvent
+	for rows.Next() {
+		row, err := scanLocalEvent(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: failed to scan event: %w", op, err)
+		}
+		events = append(events, expandLocalEvent(row, timeMin, timeMax)...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	status := models.CalendarStatus{CalendarID: LocalCalendarID, Status: models.CalendarStatusOK, Events: len(events)}
+	return events, []models.CalendarStatus{status}, nil
+}
+
+func (ls *LocalCalendarStorage) CreateEvent(ctx context.Context, calendarID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	op := "internal/storage/local_calendar.go CreateEvent"
+
+	if event.StartTime == nil {
+		return nil, fmt.Errorf("start time is required")
+	}
+
+	uid := newUID()
+	if event.UID != nil && *event.UID != "" {
+		uid = *event.UID
+	}
+	event.UID = &uid
+	event.Recurrence = normalizeRecurrence(event.Recurrence)
+	if event.ExDates == nil {
+		event.ExDates = []time.Time{}
+	}
+
+	sql_query := `
+	INSERT INTO events (calendar_id, is_event, title, start_time, duration_hours, recurrence, description, uid, is_all_day, exdates, user_id)
+	VALUES ($1, TRUE, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING id
+	`
+
+	err := ls.pool.QueryRow(ctx, sql_query,
+		LocalCalendarID,
+		event.Title,
+		event.StartTime,
+		event.DurationHours,
+		event.Recurrence,
+		event.Description,
+		uid,
+		isAllDay(event),
+		event.ExDates,
+		models.UserID(ctx),
+	).Scan(&event.ID)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to save event: %w", op, err)
+	}
+
+	return localEventToModel(&event, *event.StartTime, localEventEnd(&event), false), nil
+}
+
+// UpdateEvent works like Google patch: fields that are not set keep their values
+func (ls *LocalCalendarStorage) UpdateEvent(ctx context.Context, calendarID, eventID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	op := "internal/storage/local_calendar.go UpdateEvent"
+
+	id, err := localEventID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	sql_query := `
+	UPDATE events SET
+		title = COALESCE(NULLIF($2, ''), title),
+		start_time = COALESCE($3, start_time),
+		duration_hours = COALESCE($4, duration_hours),
+		recurrence = COALESCE($5, recurrence),
+		description = COALESCE($6, description),
+		is_all_day = COALESCE($7, is_all_day),
+		exdates = COALESCE($8, exdates),
+		updated_at = NOW()
+	WHERE id = $1 AND calendar_id = $9 AND user_id = $10 AND external_id IS NULL
+	RETURNING ` + localEventColumns
+
+	var exdates []time.Time
+	if event.ExDates != nil {
+		exdates = event.ExDates
+	}
+
+	row, err := scanLocalEvent(ls.pool.QueryRow(ctx, sql_query,
+		id,
+		event.Title,
+		event.StartTime,
+		event.DurationHours,
+		normalizeRecurrence(event.Recurrence),
+		event.Description,
+		event.IsAllDay,
+		exdates,
+		LocalCalendarID,
+		models.UserID(ctx),
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to update event: %w", op, err)
+	}
+
+	return localEventToModel(row, *row.StartTime, localEventEnd(row), false), nil
+}
+
+// DeleteEvent removes whole series, for instance id (<id>#<unix>) only this occurrence is excluded
+func (ls *LocalCalendarStorage) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	op := "internal/storage/local_calendar.go DeleteEvent"
+
+	id, err := localEventID(eventID)
+	if err != nil {
+		return err
+	}
+
+	var sql_query string
+	args := []interface{}{id, LocalCalendarID, models.UserID(ctx)}
+	if idx := strings.LastIndex(eventID, "#"); idx > 0 {
+		unix, err := strconv.ParseInt(eventID[idx+1:], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid event id %s", eventID)
+		}
+		sql_query = `UPDATE events SET exdates = array_append(exdates, $4), updated_at = NOW() WHERE id = $1 AND calendar_id = $2 AND user_id = $3`
+		args = append(args, time.Unix(unix, 0).UTC())
+	} else {
+		sql_query = `DELETE FROM events WHERE id = $1 AND calendar_id = $2 AND user_id = $3`
+	}
+
+	tag, err := ls.pool.Exec(ctx, sql_query, args...)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete event: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrEventNotFound
+	}
+	return nil
+}
+
+func (ls *LocalCalendarStorage) FreeBusy(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]models.BusyPeriod, error) {
+	events, _, err := ls.ListEvents(ctx, timeMin, timeMax)
+	if err != nil {
+		return nil, err
+	}
+	return busyFromEvents(events, timeMin, timeMax), nil
+}
+
+// PendingPush returns local events of the user that were not copied to Google yet and occur between timeMin and timeMax
+func (ls *LocalCalendarStorage) PendingPush(ctx context.Context, timeMin, timeMax time.Time) ([]*models.EventRequest, error) {
+	op := "internal/storage/local_calendar.go PendingPush"
+
+	rows, err := ls.pool.Query(ctx, `SELECT `+localEventColumns+` FROM events WHERE calendar_id = $1 AND user_id = $2 AND external_id IS NULL ORDER BY id`, LocalCalendarID, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list events: %w", op, err)
+	}
+	defer rows.Close()
+
+	var events []*models.EventRequest
+	for rows.Next() {
+		row, err := scanLocalEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan event: %w", op, err)
+		}
+		if len(expandLocalEvent(row, timeMin, timeMax)) > 0 {
+			events = append(events, row)
+		}
+	}
+	return events, rows.Err()
+}
+
+// MarkPushed links local row with event created in external calendar
+func (ls *LocalCalendarStorage) MarkPushed(ctx context.Context, id int, externalID string) error {
+	op := "internal/storage/local_calendar.go MarkPushed"
+
+	_, err := ls.pool.Exec(ctx, `UPDATE events SET external_id = $2, updated_at = NOW() WHERE id = $1 AND user_id = $3`, id, externalID, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to mark event: %w", op, err)
+	}
+	return nil
+}
+
+func scanLocalEvent(row pgx.Row) (*models.EventRequest, error) {
+	var e models.EventRequest
+	var uid *string
+	err := row.Scan(&e.ID, &e.Title, &e.StartTime, &e.DurationHours, &e.Recurrence, &e.Description, &uid, &e.IsAllDay, &e.ExDates)
+	if err != nil {
+		return nil, err
+	}
+	e.IsEvent = true
+	e.UID = uid
+	return &e, nil
+}
+
+func expandLocalEvent(e *models.EventRequest, timeMin, timeMax time.Time) []*models.CalendarEvent {
+	if e.StartTime == nil {
+		return nil
+	}
+
+	vevent := ical.Event{Start: *e.StartTime, End: localEventEnd(e), AllDay: isAllDay(*e), ExDates: e.ExDates}
+	if e.Recurrence != nil {
+		vevent.RRule = strings.TrimPrefix(*e.Recurrence, "RRULE:")
+	}
+
+	occurrences, err := vevent.Expand(timeMin, timeMax)
+	if err != nil {
+		log.Printf("Failed to expand local event %d: %v", e.ID, err)
+		return nil
+	}
+
+	events := make([]*models.CalendarEvent, 0, len(occurrences))
+	for _, occ := range occurrences {
+		events = append(events, localEventToModel(e, occ.Start, occ.End, vevent.RRule != ""))
+	}
+	return events
+}
+
+func localEventToModel(e *models.EventRequest, start, end time.Time, instance bool) *models.CalendarEvent {
+	event := &models.CalendarEvent{
+		ID:         strconv.Itoa(e.ID),
+		CalendarID: LocalCalendarID,
+		Summary:    e.Title,
+		Status:     "confirmed",
+		Start:      start,
+		End:        end,
+		IsAllDay:   isAllDay(*e),
+	}
+	if e.UID != nil {
+		event.UID = *e.UID
+	}
+	if e.Description != nil {
+		event.Description = *e.Description
+	}
+	if e.Recurrence != nil {
+		event.Recurrence = []string{*e.Recurrence}
+	}
+	if instance {
+		event.ID = fmt.Sprintf("%d#%d", e.ID, start.Unix())
+		event.RecurringEventID = strconv.Itoa(e.ID)
+	}
+	return event
+}
+
+func localEventEnd(e *models.EventRequest) time.Time {
+	duration := time.Hour
+	if isAllDay(*e) {
+		duration = 24 * time.Hour
+	}
+	if e.DurationHours != nil && *e.DurationHours > 0 {
+		duration = time.Duration(*e.DurationHours * float64(time.Hour))
+	}
+	return e.StartTime.Add(duration)
+}
+
+// isAllDay reads optional flag of request, unset means timed event
+func isAllDay(event models.EventRequest) bool {
+	return event.IsAllDay != nil && *event.IsAllDay
+}
+
+// localEventID drops instance suffix and parses row id
+func localEventID(eventID string) (int, error) {
+	id, err := strconv.Atoi(objectHref(eventID))
+	if err != nil {
+		return 0, fmt.Errorf("invalid event id %s", eventID)
+	}
+	return id, nil
+}
+
+// normalizeRecurrence stores recurrence as "RRULE:..." line, the same form Google uses
+func normalizeRecurrence(recurrence *string) *string {
+	if recurrence == nil || *recurrence == "" {
+		return nil
+	}
+	rule := formatRecurrenceRule(*recurrence)
+	return &rule
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"life_forge/internal/models"
+	"testing"
+	"time"
+)
+
+func TestLocalUpdateEventPatch(t *testing.T) {
+	ls := NewLocalCalendarStorage(testPool(t))
+	ctx := models.WithUserID(context.Background(), 1)
+
+	start := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	allDay, duration := true, 24.0
+	description := "passport"
+	created, err := ls.CreateEvent(ctx, LocalCalendarID, models.EventRequest{
+		Title:         "Trip",
+		StartTime:     &start,
+		DurationHours: &duration,
+		Description:   &description,
+		IsAllDay:      &allDay,
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		patch models.EventRequest
+		check func(t *testing.T, got *models.CalendarEvent)
+	}{
+		{
+			name:  "title only keeps other fields",
+			patch: models.EventRequest{Title: "Vacation"},
+			check: func(t *testing.T, got *models.CalendarEvent) {
+				if got.Summary != "Vacation" || !got.IsAllDay || got.Description != description || !got.Start.Equal(start) || !got.End.Equal(start.Add(24*time.Hour)) {
+					t.Fatalf("event = %+v, want renamed all-day event with old fields", got)
+				}
+			},
+		},
+		{
+			name:  "explicit false makes event timed",
+			patch: models.EventRequest{IsAllDay: new(bool)},
+			check: func(t *testing.T, got *models.CalendarEvent) {
+				if got.IsAllDay || got.Summary != "Vacation" {
+					t.Fatalf("event = %+v, want timed event", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ls.UpdateEvent(ctx, LocalCalendarID, created.ID, tt.patch)
+			if err != nil {
+				t.Fatalf("UpdateEvent: %v", err)
+			}
+			tt.check(t, got)
+		})
+	}
+
+	if _, err := ls.UpdateEvent(models.WithUserID(context.Background(), 2), LocalCalendarID, created.ID, models.EventRequest{Title: "Mine"}); !errors.Is(err, ErrEventNotFound) {
+		t.Fatalf("update of another user's event: err = %v, want ErrEventNotFound", err)
+	}
+}
+
+func TestPendingPushWindow(t *testing.T) {
+	ls := NewLocalCalendarStorage(testPool(t))
+	ctx := models.WithUserID(context.Background(), 1)
+
+	now := time.Now().UTC().Truncate(time.Hour)
+	timeMin, timeMax := now.AddDate(-1, 0, 0), now.AddDate(2, 0, 0)
+	weekly := "RRULE:FREQ=WEEKLY"
+	ended := "RRULE:FREQ=WEEKLY;COUNT=3"
+
+	tests := []struct {
+		title      string
+		start      time.Time
+		recurrence *string
+		want       bool
+	}{
+		{"next week", now.AddDate(0, 0, 7), nil, true},
+		{"last month", now.AddDate(0, -1, 0), nil, true},
+		{"two years ago", now.AddDate(-2, 0, 0), nil, false},
+		{"after window", now.AddDate(3, 0, 0), nil, false},
+		{"weekly since long ago", now.AddDate(-3, 0, 0), &weekly, true},
+		{"series ended long ago", now.AddDate(-3, 0, 0), &ended, false},
+	}
+	for _, tt := range tests {
+		start := tt.start
+		if _, err := ls.CreateEvent(ctx, LocalCalendarID, models.EventRequest{Title: tt.title, StartTime: &start, Recurrence: tt.recurrence}); err != nil {
+			t.Fatalf("CreateEvent %s: %v", tt.title, err)
+		}
+	}
+
+	events, err := ls.PendingPush(ctx, timeMin, timeMax)
+	if err != nil {
+		t.Fatalf("PendingPush: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, e := range events {
+		got[e.Title] = true
+	}
+	for _, tt := range tests {
+		if got[tt.title] != tt.want {
+			t.Errorf("%s pending = %v, want %v", tt.title, got[tt.title], tt.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrTaskNotFound is returned for unknown project task
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrProjectNotFound is returned for unknown project or project of another user
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrDependencyCycle is returned when new dependency would make tasks wait for each other
+	ErrDependencyCycle = errors.New("dependency creates a cycle")
+)
+
+// ProjectStorage keeps projects, their tasks and finish-to-start dependencies between tasks.
+// Tasks and dependencies belong to the user through their project
+type ProjectStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewProjectStorage(pool *pgxpool.Pool) *ProjectStorage {
+	return &ProjectStorage{
+		pool: pool,
+	}
+}
+
+func (ps *ProjectStorage) ListProjects(ctx context.Context) ([]models.Project, error) {
+	op := "internal/storage/projects.go ListProjects"
+
+	rows, err := ps.pool.Query(ctx, `SELECT id, name, COALESCE(color, ''), created_at FROM projects WHERE user_id = $1 ORDER BY id`, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list projects: %w", op, err)
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		var p models.Project
+		if err := rows.Scan(&p.ID, &p.Name, &p.Color, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan project: %w", op, err)
+		}
+		projects = append(projects, p)
+	}
+
+	return projects, rows.Err()
+}
+
+func (ps *ProjectStorage) CreateProject(ctx context.Context, project *models.Project) error {
+	op := "internal/storage/projects.go CreateProject"
+
+	err := ps.pool.QueryRow(ctx, `INSERT INTO projects (user_id, name, color) VALUES ($3, $1, NULLIF($2, '')) RETURNING id, created_at`,
+		project.Name, project.Color, models.UserID(ctx)).Scan(&project.ID, &project.CreatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save project: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteProject removes project with all its tasks
+func (ps *ProjectStorage) DeleteProject(ctx context.Context, id int) error {
+	op := "internal/storage/projects.go DeleteProject"
+
+	if _, err := ps.pool.Exec(ctx, `DELETE FROM projects WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx)); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete project: %w", op, err)
+	}
+	return nil
+}
+
+// ListTasks returns tasks with their dependencies, projectID 0 means every project
+func (ps *ProjectStorage) ListTasks(ctx context.Context, projectID int) ([]models.ProjectTask, error) {
+	op := "internal/storage/projects.go ListTasks"
+
+	sql_query := `
+	SELECT t.id, t.project_id, t.name, t.start_time, t.end_time, t.progress, t.is_milestone,
+		COALESCE(array_agg(d.depends_on_id ORDER BY d.depends_on_id) FILTER (WHERE d.depends_on_id IS NOT NULL), '{}')
+	FROM project_tasks t
+	LEFT JOIN project_task_dependencies d ON d.task_id = t.id
+	WHERE ($1 = 0 OR t.project_id = $1) AND t.project_id IN (SELECT id FROM projects WHERE user_id = $2)
+	GROUP BY t.id
+	ORDER BY t.project_id, t.start_time, t.id
+	`
+
+	rows, err := ps.pool.Query(ctx, sql_query, projectID, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list tasks: %w", op, err)
+	}
+	defer rows.Close()
+
+	tasks := []models.ProjectTask{}
+	for rows.Next() {
+		var t models.ProjectTask
+		var dependsOn []int32
+		if err := rows.Scan(&t.ID, &t.ProjectID, &t.Name, &t.StartTime, &t.EndTime, &t.Progress, &t.IsMilestone, &dependsOn); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan task: %w", op, err)
+		}
+		t.DependsOn = make([]int, 0, len(dependsOn))
+		for _, id := range dependsOn {
+			t.DependsOn = append(t.DependsOn, int(id))
+		}
+		tasks = append(tasks, t)
+	}
+
+	return tasks, rows.Err()
+}
+
+func (ps *ProjectStorage) CreateTask(ctx context.Context, task *models.ProjectTask) error {
+	op := "internal/storage/projects.go CreateTask"
+
+	sql_query := `
+	INSERT INTO project_tasks (project_id, name, start_time, end_time, progress, is_milestone)
+	SELECT id, $2, $3, $4, $5, $6 FROM projects WHERE id = $1 AND user_id = $7
+	RETURNING id
+	`
+
+	err := ps.pool.QueryRow(ctx, sql_query,
+		task.ProjectID,
+		task.Name,
+		task.StartTime,
+		task.EndTime,
+		task.Progress,
+		task.IsMilestone,
+		models.UserID(ctx),
+	).Scan(&task.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrProjectNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save task: %w", op, err)
+	}
+	return nil
+}
+
+func (ps *ProjectStorage) UpdateTask(ctx context.Context, task *models.ProjectTask) error {
+	op := "internal/storage/projects.go UpdateTask"
+
+	sql_query := `
+	UPDATE project_tasks
+	SET name = $2, start_time = $3, end_time = $4, progress = $5, is_milestone = $6, updated_at = NOW()
+	WHERE id = $1 AND project_id IN (SELECT id FROM projects WHERE user_id = $7)
+	RETURNING project_id
+	`
+
+	err := ps.pool.QueryRow(ctx, sql_query,
+		task.ID,
+		task.Name,
+		task.StartTime,
+		task.EndTime,
+		task.Progress,
+		task.IsMilestone,
+		models.UserID(ctx),
+	).Scan(&task.ProjectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTaskNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to update task: %w", op, err)
+	}
+	return nil
+}
+
+func (ps *ProjectStorage) DeleteTask(ctx context.Context, id int) error {
+	op := "internal/storage/projects.go DeleteTask"
+
+	if _, err := ps.pool.Exec(ctx, `DELETE FROM project_tasks WHERE id = $1 AND project_id IN (SELECT id FROM projects WHERE user_id = $2)`, id, models.UserID(ctx)); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete task: %w", op, err)
+	}
+	return nil
+}
+
+// AddDependency makes taskID wait for dependsOnID, both tasks must belong to one project
+func (ps *ProjectStorage) AddDependency(ctx context.Context, taskID, dependsOnID int) error {
+	op := "internal/storage/projects.go AddDependency"
+
+	if taskID == dependsOnID {
+		return ErrDependencyCycle
+	}
+
+	tx, err := ps.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// project row lock serializes concurrent changes of one dependency graph
+	var sameProject bool
+	err = tx.QueryRow(ctx, `
+	SELECT a.project_id = b.project_id FROM project_tasks a
+	JOIN project_tasks b ON b.id = $2
+	JOIN projects p ON p.id = a.project_id
+	WHERE a.id = $1 AND p.user_id = $3
+	FOR UPDATE OF p
+	`, taskID, dependsOnID, models.UserID(ctx)).Scan(&sameProject)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTaskNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to load tasks: %w", op, err)
+	}
+	if !sameProject {
+		return fmt.Errorf("tasks %d and %d belong to different projects", taskID, dependsOnID)
+	}
+
+	// cycle if taskID is already reachable from dependsOnID through its own prerequisites
+	var cycle bool
+	err = tx.QueryRow(ctx, `
+	WITH RECURSIVE prerequisites(id) AS (
+		SELECT depends_on_id FROM project_task_dependencies WHERE task_id = $1
+		UNION
+		SELECT d.depends_on_id FROM project_task_dependencies d JOIN prerequisites p ON d.task_id = p.id
+	)
+	SELECT EXISTS (SELECT 1 FROM prerequisites WHERE id = $2)
+	`, dependsOnID, taskID).Scan(&cycle)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to check cycle: %w", op, err)
+	}
+	if cycle {
+		return ErrDependencyCycle
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO project_task_dependencies (task_id, depends_on_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, taskID, dependsOnID)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save dependency: %w", op, err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (ps *ProjectStorage) RemoveDependency(ctx context.Context, taskID, dependsOnID int) error {
+	op := "internal/storage/projects.go RemoveDependency"
+
+	_, err := ps.pool.Exec(ctx, `
+	DELETE FROM project_task_dependencies
+	WHERE task_id = $1 AND depends_on_id = $2
+	  AND task_id IN (SELECT t.id FROM project_tasks t JOIN projects p ON p.id = t.project_id WHERE p.user_id = $3)
+	`, taskID, dependsOnID, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete dependency: %w", op, err)
+	}
+	return nil
+}
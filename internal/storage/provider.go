@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"life_forge/internal/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CalendarProvider is a calendar backend (Google, CalDAV, ...)
+type CalendarProvider interface {
+	ListCalendars(ctx context.Context) ([]models.Calendar, error)
+	ListEvents(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]*models.CalendarEvent, []models.CalendarStatus, error)
+	CreateEvent(ctx context.Context, calendarID string, event models.EventRequest) (*models.CalendarEvent, error)
+	UpdateEvent(ctx context.Context, calendarID, eventID string, event models.EventRequest) (*models.CalendarEvent, error)
+	DeleteEvent(ctx context.Context, calendarID, eventID string) error
+	FreeBusy(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]models.BusyPeriod, error)
+}
+
+// CalendarRouter merges all linked providers into one and routes calls by calendar id:
+// "local" goes to built-in calendar, ids with "caldav:<account>:" prefix go to CalDAV account,
+// ids with "google:<account>:" prefix go to linked Google account that is not primary,
+// "primary" goes to Google when it is authorized and to local calendar otherwise, everything else goes to primary Google account.
+// Google and CalDAV accounts are those of the user from context
+type CalendarRouter struct {
+	google CalendarProvider
+	local  CalendarProvider
+
+	mu     sync.RWMutex
+	caldav map[int]*CalDAVCalendarStorage
+}
+
+func NewCalendarRouter(google, local CalendarProvider) *CalendarRouter {
+	return &CalendarRouter{
+		google: google,
+		local:  local,
+		caldav: make(map[int]*CalDAVCalendarStorage),
+	}
+}
+
+func (r *CalendarRouter) SetCalDAVAccount(p *CalDAVCalendarStorage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.caldav[p.AccountID()] = p
+}
+
+func (r *CalendarRouter) RemoveCalDAVAccount(accountID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.caldav, accountID)
+}
+
+// googleAccounts is implemented by Google provider that can link several accounts
+type googleAccounts interface {
+	AccountProviders(ctx context.Context) []CalendarProvider
+	AccountProvider(accountID int, email string) CalendarProvider
+}
+
+// googleAuthorized is false for providers that can not report it
+func (r *CalendarRouter) googleAuthorized(ctx context.Context) bool {
+	a, ok := r.google.(interface{ IsAuthorized(context.Context) bool })
+	return ok && a.IsAuthorized(ctx)
+}
+
+func (r *CalendarRouter) providerFor(ctx context.Context, calendarID string) CalendarProvider {
+	switch calendarID {
+	case LocalCalendarID:
+		return r.local
+	case "", "primary":
+		if !r.googleAuthorized(ctx) {
+			return r.local
+		}
+	}
+	if accountID, ok := parseCalDAVAccountID(calendarID); ok {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		if p, found := r.caldav[accountID]; found && p.UserID() == models.UserID(ctx) {
+			return p
+		}
+		return nil
+	}
+	if accountID, ok := parseGoogleAccountID(calendarID); ok {
+		if accounts, ok := r.google.(googleAccounts); ok {
+			return accounts.AccountProvider(accountID, "")
+		}
+		return nil
+	}
+	return r.google
+}
+
+func (r *CalendarRouter) providers(ctx context.Context) []CalendarProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	userID := models.UserID(ctx)
+	ids := make([]int, 0, len(r.caldav))
+	for id, p := range r.caldav {
+		if p.UserID() == userID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	result := []CalendarProvider{r.local}
+	if r.googleAuthorized(ctx) {
+		result = append(result, r.google)
+	}
+	if accounts, ok := r.google.(googleAccounts); ok {
+		result = append(result, accounts.AccountProviders(ctx)...)
+	}
+	for _, id := range ids {
+		result = append(result, r.caldav[id])
+	}
+	return result
+}
+
+// ListCalendars fails only if every provider failed
+func (r *CalendarRouter) ListCalendars(ctx context.Context) ([]models.Calendar, error) {
+	var all []models.Calendar
+	var errs []string
+
+	providers := r.providers(ctx)
+	for _, p := range providers {
+		calendars, err := p.ListCalendars(ctx)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		all = append(all, calendars...)
+	}
+
+	if len(errs) == len(providers) {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return all, nil
+}
+
+func (r *CalendarRouter) ListEvents(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]*models.CalendarEvent, []models.CalendarStatus, error) {
+	if len(calendarIDs) == 0 {
+		calendarIDs = r.defaultCalendars(ctx)
+	}
+
+	groups := make(map[CalendarProvider][]string)
+	var order []CalendarProvider
+	var statuses []models.CalendarStatus
+
+	for _, id := range calendarIDs {
+		p := r.providerFor(ctx, id)
+		if p == nil {
+			statuses = append(statuses, models.CalendarStatus{CalendarID: id, Status: models.CalendarStatusError, Error: "calendar account is not linked"})
+			continue
+		}
+		if _, ok := groups[p]; !ok {
+			order = append(order, p)
+		}
+		groups[p] = append(groups[p], id)
+	}
+
+	var events []*models.CalendarEvent
+	for _, p := range order {
+		pEvents, pStatuses, err := p.ListEvents(ctx, timeMin, timeMax, groups[p]...)
+		if err != nil {
+			for _, id := range groups[p] {
+				statuses = append(statuses, models.CalendarStatus{CalendarID: id, Status: models.CalendarStatusError, Error: err.Error()})
+			}
+			continue
+		}
+		events = append(events, pEvents...)
+		statuses = append(statuses, pStatuses...)
+	}
+
+	return events, statuses, nil
+}
+
+// defaultCalendars is what user sees without choosing calendars: local one and Google primary
+func (r *CalendarRouter) defaultCalendars(ctx context.Context) []string {
+	if r.googleAuthorized(ctx) {
+		return []string{LocalCalendarID, "primary"}
+	}
+	return []string{LocalCalendarID}
+}
+
+func (r *CalendarRouter) CreateEvent(ctx context.Context, calendarID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	p := r.providerFor(ctx, calendarID)
+	if p == nil {
+		return nil, fmt.Errorf("calendar %s is not linked", calendarID)
+	}
+	return p.CreateEvent(ctx, calendarID, event)
+}
+
+func (r *CalendarRouter) UpdateEvent(ctx context.Context, calendarID, eventID string, event models.EventRequest) (*models.CalendarEvent, error) {
+	p := r.providerFor(ctx, calendarID)
+	if p == nil {
+		return nil, fmt.Errorf("calendar %s is not linked", calendarID)
+	}
+	return p.UpdateEvent(ctx, calendarID, eventID, event)
+}
+
+func (r *CalendarRouter) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	p := r.providerFor(ctx, calendarID)
+	if p == nil {
+		return fmt.Errorf("calendar %s is not linked", calendarID)
+	}
+	return p.DeleteEvent(ctx, calendarID, eventID)
+}
+
+func (r *CalendarRouter) FreeBusy(ctx context.Context, timeMin, timeMax time.Time, calendarIDs ...string) ([]models.BusyPeriod, error) {
+	if len(calendarIDs) == 0 {
+		calendarIDs = r.defaultCalendars(ctx)
+	}
+
+	groups := make(map[CalendarProvider][]string)
+	for _, id := range calendarIDs {
+		if p := r.providerFor(ctx, id); p != nil {
+			groups[p] = append(groups[p], id)
+		}
+	}
+
+	var busy []models.BusyPeriod
+	for p, ids := range groups {
+		periods, err := p.FreeBusy(ctx, timeMin, timeMax, ids...)
+		if err != nil {
+			return nil, err
+		}
+		busy = append(busy, periods...)
+	}
+
+	return MergeBusy(busy), nil
+}
+
+// MergeBusy sorts periods and joins overlapping ones
+func MergeBusy(periods []models.BusyPeriod) []models.BusyPeriod {
+	if len(periods) == 0 {
+		return periods
+	}
+
+	sorted := append([]models.BusyPeriod(nil), periods...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	merged := []models.BusyPeriod{sorted[0]}
+	for _, p := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if !p.Start.After(last.End) {
+			if p.End.After(last.End) {
+				last.End = p.End
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// busyFromEvents turns timed events into merged busy periods clipped to window
+func busyFromEvents(events []*models.CalendarEvent, timeMin, timeMax time.Time) []models.BusyPeriod {
+	var busy []models.BusyPeriod
+	for _, e := range events {
+		if e.IsAllDay || e.Status == "cancelled" {
+			continue
+		}
+		start, end := e.Start, e.End
+		if start.Before(timeMin) {
+			start = timeMin
+		}
+		if end.After(timeMax) {
+			end = timeMax
+		}
+		if end.After(start) {
+			busy = append(busy, models.BusyPeriod{Start: start, End: end})
+		}
+	}
+	return MergeBusy(busy)
+}
+
+// CalendarPreview is a short text list of closest events for AI prompt
+func CalendarPreview(ctx context.Context, provider CalendarProvider, days int) string {
+	timeMin := time.Now().UTC()
+	timeMax := time.Now().AddDate(0, 0, days).UTC()
+	events, _, err := provider.ListEvents(ctx, timeMin, timeMax)
+	if err != nil {
+		return fmt.Sprintf("Error to load calendar: %v", err)
+	}
+
+	if len(events) == 0 {
+		return "No events"
+	}
+
+	var preview strings.Builder
+	preview.WriteString("Closest events:\n")
+
+	for _, event := range events {
+		start := event.Start.Format(time.RFC3339)
+		if event.IsAllDay {
+			start = event.Start.Format("2006-01-02")
+		}
+		preview.WriteString(fmt.Sprintf("- %s: %s\n", start, event.Summary))
+	}
+
+	return preview.String()
+}
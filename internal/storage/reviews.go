@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReviewStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewReviewStorage(pool *pgxpool.Pool) *ReviewStorage {
+	return &ReviewStorage{
+		pool: pool,
+	}
+}
+
+func (rs *ReviewStorage) SaveReview(ctx context.Context, review *models.WeeklyReview) error {
+	op := "internal/storage/reviews.go SaveReview"
+
+	statsJSON, err := json.Marshal(review.Stats)
+	if err != nil {
+		return fmt.Errorf("%s: failed to marshal stats: %w", op, err)
+	}
+
+	sql_query := `
+	INSERT INTO weekly_reviews (user_id, period_start, period_end, summary, went_well, slipped, suggestions, stats)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id, created_at
+	`
+
+	err = rs.pool.QueryRow(ctx, sql_query,
+		models.UserID(ctx),
+		review.PeriodStart,
+		review.PeriodEnd,
+		review.Summary,
+		review.WentWell,
+		review.Slipped,
+		review.Suggestions,
+		statsJSON,
+	).Scan(&review.ID, &review.CreatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save review: %w", op, err)
+	}
+	return nil
+}
+
+// ListReviews returns newest reviews first
+func (rs *ReviewStorage) ListReviews(ctx context.Context, limit int) ([]models.WeeklyReview, error) {
+	op := "internal/storage/reviews.go ListReviews"
+
+	sql_query := `
+	SELECT id, period_start, period_end, summary, went_well, slipped, suggestions, stats, created_at
+	FROM weekly_reviews
+	WHERE user_id = $2
+	ORDER BY created_at DESC
+	LIMIT $1
+	`
+
+	rows, err := rs.pool.Query(ctx, sql_query, limit, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list reviews: %w", op, err)
+	}
+	defer rows.Close()
+
+	reviews := []models.WeeklyReview{}
+	for rows.Next() {
+		var r models.WeeklyReview
+		var statsJSON []byte
+		if err := rows.Scan(&r.ID, &r.PeriodStart, &r.PeriodEnd, &r.Summary, &r.WentWell, &r.Slipped, &r.Suggestions, &statsJSON, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan review: %w", op, err)
+		}
+		if len(statsJSON) > 0 {
+			if err := json.Unmarshal(statsJSON, &r.Stats); err != nil {
+				log.Println("Failed to unmarshal stats in ", op, "with error: ", err)
+			}
+		}
+		reviews = append(reviews, r)
+	}
+
+	return reviews, rows.Err()
+}
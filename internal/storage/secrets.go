@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/secrets"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrSecretNotFound is returned for secret that was never set
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStorage keeps named application secrets encrypted with keyring
+type SecretStorage struct {
+	pool    *pgxpool.Pool
+	keyring *secrets.Keyring
+}
+
+func NewSecretStorage(pool *pgxpool.Pool, keyring *secrets.Keyring) *SecretStorage {
+	return &SecretStorage{
+		pool:    pool,
+		keyring: keyring,
+	}
+}
+
+func (ss *SecretStorage) Get(ctx context.Context, name string) (string, error) {
+	op := "internal/storage/secrets.go Get"
+
+	var value []byte
+	var keyID string
+	err := ss.pool.QueryRow(ctx, `SELECT value, key_id FROM secrets WHERE name = $1`, name).Scan(&value, &keyID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return "", fmt.Errorf("%s: failed to get secret: %w", op, err)
+	}
+
+	plain, err := ss.keyring.Decrypt(keyID, value, secretAAD(name))
+	if err != nil {
+		return "", fmt.Errorf("%s: secret %s: %w", op, name, err)
+	}
+	return string(plain), nil
+}
+
+func (ss *SecretStorage) Put(ctx context.Context, name, value string) error {
+	op := "internal/storage/secrets.go Put"
+
+	keyID, encrypted, err := ss.keyring.Encrypt([]byte(value), secretAAD(name))
+	if err != nil {
+		return fmt.Errorf("%s: failed to encrypt secret: %w", op, err)
+	}
+
+	sql_query := `
+	INSERT INTO secrets (name, value, key_id, updated_at) VALUES ($1, $2, $3, NOW())
+	ON CONFLICT (name) DO UPDATE SET
+	value = EXCLUDED.value,
+	key_id = EXCLUDED.key_id,
+	updated_at = NOW()
+	`
+
+	if _, err := ss.pool.Exec(ctx, sql_query, name, encrypted, keyID); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save secret: %w", op, err)
+	}
+	return nil
+}
+
+// Reencrypt moves secrets encrypted with old keys to current key, returns number of updated secrets
+func (ss *SecretStorage) Reencrypt(ctx context.Context) (int, error) {
+	op := "internal/storage/secrets.go Reencrypt"
+
+	rows, err := ss.pool.Query(ctx, `SELECT name FROM secrets WHERE key_id <> $1`, ss.keyring.CurrentKeyID())
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return 0, fmt.Errorf("%s: failed to list secrets: %w", op, err)
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: failed to scan secret: %w", op, err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: failed to list secrets: %w", op, err)
+	}
+
+	var updated int
+	for _, name := range names {
+		// secret under removed key stays as is, it has to be set again
+		value, err := ss.Get(ctx, name)
+		if err != nil {
+			log.Printf("Skip secret %s in %s: %v", name, op, err)
+			continue
+		}
+		if err := ss.Put(ctx, name, value); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+func secretAAD(name string) []byte {
+	return []byte("secret:" + name)
+}
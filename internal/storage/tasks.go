@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTodoNotFound = errors.New("to-do task not found")
+
+// TaskRemote is a to-do service tasks are mirrored to. GoogleTasksStorage talks to Google Tasks,
+// tests can pass a fake
+type TaskRemote interface {
+	// ListTasks returns tasks changed since updatedMin including completed and deleted ones,
+	// zero updatedMin means all tasks
+	ListTasks(ctx context.Context, updatedMin time.Time) ([]*models.RemoteTask, error)
+	CreateTask(ctx context.Context, task *models.Task) (string, error)
+	UpdateTask(ctx context.Context, remoteID string, task *models.Task) error
+	DeleteTask(ctx context.Context, remoteID string) error
+}
+
+// TaskStorage keeps tasks of the user from context, dirty flag and tombstones tell what is not pushed to remote yet
+type TaskStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewTaskStorage(pool *pgxpool.Pool) *TaskStorage {
+	return &TaskStorage{
+		pool: pool,
+	}
+}
+
+const taskColumns = `id, title, notes, due, priority, estimate_hours, status, tags, completed_at,
+	COALESCE(google_task_id, ''), dirty, deleted_at, created_at, updated_at`
+
+func (ts *TaskStorage) CreateTask(ctx context.Context, task *models.Task) error {
+	op := "internal/storage/tasks.go CreateTask"
+
+	sql_query := `
+	INSERT INTO tasks (title, notes, due, priority, estimate_hours, status, tags, completed_at, user_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING id, created_at, updated_at
+	`
+
+	err := ts.pool.QueryRow(ctx, sql_query,
+		task.Title,
+		task.Notes,
+		task.Due,
+		task.Priority,
+		task.EstimateHours,
+		task.Status,
+		task.Tags,
+		task.CompletedAt,
+		models.UserID(ctx),
+	).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save task: %w", op, err)
+	}
+	task.Dirty = true
+	return nil
+}
+
+func (ts *TaskStorage) GetTask(ctx context.Context, id int) (*models.Task, error) {
+	op := "internal/storage/tasks.go GetTask"
+
+	row := ts.pool.QueryRow(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, id, models.UserID(ctx))
+	task, err := scanTask(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTodoNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get task: %w", op, err)
+	}
+	return task, nil
+}
+
+// ListTasks returns open tasks first, then by due date (no date last) and priority
+func (ts *TaskStorage) ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	op := "internal/storage/tasks.go ListTasks"
+
+	sql_query := `
+	SELECT ` + taskColumns + `
+	FROM tasks
+	WHERE user_id = $4 AND deleted_at IS NULL
+		AND ($1 = '' OR status = $1)
+		AND ($2 = '' OR $2 = ANY(tags))
+		AND ($3::DATE IS NULL OR due <= $3)
+	ORDER BY status = 'done', due NULLS LAST,
+		CASE priority WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END, created_at
+	`
+
+	rows, err := ts.pool.Query(ctx, sql_query, filter.Status, filter.Tag, filter.DueBefore, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list tasks: %w", op, err)
+	}
+	return collectTasks(op, rows)
+}
+
+// UpdateTask saves all fields and marks task dirty for the next sync
+func (ts *TaskStorage) UpdateTask(ctx context.Context, task *models.Task) error {
+	op := "internal/storage/tasks.go UpdateTask"
+
+	sql_query := `
+	UPDATE tasks
+	SET title = $2, notes = $3, due = $4, priority = $5, estimate_hours = $6, status = $7, tags = $8,
+		completed_at = $9, dirty = TRUE, updated_at = NOW()
+	WHERE id = $1 AND user_id = $10 AND deleted_at IS NULL
+	RETURNING updated_at
+	`
+
+	err := ts.pool.QueryRow(ctx, sql_query,
+		task.ID,
+		task.Title,
+		task.Notes,
+		task.Due,
+		task.Priority,
+		task.EstimateHours,
+		task.Status,
+		task.Tags,
+		task.CompletedAt,
+		models.UserID(ctx),
+	).Scan(&task.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTodoNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to update task: %w", op, err)
+	}
+	task.Dirty = true
+	return nil
+}
+
+// DeleteTask removes task that was never synced, synced ones stay as tombstone until
+// the deletion is pushed
+func (ts *TaskStorage) DeleteTask(ctx context.Context, id int) error {
+	op := "internal/storage/tasks.go DeleteTask"
+
+	tag, err := ts.pool.Exec(ctx, `DELETE FROM tasks WHERE id = $1 AND user_id = $2 AND google_task_id IS NULL`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete task: %w", op, err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	tag, err = ts.pool.Exec(ctx, `
+	UPDATE tasks SET deleted_at = NOW(), dirty = TRUE, updated_at = NOW()
+	WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete task: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTodoNotFound
+	}
+	return nil
+}
+
+// ListDirty returns tasks changed since last push, tombstones included
+func (ts *TaskStorage) ListDirty(ctx context.Context) ([]*models.Task, error) {
+	op := "internal/storage/tasks.go ListDirty"
+
+	rows, err := ts.pool.Query(ctx, `SELECT `+taskColumns+` FROM tasks WHERE user_id = $1 AND dirty ORDER BY id`, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list dirty tasks: %w", op, err)
+	}
+	return collectTasks(op, rows)
+}
+
+// MarkPushed binds remote id and clears dirty flag unless task was changed after it was read
+func (ts *TaskStorage) MarkPushed(ctx context.Context, task *models.Task, remoteID string) error {
+	op := "internal/storage/tasks.go MarkPushed"
+
+	sql_query := `
+	UPDATE tasks
+	SET google_task_id = $2, dirty = updated_at <> $3
+	WHERE id = $1 AND user_id = $4
+	`
+
+	if _, err := ts.pool.Exec(ctx, sql_query, task.ID, remoteID, task.UpdatedAt, models.UserID(ctx)); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to mark task pushed: %w", op, err)
+	}
+	return nil
+}
+
+// PurgeTask removes tombstone after deletion was pushed
+func (ts *TaskStorage) PurgeTask(ctx context.Context, id int) error {
+	op := "internal/storage/tasks.go PurgeTask"
+
+	if _, err := ts.pool.Exec(ctx, `DELETE FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`, id, models.UserID(ctx)); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to purge task: %w", op, err)
+	}
+	return nil
+}
+
+// ApplyRemote inserts or updates task from remote, tasks with local changes are left alone.
+// Returns false when nothing was changed
+func (ts *TaskStorage) ApplyRemote(ctx context.Context, remote *models.RemoteTask) (bool, error) {
+	op := "internal/storage/tasks.go ApplyRemote"
+
+	status := models.TaskStatusTodo
+	if remote.Completed {
+		status = models.TaskStatusDone
+	}
+
+	sql_query := `
+	INSERT INTO tasks (title, notes, due, status, completed_at, google_task_id, dirty, user_id)
+	VALUES ($1, $2, $3, $4, $5, $6, FALSE, $7)
+	ON CONFLICT (user_id, google_task_id) DO UPDATE
+	SET title = EXCLUDED.title,
+		notes = EXCLUDED.notes,
+		due = EXCLUDED.due,
+		status = CASE
+			WHEN EXCLUDED.status = 'done' THEN 'done'
+			WHEN tasks.status = 'done' THEN 'todo'
+			ELSE tasks.status
+		END,
+		completed_at = EXCLUDED.completed_at,
+		updated_at = NOW()
+	WHERE NOT tasks.dirty AND tasks.deleted_at IS NULL
+		AND (tasks.title, tasks.notes, tasks.due, tasks.status = 'done')
+			IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.notes, EXCLUDED.due, EXCLUDED.status = 'done')
+	`
+
+	tag, err := ts.pool.Exec(ctx, sql_query, remote.Title, remote.Notes, remote.Due, status, remote.CompletedAt, remote.ID, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return false, fmt.Errorf("%s: failed to apply remote task: %w", op, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteRemoteDeleted removes task deleted on remote side, unless it has local changes
+func (ts *TaskStorage) DeleteRemoteDeleted(ctx context.Context, remoteID string) (bool, error) {
+	op := "internal/storage/tasks.go DeleteRemoteDeleted"
+
+	tag, err := ts.pool.Exec(ctx, `DELETE FROM tasks WHERE user_id = $2 AND google_task_id = $1 AND NOT dirty`, remoteID, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return false, fmt.Errorf("%s: failed to delete task: %w", op, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// SyncedAt is time of the last successful pull of the list, zero if never
+func (ts *TaskStorage) SyncedAt(ctx context.Context, listID string) (time.Time, error) {
+	op := "internal/storage/tasks.go SyncedAt"
+
+	var syncedAt time.Time
+	err := ts.pool.QueryRow(ctx, `SELECT synced_at FROM task_sync WHERE user_id = $2 AND list_id = $1`, listID, models.UserID(ctx)).Scan(&syncedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return time.Time{}, fmt.Errorf("%s: failed to get sync time: %w", op, err)
+	}
+	return syncedAt, nil
+}
+
+func (ts *TaskStorage) SetSyncedAt(ctx context.Context, listID string, syncedAt time.Time) error {
+	op := "internal/storage/tasks.go SetSyncedAt"
+
+	sql_query := `
+	INSERT INTO task_sync (user_id, list_id, synced_at) VALUES ($3, $1, $2)
+	ON CONFLICT (user_id, list_id) DO UPDATE SET synced_at = EXCLUDED.synced_at
+	`
+
+	if _, err := ts.pool.Exec(ctx, sql_query, listID, syncedAt, models.UserID(ctx)); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save sync time: %w", op, err)
+	}
+	return nil
+}
+
+func collectTasks(op string, rows pgx.Rows) ([]*models.Task, error) {
+	defer rows.Close()
+
+	tasks := []*models.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan task: %w", op, err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tasks, nil
+}
+
+func scanTask(row pgx.Row) (*models.Task, error) {
+	t := &models.Task{}
+	err := row.Scan(&t.ID, &t.Title, &t.Notes, &t.Due, &t.Priority, &t.EstimateHours, &t.Status, &t.Tags,
+		&t.CompletedAt, &t.GoogleTaskID, &t.Dirty, &t.DeletedAt, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"life_forge/internal/models"
+	"testing"
+)
+
+func TestApplyRemoteKeepsLocalChanges(t *testing.T) {
+	ts := NewTaskStorage(testPool(t))
+	ctx := models.WithUserID(context.Background(), 1)
+
+	task := &models.Task{Title: "Купить билеты", Priority: models.TaskPriorityMedium, Status: models.TaskStatusTodo, Tags: []string{}}
+	if err := ts.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if err := ts.MarkPushed(ctx, task, "g1"); err != nil {
+		t.Fatalf("MarkPushed: %v", err)
+	}
+	dirty, err := ts.ListDirty(ctx)
+	if err != nil || len(dirty) != 0 {
+		t.Fatalf("ListDirty() = %d tasks, %v, want none after push", len(dirty), err)
+	}
+
+	changed, err := ts.ApplyRemote(ctx, &models.RemoteTask{ID: "g1", Title: "Купить билеты на поезд", Completed: true})
+	if err != nil || !changed {
+		t.Fatalf("ApplyRemote() = %v, %v, want change of clean task", changed, err)
+	}
+	got, err := ts.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Title != "Купить билеты на поезд" || got.Status != models.TaskStatusDone || got.Priority != models.TaskPriorityMedium {
+		t.Fatalf("task = %+v, want remote title and status with local priority", got)
+	}
+
+	// the same remote state again is not a change
+	if changed, err := ts.ApplyRemote(ctx, &models.RemoteTask{ID: "g1", Title: "Купить билеты на поезд", Completed: true}); err != nil || changed {
+		t.Fatalf("repeated ApplyRemote() = %v, %v, want no change", changed, err)
+	}
+
+	got.Title = "Билеты и отель"
+	if err := ts.UpdateTask(ctx, got); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if changed, err := ts.ApplyRemote(ctx, &models.RemoteTask{ID: "g1", Title: "Чужая правка"}); err != nil || changed {
+		t.Fatalf("ApplyRemote() over local change = %v, %v, want skipped", changed, err)
+	}
+	if deleted, err := ts.DeleteRemoteDeleted(ctx, "g1"); err != nil || deleted {
+		t.Fatalf("DeleteRemoteDeleted() of changed task = %v, %v, want kept", deleted, err)
+	}
+
+	// push of a stale copy keeps the task dirty
+	if err := ts.MarkPushed(ctx, task, "g1"); err != nil {
+		t.Fatalf("MarkPushed: %v", err)
+	}
+	dirty, err = ts.ListDirty(ctx)
+	if err != nil || len(dirty) != 1 || dirty[0].Title != "Билеты и отель" {
+		t.Fatalf("ListDirty() = %+v, %v, want the locally changed task", dirty, err)
+	}
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const telegramLinkCodeTTL = 15 * time.Minute
+
+var (
+	// ErrLinkCodeNotFound is returned for unknown or expired link code
+	ErrLinkCodeNotFound  = errors.New("link code not found or expired")
+	ErrTelegramNotLinked = errors.New("telegram account is not linked")
+	ErrActionNotFound    = errors.New("telegram action not found")
+)
+
+type TelegramStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewTelegramStorage(pool *pgxpool.Pool) *TelegramStorage {
+	return &TelegramStorage{
+		pool: pool,
+	}
+}
+
+// CreateLinkCode returns one-time code, user sends "/start <code>" to the bot to link account
+func (ts *TelegramStorage) CreateLinkCode(ctx context.Context, userID int) (*models.TelegramLinkCode, error) {
+	op := "internal/storage/telegram.go CreateLinkCode"
+
+	raw := make([]byte, 9)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("%s: failed to generate code: %w", op, err)
+	}
+
+	link := &models.TelegramLinkCode{
+		Code:      base64.RawURLEncoding.EncodeToString(raw),
+		ExpiresAt: time.Now().Add(telegramLinkCodeTTL),
+	}
+	link.Command = "/start " + link.Code
+
+	sql_query := `
+	INSERT INTO telegram_link_codes (code, user_id, expires_at) VALUES ($1, $2, $3)
+	`
+
+	if _, err := ts.pool.Exec(ctx, sql_query, link.Code, userID, link.ExpiresAt); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to save code: %w", op, err)
+	}
+
+	if _, err := ts.pool.Exec(ctx, `DELETE FROM telegram_link_codes WHERE expires_at < NOW()`); err != nil {
+		log.Println("Failed to delete expired codes in ", op, "with error: ", err)
+	}
+	return link, nil
+}
+
+// LinkAccount spends link code and maps Telegram account to its user, relinking moves account to the new user
+func (ts *TelegramStorage) LinkAccount(ctx context.Context, code string, link *models.TelegramLink) error {
+	op := "internal/storage/telegram.go LinkAccount"
+
+	tx, err := ts.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	sql_query := `
+	DELETE FROM telegram_link_codes
+	WHERE code = $1 AND expires_at > NOW()
+	RETURNING user_id
+	`
+
+	err = tx.QueryRow(ctx, sql_query, code).Scan(&link.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrLinkCodeNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to use code: %w", op, err)
+	}
+
+	sql_query = `
+	INSERT INTO telegram_users (telegram_id, user_id, chat_id, username)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (telegram_id) DO UPDATE SET
+		user_id = EXCLUDED.user_id,
+		chat_id = EXCLUDED.chat_id,
+		username = EXCLUDED.username,
+		linked_at = NOW()
+	RETURNING linked_at
+	`
+
+	err = tx.QueryRow(ctx, sql_query, link.TelegramID, link.UserID, link.ChatID, link.Username).Scan(&link.LinkedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to link account: %w", op, err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetUserID returns LifeForge user of Telegram account or ErrTelegramNotLinked
+func (ts *TelegramStorage) GetUserID(ctx context.Context, telegramID int64) (int, error) {
+	op := "internal/storage/telegram.go GetUserID"
+
+	var userID int
+	err := ts.pool.QueryRow(ctx, `SELECT user_id FROM telegram_users WHERE telegram_id = $1`, telegramID).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrTelegramNotLinked
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return 0, fmt.Errorf("%s: failed to load link: %w", op, err)
+	}
+	return userID, nil
+}
+
+func (ts *TelegramStorage) ListLinks(ctx context.Context, userID int) ([]models.TelegramLink, error) {
+	op := "internal/storage/telegram.go ListLinks"
+
+	sql_query := `
+	SELECT telegram_id, user_id, chat_id, username, linked_at
+	FROM telegram_users
+	WHERE user_id = $1
+	ORDER BY linked_at
+	`
+
+	rows, err := ts.pool.Query(ctx, sql_query, userID)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list links: %w", op, err)
+	}
+	defer rows.Close()
+
+	links := []models.TelegramLink{}
+	for rows.Next() {
+		var l models.TelegramLink
+		if err := rows.Scan(&l.TelegramID, &l.UserID, &l.ChatID, &l.Username, &l.LinkedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan link: %w", op, err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+func (ts *TelegramStorage) Unlink(ctx context.Context, userID int, telegramID int64) error {
+	op := "internal/storage/telegram.go Unlink"
+
+	_, err := ts.pool.Exec(ctx, `DELETE FROM telegram_users WHERE user_id = $1 AND telegram_id = $2`, userID, telegramID)
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to unlink: %w", op, err)
+	}
+	return nil
+}
+
+func (ts *TelegramStorage) SaveAction(ctx context.Context, action *models.TelegramEventAction) error {
+	op := "internal/storage/telegram.go SaveAction"
+
+	sql_query := `
+	INSERT INTO telegram_event_actions (user_id, calendar_id, event_id)
+	VALUES ($1, $2, $3)
+	RETURNING id
+	`
+
+	err := ts.pool.QueryRow(ctx, sql_query, action.UserID, action.CalendarID, action.EventID).Scan(&action.ID)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save action: %w", op, err)
+	}
+	return nil
+}
+
+// TakeAction returns action and deletes it, so a button works only once
+func (ts *TelegramStorage) TakeAction(ctx context.Context, id, userID int) (*models.TelegramEventAction, error) {
+	op := "internal/storage/telegram.go TakeAction"
+
+	sql_query := `
+	DELETE FROM telegram_event_actions
+	WHERE id = $1 AND user_id = $2
+	RETURNING id, user_id, calendar_id, event_id
+	`
+
+	var a models.TelegramEventAction
+	err := ts.pool.QueryRow(ctx, sql_query, id, userID).Scan(&a.ID, &a.UserID, &a.CalendarID, &a.EventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrActionNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to take action: %w", op, err)
+	}
+	return &a, nil
+}
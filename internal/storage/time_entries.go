@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrTimeEntryNotFound = errors.New("time entry not found")
+	ErrNoRunningTimer    = errors.New("no running timer")
+)
+
+// TimeEntryStorage keeps tracked time, at most one entry of a user (the running timer) has no end
+type TimeEntryStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewTimeEntryStorage(pool *pgxpool.Pool) *TimeEntryStorage {
+	return &TimeEntryStorage{
+		pool: pool,
+	}
+}
+
+// hours of running entry are counted up to now
+const timeEntryColumns = `id, title, calendar_id, event_id, task_id, started_at, ended_at, note, source,
+	ROUND((EXTRACT(EPOCH FROM COALESCE(ended_at, NOW()) - started_at) / 3600)::NUMERIC, 2)::DOUBLE PRECISION,
+	created_at`
+
+// StartTimer stops running timer at entry start and starts entry in one transaction,
+// stopped entry is returned, nil if nothing was running
+func (ts *TimeEntryStorage) StartTimer(ctx context.Context, entry *models.TimeEntry) (*models.TimeEntry, error) {
+	op := "internal/storage/time_entries.go StartTimer"
+
+	tx, err := ts.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// timer started less than a second ago is stopped a second after its start to keep end after start
+	sql_query := `
+	UPDATE time_entries
+	SET ended_at = GREATEST($1, started_at + INTERVAL '1 second')
+	WHERE user_id = $2 AND ended_at IS NULL
+	RETURNING ` + timeEntryColumns
+
+	stopped, err := scanTimeEntry(tx.QueryRow(ctx, sql_query, entry.Start, models.UserID(ctx)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		stopped = nil
+	} else if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to stop running timer: %w", op, err)
+	}
+
+	sql_query = `
+	INSERT INTO time_entries (title, calendar_id, event_id, task_id, started_at, note, source, user_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(ctx, sql_query,
+		entry.Title,
+		entry.CalendarID,
+		entry.EventID,
+		entry.TaskID,
+		entry.Start,
+		entry.Note,
+		models.TimeSourceTimer,
+		models.UserID(ctx),
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to start timer: %w", op, err)
+	}
+	entry.End, entry.Source = nil, models.TimeSourceTimer
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit: %w", op, err)
+	}
+	return stopped, nil
+}
+
+// StopTimer ends running timer at the given time
+func (ts *TimeEntryStorage) StopTimer(ctx context.Context, at time.Time) (*models.TimeEntry, error) {
+	op := "internal/storage/time_entries.go StopTimer"
+
+	sql_query := `
+	UPDATE time_entries
+	SET ended_at = GREATEST($1, started_at + INTERVAL '1 second')
+	WHERE user_id = $2 AND ended_at IS NULL
+	RETURNING ` + timeEntryColumns
+
+	entry, err := scanTimeEntry(ts.pool.QueryRow(ctx, sql_query, at, models.UserID(ctx)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNoRunningTimer
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to stop timer: %w", op, err)
+	}
+	return entry, nil
+}
+
+// RunningTimer returns running entry, nil if there is none
+func (ts *TimeEntryStorage) RunningTimer(ctx context.Context) (*models.TimeEntry, error) {
+	op := "internal/storage/time_entries.go RunningTimer"
+
+	row := ts.pool.QueryRow(ctx, `SELECT `+timeEntryColumns+` FROM time_entries WHERE user_id = $1 AND ended_at IS NULL`, models.UserID(ctx))
+	entry, err := scanTimeEntry(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get running timer: %w", op, err)
+	}
+	return entry, nil
+}
+
+// CreateEntry saves manual log, it always has an end
+func (ts *TimeEntryStorage) CreateEntry(ctx context.Context, entry *models.TimeEntry) error {
+	op := "internal/storage/time_entries.go CreateEntry"
+
+	sql_query := `
+	INSERT INTO time_entries (title, calendar_id, event_id, task_id, started_at, ended_at, note, source, user_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING ` + timeEntryColumns
+
+	created, err := scanTimeEntry(ts.pool.QueryRow(ctx, sql_query,
+		entry.Title,
+		entry.CalendarID,
+		entry.EventID,
+		entry.TaskID,
+		entry.Start,
+		entry.End,
+		entry.Note,
+		models.TimeSourceManual,
+		models.UserID(ctx),
+	))
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save time entry: %w", op, err)
+	}
+	*entry = *created
+	return nil
+}
+
+func (ts *TimeEntryStorage) GetEntry(ctx context.Context, id int) (*models.TimeEntry, error) {
+	op := "internal/storage/time_entries.go GetEntry"
+
+	entry, err := scanTimeEntry(ts.pool.QueryRow(ctx, `SELECT `+timeEntryColumns+` FROM time_entries WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTimeEntryNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get time entry: %w", op, err)
+	}
+	return entry, nil
+}
+
+// ListEntries returns entries overlapping [from, to), running one included, oldest first
+func (ts *TimeEntryStorage) ListEntries(ctx context.Context, from, to time.Time) ([]*models.TimeEntry, error) {
+	op := "internal/storage/time_entries.go ListEntries"
+
+	sql_query := `
+	SELECT ` + timeEntryColumns + `
+	FROM time_entries
+	WHERE user_id = $3 AND started_at < $2 AND COALESCE(ended_at, NOW()) > $1
+	ORDER BY started_at
+	`
+
+	rows, err := ts.pool.Query(ctx, sql_query, from, to, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list time entries: %w", op, err)
+	}
+	defer rows.Close()
+
+	entries := []*models.TimeEntry{}
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan time entry: %w", op, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
+
+// UpdateEntry saves all editable fields, running entry stays running while End is nil
+func (ts *TimeEntryStorage) UpdateEntry(ctx context.Context, entry *models.TimeEntry) error {
+	op := "internal/storage/time_entries.go UpdateEntry"
+
+	sql_query := `
+	UPDATE time_entries
+	SET title = $2, calendar_id = $3, event_id = $4, task_id = $5, started_at = $6, ended_at = $7, note = $8
+	WHERE id = $1 AND user_id = $9
+	RETURNING ` + timeEntryColumns
+
+	updated, err := scanTimeEntry(ts.pool.QueryRow(ctx, sql_query,
+		entry.ID,
+		entry.Title,
+		entry.CalendarID,
+		entry.EventID,
+		entry.TaskID,
+		entry.Start,
+		entry.End,
+		entry.Note,
+		models.UserID(ctx),
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTimeEntryNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to update time entry: %w", op, err)
+	}
+	*entry = *updated
+	return nil
+}
+
+func (ts *TimeEntryStorage) DeleteEntry(ctx context.Context, id int) error {
+	op := "internal/storage/time_entries.go DeleteEntry"
+
+	tag, err := ts.pool.Exec(ctx, `DELETE FROM time_entries WHERE id = $1 AND user_id = $2`, id, models.UserID(ctx))
+	if err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete time entry: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTimeEntryNotFound
+	}
+	return nil
+}
+
+func scanTimeEntry(row pgx.Row) (*models.TimeEntry, error) {
+	e := &models.TimeEntry{}
+	err := row.Scan(&e.ID, &e.Title, &e.CalendarID, &e.EventID, &e.TaskID, &e.Start, &e.End, &e.Note, &e.Source,
+		&e.Hours, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrUserNotFound         = errors.New("user not found")
+	ErrEmailTaken           = errors.New("user with this email already exists")
+	ErrLoginSessionNotFound = errors.New("login session not found or expired")
+)
+
+// UserStorage keeps accounts and their login sessions
+type UserStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewUserStorage(pool *pgxpool.Pool) *UserStorage {
+	return &UserStorage{
+		pool: pool,
+	}
+}
+
+// CreateUser saves account, emails are unique ignoring case
+func (us *UserStorage) CreateUser(ctx context.Context, user *models.User, passwordHash string) error {
+	op := "internal/storage/users.go CreateUser"
+
+	sql_query := `
+	INSERT INTO users (email, name, password_hash) VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING
+	RETURNING id, created_at
+	`
+
+	err := us.pool.QueryRow(ctx, sql_query, user.Email, user.Name, passwordHash).Scan(&user.ID, &user.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to save user: %w", op, err)
+	}
+	return nil
+}
+
+// GetUserByEmail returns user with password hash to check login
+func (us *UserStorage) GetUserByEmail(ctx context.Context, email string) (*models.User, string, error) {
+	op := "internal/storage/users.go GetUserByEmail"
+
+	sql_query := `
+	SELECT id, email, name, created_at, password_hash FROM users
+	WHERE LOWER(email) = LOWER($1)
+	`
+
+	var user models.User
+	var passwordHash string
+	err := us.pool.QueryRow(ctx, sql_query, email).Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &passwordHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", ErrUserNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, "", fmt.Errorf("%s: failed to get user: %w", op, err)
+	}
+	return &user, passwordHash, nil
+}
+
+func (us *UserStorage) GetUser(ctx context.Context, id int) (*models.User, error) {
+	op := "internal/storage/users.go GetUser"
+
+	var user models.User
+	err := us.pool.QueryRow(ctx, `SELECT id, email, name, created_at FROM users WHERE id = $1`, id).
+		Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get user: %w", op, err)
+	}
+	return &user, nil
+}
+
+// ListUserIDs is used by background jobs that run for every user
+func (us *UserStorage) ListUserIDs(ctx context.Context) ([]int, error) {
+	op := "internal/storage/users.go ListUserIDs"
+
+	rows, err := us.pool.Query(ctx, `SELECT id FROM users ORDER BY id`)
+	if err != nil {
+		log.Println("Error with Query method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to list users: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan user: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CreateSession generates session token, only its hash is stored, the token itself lives in user's cookie
+func (us *UserStorage) CreateSession(ctx context.Context, userID int, expiresAt time.Time) (string, error) {
+	op := "internal/storage/users.go CreateSession"
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("%s: failed to generate token: %w", op, err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	sql_query := `
+	INSERT INTO sessions (token_hash, user_id, expires_at) VALUES ($1, $2, $3)
+	`
+
+	if _, err := us.pool.Exec(ctx, sql_query, hashToken(token), userID, expiresAt); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return "", fmt.Errorf("%s: failed to save session: %w", op, err)
+	}
+
+	if _, err := us.pool.Exec(ctx, `DELETE FROM sessions WHERE expires_at < NOW()`); err != nil {
+		log.Println("Failed to delete expired sessions in ", op, "with error: ", err)
+	}
+	return token, nil
+}
+
+// GetSessionUser returns owner of live session
+func (us *UserStorage) GetSessionUser(ctx context.Context, token string) (*models.User, error) {
+	op := "internal/storage/users.go GetSessionUser"
+
+	sql_query := `
+	SELECT u.id, u.email, u.name, u.created_at
+	FROM sessions s JOIN users u ON u.id = s.user_id
+	WHERE s.token_hash = $1 AND s.expires_at > NOW()
+	`
+
+	var user models.User
+	err := us.pool.QueryRow(ctx, sql_query, hashToken(token)).Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrLoginSessionNotFound
+	}
+	if err != nil {
+		log.Println("Error with QueryRow method in ", op, " with error: ", err)
+		return nil, fmt.Errorf("%s: failed to get session: %w", op, err)
+	}
+	return &user, nil
+}
+
+func (us *UserStorage) DeleteSession(ctx context.Context, token string) error {
+	op := "internal/storage/users.go DeleteSession"
+
+	if _, err := us.pool.Exec(ctx, `DELETE FROM sessions WHERE token_hash = $1`, hashToken(token)); err != nil {
+		log.Println("Error with Exec method in ", op, " with error: ", err)
+		return fmt.Errorf("%s: failed to delete session: %w", op, err)
+	}
+	return nil
+}
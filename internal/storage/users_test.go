@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"life_forge/internal/models"
+	"testing"
+	"time"
+)
+
+func TestUserSessions(t *testing.T) {
+	us := NewUserStorage(testPool(t))
+	ctx := context.Background()
+
+	user := &models.User{Email: "Me@Example.com", Name: "Me"}
+	if err := us.CreateUser(ctx, user, "hash"); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := us.CreateUser(ctx, &models.User{Email: "me@example.COM"}, "other"); !errors.Is(err, ErrEmailTaken) {
+		t.Fatalf("CreateUser() with the same email = %v, want ErrEmailTaken", err)
+	}
+	got, hash, err := us.GetUserByEmail(ctx, "ME@example.com")
+	if err != nil || got.ID != user.ID || hash != "hash" {
+		t.Fatalf("GetUserByEmail() = %+v, %q, %v, want user %d", got, hash, err, user.ID)
+	}
+	if _, _, err := us.GetUserByEmail(ctx, "nobody@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetUserByEmail() of unknown email = %v, want ErrUserNotFound", err)
+	}
+
+	token, err := us.CreateSession(ctx, user.ID, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if got, err := us.GetSessionUser(ctx, token); err != nil || got.ID != user.ID {
+		t.Fatalf("GetSessionUser() = %+v, %v, want user %d", got, err, user.ID)
+	}
+	if _, err := us.GetSessionUser(ctx, token+"x"); !errors.Is(err, ErrLoginSessionNotFound) {
+		t.Fatalf("GetSessionUser() of unknown token = %v, want ErrLoginSessionNotFound", err)
+	}
+
+	expired, err := us.CreateSession(ctx, user.ID, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if _, err := us.GetSessionUser(ctx, expired); !errors.Is(err, ErrLoginSessionNotFound) {
+		t.Fatalf("GetSessionUser() of expired session = %v, want ErrLoginSessionNotFound", err)
+	}
+
+	if err := us.DeleteSession(ctx, token); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	if _, err := us.GetSessionUser(ctx, token); !errors.Is(err, ErrLoginSessionNotFound) {
+		t.Fatalf("GetSessionUser() after logout = %v, want ErrLoginSessionNotFound", err)
+	}
+}
+
+func TestDataScopedByUser(t *testing.T) {
+	ts := NewTaskStorage(testPool(t))
+	owner := models.WithUserID(context.Background(), 1)
+	stranger := models.WithUserID(context.Background(), 2)
+
+	task := &models.Task{Title: "Личное", Priority: models.TaskPriorityLow, Status: models.TaskStatusTodo, Tags: []string{}}
+	if err := ts.CreateTask(owner, task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	if _, err := ts.GetTask(stranger, task.ID); !errors.Is(err, ErrTodoNotFound) {
+		t.Fatalf("GetTask() by another user = %v, want ErrTodoNotFound", err)
+	}
+	if tasks, err := ts.ListTasks(stranger, models.TaskFilter{}); err != nil || len(tasks) != 0 {
+		t.Fatalf("ListTasks() by another user = %d tasks, %v, want none", len(tasks), err)
+	}
+	if err := ts.DeleteTask(stranger, task.ID); !errors.Is(err, ErrTodoNotFound) {
+		t.Fatalf("DeleteTask() by another user = %v, want ErrTodoNotFound", err)
+	}
+	if _, err := ts.GetTask(owner, task.ID); err != nil {
+		t.Fatalf("GetTask() by owner: %v", err)
+	}
+}
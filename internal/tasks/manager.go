@@ -0,0 +1,329 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	maxTags     = 10
+	maxEstimate = 1000
+	syncTimeout = 10 * time.Second
+)
+
+var (
+	// ErrInvalidTask is returned for empty title, unknown priority or status and negative estimate
+	ErrInvalidTask = errors.New("invalid task")
+	// ErrSyncDisabled is returned by Sync when Google Tasks sync is off
+	ErrSyncDisabled = errors.New("tasks sync is disabled")
+)
+
+// Manager keeps to-dos and, with remote set, mirrors them to a Google Tasks list
+type Manager struct {
+	taskStorage *storage.TaskStorage
+	remote      storage.TaskRemote // nil when sync is off
+	listID      string
+}
+
+func NewManager(ts *storage.TaskStorage, remote storage.TaskRemote, listID string) *Manager {
+	return &Manager{
+		taskStorage: ts,
+		remote:      remote,
+		listID:      listID,
+	}
+}
+
+// SyncEnabled tells if tasks are mirrored to Google Tasks
+func (m *Manager) SyncEnabled() bool {
+	return m.remote != nil
+}
+
+// Create validates task and fills defaults: medium priority, todo status
+func (m *Manager) Create(ctx context.Context, task *models.Task) (*models.Task, error) {
+	if task.Priority == "" {
+		task.Priority = models.TaskPriorityMedium
+	}
+	if task.Status == "" {
+		task.Status = models.TaskStatusTodo
+	}
+	if err := normalize(task); err != nil {
+		return nil, err
+	}
+	if task.Status == models.TaskStatusDone {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+
+	if err := m.taskStorage.CreateTask(ctx, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// CreateFromChat saves tasks the model found in the message, failed ones are only logged
+func (m *Manager) CreateFromChat(ctx context.Context, tasks []*models.Task) []*models.Task {
+	op := "internal/tasks/manager.go CreateFromChat"
+
+	created := []*models.Task{}
+	for _, task := range tasks {
+		// model may answer with unknown priority, it is not worth losing the task
+		if !validPriority(task.Priority) {
+			task.Priority = ""
+		}
+		if task.EstimateHours != nil && (*task.EstimateHours <= 0 || *task.EstimateHours > maxEstimate) {
+			task.EstimateHours = nil
+		}
+		t, err := m.Create(ctx, task)
+		if err != nil {
+			log.Printf("%s: task %q: %v", op, task.Title, err)
+			continue
+		}
+		created = append(created, t)
+	}
+	return created
+}
+
+// Update applies patch, status change sets or clears completion time
+func (m *Manager) Update(ctx context.Context, id int, patch models.TaskPatch) (*models.Task, error) {
+	task, err := m.taskStorage.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	wasDone := task.Status == models.TaskStatusDone
+
+	if patch.Title != nil {
+		task.Title = *patch.Title
+	}
+	if patch.Notes != nil {
+		task.Notes = *patch.Notes
+	}
+	if patch.Due != nil {
+		if *patch.Due == "" {
+			task.Due = nil
+		} else {
+			due, err := time.Parse("2006-01-02", *patch.Due)
+			if err != nil {
+				return nil, fmt.Errorf("%w: due must be YYYY-MM-DD", ErrInvalidTask)
+			}
+			task.Due = &due
+		}
+	}
+	if patch.Priority != nil {
+		task.Priority = *patch.Priority
+	}
+	if patch.EstimateHours != nil {
+		task.EstimateHours = patch.EstimateHours
+		// zero estimate clears it
+		if *patch.EstimateHours == 0 {
+			task.EstimateHours = nil
+		}
+	}
+	if patch.Status != nil {
+		task.Status = *patch.Status
+	}
+	if patch.Tags != nil {
+		task.Tags = *patch.Tags
+	}
+	if err := normalize(task); err != nil {
+		return nil, err
+	}
+
+	switch done := task.Status == models.TaskStatusDone; {
+	case done && !wasDone:
+		now := time.Now()
+		task.CompletedAt = &now
+	case !done:
+		task.CompletedAt = nil
+	}
+
+	if err := m.taskStorage.UpdateTask(ctx, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (m *Manager) Get(ctx context.Context, id int) (*models.Task, error) {
+	return m.taskStorage.GetTask(ctx, id)
+}
+
+func (m *Manager) List(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	if filter.Status != "" && !validStatus(filter.Status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidTask, filter.Status)
+	}
+	return m.taskStorage.ListTasks(ctx, filter)
+}
+
+// Delete removes task, synced one is removed from Google Tasks on the next sync
+func (m *Manager) Delete(ctx context.Context, id int) error {
+	return m.taskStorage.DeleteTask(ctx, id)
+}
+
+// Sync pushes local changes first, then pulls remote changes since the last sync.
+// Local changes win: remote edits of a task changed here are overwritten by the push
+func (m *Manager) Sync(ctx context.Context) (*models.TaskSyncResult, error) {
+	op := "internal/tasks/manager.go Sync"
+
+	if m.remote == nil {
+		return nil, ErrSyncDisabled
+	}
+	startedAt := time.Now()
+	result := &models.TaskSyncResult{}
+
+	dirty, err := m.taskStorage.ListDirty(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range dirty {
+		if err := m.push(ctx, task); err != nil {
+			log.Printf("%s: push task %d: %v", op, task.ID, err)
+			result.Failed++
+			continue
+		}
+		result.Pushed++
+	}
+
+	syncedAt, err := m.taskStorage.SyncedAt(ctx, m.listID)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := m.remote.ListTasks(ctx, syncedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for _, r := range remote {
+		var changed bool
+		if r.Deleted {
+			changed, err = m.taskStorage.DeleteRemoteDeleted(ctx, r.ID)
+			if changed {
+				result.Deleted++
+			}
+		} else {
+			changed, err = m.taskStorage.ApplyRemote(ctx, r)
+			if changed {
+				result.Pulled++
+			}
+		}
+		if err != nil {
+			log.Printf("%s: pull task %s: %v", op, r.ID, err)
+			result.Failed++
+		}
+	}
+
+	// next pull starts from the time this one started, changes made meanwhile are fetched again
+	if err := m.taskStorage.SetSyncedAt(ctx, m.listID, startedAt); err != nil {
+		return nil, err
+	}
+	result.SyncedAt = startedAt
+	return result, nil
+}
+
+// RunSync is the scheduled job
+func (m *Manager) RunSync(ctx context.Context) error {
+	result, err := m.Sync(ctx)
+	if err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("tasks sync: %d tasks failed", result.Failed)
+	}
+	return nil
+}
+
+func (m *Manager) push(ctx context.Context, task *models.Task) error {
+	pushCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+
+	switch {
+	case task.DeletedAt != nil:
+		if err := m.remote.DeleteTask(pushCtx, task.GoogleTaskID); err != nil {
+			return err
+		}
+		return m.taskStorage.PurgeTask(ctx, task.ID)
+
+	case task.GoogleTaskID == "":
+		remoteID, err := m.remote.CreateTask(pushCtx, task)
+		if err != nil {
+			return err
+		}
+		return m.taskStorage.MarkPushed(ctx, task, remoteID)
+
+	default:
+		if err := m.remote.UpdateTask(pushCtx, task.GoogleTaskID, task); err != nil {
+			return err
+		}
+		return m.taskStorage.MarkPushed(ctx, task, task.GoogleTaskID)
+	}
+}
+
+// normalize trims title and tags and checks enums
+func normalize(task *models.Task) error {
+	task.Title = strings.TrimSpace(task.Title)
+	task.Notes = strings.TrimSpace(task.Notes)
+	task.Priority = strings.ToLower(strings.TrimSpace(task.Priority))
+	task.Status = strings.ToLower(strings.TrimSpace(task.Status))
+
+	if task.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidTask)
+	}
+	if !validPriority(task.Priority) {
+		return fmt.Errorf("%w: priority must be low, medium or high", ErrInvalidTask)
+	}
+	if !validStatus(task.Status) {
+		return fmt.Errorf("%w: status must be todo, in_progress or done", ErrInvalidTask)
+	}
+	if task.EstimateHours != nil && (*task.EstimateHours < 0 || *task.EstimateHours > maxEstimate) {
+		return fmt.Errorf("%w: estimate must be between 0 and %d hours", ErrInvalidTask, maxEstimate)
+	}
+
+	tags := []string{}
+	for _, tag := range task.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxTags {
+		return fmt.Errorf("%w: at most %d tags", ErrInvalidTask, maxTags)
+	}
+	task.Tags = tags
+	return nil
+}
+
+func validPriority(priority string) bool {
+	switch priority {
+	case models.TaskPriorityLow, models.TaskPriorityMedium, models.TaskPriorityHigh:
+		return true
+	}
+	return false
+}
+
+func validStatus(status string) bool {
+	switch status {
+	case models.TaskStatusTodo, models.TaskStatusInProgress, models.TaskStatusDone:
+		return true
+	}
+	return false
+}
+
+// FormatTasks is chat confirmation of created tasks
+func FormatTasks(tasks []*models.Task) string {
+	var b strings.Builder
+	b.WriteString("📝 Задачи:")
+	for _, t := range tasks {
+		fmt.Fprintf(&b, "\n• %s", t.Title)
+		if t.Due != nil {
+			fmt.Fprintf(&b, " — до %s", t.Due.Format("02.01"))
+		}
+		if t.Priority == models.TaskPriorityHigh {
+			b.WriteString(" ❗")
+		}
+	}
+	return b.String()
+}
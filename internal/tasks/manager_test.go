@@ -0,0 +1,62 @@
+package tasks
+
+import (
+	"errors"
+	"life_forge/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	task := &models.Task{
+		Title:    "  Купить билеты ",
+		Priority: " High",
+		Status:   "TODO",
+		Tags:     []string{" Поездка", "поездка", "", "Семья"},
+	}
+	if err := normalize(task); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if task.Title != "Купить билеты" || task.Priority != models.TaskPriorityHigh || task.Status != models.TaskStatusTodo {
+		t.Fatalf("task = %+v", task)
+	}
+	if want := []string{"поездка", "семья"}; !reflect.DeepEqual(task.Tags, want) {
+		t.Fatalf("tags = %v, want %v", task.Tags, want)
+	}
+
+	estimate := func(v float64) *float64 { return &v }
+	tooManyTags := make([]string, maxTags+1)
+	for i := range tooManyTags {
+		tooManyTags[i] = string(rune('a' + i))
+	}
+	tests := []struct {
+		name string
+		task models.Task
+	}{
+		{"empty title", models.Task{Title: " ", Priority: "low", Status: "todo"}},
+		{"unknown priority", models.Task{Title: "x", Priority: "urgent", Status: "todo"}},
+		{"unknown status", models.Task{Title: "x", Priority: "low", Status: "later"}},
+		{"negative estimate", models.Task{Title: "x", Priority: "low", Status: "todo", EstimateHours: estimate(-1)}},
+		{"huge estimate", models.Task{Title: "x", Priority: "low", Status: "todo", EstimateHours: estimate(maxEstimate + 1)}},
+		{"too many tags", models.Task{Title: "x", Priority: "low", Status: "todo", Tags: tooManyTags}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := normalize(&tt.task); !errors.Is(err, ErrInvalidTask) {
+				t.Fatalf("normalize() = %v, want ErrInvalidTask", err)
+			}
+		})
+	}
+}
+
+func TestFormatTasks(t *testing.T) {
+	due := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	got := FormatTasks([]*models.Task{
+		{Title: "Купить билеты", Due: &due, Priority: models.TaskPriorityHigh},
+		{Title: "Позвонить маме", Priority: models.TaskPriorityMedium},
+	})
+	if want := "📝 Задачи:\n• Купить билеты — до 20.03 ❗\n• Позвонить маме"; got != want {
+		t.Fatalf("FormatTasks() = %q, want %q", got, want)
+	}
+}
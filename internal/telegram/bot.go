@@ -0,0 +1,355 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/chat"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/tasks"
+	"life_forge/internal/usecases"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxMessageRunes = 4096
+	updateWorkers   = 4
+	updateTimeout   = 2 * time.Minute
+	retryDelay      = 5 * time.Second
+	deletePrefix    = "del:"
+	planPrefix      = "plan:"
+
+	helpText = "Привет! Я LifeForge: пишите, что нужно запланировать, а я добавлю события в календарь.\n" +
+		"Например: «тренировка завтра в 19:00 на час» или «подведи итоги недели»."
+	notLinkedText = "Этот Telegram ещё не привязан к LifeForge. Получите код в приложении (POST /api/telegram/link) и отправьте мне /start <код>."
+)
+
+// Assistant answers chat messages, implemented by chat.Assistant
+type Assistant interface {
+	Reply(ctx context.Context, message string) (*chat.Reply, error)
+}
+
+// PlanAcceptor turns drafted goal plan into goal with sessions, implemented by goals.Planner
+type PlanAcceptor interface {
+	Accept(ctx context.Context, planID int, calendarID string) (*models.Goal, error)
+}
+
+// LinkStorage keeps linked Telegram accounts and actions behind buttons, implemented by storage.TelegramStorage
+type LinkStorage interface {
+	LinkAccount(ctx context.Context, code string, link *models.TelegramLink) error
+	GetUserID(ctx context.Context, telegramID int64) (int, error)
+	SaveAction(ctx context.Context, action *models.TelegramEventAction) error
+	TakeAction(ctx context.Context, id, userID int) (*models.TelegramEventAction, error)
+}
+
+// EventDeleter removes events of delete buttons, implemented by storage.CalendarProvider
+type EventDeleter interface {
+	DeleteEvent(ctx context.Context, calendarID, eventID string) error
+}
+
+// Bot answers Telegram messages through the same chat pipeline as /chat
+type Bot struct {
+	client           *Client
+	assistant        Assistant
+	planner          PlanAcceptor
+	telegramStorage  LinkStorage
+	calendarProvider EventDeleter
+	location         *time.Location
+}
+
+func NewBot(client *Client, assistant Assistant, planner PlanAcceptor, ts LinkStorage, cp EventDeleter, location *time.Location) *Bot {
+	return &Bot{
+		client:           client,
+		assistant:        assistant,
+		planner:          planner,
+		telegramStorage:  ts,
+		calendarProvider: cp,
+		location:         location,
+	}
+}
+
+// Run long-polls updates until ctx is done and waits for updates in progress
+func (b *Bot) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	workers := make(chan struct{}, updateWorkers)
+	var offset int64
+
+	for {
+		updates, err := b.client.GetUpdates(ctx, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Telegram polling failed: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+
+			select {
+			case <-ctx.Done():
+				return
+			case workers <- struct{}{}:
+			}
+			wg.Add(1)
+			go func(update Update) {
+				defer func() {
+					<-workers
+					wg.Done()
+				}()
+				// update is already confirmed by offset, finish it even if shutdown started
+				updateCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), updateTimeout)
+				defer cancel()
+				b.handleUpdate(updateCtx, update)
+			}(update)
+		}
+	}
+}
+
+func (b *Bot) handleUpdate(ctx context.Context, update Update) {
+	switch {
+	case update.Message != nil:
+		b.handleMessage(ctx, update.Message)
+	case update.CallbackQuery != nil:
+		b.handleCallback(ctx, update.CallbackQuery)
+	}
+}
+
+func (b *Bot) handleMessage(ctx context.Context, msg *Message) {
+	op := "internal/telegram/bot.go handleMessage"
+
+	if msg.From == nil || strings.TrimSpace(msg.Text) == "" {
+		return
+	}
+	text := strings.TrimSpace(msg.Text)
+
+	if command, payload, ok := parseCommand(text); ok {
+		switch command {
+		case "/start":
+			if payload != "" {
+				b.link(ctx, msg, payload)
+				return
+			}
+			b.send(ctx, msg.Chat.ID, helpText, nil)
+			return
+		case "/help":
+			b.send(ctx, msg.Chat.ID, helpText, nil)
+			return
+		}
+	}
+
+	userID, err := b.telegramStorage.GetUserID(ctx, msg.From.ID)
+	if errors.Is(err, storage.ErrTelegramNotLinked) {
+		b.send(ctx, msg.Chat.ID, notLinkedText, nil)
+		return
+	}
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		b.send(ctx, msg.Chat.ID, "Что-то пошло не так, попробуйте позже.", nil)
+		return
+	}
+	ctx = models.WithUserID(ctx, userID)
+
+	reply, err := b.assistant.Reply(ctx, text)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		b.send(ctx, msg.Chat.ID, "AI сейчас недоступен, попробуйте позже.", nil)
+		return
+	}
+
+	answer := reply.Text
+	if answer == "" {
+		answer = "✅ Готово!"
+	}
+	if len(reply.Created) > 0 {
+		answer += "\n\n📅 Созданы события:"
+		for _, e := range reply.Created {
+			answer += "\n• " + b.formatEvent(e)
+		}
+	}
+
+	if len(reply.Tasks) > 0 {
+		answer += "\n\n" + tasks.FormatTasks(reply.Tasks)
+	}
+	if reply.Context != nil {
+		answer += "\n\n🎯 Цели обновлены: " + strings.Join(reply.Context.Goals, ", ")
+	}
+	for _, h := range reply.Tracked {
+		answer += "\n\n📌 Привычка «" + h.Title + "» добавлена в трекер"
+	}
+	for _, h := range reply.CheckIns {
+		answer += "\n\n🔥 " + h.Title + ": " + usecases.HabitProgress(h.Stats)
+	}
+
+	markup := b.eventButtons(ctx, userID, reply.Created)
+	if reply.Plan != nil {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []InlineKeyboardButton{{
+			Text:         "✅ Принять план",
+			CallbackData: planPrefix + strconv.Itoa(reply.Plan.ID),
+		}})
+	}
+	b.send(ctx, msg.Chat.ID, answer, markup)
+}
+
+// link spends code from /start <code> and maps this Telegram account to user of the code
+func (b *Bot) link(ctx context.Context, msg *Message, code string) {
+	op := "internal/telegram/bot.go link"
+
+	link := &models.TelegramLink{
+		TelegramID: msg.From.ID,
+		ChatID:     msg.Chat.ID,
+		Username:   msg.From.Username,
+	}
+	err := b.telegramStorage.LinkAccount(ctx, code, link)
+	if errors.Is(err, storage.ErrLinkCodeNotFound) {
+		b.send(ctx, msg.Chat.ID, "Код не найден или устарел, получите новый в приложении.", nil)
+		return
+	}
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		b.send(ctx, msg.Chat.ID, "Не удалось привязать аккаунт, попробуйте позже.", nil)
+		return
+	}
+	b.send(ctx, msg.Chat.ID, "✅ Telegram привязан к LifeForge.\n\n"+helpText, nil)
+}
+
+func (b *Bot) handleCallback(ctx context.Context, query *CallbackQuery) {
+	op := "internal/telegram/bot.go handleCallback"
+
+	var answer string
+	if strings.HasPrefix(query.Data, planPrefix) {
+		answer = b.acceptPlan(ctx, query)
+	} else {
+		answer = b.deleteByButton(ctx, query)
+	}
+	if err := b.client.AnswerCallbackQuery(ctx, query.ID, answer); err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+}
+
+func (b *Bot) deleteByButton(ctx context.Context, query *CallbackQuery) string {
+	op := "internal/telegram/bot.go deleteByButton"
+
+	id, err := strconv.Atoi(strings.TrimPrefix(query.Data, deletePrefix))
+	if !strings.HasPrefix(query.Data, deletePrefix) || err != nil {
+		return "Неизвестная команда"
+	}
+
+	userID, err := b.telegramStorage.GetUserID(ctx, query.From.ID)
+	if err != nil {
+		return "Telegram не привязан к LifeForge"
+	}
+
+	action, err := b.telegramStorage.TakeAction(ctx, id, userID)
+	if errors.Is(err, storage.ErrActionNotFound) {
+		return "Событие уже удалено"
+	}
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return "Не удалось удалить событие"
+	}
+
+	ctx = models.WithUserID(ctx, userID)
+	if err := b.calendarProvider.DeleteEvent(ctx, action.CalendarID, action.EventID); err != nil {
+		log.Printf("%s: %v", op, err)
+		return "Не удалось удалить событие"
+	}
+	return "🗑 Событие удалено"
+}
+
+// acceptPlan creates goal and its sessions in default calendar from plan:<id> button
+func (b *Bot) acceptPlan(ctx context.Context, query *CallbackQuery) string {
+	op := "internal/telegram/bot.go acceptPlan"
+
+	id, err := strconv.Atoi(strings.TrimPrefix(query.Data, planPrefix))
+	if err != nil {
+		return "Неизвестная команда"
+	}
+
+	userID, err := b.telegramStorage.GetUserID(ctx, query.From.ID)
+	if err != nil {
+		return "Telegram не привязан к LifeForge"
+	}
+
+	goal, err := b.planner.Accept(models.WithUserID(ctx, userID), id, "")
+	if errors.Is(err, storage.ErrPlanAlreadyAccepted) {
+		return "План уже принят"
+	}
+	if errors.Is(err, storage.ErrPlanNotFound) {
+		return "План не найден"
+	}
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return "Не удалось принять план"
+	}
+	return fmt.Sprintf("✅ Цель «%s»: запланировано занятий — %d", goal.Title, len(goal.Sessions))
+}
+
+// eventButtons adds "open" and "delete" buttons per created event
+func (b *Bot) eventButtons(ctx context.Context, userID int, events []*models.CalendarEvent) *InlineKeyboardMarkup {
+	op := "internal/telegram/bot.go eventButtons"
+
+	markup := &InlineKeyboardMarkup{}
+	for _, e := range events {
+		action := &models.TelegramEventAction{UserID: userID, CalendarID: e.CalendarID, EventID: e.ID}
+		if err := b.telegramStorage.SaveAction(ctx, action); err != nil {
+			log.Printf("%s: %v", op, err)
+			continue
+		}
+
+		row := []InlineKeyboardButton{{
+			Text:         "🗑 " + truncate(e.Summary, 30),
+			CallbackData: deletePrefix + strconv.Itoa(action.ID),
+		}}
+		if e.HTMLLink != "" {
+			row = append(row, InlineKeyboardButton{Text: "📅 Открыть", URL: e.HTMLLink})
+		}
+		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+	}
+	return markup
+}
+
+func (b *Bot) formatEvent(e *models.CalendarEvent) string {
+	if e.IsAllDay {
+		return fmt.Sprintf("%s, %s (весь день)", e.Summary, e.Start.In(b.location).Format("02.01"))
+	}
+	start := e.Start.In(b.location)
+	return fmt.Sprintf("%s, %s %s–%s", e.Summary, start.Format("02.01"), start.Format("15:04"), e.End.In(b.location).Format("15:04"))
+}
+
+func (b *Bot) send(ctx context.Context, chatID int64, text string, markup *InlineKeyboardMarkup) {
+	if err := b.client.SendMessage(ctx, chatID, truncate(text, maxMessageRunes), markup); err != nil {
+		log.Printf("internal/telegram/bot.go send: %v", err)
+	}
+}
+
+// parseCommand splits "/start@bot payload" into command and payload
+func parseCommand(text string) (string, string, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+	command, payload, _ := strings.Cut(text, " ")
+	command, _, _ = strings.Cut(command, "@")
+	return strings.ToLower(command), strings.TrimSpace(payload), true
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
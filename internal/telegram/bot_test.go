@@ -0,0 +1,258 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"life_forge/internal/chat"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testToken = "123:secret"
+
+// fakeBotAPI serves getUpdates once with given updates, later polls hang until client gives up
+type fakeBotAPI struct {
+	mu       sync.Mutex
+	updates  []Update
+	offsets  []int64
+	sent     []map[string]any
+	answered []map[string]any
+	done     chan struct{} // closed on first sendMessage or answerCallbackQuery
+	once     sync.Once
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+testToken+"/")
+	if !ok || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var params map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	var result any = true
+	switch method {
+	case "getUpdates":
+		f.offsets = append(f.offsets, int64(params["offset"].(float64)))
+		if len(f.offsets) > 1 {
+			f.mu.Unlock()
+			<-r.Context().Done()
+			return
+		}
+		result = f.updates
+	case "sendMessage":
+		f.sent = append(f.sent, params)
+		f.once.Do(func() { close(f.done) })
+	case "answerCallbackQuery":
+		f.answered = append(f.answered, params)
+		f.once.Do(func() { close(f.done) })
+	default:
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "description": "Not Found: method not found"})
+		return
+	}
+	f.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+type fakeAssistant struct {
+	reply  *chat.Reply
+	userID int
+	text   string
+}
+
+func (a *fakeAssistant) Reply(ctx context.Context, message string) (*chat.Reply, error) {
+	a.userID, a.text = models.UserID(ctx), message
+	return a.reply, nil
+}
+
+type noPlans struct{}
+
+func (noPlans) Accept(ctx context.Context, planID int, calendarID string) (*models.Goal, error) {
+	return nil, storage.ErrPlanNotFound
+}
+
+// fakeLinks has Telegram user 42 linked to user 7
+type fakeLinks struct {
+	actions []*models.TelegramEventAction
+}
+
+func (l *fakeLinks) LinkAccount(ctx context.Context, code string, link *models.TelegramLink) error {
+	return storage.ErrLinkCodeNotFound
+}
+
+func (l *fakeLinks) GetUserID(ctx context.Context, telegramID int64) (int, error) {
+	if telegramID != 42 {
+		return 0, storage.ErrTelegramNotLinked
+	}
+	return 7, nil
+}
+
+func (l *fakeLinks) SaveAction(ctx context.Context, action *models.TelegramEventAction) error {
+	l.actions = append(l.actions, action)
+	action.ID = len(l.actions)
+	return nil
+}
+
+func (l *fakeLinks) TakeAction(ctx context.Context, id, userID int) (*models.TelegramEventAction, error) {
+	if id < 1 || id > len(l.actions) || l.actions[id-1] == nil || l.actions[id-1].UserID != userID {
+		return nil, storage.ErrActionNotFound
+	}
+	action := l.actions[id-1]
+	l.actions[id-1] = nil
+	return action, nil
+}
+
+type deletedEvent struct {
+	userID              int
+	calendarID, eventID string
+}
+
+type fakeDeleter struct {
+	deleted []deletedEvent
+}
+
+func (d *fakeDeleter) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	d.deleted = append(d.deleted, deletedEvent{models.UserID(ctx), calendarID, eventID})
+	return nil
+}
+
+// runBot runs bot against fake API until it answers once
+func runBot(t *testing.T, api *fakeBotAPI, bot func(*Client) *Bot) {
+	t.Helper()
+	api.done = make(chan struct{})
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		bot(NewClient(srv.URL+"/", testToken)).Run(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-api.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("bot did not answer")
+	}
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("bot did not stop")
+	}
+}
+
+func TestBotRepliesWithEventButtons(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	start := time.Date(2026, 3, 12, 19, 0, 0, 0, moscow)
+	assistant := &fakeAssistant{reply: &chat.Reply{
+		Text: "Добавил тренировку",
+		Created: []*models.CalendarEvent{{
+			ID: "ev1", CalendarID: "primary", Summary: "Тренировка",
+			Start: start, End: start.Add(time.Hour), HTMLLink: "https://calendar.google.com/event?eid=1",
+		}},
+	}}
+	links := &fakeLinks{}
+	api := &fakeBotAPI{updates: []Update{{
+		UpdateID: 100,
+		Message: &Message{
+			MessageID: 1, From: &User{ID: 42, Username: "ivan"}, Chat: Chat{ID: 500},
+			Text: " тренировка завтра в 19:00 ",
+		},
+	}}}
+
+	runBot(t, api, func(c *Client) *Bot {
+		return NewBot(c, assistant, noPlans{}, links, &fakeDeleter{}, moscow)
+	})
+
+	if assistant.userID != 7 || assistant.text != "тренировка завтра в 19:00" {
+		t.Fatalf("assistant got user %d and %q, want linked user and trimmed text", assistant.userID, assistant.text)
+	}
+	if len(api.offsets) < 2 || api.offsets[0] != 0 || api.offsets[1] != 101 {
+		t.Fatalf("getUpdates offsets = %v, want 0 then 101", api.offsets)
+	}
+
+	if len(api.sent) != 1 {
+		t.Fatalf("sent = %+v, want one reply", api.sent)
+	}
+	sent := api.sent[0]
+	if sent["chat_id"] != float64(500) {
+		t.Fatalf("chat_id = %v", sent["chat_id"])
+	}
+	text, _ := sent["text"].(string)
+	if !strings.HasPrefix(text, "Добавил тренировку") || !strings.Contains(text, "• Тренировка, 12.03 19:00–20:00") {
+		t.Fatalf("text = %q", text)
+	}
+
+	raw, err := json.Marshal(sent["reply_markup"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var markup InlineKeyboardMarkup
+	if err := json.Unmarshal(raw, &markup); err != nil {
+		t.Fatal(err)
+	}
+	want := []InlineKeyboardButton{
+		{Text: "🗑 Тренировка", CallbackData: "del:1"},
+		{Text: "📅 Открыть", URL: "https://calendar.google.com/event?eid=1"},
+	}
+	if len(markup.InlineKeyboard) != 1 || len(markup.InlineKeyboard[0]) != 2 ||
+		markup.InlineKeyboard[0][0] != want[0] || markup.InlineKeyboard[0][1] != want[1] {
+		t.Fatalf("keyboard = %+v, want %+v", markup.InlineKeyboard, want)
+	}
+	if len(links.actions) != 1 || links.actions[0].UserID != 7 || links.actions[0].EventID != "ev1" {
+		t.Fatalf("saved actions = %+v", links.actions)
+	}
+}
+
+func TestBotDeletesEventByButton(t *testing.T) {
+	links := &fakeLinks{actions: []*models.TelegramEventAction{{ID: 1, UserID: 7, CalendarID: "primary", EventID: "ev1"}}}
+	deleter := &fakeDeleter{}
+	api := &fakeBotAPI{updates: []Update{{
+		UpdateID:      200,
+		CallbackQuery: &CallbackQuery{ID: "cb1", From: User{ID: 42}, Data: "del:1"},
+	}}}
+
+	runBot(t, api, func(c *Client) *Bot {
+		return NewBot(c, &fakeAssistant{}, noPlans{}, links, deleter, time.UTC)
+	})
+
+	if len(deleter.deleted) != 1 || deleter.deleted[0] != (deletedEvent{7, "primary", "ev1"}) {
+		t.Fatalf("deleted = %+v", deleter.deleted)
+	}
+	if len(api.answered) != 1 || api.answered[0]["callback_query_id"] != "cb1" || api.answered[0]["text"] != "🗑 Событие удалено" {
+		t.Fatalf("answered = %+v", api.answered)
+	}
+}
+
+func TestBotAsksUnlinkedUserToLink(t *testing.T) {
+	api := &fakeBotAPI{updates: []Update{{
+		UpdateID: 300,
+		Message:  &Message{From: &User{ID: 99}, Chat: Chat{ID: 900}, Text: "привет"},
+	}}}
+	assistant := &fakeAssistant{}
+
+	runBot(t, api, func(c *Client) *Bot {
+		return NewBot(c, assistant, noPlans{}, &fakeLinks{}, &fakeDeleter{}, time.UTC)
+	})
+
+	if assistant.text != "" {
+		t.Fatal("message of unlinked user reached assistant")
+	}
+	if len(api.sent) != 1 || api.sent[0]["text"] != notLinkedText || api.sent[0]["reply_markup"] != nil {
+		t.Fatalf("sent = %+v, want link instructions", api.sent)
+	}
+}
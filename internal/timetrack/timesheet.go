@@ -0,0 +1,351 @@
+package timetrack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"life_forge/internal/models"
+	"life_forge/internal/storage"
+	"life_forge/internal/usecases"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	maxEntryHours   = 24
+	currentLookback = 12 * time.Hour
+)
+
+// ErrInvalidEntry is returned for bad times, unknown task or missing title
+var ErrInvalidEntry = errors.New("invalid time entry")
+
+var (
+	// startRequest matches "запусти таймер: отчёт", "начни таймер" and keeps the title
+	startRequest = regexp.MustCompile(`(?is)^\s*(?:запусти|начни|включи|старт)\s+таймер\s*[:,.\-—]?\s*(.*)$`)
+	stopRequest  = regexp.MustCompile(`(?i)^\s*(?:останови|выключи|заверши|стоп)\s+таймер`)
+	// reportRequest matches "план и факт за неделю", "план/факт сегодня", "план vs факт"
+	reportRequest = regexp.MustCompile(`(?i)план\s*(?:и|/|vs|против)\s*факт`)
+)
+
+// Timesheet tracks time with timers and manual logs and compares it with calendar plan
+type Timesheet struct {
+	calendarProvider storage.CalendarProvider
+	entryStorage     *storage.TimeEntryStorage
+	taskStorage      *storage.TaskStorage
+	location         *time.Location
+}
+
+func NewTimesheet(cp storage.CalendarProvider, es *storage.TimeEntryStorage, ts *storage.TaskStorage, location *time.Location) *Timesheet {
+	return &Timesheet{
+		calendarProvider: cp,
+		entryStorage:     es,
+		taskStorage:      ts,
+		location:         location,
+	}
+}
+
+// ParseTimerCommand recognizes "запусти таймер: ..." (start, title) and "останови таймер" (stop)
+func ParseTimerCommand(message string) (action string, title string, ok bool) {
+	if m := startRequest.FindStringSubmatch(message); m != nil {
+		return "start", strings.TrimSpace(m[1]), true
+	}
+	if stopRequest.MatchString(message) {
+		return "stop", "", true
+	}
+	return "", "", false
+}
+
+// ParseReportRequest recognizes "план и факт" requests, "недел" in message asks for the current week
+func ParseReportRequest(message string) (week bool, ok bool) {
+	if !reportRequest.MatchString(message) {
+		return false, false
+	}
+	return strings.Contains(strings.ToLower(message), "недел"), true
+}
+
+// Start runs timer, running one is stopped first. Timer without link is linked to event going on now
+// whose title matches (or to the only current event when title is empty). Returns stopped entry too
+func (ts *Timesheet) Start(ctx context.Context, entry *models.TimeEntry) (*models.TimeEntry, *models.TimeEntry, error) {
+	entry.Title = strings.TrimSpace(entry.Title)
+	entry.Note = strings.TrimSpace(entry.Note)
+	if entry.Start.IsZero() {
+		entry.Start = time.Now()
+	}
+	if entry.Start.After(time.Now().Add(time.Minute)) {
+		return nil, nil, fmt.Errorf("%w: timer can not start in the future", ErrInvalidEntry)
+	}
+	if err := ts.link(ctx, entry); err != nil {
+		return nil, nil, err
+	}
+	if entry.Title == "" {
+		return nil, nil, fmt.Errorf("%w: title is required", ErrInvalidEntry)
+	}
+
+	stopped, err := ts.entryStorage.StartTimer(ctx, entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry.Hours = 0
+	return entry, stopped, nil
+}
+
+// Stop ends running timer now
+func (ts *Timesheet) Stop(ctx context.Context) (*models.TimeEntry, error) {
+	return ts.entryStorage.StopTimer(ctx, time.Now())
+}
+
+// Running returns running timer, nil when nothing runs
+func (ts *Timesheet) Running(ctx context.Context) (*models.TimeEntry, error) {
+	return ts.entryStorage.RunningTimer(ctx)
+}
+
+// Log saves finished piece of work, end is required
+func (ts *Timesheet) Log(ctx context.Context, entry *models.TimeEntry) (*models.TimeEntry, error) {
+	entry.Title = strings.TrimSpace(entry.Title)
+	entry.Note = strings.TrimSpace(entry.Note)
+	if entry.End == nil {
+		return nil, fmt.Errorf("%w: end is required", ErrInvalidEntry)
+	}
+	if err := validPeriod(entry.Start, *entry.End); err != nil {
+		return nil, err
+	}
+	if err := ts.link(ctx, entry); err != nil {
+		return nil, err
+	}
+	if entry.Title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidEntry)
+	}
+
+	if err := ts.entryStorage.CreateEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Update applies patch, running entry can get an end only with Stop
+func (ts *Timesheet) Update(ctx context.Context, id int, patch models.TimeEntryPatch) (*models.TimeEntry, error) {
+	entry, err := ts.entryStorage.GetEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Title != nil {
+		entry.Title = strings.TrimSpace(*patch.Title)
+	}
+	if patch.CalendarID != nil {
+		entry.CalendarID = *patch.CalendarID
+	}
+	if patch.EventID != nil {
+		entry.EventID = *patch.EventID
+	}
+	if patch.TaskID != nil {
+		entry.TaskID = patch.TaskID
+		if *patch.TaskID == 0 {
+			entry.TaskID = nil
+		}
+	}
+	if patch.Start != nil {
+		entry.Start = *patch.Start
+	}
+	if patch.End != nil {
+		if entry.End == nil {
+			return nil, fmt.Errorf("%w: stop the timer instead of setting end", ErrInvalidEntry)
+		}
+		entry.End = patch.End
+	}
+	if patch.Note != nil {
+		entry.Note = strings.TrimSpace(*patch.Note)
+	}
+
+	end := time.Now()
+	if entry.End != nil {
+		end = *entry.End
+	}
+	if err := validPeriod(entry.Start, end); err != nil {
+		return nil, err
+	}
+	if entry.Title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidEntry)
+	}
+	if err := ts.checkTask(ctx, entry.TaskID); err != nil {
+		return nil, err
+	}
+
+	if err := ts.entryStorage.UpdateEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (ts *Timesheet) Entry(ctx context.Context, id int) (*models.TimeEntry, error) {
+	return ts.entryStorage.GetEntry(ctx, id)
+}
+
+func (ts *Timesheet) Entries(ctx context.Context, from, to time.Time) ([]*models.TimeEntry, error) {
+	return ts.entryStorage.ListEntries(ctx, from, to)
+}
+
+func (ts *Timesheet) Delete(ctx context.Context, id int) error {
+	return ts.entryStorage.DeleteEntry(ctx, id)
+}
+
+// Report compares events of [from, to) from ListEvents with time tracked in the window
+func (ts *Timesheet) Report(ctx context.Context, from, to time.Time, groupBy string, calendarIDs ...string) (*models.PlanActualReport, error) {
+	op := "internal/timetrack/timesheet.go Report"
+
+	events, _, err := ts.calendarProvider.ListEvents(ctx, from, to, calendarIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to load events: %w", op, err)
+	}
+	entries, err := ts.entryStorage.ListEntries(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	if groupBy == models.PlanGroupCalendar {
+		if calendars, err := ts.calendarProvider.ListCalendars(ctx); err == nil {
+			for _, c := range calendars {
+				names[c.ID] = c.Summary
+				if c.Primary && c.Provider == models.ProviderGoogle {
+					names["primary"] = c.Summary
+				}
+			}
+		}
+	}
+
+	report, err := usecases.PlanVsActual(events, entries, from, to, groupBy, ts.location, time.Now(), names)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+	}
+	return report, nil
+}
+
+// Today is [start of today, start of tomorrow), Week is monday to next monday, both in calendar timezone
+func (ts *Timesheet) Today() (time.Time, time.Time) {
+	now := time.Now().In(ts.location)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, ts.location)
+	return day, day.AddDate(0, 0, 1)
+}
+
+func (ts *Timesheet) Week() (time.Time, time.Time) {
+	day, _ := ts.Today()
+	offset := (int(day.Weekday()) + 6) % 7
+	monday := day.AddDate(0, 0, -offset)
+	return monday, monday.AddDate(0, 0, 7)
+}
+
+// link checks task and fills title from it, entries without any link are matched to current event
+func (ts *Timesheet) link(ctx context.Context, entry *models.TimeEntry) error {
+	if entry.TaskID != nil {
+		task, err := ts.taskStorage.GetTask(ctx, *entry.TaskID)
+		if errors.Is(err, storage.ErrTodoNotFound) {
+			return fmt.Errorf("%w: task %d not found", ErrInvalidEntry, *entry.TaskID)
+		}
+		if err != nil {
+			return err
+		}
+		if entry.Title == "" {
+			entry.Title = task.Title
+		}
+		return nil
+	}
+	if entry.EventID != "" {
+		return nil
+	}
+
+	events, _, err := ts.calendarProvider.ListEvents(ctx, entry.Start.Add(-currentLookback), entry.Start.Add(time.Minute))
+	if err != nil {
+		// timer works without calendar, it just stays unplanned
+		return nil
+	}
+	var current []*models.CalendarEvent
+	for _, e := range events {
+		if e.IsAllDay || e.Status == "cancelled" || e.Start.After(entry.Start) || !e.End.After(entry.Start) {
+			continue
+		}
+		if entry.Title == "" || strings.EqualFold(strings.TrimSpace(e.Summary), entry.Title) {
+			current = append(current, e)
+		}
+	}
+	if len(current) == 1 {
+		entry.CalendarID, entry.EventID = current[0].CalendarID, current[0].ID
+		if entry.Title == "" {
+			entry.Title = current[0].Summary
+		}
+	}
+	return nil
+}
+
+func (ts *Timesheet) checkTask(ctx context.Context, taskID *int) error {
+	if taskID == nil {
+		return nil
+	}
+	_, err := ts.taskStorage.GetTask(ctx, *taskID)
+	if errors.Is(err, storage.ErrTodoNotFound) {
+		return fmt.Errorf("%w: task %d not found", ErrInvalidEntry, *taskID)
+	}
+	return err
+}
+
+func validPeriod(start, end time.Time) error {
+	switch {
+	case start.IsZero():
+		return fmt.Errorf("%w: start is required", ErrInvalidEntry)
+	case !end.After(start):
+		return fmt.Errorf("%w: end must be after start", ErrInvalidEntry)
+	case end.Sub(start) > maxEntryHours*time.Hour:
+		return fmt.Errorf("%w: entry is longer than %d hours", ErrInvalidEntry, maxEntryHours)
+	case end.After(time.Now().Add(time.Minute)):
+		return fmt.Errorf("%w: end is in the future", ErrInvalidEntry)
+	}
+	return nil
+}
+
+// FormatEntry is chat confirmation of timer start, stop or manual log
+func FormatEntry(entry *models.TimeEntry) string {
+	if entry.End == nil {
+		return fmt.Sprintf("⏱ Таймер запущен: %s", entry.Title)
+	}
+	return fmt.Sprintf("⏹ %s: %s", entry.Title, formatHours(entry.Hours))
+}
+
+// FormatReport renders plan vs actual as plain text for chat
+func FormatReport(report *models.PlanActualReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 План и факт: запланировано %s, отслежено %s (по плану %s, вне плана %s)\n",
+		formatHours(report.Totals.PlannedHours), formatHours(report.Totals.ActualHours),
+		formatHours(report.Totals.OnPlanHours), formatHours(report.Totals.UnplannedHours))
+	if report.Totals.PlannedHours > 0 {
+		fmt.Fprintf(&b, "Выполнение плана: %.0f%%\n", report.Totals.Adherence*100)
+	}
+
+	if len(report.Groups) > 1 {
+		b.WriteString("\n")
+		for _, g := range report.Groups {
+			if g.PlannedHours == 0 && g.ActualHours == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "• %s: план %s, факт %s\n", g.Label, formatHours(g.PlannedHours), formatHours(g.ActualHours))
+		}
+	}
+
+	var missed []string
+	for _, e := range report.Events {
+		if e.Status == models.PlanStatusMissed {
+			missed = append(missed, e.Summary)
+		}
+	}
+	if len(missed) > 0 {
+		fmt.Fprintf(&b, "\nБез отметок: %s\n", strings.Join(missed, ", "))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func formatHours(h float64) string {
+	if h < 1 {
+		return fmt.Sprintf("%.0f мин", h*60)
+	}
+	return fmt.Sprintf("%.1f ч", h)
+}
@@ -0,0 +1,98 @@
+package usecases
+
+import (
+	"fmt"
+	"life_forge/internal/models"
+	"time"
+)
+
+// CriticalTasks marks tasks that have no slack: moving their end delays the end of their project.
+// Planned dates are used as early schedule, late finish of a task is the earliest late start of its
+// successors (or project end), so slack = late finish - planned end
+func CriticalTasks(tasks []models.ProjectTask) (map[int]bool, error) {
+	byProject := make(map[int][]models.ProjectTask)
+	for _, t := range tasks {
+		byProject[t.ProjectID] = append(byProject[t.ProjectID], t)
+	}
+
+	critical := make(map[int]bool)
+	for projectID, projectTasks := range byProject {
+		order, err := topoOrder(projectTasks)
+		if err != nil {
+			return nil, fmt.Errorf("project %d: %w", projectID, err)
+		}
+
+		var projectEnd time.Time
+		successors := make(map[int][]int)
+		byID := make(map[int]models.ProjectTask, len(projectTasks))
+		for _, t := range projectTasks {
+			byID[t.ID] = t
+			if t.EndTime.After(projectEnd) {
+				projectEnd = t.EndTime
+			}
+			for _, dep := range t.DependsOn {
+				successors[dep] = append(successors[dep], t.ID)
+			}
+		}
+
+		lateStart := make(map[int]time.Time, len(projectTasks))
+		for i := len(order) - 1; i >= 0; i-- {
+			t := byID[order[i]]
+			lateFinish := projectEnd
+			for _, s := range successors[t.ID] {
+				if ls := lateStart[s]; ls.Before(lateFinish) {
+					lateFinish = ls
+				}
+			}
+			lateStart[t.ID] = lateFinish.Add(-t.EndTime.Sub(t.StartTime))
+			if !lateFinish.After(t.EndTime) {
+				critical[t.ID] = true
+			}
+		}
+	}
+
+	return critical, nil
+}
+
+// topoOrder sorts tasks so that prerequisites go first, dependencies on other projects are ignored
+func topoOrder(tasks []models.ProjectTask) ([]int, error) {
+	known := make(map[int]bool, len(tasks))
+	for _, t := range tasks {
+		known[t.ID] = true
+	}
+
+	inDegree := make(map[int]int, len(tasks))
+	successors := make(map[int][]int)
+	for _, t := range tasks {
+		for _, dep := range t.DependsOn {
+			if !known[dep] {
+				continue
+			}
+			inDegree[t.ID]++
+			successors[dep] = append(successors[dep], t.ID)
+		}
+	}
+
+	var queue, order []int
+	for _, t := range tasks {
+		if inDegree[t.ID] == 0 {
+			queue = append(queue, t.ID)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+		for _, s := range successors[id] {
+			inDegree[s]--
+			if inDegree[s] == 0 {
+				queue = append(queue, s)
+			}
+		}
+	}
+
+	if len(order) != len(tasks) {
+		return nil, fmt.Errorf("dependency cycle")
+	}
+	return order, nil
+}
@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"testing"
+	"time"
+)
+
+// ptask makes task of project lasting from day start to day end
+func ptask(id, project, start, end int, dependsOn ...int) models.ProjectTask {
+	day0 := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	return models.ProjectTask{
+		ID:        id,
+		ProjectID: project,
+		StartTime: day0.AddDate(0, 0, start),
+		EndTime:   day0.AddDate(0, 0, end),
+		DependsOn: dependsOn,
+	}
+}
+
+func TestCriticalTasks(t *testing.T) {
+	tests := []struct {
+		name    string
+		tasks   []models.ProjectTask
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:  "chain",
+			tasks: []models.ProjectTask{ptask(1, 1, 0, 2), ptask(2, 1, 2, 5, 1), ptask(3, 1, 5, 7, 2)},
+			want:  []int{1, 2, 3},
+		},
+		{
+			name:  "chain with gap gives slack to first task",
+			tasks: []models.ProjectTask{ptask(1, 1, 0, 2), ptask(2, 1, 3, 5, 1)},
+			want:  []int{2},
+		},
+		{
+			name: "diamond",
+			tasks: []models.ProjectTask{
+				ptask(1, 1, 0, 1),
+				ptask(2, 1, 1, 4, 1),
+				ptask(3, 1, 1, 2, 1),
+				ptask(4, 1, 4, 6, 2, 3),
+			},
+			want: []int{1, 2, 4},
+		},
+		{
+			name: "parallel branches ending together are both critical",
+			tasks: []models.ProjectTask{
+				ptask(1, 1, 0, 1),
+				ptask(2, 1, 1, 3, 1),
+				ptask(3, 1, 1, 3, 1),
+			},
+			want: []int{1, 2, 3},
+		},
+		{
+			name:    "cycle",
+			tasks:   []models.ProjectTask{ptask(1, 1, 0, 1, 3), ptask(2, 1, 1, 2, 1), ptask(3, 1, 2, 3, 2)},
+			wantErr: true,
+		},
+		{
+			name:    "self dependency",
+			tasks:   []models.ProjectTask{ptask(1, 1, 0, 1, 1)},
+			wantErr: true,
+		},
+		{
+			name:  "disconnected tasks of one project",
+			tasks: []models.ProjectTask{ptask(1, 1, 0, 5), ptask(2, 1, 0, 2), ptask(3, 1, 2, 3, 2)},
+			want:  []int{1},
+		},
+		{
+			name: "projects are computed separately",
+			tasks: []models.ProjectTask{
+				ptask(1, 1, 0, 10),
+				ptask(2, 2, 0, 1),
+				ptask(3, 2, 1, 2, 2),
+			},
+			want: []int{1, 2, 3},
+		},
+		{
+			name:  "dependency on other project is ignored",
+			tasks: []models.ProjectTask{ptask(1, 1, 0, 3), ptask(2, 2, 0, 1, 1), ptask(3, 2, 0, 2)},
+			want:  []int{1, 3},
+		},
+		{
+			name: "milestone at project end",
+			tasks: []models.ProjectTask{
+				ptask(1, 1, 0, 4),
+				ptask(2, 1, 4, 4, 1),
+			},
+			want: []int{1, 2},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CriticalTasks(tt.tasks)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("CriticalTasks = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CriticalTasks: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("critical = %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Fatalf("critical = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
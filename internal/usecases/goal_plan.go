@@ -0,0 +1,159 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"life_forge/internal/models"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxPlanSessions = 200
+	maxPlanDays     = 730
+)
+
+// ParseGoalPlanResponse takes JSON plan from model answer, sessions are not expanded yet
+func ParseGoalPlanResponse(response string) (*models.GoalPlan, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("no JSON in goal plan response")
+	}
+
+	var temp struct {
+		Title      string `json:"title"`
+		Deadline   string `json:"deadline"`
+		Milestones []struct {
+			Title   string `json:"title"`
+			DueDate string `json:"due_date"`
+		} `json:"milestones"`
+		Session models.SessionPattern `json:"session"`
+		Advice  string                `json:"advice"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &temp); err != nil {
+		logParse("Error parsing goal plan JSON: %v", err)
+		return nil, fmt.Errorf("error parsing goal plan JSON: %w", err)
+	}
+
+	deadline, err := time.Parse("2006-01-02", temp.Deadline)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deadline %q", temp.Deadline)
+	}
+
+	plan := &models.GoalPlan{
+		Title:    strings.TrimSpace(temp.Title),
+		Deadline: deadline,
+		Session:  temp.Session,
+		Advice:   strings.TrimSpace(temp.Advice),
+	}
+	for _, m := range temp.Milestones {
+		due, err := time.Parse("2006-01-02", m.DueDate)
+		if err != nil || strings.TrimSpace(m.Title) == "" {
+			continue
+		}
+		plan.Milestones = append(plan.Milestones, models.PlannedMilestone{Title: strings.TrimSpace(m.Title), DueDate: due})
+	}
+	return plan, nil
+}
+
+// ExpandSessions validates plan and fills Sessions with weekly sessions from the day after `from`
+// till deadline. Every session belongs to the first milestone that is not due before it
+func ExpandSessions(plan *models.GoalPlan, from time.Time, loc *time.Location) error {
+	if plan.Title == "" {
+		return fmt.Errorf("plan title is empty")
+	}
+	if len(plan.Milestones) == 0 {
+		return fmt.Errorf("plan has no milestones")
+	}
+
+	p := plan.Session
+	if strings.TrimSpace(p.Title) == "" {
+		p.Title = plan.Title
+	}
+	at, err := time.Parse("15:04", p.Time)
+	if err != nil {
+		return fmt.Errorf("session time must be HH:MM")
+	}
+	if p.DurationHours <= 0 || p.DurationHours > 8 {
+		return fmt.Errorf("session duration must be between 0 and 8 hours")
+	}
+	if len(p.Weekdays) == 0 {
+		return fmt.Errorf("session weekdays are empty")
+	}
+	for _, wd := range p.Weekdays {
+		if wd < 1 || wd > 7 {
+			return fmt.Errorf("weekday %d is out of range 1-7", wd)
+		}
+	}
+	plan.Session = p
+
+	from = from.In(loc)
+	firstDay := time.Date(from.Year(), from.Month(), from.Day()+1, 0, 0, 0, 0, loc)
+	lastDay := time.Date(plan.Deadline.Year(), plan.Deadline.Month(), plan.Deadline.Day(), 0, 0, 0, 0, loc)
+	if lastDay.Before(firstDay) {
+		return fmt.Errorf("deadline must be in the future")
+	}
+	if lastDay.Sub(firstDay) > maxPlanDays*24*time.Hour {
+		return fmt.Errorf("deadline is more than %d days away", maxPlanDays)
+	}
+
+	// milestones after deadline are moved to it, so every session has a milestone
+	for i := range plan.Milestones {
+		if plan.Milestones[i].DueDate.After(plan.Deadline) {
+			plan.Milestones[i].DueDate = plan.Deadline
+		}
+	}
+	sort.SliceStable(plan.Milestones, func(i, j int) bool {
+		return plan.Milestones[i].DueDate.Before(plan.Milestones[j].DueDate)
+	})
+
+	plan.Sessions = nil
+	for day := firstDay; !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		if !slices.Contains(p.Weekdays, isoWeekday(day)) {
+			continue
+		}
+		if len(plan.Sessions) == maxPlanSessions {
+			return fmt.Errorf("plan has more than %d sessions", maxPlanSessions)
+		}
+
+		date := day.Format("2006-01-02")
+		milestone := len(plan.Milestones) - 1
+		for i, m := range plan.Milestones {
+			if m.DueDate.Format("2006-01-02") >= date {
+				milestone = i
+				break
+			}
+		}
+
+		plan.Sessions = append(plan.Sessions, models.PlannedSession{
+			Title:         p.Title,
+			Start:         time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), 0, 0, loc),
+			DurationHours: p.DurationHours,
+			Milestone:     milestone,
+		})
+	}
+	if len(plan.Sessions) == 0 {
+		return fmt.Errorf("no sessions fit before deadline")
+	}
+	return nil
+}
+
+// GoalProgress is how progress of tracked goal looks in Context.Progress
+func GoalProgress(completed, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	percent := int(math.Round(float64(completed) * 100 / float64(total)))
+	return fmt.Sprintf("%d%% (%d из %d занятий)", percent, completed, total)
+}
+
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
@@ -0,0 +1,124 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"testing"
+	"time"
+)
+
+func TestParseGoalPlanResponse(t *testing.T) {
+	response := "Вот план:\n```json\n" + `{
+		"title": "Выучить Go",
+		"deadline": "2026-03-15",
+		"milestones": [
+			{"title": "Синтаксис", "due_date": "2026-03-06"},
+			{"title": "", "due_date": "2026-03-08"},
+			{"title": "Без даты", "due_date": "скоро"},
+			{"title": "Проект", "due_date": "2026-03-15"}
+		],
+		"session": {"title": "Go", "weekdays": [1, 3], "time": "19:00", "duration": 1.5},
+		"advice": " Пиши код каждый день "
+	}` + "\n```"
+
+	plan, err := ParseGoalPlanResponse(response)
+	if err != nil {
+		t.Fatalf("ParseGoalPlanResponse: %v", err)
+	}
+	if plan.Title != "Выучить Go" || plan.Advice != "Пиши код каждый день" || plan.Deadline.Format("2006-01-02") != "2026-03-15" {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if len(plan.Milestones) != 2 || plan.Milestones[0].Title != "Синтаксис" || plan.Milestones[1].Title != "Проект" {
+		t.Fatalf("milestones = %+v, want two valid ones", plan.Milestones)
+	}
+	if plan.Session.DurationHours != 1.5 || len(plan.Session.Weekdays) != 2 {
+		t.Fatalf("session = %+v", plan.Session)
+	}
+
+	for _, bad := range []string{"нет плана", `{"title": "x", "deadline": "завтра"}`, `{"title": }`} {
+		if _, err := ParseGoalPlanResponse(bad); err == nil {
+			t.Errorf("ParseGoalPlanResponse(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestExpandSessions(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	date := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	newPlan := func() *models.GoalPlan {
+		return &models.GoalPlan{
+			Title:    "Выучить Go",
+			Deadline: date(15),
+			Milestones: []models.PlannedMilestone{
+				{Title: "Проект", DueDate: date(20)}, // after deadline
+				{Title: "Синтаксис", DueDate: date(4)},
+			},
+			Session: models.SessionPattern{Weekdays: []int{1, 3}, Time: "19:00", DurationHours: 1.5},
+		}
+	}
+	// Sunday evening, planning starts on Monday
+	from := time.Date(2026, 3, 1, 18, 0, 0, 0, loc)
+
+	plan := newPlan()
+	if err := ExpandSessions(plan, from, loc); err != nil {
+		t.Fatalf("ExpandSessions: %v", err)
+	}
+
+	if plan.Milestones[0].Title != "Синтаксис" || !plan.Milestones[1].DueDate.Equal(plan.Deadline) {
+		t.Fatalf("milestones = %+v, want sorted with the late one moved to deadline", plan.Milestones)
+	}
+	// Mondays and Wednesdays from 2 to 15 March
+	wantDays := []int{2, 4, 9, 11}
+	if len(plan.Sessions) != len(wantDays) {
+		t.Fatalf("sessions = %+v, want %d", plan.Sessions, len(wantDays))
+	}
+	for i, day := range wantDays {
+		s := plan.Sessions[i]
+		wantMilestone := 1
+		if day <= 4 {
+			wantMilestone = 0
+		}
+		if !s.Start.Equal(time.Date(2026, 3, day, 19, 0, 0, 0, loc)) || s.Title != "Выучить Go" || s.Milestone != wantMilestone {
+			t.Fatalf("session %d = %+v, want %d March 19:00 of milestone %d", i, s, day, wantMilestone)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(p *models.GoalPlan)
+	}{
+		{"no milestones", func(p *models.GoalPlan) { p.Milestones = nil }},
+		{"bad time", func(p *models.GoalPlan) { p.Session.Time = "7pm" }},
+		{"too long session", func(p *models.GoalPlan) { p.Session.DurationHours = 9 }},
+		{"no weekdays", func(p *models.GoalPlan) { p.Session.Weekdays = nil }},
+		{"weekday out of range", func(p *models.GoalPlan) { p.Session.Weekdays = []int{0} }},
+		{"deadline passed", func(p *models.GoalPlan) { p.Deadline = date(1) }},
+		{"deadline too far", func(p *models.GoalPlan) { p.Deadline = date(1).AddDate(3, 0, 0) }},
+		{"no session fits", func(p *models.GoalPlan) { p.Deadline = date(3); p.Session.Weekdays = []int{5} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := newPlan()
+			tt.change(plan)
+			if err := ExpandSessions(plan, from, loc); err == nil {
+				t.Fatalf("ExpandSessions succeeded with %d sessions, want error", len(plan.Sessions))
+			}
+		})
+	}
+}
+
+func TestGoalProgress(t *testing.T) {
+	tests := []struct {
+		completed, total int
+		want             string
+	}{
+		{0, 0, "0%"},
+		{1, 3, "33% (1 из 3 занятий)"},
+		{2, 3, "67% (2 из 3 занятий)"},
+		{8, 8, "100% (8 из 8 занятий)"},
+	}
+	for _, tt := range tests {
+		if got := GoalProgress(tt.completed, tt.total); got != tt.want {
+			t.Errorf("GoalProgress(%d, %d) = %q, want %q", tt.completed, tt.total, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package usecases
+
+import (
+	"fmt"
+	"life_forge/internal/models"
+	"math"
+	"time"
+)
+
+const (
+	habitRateDays = 30
+	dateLayout    = "2006-01-02"
+)
+
+// HabitDay is the date of t in loc as midnight UTC, the form check-in days are stored in
+func HabitDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// HabitStats computes streaks, 30 days completion rate and weekly history up to today.
+// Today without check-in is not counted yet: the day is not over
+func HabitStats(habit *models.Habit, checkins []*models.HabitCheckin, today time.Time, weeks int) *models.HabitStats {
+	byDay := make(map[string]string, len(checkins))
+	for _, c := range checkins {
+		byDay[c.Day.Format(dateLayout)] = c.Status
+	}
+	start := habit.StartDate
+	if today.Before(start) {
+		start = today
+	}
+
+	// status of one period: a day for daily habits, the best mark of a week for weekly ones
+	unit, step := "day", 1
+	periodStart := func(d time.Time) time.Time { return d }
+	if habit.Recurrence == models.HabitWeekly {
+		unit, step = "week", 7
+		periodStart = weekStart
+	}
+	status := func(p time.Time) string {
+		best := ""
+		for i := 0; i < step; i++ {
+			switch byDay[p.AddDate(0, 0, i).Format(dateLayout)] {
+			case models.CheckinDone:
+				return models.CheckinDone
+			case models.CheckinPartial:
+				best = models.CheckinPartial
+			case models.CheckinSkipped:
+				if best == "" {
+					best = models.CheckinSkipped
+				}
+			}
+		}
+		return best
+	}
+
+	first, current := periodStart(start), periodStart(today)
+	stats := &models.HabitStats{Unit: unit, History: []models.HabitPeriod{}}
+
+	run := 0
+	for p := first; !p.After(current); p = p.AddDate(0, 0, step) {
+		if s := status(p); s == models.CheckinDone || s == models.CheckinPartial {
+			run++
+			stats.LongestStreak = max(stats.LongestStreak, run)
+		} else if !p.Equal(current) || s == models.CheckinSkipped {
+			run = 0
+		}
+	}
+	stats.Streak = run
+
+	var score float64
+	expected := 0
+	rateFrom := periodStart(today.AddDate(0, 0, 1-habitRateDays))
+	for p := maxTime(rateFrom, first); !p.After(current); p = p.AddDate(0, 0, step) {
+		s := status(p)
+		if p.Equal(current) && s == "" {
+			continue
+		}
+		expected++
+		score += checkinScore(s)
+	}
+	stats.CompletionRate = rate(score, expected)
+
+	for w := weekStart(today).AddDate(0, 0, -7*(weeks-1)); !w.After(today); w = w.AddDate(0, 0, 7) {
+		if w.AddDate(0, 0, 6).Before(start) {
+			continue
+		}
+		stats.History = append(stats.History, habitWeek(habit, byDay, w, start, today))
+	}
+	return stats
+}
+
+// habitWeek counts marks of one week, daily habits expect every day, weekly ones one mark
+func habitWeek(habit *models.Habit, byDay map[string]string, week, start, today time.Time) models.HabitPeriod {
+	period := models.HabitPeriod{Start: week}
+	var score, best float64
+	days := 0
+	for i := 0; i < 7; i++ {
+		d := week.AddDate(0, 0, i)
+		if d.Before(start) || d.After(today) {
+			continue
+		}
+		s := byDay[d.Format(dateLayout)]
+		switch s {
+		case models.CheckinDone:
+			period.Done++
+		case models.CheckinPartial:
+			period.Partial++
+		case models.CheckinSkipped:
+			period.Skipped++
+		}
+		if d.Equal(today) && s == "" {
+			continue
+		}
+		days++
+		score += checkinScore(s)
+		best = math.Max(best, checkinScore(s))
+	}
+
+	if habit.Recurrence == models.HabitWeekly {
+		if period.Done+period.Partial+period.Skipped > 0 || !today.Before(week.AddDate(0, 0, 6)) {
+			period.Expected = 1
+		}
+		period.Rate = rate(best, period.Expected)
+		return period
+	}
+	period.Expected = days
+	period.Rate = rate(score, days)
+	return period
+}
+
+// HabitProgress is how habit looks in Context.Progress
+func HabitProgress(stats *models.HabitStats) string {
+	unit := "дн."
+	if stats.Unit == "week" {
+		unit = "нед."
+	}
+	return fmt.Sprintf("серия %d %s (рекорд %d), %d%% за %d дн.",
+		stats.Streak, unit, stats.LongestStreak, int(math.Round(stats.CompletionRate*100)), habitRateDays)
+}
+
+// ValidCheckinStatus reports whether status is one of done, partial, skipped
+func ValidCheckinStatus(status string) bool {
+	return status == models.CheckinDone || status == models.CheckinPartial || status == models.CheckinSkipped
+}
+
+func checkinScore(status string) float64 {
+	switch status {
+	case models.CheckinDone:
+		return 1
+	case models.CheckinPartial:
+		return 0.5
+	}
+	return 0
+}
+
+func rate(score float64, expected int) float64 {
+	if expected == 0 {
+		return 0
+	}
+	return math.Round(score/float64(expected)*100) / 100
+}
+
+func weekStart(d time.Time) time.Time {
+	return d.AddDate(0, 0, 1-isoWeekday(d))
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHabitStatsDaily(t *testing.T) {
+	date := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	habit := &models.Habit{Recurrence: models.HabitDaily, StartDate: date(1)}
+	marks := map[int]string{
+		1: models.CheckinDone, 2: models.CheckinDone, 3: models.CheckinDone,
+		// 4 March is missing
+		5: models.CheckinPartial, 6: models.CheckinDone, 7: models.CheckinSkipped,
+		8: models.CheckinDone, 9: models.CheckinDone,
+	}
+	var checkins []*models.HabitCheckin
+	for day, status := range marks {
+		checkins = append(checkins, &models.HabitCheckin{Day: date(day), Status: status})
+	}
+
+	// today has no check-in yet and does not break the streak
+	stats := HabitStats(habit, checkins, date(10), 2)
+	if stats.Unit != "day" || stats.Streak != 2 || stats.LongestStreak != 3 {
+		t.Fatalf("stats = %+v, want streak 2 and record 3 days", stats)
+	}
+	// 6.5 of 9 days before today
+	if stats.CompletionRate != 0.72 {
+		t.Fatalf("completion rate = %v, want 0.72", stats.CompletionRate)
+	}
+	wantHistory := []models.HabitPeriod{
+		{Start: date(2), Done: 4, Partial: 1, Skipped: 1, Expected: 7, Rate: 0.64},
+		{Start: date(9), Done: 1, Expected: 1, Rate: 1},
+	}
+	if !reflect.DeepEqual(stats.History, wantHistory) {
+		t.Fatalf("history = %+v, want %+v", stats.History, wantHistory)
+	}
+	if got, want := HabitProgress(stats), "серия 2 дн. (рекорд 3), 72% за 30 дн."; got != want {
+		t.Fatalf("HabitProgress() = %q, want %q", got, want)
+	}
+
+	checkins = append(checkins, &models.HabitCheckin{Day: date(10), Status: models.CheckinSkipped})
+	if stats := HabitStats(habit, checkins, date(10), 2); stats.Streak != 0 {
+		t.Fatalf("streak = %d after today is skipped, want 0", stats.Streak)
+	}
+}
+
+func TestHabitStatsWeekly(t *testing.T) {
+	date := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	habit := &models.Habit{Recurrence: models.HabitWeekly, StartDate: date(2)}
+	checkins := []*models.HabitCheckin{
+		{Day: date(4), Status: models.CheckinPartial},
+		{Day: date(12), Status: models.CheckinDone},
+		{Day: date(13), Status: models.CheckinSkipped},
+		// week of 16 March is missing
+		{Day: date(24), Status: models.CheckinDone},
+	}
+
+	stats := HabitStats(habit, checkins, date(25), 4)
+	if stats.Unit != "week" || stats.Streak != 1 || stats.LongestStreak != 2 {
+		t.Fatalf("stats = %+v, want streak 1 and record 2 weeks", stats)
+	}
+	// 2.5 of 4 weeks
+	if stats.CompletionRate != 0.63 {
+		t.Fatalf("completion rate = %v, want 0.63", stats.CompletionRate)
+	}
+	wantHistory := []models.HabitPeriod{
+		{Start: date(2), Partial: 1, Expected: 1, Rate: 0.5},
+		{Start: date(9), Done: 1, Skipped: 1, Expected: 1, Rate: 1},
+		{Start: date(16), Expected: 1, Rate: 0},
+		{Start: date(23), Done: 1, Expected: 1, Rate: 1},
+	}
+	if !reflect.DeepEqual(stats.History, wantHistory) {
+		t.Fatalf("history = %+v, want %+v", stats.History, wantHistory)
+	}
+
+	// current week without marks is not expected yet
+	stats = HabitStats(habit, checkins[:3], date(25), 1)
+	if len(stats.History) != 1 || stats.History[0].Expected != 0 || stats.Streak != 0 {
+		t.Fatalf("stats = %+v, want empty current week not expected", stats)
+	}
+}
+
+func TestHabitDay(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	// 22:30 UTC is already the next day in Moscow
+	got := HabitDay(time.Date(2026, 3, 9, 22, 30, 0, 0, time.UTC), loc)
+	if want := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC); !got.Equal(want) || got.Location() != time.UTC {
+		t.Fatalf("HabitDay() = %v, want %v", got, want)
+	}
+}
+
+func TestParseHabitUpdates(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     map[string]string
+	}{
+		{"no block", "Привычки: бег: done", map[string]string{}},
+		{
+			name: "statuses in block",
+			response: "Ответ: Молодец\n|||UPDATE_DATA|||\n" +
+				"Прогресс: выучить Go: 40%\n" +
+				"Привычки: бег: done, чтение: Partial, медитация: skipped, зарядка: сделал\n" +
+				"|||UPDATE_DATA|||",
+			want: map[string]string{"бег": "done", "чтение": "partial", "медитация": "skipped"},
+		},
+		{"no habits line", "Ок\n|||UPDATE_DATA|||\nЦели: бег\n|||UPDATE_DATA|||", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseHabitUpdates(tt.response); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseHabitUpdates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package usecases
+
+import (
+	"life_forge/internal/ical"
+	"life_forge/internal/models"
+	"strings"
+	"time"
+)
+
+// EventsToICS converts listed events into iCalendar. Export is instance based: every provider
+// returns recurring events expanded over the window, so each instance is written as its own
+// VEVENT with unique UID and without RRULE, EXDATE is not needed for skipped instances either
+func EventsToICS(events []*models.CalendarEvent, name string, loc *time.Location) *ical.Calendar {
+	cal := &ical.Calendar{Name: name, Location: loc}
+
+	for _, e := range events {
+		if e.Status == "cancelled" || e.Start.IsZero() {
+			continue
+		}
+
+		vevent := ical.Event{
+			UID:         exportUID(e),
+			Summary:     e.Summary,
+			Description: e.Description,
+			Location:    e.Location,
+			Start:       e.Start,
+			End:         e.End,
+			AllDay:      e.IsAllDay,
+		}
+
+		switch e.Status {
+		case "confirmed", "tentative":
+			vevent.Status = strings.ToUpper(e.Status)
+		}
+
+		cal.Events = append(cal.Events, vevent)
+	}
+
+	return cal
+}
+
+func exportUID(e *models.CalendarEvent) string {
+	if e.RecurringEventID == "" && e.UID != "" {
+		return e.UID
+	}
+	id := e.ID
+	if e.CalendarID != "" {
+		id = e.CalendarID + "/" + id
+	}
+	return id + "@lifeforge"
+}
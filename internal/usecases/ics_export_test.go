@@ -0,0 +1,95 @@
+package usecases
+
+import (
+	"bytes"
+	"life_forge/internal/ical"
+	"life_forge/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventsToICSRoundTrip(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata: ", err)
+	}
+
+	// the range crosses DST change, so VTIMEZONE has both observances
+	series := func(start time.Time) *models.CalendarEvent {
+		return &models.CalendarEvent{
+			ID:               "s1_" + start.UTC().Format("20060102T150405Z"),
+			CalendarID:       "primary",
+			UID:              "series@example.com",
+			Summary:          "Standup",
+			Status:           "confirmed",
+			RecurringEventID: "s1",
+			Recurrence:       []string{"RRULE:FREQ=WEEKLY;BYDAY=MO"},
+			Start:            start,
+			End:              start.Add(15 * time.Minute),
+		}
+	}
+	events := []*models.CalendarEvent{
+		{
+			ID: "one", CalendarID: "primary", UID: "one@example.com",
+			Summary: "Обед, встреча; важно", Description: "строка 1\nстрока 2", Location: "Кафе",
+			Status: "tentative",
+			Start:  time.Date(2026, 3, 24, 13, 0, 0, 0, berlin), End: time.Date(2026, 3, 24, 14, 0, 0, 0, berlin),
+		},
+		series(time.Date(2026, 3, 23, 10, 0, 0, 0, berlin)),
+		series(time.Date(2026, 3, 30, 10, 0, 0, 0, berlin)),
+		{
+			ID: "trip", CalendarID: "local", Summary: "Отпуск", IsAllDay: true,
+			// all-day events start at midnight in calendar time zone, whatever zone the instant is read in
+			Start: time.Date(2026, 3, 27, 0, 0, 0, 0, berlin).UTC(), End: time.Date(2026, 3, 29, 0, 0, 0, 0, berlin).UTC(),
+		},
+		{
+			ID: "gone", CalendarID: "primary", Summary: "Отменено", Status: "cancelled",
+			Start: time.Date(2026, 3, 25, 9, 0, 0, 0, berlin), End: time.Date(2026, 3, 25, 10, 0, 0, 0, berlin),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, EventsToICS(events, "LifeForge", berlin)); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if strings.Contains(buf.String(), "RRULE") || strings.Contains(buf.String(), "EXDATE") {
+		t.Fatalf("instance based export must not contain recurrence:\n%s", buf.String())
+	}
+
+	cal, err := ical.Parse(&buf, berlin)
+	if err != nil {
+		t.Fatalf("Parse of exported calendar: %v", err)
+	}
+	if cal.Name != "LifeForge" {
+		t.Fatalf("name = %q", cal.Name)
+	}
+	if len(cal.Events) != 4 {
+		t.Fatalf("events = %d, want 4 without cancelled", len(cal.Events))
+	}
+
+	uids := make(map[string]bool)
+	for i, got := range cal.Events {
+		want := events[i]
+		if uids[got.UID] {
+			t.Fatalf("duplicate UID %q", got.UID)
+		}
+		uids[got.UID] = true
+
+		if got.Summary != want.Summary || got.Description != want.Description || got.Location != want.Location {
+			t.Fatalf("event %d text = %q / %q / %q", i, got.Summary, got.Description, got.Location)
+		}
+		if !got.Start.Equal(want.Start) || !got.End.Equal(want.End) || got.AllDay != want.IsAllDay {
+			t.Fatalf("event %d = %v..%v allDay=%v, want %v..%v", i, got.Start, got.End, got.AllDay, want.Start, want.End)
+		}
+		if got.RRule != "" {
+			t.Fatalf("event %d has RRULE %q", i, got.RRule)
+		}
+	}
+	if cal.Events[0].UID != "one@example.com" || cal.Events[0].Status != "TENTATIVE" {
+		t.Fatalf("single event = %+v, want its own UID and status", cal.Events[0])
+	}
+	if cal.Events[1].UID == "series@example.com" {
+		t.Fatal("instance must not reuse UID of the series")
+	}
+}
@@ -0,0 +1,159 @@
+package usecases
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"life_forge/internal/ical"
+	"life_forge/internal/models"
+	"time"
+)
+
+const (
+	importConflictDays = 90  // how far recurring events are checked for conflicts
+	importMaxSpanDays  = 365 // upper bound of window loaded from calendar
+)
+
+// ImportWindow returns time range that has to be loaded from calendar to find conflicts
+func ImportWindow(cal *ical.Calendar) (time.Time, time.Time) {
+	var from, to time.Time
+	for _, e := range cal.Events {
+		if from.IsZero() || e.Start.Before(from) {
+			from = e.Start
+		}
+		end := e.End
+		if e.RRule != "" {
+			end = e.Start.AddDate(0, 0, importConflictDays)
+		}
+		if to.IsZero() || end.After(to) {
+			to = end
+		}
+	}
+	if limit := from.AddDate(0, 0, importMaxSpanDays); to.After(limit) {
+		to = limit
+	}
+	return from, to
+}
+
+// BuildImportPreview turns VEVENTs into event requests and finds overlaps with existing events.
+// Modified instances (RECURRENCE-ID) become standalone events and are excluded from their series
+func BuildImportPreview(cal *ical.Calendar, existing []*models.CalendarEvent) []models.ImportCandidate {
+	overrides := make(map[string][]time.Time)
+	for _, e := range cal.Events {
+		if !e.RecurrenceID.IsZero() {
+			overrides[e.UID] = append(overrides[e.UID], e.RecurrenceID)
+		}
+	}
+
+	candidates := make([]models.ImportCandidate, 0, len(cal.Events))
+	for _, e := range cal.Events {
+		if e.Status == "CANCELLED" {
+			continue
+		}
+
+		uid := e.UID
+		if uid == "" {
+			uid = syntheticUID(e)
+		}
+		if !e.RecurrenceID.IsZero() {
+			uid = uid + "-" + e.RecurrenceID.UTC().Format("20060102T150405Z")
+			e.RRule = ""
+		} else if e.RRule != "" {
+			e.ExDates = append(e.ExDates, overrides[e.UID]...)
+		}
+
+		candidate := models.ImportCandidate{
+			UID:       uid,
+			Event:     eventRequestFromICS(uid, e),
+			Conflicts: []models.ImportConflict{},
+		}
+
+		windowEnd := e.End
+		if e.RRule != "" {
+			windowEnd = e.Start.AddDate(0, 0, importConflictDays)
+		}
+		occurrences, err := e.Expand(e.Start, windowEnd.Add(time.Second))
+		if err != nil {
+			logParse("Failed to expand %s: %v", uid, err)
+			occurrences = []ical.Occurrence{{Start: e.Start, End: e.End}}
+		}
+		candidate.Occurrences = len(occurrences)
+
+		if !e.AllDay {
+			candidate.Conflicts = findConflicts(occurrences, existing)
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+func eventRequestFromICS(uid string, e ical.Event) models.EventRequest {
+	start := e.Start
+	duration := e.End.Sub(e.Start).Hours()
+	allDay := e.AllDay
+
+	event := models.EventRequest{
+		IsEvent:       true,
+		Title:         e.Summary,
+		StartTime:     &start,
+		DurationHours: &duration,
+		IsAllDay:      &allDay,
+		UID:           &uid,
+	}
+
+	description := e.Description
+	if e.Location != "" {
+		if description != "" {
+			description += "\n"
+		}
+		description += "Место: " + e.Location
+	}
+	if description != "" {
+		event.Description = &description
+	}
+
+	if e.RRule != "" {
+		rule := "RRULE:" + e.RRule
+		event.Recurrence = &rule
+		event.ExDates = e.ExDates
+	}
+
+	return event
+}
+
+func findConflicts(occurrences []ical.Occurrence, existing []*models.CalendarEvent) []models.ImportConflict {
+	conflicts := []models.ImportConflict{}
+	seen := make(map[string]bool)
+
+	for _, occ := range occurrences {
+		for _, ev := range existing {
+			if ev.IsAllDay || ev.Status == "cancelled" {
+				continue
+			}
+			if !(ev.Start.Before(occ.End) && ev.End.After(occ.Start)) {
+				continue
+			}
+			key := ev.CalendarID + "/" + ev.ID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			conflicts = append(conflicts, models.ImportConflict{
+				CalendarID: ev.CalendarID,
+				EventID:    ev.ID,
+				Summary:    ev.Summary,
+				Start:      ev.Start,
+				End:        ev.End,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// syntheticUID keeps duplicate detection working for files without UID
+func syntheticUID(e ical.Event) string {
+	sum := sha1.Sum([]byte(e.Summary + "|" + e.Start.UTC().Format(time.RFC3339)))
+	return hex.EncodeToString(sum[:]) + "@import.lifeforge"
+}
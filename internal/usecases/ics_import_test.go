@@ -0,0 +1,132 @@
+package usecases
+
+import (
+	"life_forge/internal/ical"
+	"life_forge/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+const importICS = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Conf//EN
+BEGIN:VEVENT
+UID:talks@conf
+SUMMARY:Talks
+DTSTART:20260302T090000Z
+DTEND:20260302T100000Z
+RRULE:FREQ=WEEKLY;COUNT=4
+EXDATE:20260309T090000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:talks@conf
+RECURRENCE-ID:20260316T090000Z
+SUMMARY:Talks moved
+DTSTART:20260316T140000Z
+DTEND:20260316T150000Z
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Keynote
+LOCATION:Hall A
+DTSTART:20260302T120000Z
+DTEND:20260302T130000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:party@conf
+SUMMARY:Party
+STATUS:CANCELLED
+DTSTART:20260302T180000Z
+DTEND:20260302T200000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:day@conf
+SUMMARY:Workshop day
+DTSTART;VALUE=DATE:20260302
+DTEND;VALUE=DATE:20260303
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestBuildImportPreview(t *testing.T) {
+	cal, err := ical.Parse(strings.NewReader(importICS), time.UTC)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	at := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC) }
+	existing := []*models.CalendarEvent{
+		// overlaps third instance of the series, the moved one only
+		{ID: "standup", CalendarID: "primary", Summary: "Standup", Start: at(23, 9).Add(30 * time.Minute), End: at(23, 10)},
+		{ID: "lunch", CalendarID: "local", Summary: "Lunch", Start: at(2, 12), End: at(2, 14)},
+		{ID: "skipped", CalendarID: "primary", Summary: "Excluded date", Start: at(9, 9), End: at(9, 10)},
+		{ID: "gone", CalendarID: "primary", Summary: "Cancelled", Status: "cancelled", Start: at(2, 9), End: at(2, 10)},
+	}
+
+	candidates := BuildImportPreview(cal, existing)
+	byUID := make(map[string]models.ImportCandidate, len(candidates))
+	for _, c := range candidates {
+		byUID[c.UID] = c
+	}
+	if len(candidates) != 4 {
+		t.Fatalf("candidates = %d (%v), want series, moved instance, keynote and all-day event without cancelled one", len(candidates), byUID)
+	}
+
+	series, ok := byUID["talks@conf"]
+	if !ok {
+		t.Fatalf("no series in %v", byUID)
+	}
+	if series.Event.Recurrence == nil || *series.Event.Recurrence != "RRULE:FREQ=WEEKLY;COUNT=4" {
+		t.Fatalf("series recurrence = %v", series.Event.Recurrence)
+	}
+	// EXDATE and moved instance are both excluded from the series
+	if series.Occurrences != 2 || len(series.Event.ExDates) != 2 {
+		t.Fatalf("series occurrences = %d, exdates = %v, want 2 and 2", series.Occurrences, series.Event.ExDates)
+	}
+	if len(series.Conflicts) != 1 || series.Conflicts[0].EventID != "standup" {
+		t.Fatalf("series conflicts = %+v, want standup only", series.Conflicts)
+	}
+
+	moved, ok := byUID["talks@conf-20260316T090000Z"]
+	if !ok || moved.Event.Recurrence != nil || !moved.Event.StartTime.Equal(at(16, 14)) || len(moved.Conflicts) != 0 {
+		t.Fatalf("moved instance = %+v, want standalone event at 14:00 without conflicts", moved)
+	}
+
+	var keynote models.ImportCandidate
+	for uid, c := range byUID {
+		if strings.HasSuffix(uid, "@import.lifeforge") {
+			keynote = c
+		}
+	}
+	if keynote.Event.Title != "Keynote" || keynote.Event.Description == nil || *keynote.Event.Description != "Место: Hall A" {
+		t.Fatalf("keynote = %+v, want synthetic UID and location in description", keynote)
+	}
+	if len(keynote.Conflicts) != 1 || keynote.Conflicts[0].CalendarID != "local" {
+		t.Fatalf("keynote conflicts = %+v, want lunch", keynote.Conflicts)
+	}
+	again := BuildImportPreview(cal, nil)
+	for _, c := range again {
+		if c.Event.Title == "Keynote" && c.UID != keynote.UID {
+			t.Fatalf("synthetic UID changed between previews: %s, %s", keynote.UID, c.UID)
+		}
+	}
+
+	day := byUID["day@conf"]
+	if day.Event.IsAllDay == nil || !*day.Event.IsAllDay || len(day.Conflicts) != 0 {
+		t.Fatalf("all-day candidate = %+v, want all-day event without conflicts", day)
+	}
+}
+
+func TestImportWindow(t *testing.T) {
+	cal, err := ical.Parse(strings.NewReader(importICS), time.UTC)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	from, to := ImportWindow(cal)
+	wantFrom := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	wantTo := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC).AddDate(0, 0, importConflictDays)
+	if !from.Equal(wantFrom) || !to.Equal(wantTo) {
+		t.Fatalf("window = %v..%v, want %v..%v", from, to, wantFrom, wantTo)
+	}
+}
@@ -0,0 +1,170 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"sort"
+	"time"
+)
+
+// minLayoutMinutes is the height short events take on the grid, so they still collide with neighbours
+const minLayoutMinutes = 30
+
+// LayoutDays splits events into days of [from, to) in loc and assigns overlap lanes.
+// Events are grouped into clusters of transitively overlapping items, every item of a cluster
+// shares the same lane count and gets the first lane that is free at its start.
+// All-day events start at midnight in calendarLoc (CALENDAR_TIMEZONE), their dates are read there
+func LayoutDays(events []*models.CalendarEvent, from, to time.Time, loc, calendarLoc *time.Location) []models.LayoutDay {
+	from = from.In(loc)
+	firstDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+
+	var days []models.LayoutDay
+	for day := firstDay; day.Before(to); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+
+		allDay := []models.LayoutItem{}
+		timed := []models.LayoutItem{}
+		for _, e := range events {
+			if e.Status == "cancelled" || e.Start.IsZero() {
+				continue
+			}
+			if e.IsAllDay {
+				if allDayCovers(e, day, calendarLoc) {
+					allDay = append(allDay, layoutItem(e, day, next))
+				}
+				continue
+			}
+			if e.Start.Before(next) && (e.End.After(day) || (e.End.Equal(e.Start) && !e.Start.Before(day))) {
+				timed = append(timed, layoutItem(e, day, next))
+			}
+		}
+
+		assignLanes(allDay, true)
+		assignLanes(timed, false)
+
+		days = append(days, models.LayoutDay{
+			Date:   day.Format("2006-01-02"),
+			AllDay: allDay,
+			Events: timed,
+		})
+	}
+
+	return days
+}
+
+// allDayCovers compares calendar dates, providers store all-day events from midnight in calendarLoc
+func allDayCovers(e *models.CalendarEvent, day time.Time, calendarLoc *time.Location) bool {
+	eventStart, eventEnd := e.Start.In(calendarLoc), e.End.In(calendarLoc)
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	start := time.Date(eventStart.Year(), eventStart.Month(), eventStart.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(eventEnd.Year(), eventEnd.Month(), eventEnd.Day(), 0, 0, 0, 0, time.UTC)
+	if !end.After(start) {
+		end = start.AddDate(0, 0, 1)
+	}
+	return !date.Before(start) && date.Before(end)
+}
+
+func layoutItem(e *models.CalendarEvent, day, next time.Time) models.LayoutItem {
+	start, end := e.Start.In(day.Location()), e.End.In(day.Location())
+	item := models.LayoutItem{
+		EventID:    e.ID,
+		CalendarID: e.CalendarID,
+		Name:       e.Summary,
+		EventStart: e.Start,
+		EventEnd:   e.End,
+	}
+
+	if e.IsAllDay {
+		item.Start, item.End = day, next
+		item.DurationMinutes = int(next.Sub(day).Minutes())
+		return item
+	}
+
+	if start.Before(day) {
+		start = day
+		item.ContinuesBefore = true
+	}
+	if end.After(next) {
+		end = next
+		item.ContinuesAfter = true
+	}
+
+	item.Start, item.End = start, end
+	item.StartMinute = int(start.Sub(day).Minutes())
+	item.DurationMinutes = int(end.Sub(start).Minutes())
+	return item
+}
+
+func assignLanes(items []models.LayoutItem, allDay bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if allDay && !a.EventStart.Equal(b.EventStart) {
+			return a.EventStart.Before(b.EventStart)
+		}
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if !a.End.Equal(b.End) {
+			return a.End.After(b.End) // longer first, so it keeps the leftmost lane
+		}
+		if a.CalendarID != b.CalendarID {
+			return a.CalendarID < b.CalendarID
+		}
+		return a.EventID < b.EventID
+	})
+
+	if allDay {
+		// all-day items of one day always share the row
+		for i := range items {
+			items[i].Lane = i
+			items[i].Lanes = len(items)
+		}
+		return
+	}
+
+	clusterStart := 0
+	var clusterEnd time.Time
+	var laneEnds []time.Time
+
+	closeCluster := func(end int) {
+		for k := clusterStart; k < end; k++ {
+			items[k].Lanes = len(laneEnds)
+		}
+	}
+
+	for i := range items {
+		start, end := items[i].Start, layoutEnd(items[i])
+
+		if i > 0 && !start.Before(clusterEnd) {
+			closeCluster(i)
+			clusterStart = i
+			laneEnds = laneEnds[:0]
+		}
+
+		lane := -1
+		for l, laneEnd := range laneEnds {
+			if !laneEnd.After(start) {
+				lane = l
+				break
+			}
+		}
+		if lane < 0 {
+			lane = len(laneEnds)
+			laneEnds = append(laneEnds, end)
+		} else {
+			laneEnds[lane] = end
+		}
+		items[i].Lane = lane
+
+		if i == clusterStart || end.After(clusterEnd) {
+			clusterEnd = end
+		}
+	}
+	closeCluster(len(items))
+}
+
+func layoutEnd(item models.LayoutItem) time.Time {
+	if minEnd := item.Start.Add(minLayoutMinutes * time.Minute); item.End.Before(minEnd) {
+		return minEnd
+	}
+	return item.End
+}
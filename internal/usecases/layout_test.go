@@ -0,0 +1,182 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"testing"
+	"time"
+)
+
+var layoutLoc = time.FixedZone("MSK", 3*60*60)
+
+// layoutAt returns time of 2026-03-10 plus days in layoutLoc, clock is "15:04"
+func layoutAt(days int, clock string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", "2026-03-10 "+clock, layoutLoc)
+	if err != nil {
+		panic(err)
+	}
+	return t.AddDate(0, 0, days)
+}
+
+func layoutEvent(id string, start, end time.Time) *models.CalendarEvent {
+	return &models.CalendarEvent{ID: id, CalendarID: "primary", Summary: id, Start: start, End: end}
+}
+
+// lanes is Lane and Lanes of item
+type lanes [2]int
+
+func TestLayoutLanes(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []*models.CalendarEvent
+		want   map[string]lanes
+	}{
+		{
+			name: "touching events do not overlap",
+			events: []*models.CalendarEvent{
+				layoutEvent("a", layoutAt(0, "09:00"), layoutAt(0, "10:00")),
+				layoutEvent("b", layoutAt(0, "10:00"), layoutAt(0, "11:00")),
+			},
+			want: map[string]lanes{"a": {0, 1}, "b": {0, 1}},
+		},
+		{
+			name: "overlapping pair",
+			events: []*models.CalendarEvent{
+				layoutEvent("b", layoutAt(0, "10:00"), layoutAt(0, "12:00")),
+				layoutEvent("a", layoutAt(0, "09:00"), layoutAt(0, "11:00")),
+			},
+			want: map[string]lanes{"a": {0, 2}, "b": {1, 2}},
+		},
+		{
+			name: "transitive cluster shares lane count and reuses free lane",
+			events: []*models.CalendarEvent{
+				layoutEvent("a", layoutAt(0, "09:00"), layoutAt(0, "10:30")),
+				layoutEvent("b", layoutAt(0, "10:00"), layoutAt(0, "11:30")),
+				layoutEvent("c", layoutAt(0, "11:00"), layoutAt(0, "12:00")),
+				layoutEvent("d", layoutAt(0, "13:00"), layoutAt(0, "14:00")),
+			},
+			want: map[string]lanes{"a": {0, 2}, "b": {1, 2}, "c": {0, 2}, "d": {0, 1}},
+		},
+		{
+			name: "three at once",
+			events: []*models.CalendarEvent{
+				layoutEvent("a", layoutAt(0, "09:00"), layoutAt(0, "12:00")),
+				layoutEvent("b", layoutAt(0, "09:30"), layoutAt(0, "10:00")),
+				layoutEvent("c", layoutAt(0, "09:45"), layoutAt(0, "11:00")),
+				layoutEvent("d", layoutAt(0, "10:00"), layoutAt(0, "10:30")),
+			},
+			want: map[string]lanes{"a": {0, 3}, "b": {1, 3}, "c": {2, 3}, "d": {1, 3}},
+		},
+		{
+			name: "longer event keeps leftmost lane at same start",
+			events: []*models.CalendarEvent{
+				layoutEvent("short", layoutAt(0, "09:00"), layoutAt(0, "10:00")),
+				layoutEvent("long", layoutAt(0, "09:00"), layoutAt(0, "12:00")),
+			},
+			want: map[string]lanes{"long": {0, 2}, "short": {1, 2}},
+		},
+		{
+			name: "zero-length event takes minimal height",
+			events: []*models.CalendarEvent{
+				layoutEvent("reminder", layoutAt(0, "09:00"), layoutAt(0, "09:00")),
+				layoutEvent("b", layoutAt(0, "09:15"), layoutAt(0, "10:00")),
+				layoutEvent("c", layoutAt(0, "09:30"), layoutAt(0, "10:00")),
+			},
+			want: map[string]lanes{"reminder": {0, 2}, "b": {1, 2}, "c": {0, 2}},
+		},
+		{
+			name: "cancelled events are skipped",
+			events: []*models.CalendarEvent{
+				layoutEvent("a", layoutAt(0, "09:00"), layoutAt(0, "10:00")),
+				{ID: "gone", Status: "cancelled", Start: layoutAt(0, "09:00"), End: layoutAt(0, "10:00")},
+			},
+			want: map[string]lanes{"a": {0, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := LayoutDays(tt.events, layoutAt(0, "00:00"), layoutAt(1, "00:00"), layoutLoc, layoutLoc)
+			if len(days) != 1 {
+				t.Fatalf("days = %d, want 1", len(days))
+			}
+
+			got := make(map[string]lanes)
+			for _, item := range days[0].Events {
+				got[item.EventID] = lanes{item.Lane, item.Lanes}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("items = %v, want %v", got, tt.want)
+			}
+			for id, want := range tt.want {
+				if got[id] != want {
+					t.Fatalf("%s lane = %v, want %v (all %v)", id, got[id], want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLayoutCrossingMidnight(t *testing.T) {
+	events := []*models.CalendarEvent{
+		layoutEvent("night", layoutAt(0, "22:00"), layoutAt(1, "02:00")),
+		layoutEvent("early", layoutAt(1, "01:00"), layoutAt(1, "03:00")),
+	}
+
+	days := LayoutDays(events, layoutAt(0, "00:00"), layoutAt(2, "00:00"), layoutLoc, layoutLoc)
+	if len(days) != 2 || days[0].Date != "2026-03-10" || days[1].Date != "2026-03-11" {
+		t.Fatalf("days = %+v", days)
+	}
+
+	first := days[0].Events
+	if len(first) != 1 {
+		t.Fatalf("first day items = %+v", first)
+	}
+	night := first[0]
+	if night.StartMinute != 22*60 || night.DurationMinutes != 120 || night.ContinuesBefore || !night.ContinuesAfter || night.Lanes != 1 {
+		t.Fatalf("first day part = %+v", night)
+	}
+	if !night.EventStart.Equal(layoutAt(0, "22:00")) || !night.EventEnd.Equal(layoutAt(1, "02:00")) {
+		t.Fatalf("event bounds = %v..%v, want whole event", night.EventStart, night.EventEnd)
+	}
+
+	second := days[1].Events
+	if len(second) != 2 || second[0].EventID != "night" {
+		t.Fatalf("second day items = %+v", second)
+	}
+	tail := second[0]
+	if tail.StartMinute != 0 || tail.DurationMinutes != 120 || !tail.ContinuesBefore || tail.ContinuesAfter {
+		t.Fatalf("second day part = %+v", tail)
+	}
+	if tail.Lane != 0 || tail.Lanes != 2 || second[1].Lane != 1 || second[1].Lanes != 2 {
+		t.Fatalf("second day lanes = %+v", second)
+	}
+}
+
+func TestLayoutAllDay(t *testing.T) {
+	// providers store all-day events from midnight in calendar time zone, read back in UTC
+	calendarDay := func(d int) time.Time { return layoutAt(d, "00:00").UTC() }
+	events := []*models.CalendarEvent{
+		{ID: "trip", IsAllDay: true, Start: calendarDay(0), End: calendarDay(2)},
+		{ID: "holiday", IsAllDay: true, Start: calendarDay(1), End: calendarDay(2)},
+	}
+
+	days := LayoutDays(events, layoutAt(0, "00:00"), layoutAt(3, "00:00"), layoutLoc, layoutLoc)
+	if len(days) != 3 {
+		t.Fatalf("days = %d, want 3", len(days))
+	}
+
+	counts := []int{1, 2, 0}
+	for i, day := range days {
+		if len(day.AllDay) != counts[i] {
+			t.Fatalf("day %s all-day items = %+v, want %d", day.Date, day.AllDay, counts[i])
+		}
+		for lane, item := range day.AllDay {
+			if item.Lane != lane || item.Lanes != counts[i] || item.DurationMinutes != 24*60 {
+				t.Fatalf("day %s item = %+v", day.Date, item)
+			}
+		}
+	}
+	if days[1].AllDay[0].EventID != "trip" {
+		t.Fatalf("earlier all-day event must come first, got %+v", days[1].AllDay)
+	}
+}
@@ -0,0 +1,82 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"math"
+	"time"
+)
+
+// minCorrelationDays is how many days with mood are needed to say anything about correlation
+const minCorrelationDays = 3
+
+// MoodTrend puts average mood of each day next to its calendar load. days come from ComputeStats
+// and define the period, entries outside of it are ignored
+func MoodTrend(entries []*models.JournalEntry, days []models.DayLoad, from, to time.Time, loc *time.Location) *models.MoodTrend {
+	sum := make(map[string]int)
+	scored := make(map[string]int)
+	count := make(map[string]int)
+	for _, e := range entries {
+		date := e.CreatedAt.In(loc).Format(dateLayout)
+		count[date]++
+		if e.Mood != nil {
+			sum[date] += *e.Mood
+			scored[date]++
+		}
+	}
+
+	trend := &models.MoodTrend{Start: from, End: to, Days: []models.MoodDay{}}
+	var moods, busy []float64
+	var total, n int
+	for _, d := range days {
+		day := models.MoodDay{Date: d.Date, Entries: count[d.Date], BusyHours: d.BusyHours, Events: d.Events}
+		if scored[d.Date] > 0 {
+			avg := round(float64(sum[d.Date]) / float64(scored[d.Date]))
+			day.Mood = &avg
+			moods = append(moods, avg)
+			busy = append(busy, d.BusyHours)
+			total += sum[d.Date]
+			n += scored[d.Date]
+		}
+		trend.Days = append(trend.Days, day)
+	}
+
+	if n > 0 {
+		avg := round(float64(total) / float64(n))
+		trend.Average = &avg
+	}
+	if len(moods) >= 2 {
+		half := len(moods) / 2
+		change := round(mean(moods[len(moods)-half:]) - mean(moods[:half]))
+		trend.Change = &change
+	}
+	if len(moods) >= minCorrelationDays {
+		if r, ok := pearson(moods, busy); ok {
+			r = round(r)
+			trend.Correlation = &r
+		}
+	}
+	return trend
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// pearson is false when one of the series is constant
+func pearson(x, y []float64) (float64, bool) {
+	mx, my := mean(x), mean(y)
+	var cov, vx, vy float64
+	for i := range x {
+		cov += (x[i] - mx) * (y[i] - my)
+		vx += (x[i] - mx) * (x[i] - mx)
+		vy += (y[i] - my) * (y[i] - my)
+	}
+	if vx == 0 || vy == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(vx*vy), true
+}
@@ -0,0 +1,126 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"testing"
+	"time"
+)
+
+func TestMoodTrend(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 4)
+	mood := func(v int) *int { return &v }
+	at := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC) }
+
+	entries := []*models.JournalEntry{
+		{Mood: mood(8), CreatedAt: at(2, 9)},
+		{Mood: mood(6), CreatedAt: at(2, 18)},
+		{Mood: mood(6), CreatedAt: at(3, 12)},
+		{CreatedAt: at(4, 12)},                  // not scored
+		{Mood: mood(3), CreatedAt: at(4, 22)},   // already 5 March in Moscow
+		{Mood: mood(10), CreatedAt: at(10, 12)}, // outside of the period
+	}
+	days := []models.DayLoad{
+		{Date: "2026-03-02", BusyHours: 2, Events: 1},
+		{Date: "2026-03-03", BusyHours: 4, Events: 2},
+		{Date: "2026-03-04", BusyHours: 6, Events: 3},
+		{Date: "2026-03-05", BusyHours: 5, Events: 2},
+	}
+
+	trend := MoodTrend(entries, days, from, to, loc)
+	if len(trend.Days) != 4 {
+		t.Fatalf("days = %+v, want 4", trend.Days)
+	}
+	wantMood := []float64{7, 6, 0, 3}
+	wantEntries := []int{2, 1, 1, 1}
+	for i, d := range trend.Days {
+		if d.Entries != wantEntries[i] || d.BusyHours != days[i].BusyHours || d.Events != days[i].Events {
+			t.Fatalf("day %d = %+v, want %d entries and load of %+v", i, d, wantEntries[i], days[i])
+		}
+		if (d.Mood == nil) != (wantMood[i] == 0) || d.Mood != nil && *d.Mood != wantMood[i] {
+			t.Fatalf("day %s mood = %v, want %v", d.Date, d.Mood, wantMood[i])
+		}
+	}
+	// average of entries, not of days
+	if trend.Average == nil || *trend.Average != 5.75 {
+		t.Fatalf("average = %v, want 5.75", trend.Average)
+	}
+	if trend.Change == nil || *trend.Change != -4 {
+		t.Fatalf("change = %v, want -4", trend.Change)
+	}
+	if trend.Correlation == nil || *trend.Correlation != -0.89 {
+		t.Fatalf("correlation = %v, want -0.89", trend.Correlation)
+	}
+}
+
+func TestMoodTrendNotEnoughData(t *testing.T) {
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	mood := func(v int) *int { return &v }
+	at := func(day int) time.Time { return time.Date(2026, 3, day, 12, 0, 0, 0, time.UTC) }
+	days := []models.DayLoad{
+		{Date: "2026-03-02", BusyHours: 3},
+		{Date: "2026-03-03", BusyHours: 3},
+		{Date: "2026-03-04", BusyHours: 3},
+	}
+
+	trend := MoodTrend(nil, days, from, from.AddDate(0, 0, 3), time.UTC)
+	if trend.Average != nil || trend.Change != nil || trend.Correlation != nil || len(trend.Days) != 3 {
+		t.Fatalf("trend = %+v, want days without figures", trend)
+	}
+
+	entries := []*models.JournalEntry{{Mood: mood(5), CreatedAt: at(2)}, {Mood: mood(7), CreatedAt: at(3)}}
+	trend = MoodTrend(entries, days, from, from.AddDate(0, 0, 3), time.UTC)
+	if trend.Change == nil || *trend.Change != 2 || trend.Correlation != nil {
+		t.Fatalf("two days: change = %v, correlation = %v, want 2 and nil", trend.Change, trend.Correlation)
+	}
+
+	// constant load says nothing about correlation
+	entries = append(entries, &models.JournalEntry{Mood: mood(4), CreatedAt: at(4)})
+	trend = MoodTrend(entries, days, from, from.AddDate(0, 0, 3), time.UTC)
+	if trend.Correlation != nil {
+		t.Fatalf("correlation = %v with constant load, want nil", *trend.Correlation)
+	}
+}
+
+func TestParseMoodResponse(t *testing.T) {
+	tests := []struct {
+		response string
+		want     int
+		wantErr  bool
+	}{
+		{`Оценка: {"mood": 7}`, 7, false},
+		{`{"mood": 6.6}`, 7, false},
+		{`{"mood": 15}`, models.MoodMax, false},
+		{`{"mood": -2}`, models.MoodMin, false},
+		{`{"score": 5}`, 0, true},
+		{`{"mood": "хорошо"}`, 0, true},
+		{"нет оценки", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoodResponse(tt.response)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMoodResponse(%q) = %d, %v, want %d, error %v", tt.response, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseReflectionResponse(t *testing.T) {
+	reflection, err := ParseReflectionResponse("Вот разбор:\n" + `{
+		"summary": " Неделя была загруженной ",
+		"insights": ["Настроение падает в дни с 6+ часами встреч", " "],
+		"suggestions": ["Оставляй свободный вечер в среду"]
+	}`)
+	if err != nil {
+		t.Fatalf("ParseReflectionResponse: %v", err)
+	}
+	if reflection.Summary != "Неделя была загруженной" || len(reflection.Insights) != 1 || len(reflection.Suggestions) != 1 {
+		t.Fatalf("reflection = %+v", reflection)
+	}
+
+	for _, bad := range []string{"нет JSON", `{"summary": ""}`, `{"summary": }`} {
+		if _, err := ParseReflectionResponse(bad); err == nil {
+			t.Errorf("ParseReflectionResponse(%q) succeeded, want error", bad)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 const SEPARATOR = "|||UPDATE_DATA|||"
 
+// ParseAIResponse cuts |||UPDATE_DATA||| block out of model answer, userAnswer is the text around it
 func ParseAIResponse(response string) (userAnswer string, updatedContext models.Context) {
 	if !strings.Contains(response, SEPARATOR) {
 		return strings.TrimSpace(response), models.Context{}
@@ -17,8 +18,11 @@ func ParseAIResponse(response string) (userAnswer string, updatedContext models.
 		return strings.TrimSpace(parts[0]), models.Context{}
 	}
 
-	// Ответ (часть до первого SEPARATOR)
+	// Ответ (всё вне блока обновлений)
 	userAnswer = strings.TrimSpace(parts[0])
+	if len(parts) == 3 {
+		userAnswer = strings.TrimSpace(userAnswer + "\n" + parts[2])
+	}
 	userAnswer = strings.TrimPrefix(userAnswer, "Ответ:")
 	userAnswer = strings.TrimSpace(userAnswer)
 
@@ -29,6 +33,31 @@ func ParseAIResponse(response string) (userAnswer string, updatedContext models.
 	return userAnswer, updatedContext
 }
 
+// ParseHabitUpdates takes "Привычки: название: done" line of |||UPDATE_DATA||| block,
+// unknown statuses are dropped
+func ParseHabitUpdates(response string) map[string]string {
+	result := make(map[string]string)
+
+	parts := strings.SplitN(response, SEPARATOR, 3)
+	if len(parts) < 2 {
+		return result
+	}
+
+	for _, line := range strings.Split(parts[1], "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "Привычки:") {
+			continue
+		}
+		for habit, status := range parseProgress(strings.TrimPrefix(line, "Привычки:")) {
+			status = strings.ToLower(status)
+			if ValidCheckinStatus(status) {
+				result[habit] = status
+			}
+		}
+	}
+	return result
+}
+
 func parseUpdates(updatesText string, ctx *models.Context) {
 	tempCtx := models.Context{
 		Goals:    []string{},
@@ -108,3 +137,37 @@ func parseProgress(text string) map[string]string {
 	}
 	return result
 }
+
+// MergeContext applies model updates: goals and recent actions are replaced as whole lists,
+// progress is merged by key
+func MergeContext(oldContext, newUpdates models.Context) (models.Context, bool) {
+	result := oldContext
+	changed := false
+
+	if len(newUpdates.Goals) > 0 {
+		result.Goals = newUpdates.Goals
+		changed = true
+	}
+
+	if len(newUpdates.Recent5) > 0 {
+		result.Recent5 = newUpdates.Recent5
+		if len(result.Recent5) > 5 {
+			result.Recent5 = result.Recent5[:5]
+		}
+		changed = true
+	}
+
+	if len(newUpdates.Progress) > 0 {
+		progress := make(map[string]string, len(oldContext.Progress)+len(newUpdates.Progress))
+		for k, v := range oldContext.Progress {
+			progress[k] = v
+		}
+		for k, v := range newUpdates.Progress {
+			progress[k] = v
+		}
+		result.Progress = progress
+		changed = true
+	}
+
+	return result, changed
+}
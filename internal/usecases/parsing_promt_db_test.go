@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseAIResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		wantAnswer string
+		want       models.Context
+	}{
+		{
+			name:       "no updates",
+			response:   "  Ответ: Хорошо, записал  ",
+			wantAnswer: "Ответ: Хорошо, записал",
+		},
+		{
+			name: "block in the middle",
+			response: "Ответ: Отличный план!\n|||UPDATE_DATA|||\n" +
+				"Цели: выучить Go, пробежать марафон\n" +
+				"Недавние действия: купил кроссовки\n" +
+				"Прогресс: выучить Go: 40%, пробежать марафон: 10 км\n" +
+				"|||UPDATE_DATA|||\nУдачи!",
+			wantAnswer: "Отличный план!\n\nУдачи!",
+			want: models.Context{
+				Goals:    []string{"выучить Go", "пробежать марафон"},
+				Recent5:  []string{"купил кроссовки"},
+				Progress: map[string]string{"выучить Go": "40%", "пробежать марафон": "10 км"},
+			},
+		},
+		{
+			name:       "only progress, broken items dropped",
+			response:   "Молодец\n|||UPDATE_DATA|||\nПрогресс: выучить Go: 50%, без значения:, : пусто\n",
+			wantAnswer: "Молодец",
+			want:       models.Context{Progress: map[string]string{"выучить Go": "50%"}},
+		},
+		{
+			name:       "empty block changes nothing",
+			response:   "Готово|||UPDATE_DATA|||\n|||UPDATE_DATA|||",
+			wantAnswer: "Готово",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, got := ParseAIResponse(tt.response)
+			if answer != tt.wantAnswer {
+				t.Errorf("answer = %q, want %q", answer, tt.wantAnswer)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("context = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeContext(t *testing.T) {
+	old := models.Context{
+		Goals:    []string{"выучить Go"},
+		Recent5:  []string{"прочитал главу"},
+		Progress: map[string]string{"выучить Go": "30%", "бег": "5 км"},
+	}
+
+	merged, changed := MergeContext(old, models.Context{})
+	if changed || !reflect.DeepEqual(merged, old) {
+		t.Fatalf("empty update: merged = %+v, changed = %v, want old context unchanged", merged, changed)
+	}
+
+	merged, changed = MergeContext(old, models.Context{
+		Recent5:  []string{"1", "2", "3", "4", "5", "6"},
+		Progress: map[string]string{"выучить Go": "45%"},
+	})
+	want := models.Context{
+		Goals:    []string{"выучить Go"},
+		Recent5:  []string{"1", "2", "3", "4", "5"},
+		Progress: map[string]string{"выучить Go": "45%", "бег": "5 км"},
+	}
+	if !changed || !reflect.DeepEqual(merged, want) {
+		t.Fatalf("merged = %+v, changed = %v, want %+v", merged, changed, want)
+	}
+	if old.Progress["выучить Go"] != "30%" {
+		t.Fatal("merge modified progress of old context")
+	}
+}
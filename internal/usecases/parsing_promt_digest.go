@@ -0,0 +1,32 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"life_forge/internal/models"
+	"strings"
+)
+
+// ParseDigestResponse takes JSON object from model answer, text around it is ignored
+func ParseDigestResponse(response string) (*models.DigestSummary, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("no JSON in digest response")
+	}
+
+	var temp models.DigestSummary
+	if err := json.Unmarshal([]byte(response[start:end+1]), &temp); err != nil {
+		logParse("Error parsing digest JSON: %v", err)
+		return nil, fmt.Errorf("error parsing digest JSON: %w", err)
+	}
+
+	summary := &models.DigestSummary{
+		Summary: strings.TrimSpace(temp.Summary),
+		Focus:   nonEmpty(temp.Focus),
+	}
+	if summary.Summary == "" && len(summary.Focus) == 0 {
+		return nil, fmt.Errorf("empty digest")
+	}
+	return summary, nil
+}
@@ -0,0 +1,61 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"life_forge/internal/models"
+	"math"
+	"strings"
+)
+
+// ParseMoodResponse takes {"mood": 7} from model answer, score is clamped to 1..10
+func ParseMoodResponse(response string) (int, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end <= start {
+		return 0, fmt.Errorf("no JSON in mood response")
+	}
+
+	var temp struct {
+		Mood *float64 `json:"mood"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &temp); err != nil {
+		logParse("Error parsing mood JSON: %v", err)
+		return 0, fmt.Errorf("error parsing mood JSON: %w", err)
+	}
+	if temp.Mood == nil {
+		return 0, fmt.Errorf("mood is missing")
+	}
+
+	mood := int(math.Round(*temp.Mood))
+	return min(max(mood, models.MoodMin), models.MoodMax), nil
+}
+
+// ParseReflectionResponse takes JSON object from model answer, text around it is ignored
+func ParseReflectionResponse(response string) (*models.JournalReflection, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("no JSON in reflection response")
+	}
+
+	var temp struct {
+		Summary     string   `json:"summary"`
+		Insights    []string `json:"insights"`
+		Suggestions []string `json:"suggestions"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &temp); err != nil {
+		logParse("Error parsing reflection JSON: %v", err)
+		return nil, fmt.Errorf("error parsing reflection JSON: %w", err)
+	}
+
+	reflection := &models.JournalReflection{
+		Summary:     strings.TrimSpace(temp.Summary),
+		Insights:    nonEmpty(temp.Insights),
+		Suggestions: nonEmpty(temp.Suggestions),
+	}
+	if reflection.Summary == "" && len(reflection.Insights) == 0 && len(reflection.Suggestions) == 0 {
+		return nil, fmt.Errorf("empty reflection")
+	}
+	return reflection, nil
+}
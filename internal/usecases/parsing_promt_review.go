@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"life_forge/internal/models"
+	"strings"
+)
+
+// ParseWeeklyReviewResponse takes JSON object from model answer, text around it is ignored
+func ParseWeeklyReviewResponse(response string) (*models.WeeklyReview, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("no JSON in review response")
+	}
+
+	var temp struct {
+		Summary     string   `json:"summary"`
+		WentWell    []string `json:"went_well"`
+		Slipped     []string `json:"slipped"`
+		Suggestions []string `json:"suggestions"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &temp); err != nil {
+		logParse("Error parsing review JSON: %v", err)
+		return nil, fmt.Errorf("error parsing review JSON: %w", err)
+	}
+
+	review := &models.WeeklyReview{
+		Summary:     strings.TrimSpace(temp.Summary),
+		WentWell:    nonEmpty(temp.WentWell),
+		Slipped:     nonEmpty(temp.Slipped),
+		Suggestions: nonEmpty(temp.Suggestions),
+	}
+	if review.Summary == "" && len(review.WentWell) == 0 && len(review.Slipped) == 0 && len(review.Suggestions) == 0 {
+		return nil, fmt.Errorf("empty review")
+	}
+	return review, nil
+}
+
+func nonEmpty(items []string) []string {
+	result := []string{}
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
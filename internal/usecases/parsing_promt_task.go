@@ -0,0 +1,81 @@
+package usecases
+
+import (
+	"encoding/json"
+	"life_forge/internal/models"
+	"strings"
+	"time"
+)
+
+const TASK_SEPARATOR = "|||TASK|||"
+
+type taskJSON struct {
+	Title    string   `json:"title"`
+	Notes    string   `json:"notes"`
+	Due      string   `json:"due"` // YYYY-MM-DD
+	Priority string   `json:"priority"`
+	Estimate *float64 `json:"estimate"`
+	Tags     []string `json:"tags"`
+}
+
+// ParseTaskAIResponse cuts |||TASK||| block out of model answer. Tasks have no time, they are
+// to-dos, unlike events of |||CALENDAR_EVENT||| block. Broken items are dropped
+func ParseTaskAIResponse(response string) (string, []*models.Task) {
+	if !strings.Contains(response, TASK_SEPARATOR) {
+		return response, nil
+	}
+
+	parts := strings.SplitN(response, TASK_SEPARATOR, 3)
+	if len(parts) < 3 {
+		return strings.TrimSpace(parts[0]), nil
+	}
+	rest := strings.TrimSpace(strings.TrimSpace(parts[0]) + "\n" + strings.TrimSpace(parts[2]))
+
+	jsonPart := strings.TrimSpace(parts[1])
+	if jsonPart == "" || jsonPart == "{}" || jsonPart == "[]" {
+		return rest, nil
+	}
+
+	var items []taskJSON
+	if strings.HasPrefix(jsonPart, "[") {
+		if err := json.Unmarshal([]byte(jsonPart), &items); err != nil {
+			logParse("Error parsing tasks: %v", err)
+			return rest, nil
+		}
+	} else {
+		var item taskJSON
+		if err := json.Unmarshal([]byte(jsonPart), &item); err != nil {
+			logParse("Error parsing task: %v", err)
+			return rest, nil
+		}
+		items = append(items, item)
+	}
+
+	var tasks []*models.Task
+	for i, item := range items {
+		title := strings.TrimSpace(item.Title)
+		if title == "" {
+			logParse("Task %d skiped (no title)", i+1)
+			continue
+		}
+		task := &models.Task{
+			Title:         title,
+			Notes:         strings.TrimSpace(item.Notes),
+			Priority:      strings.ToLower(strings.TrimSpace(item.Priority)),
+			EstimateHours: item.Estimate,
+			Tags:          item.Tags,
+		}
+		if item.Due != "" {
+			due, err := time.Parse("2006-01-02", item.Due)
+			if err != nil {
+				logParse("Task %d: wrong due %q", i+1, item.Due)
+			} else {
+				task.Due = &due
+			}
+		}
+		tasks = append(tasks, task)
+	}
+
+	logParse("Succesfully parsed %d tasks", len(tasks))
+	return rest, tasks
+}
@@ -0,0 +1,51 @@
+package usecases
+
+import (
+	"testing"
+)
+
+func TestParseTaskAIResponse(t *testing.T) {
+	response := "Записал задачи.\n|||TASK|||\n" + `[
+		{"title": " Купить билеты ", "due": "2026-03-20", "priority": "HIGH", "estimate": 0.5, "tags": ["поездка"]},
+		{"title": "", "due": "2026-03-21"},
+		{"title": "Позвонить маме", "due": "в пятницу"}
+	]` + "\n|||TASK|||\nЧто-то ещё?"
+
+	answer, tasks := ParseTaskAIResponse(response)
+	if answer != "Записал задачи.\nЧто-то ещё?" {
+		t.Fatalf("answer = %q", answer)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("tasks = %d, want 2 with titles", len(tasks))
+	}
+	first := tasks[0]
+	if first.Title != "Купить билеты" || first.Priority != "high" || first.Due == nil || first.Due.Format("2006-01-02") != "2026-03-20" ||
+		first.EstimateHours == nil || *first.EstimateHours != 0.5 || len(first.Tags) != 1 {
+		t.Fatalf("first task = %+v", first)
+	}
+	// broken due keeps the task without date
+	if tasks[1].Title != "Позвонить маме" || tasks[1].Due != nil {
+		t.Fatalf("second task = %+v, want task without due", tasks[1])
+	}
+
+	tests := []struct {
+		name       string
+		response   string
+		wantAnswer string
+		wantTasks  int
+	}{
+		{"no block", "Просто ответ", "Просто ответ", 0},
+		{"single object", "Ок|||TASK|||{\"title\": \"Сдать отчёт\"}|||TASK|||", "Ок", 1},
+		{"empty block", "Ок\n|||TASK|||\n[]\n|||TASK|||", "Ок", 0},
+		{"broken json", "Ок|||TASK|||{\"title\": |||TASK|||", "Ок", 0},
+		{"unclosed block", "Ок|||TASK|||{\"title\": \"x\"}", "Ок", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, tasks := ParseTaskAIResponse(tt.response)
+			if answer != tt.wantAnswer || len(tasks) != tt.wantTasks {
+				t.Fatalf("ParseTaskAIResponse() = %q, %d tasks, want %q, %d", answer, len(tasks), tt.wantAnswer, tt.wantTasks)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package usecases
+
+import (
+	"fmt"
+	"life_forge/internal/models"
+	"sort"
+	"time"
+)
+
+const (
+	planDoneShare  = 0.8
+	unplannedKey   = ""
+	unplannedLabel = "Вне плана"
+)
+
+// PlanVsActual compares timed events of [from, to) with entries tracked in the window.
+// Entry counts for event it is linked to (whole entry, even outside of event time),
+// entries linked to tasks or to nothing are unplanned. Time in groups is clipped to the window,
+// running entries end at now
+func PlanVsActual(events []*models.CalendarEvent, entries []*models.TimeEntry, from, to time.Time, groupBy string, loc *time.Location, now time.Time, calendarNames map[string]string) (*models.PlanActualReport, error) {
+	var bucket func(t time.Time) (string, time.Time)
+	switch groupBy {
+	case models.PlanGroupDay:
+		bucket = func(t time.Time) (string, time.Time) {
+			day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+			return day.Format(dateLayout), day.AddDate(0, 0, 1)
+		}
+	case models.PlanGroupWeek:
+		bucket = func(t time.Time) (string, time.Time) {
+			monday := weekStart(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc))
+			return monday.Format(dateLayout), monday.AddDate(0, 0, 7)
+		}
+	case models.PlanGroupCalendar:
+	default:
+		return nil, fmt.Errorf("unknown group_by %q", groupBy)
+	}
+
+	report := &models.PlanActualReport{
+		Start:    from,
+		End:      to,
+		TimeZone: loc.String(),
+		GroupBy:  groupBy,
+		Groups:   []models.PlanActualGroup{},
+		Events:   []models.EventActual{},
+	}
+
+	var planned []*models.EventActual
+	byEventID := make(map[string][]*models.EventActual)
+	var plannedPieces, actualPieces []piece
+	var plannedAll []interval
+
+	for _, e := range events {
+		if e.IsAllDay || e.Status == "cancelled" || e.Start.IsZero() {
+			continue
+		}
+		iv, ok := clip(interval{e.Start, e.End}, from, to)
+		if !ok {
+			continue
+		}
+		ea := &models.EventActual{
+			CalendarID:   e.CalendarID,
+			EventID:      e.ID,
+			Summary:      e.Summary,
+			Start:        e.Start,
+			End:          e.End,
+			PlannedHours: hours(e.End.Sub(e.Start)),
+		}
+		planned = append(planned, ea)
+		byEventID[e.ID] = append(byEventID[e.ID], ea)
+		plannedAll = append(plannedAll, iv)
+		plannedPieces = append(plannedPieces, pieceOf(iv, e.CalendarID, bucket, loc)...)
+	}
+
+	var actual, onPlan, unplanned time.Duration
+	for _, entry := range entries {
+		end := now
+		if entry.End != nil {
+			end = *entry.End
+		}
+
+		// entry without calendar id matches event of any calendar with this id
+		var linked *models.EventActual
+		for _, ea := range byEventID[entry.EventID] {
+			if entry.CalendarID == "" || entry.CalendarID == ea.CalendarID {
+				linked = ea
+				break
+			}
+		}
+
+		key := unplannedKey
+		if linked != nil {
+			linked.ActualHours += end.Sub(entry.Start).Hours()
+			key = linked.CalendarID
+		}
+
+		iv, ok := clip(interval{entry.Start, end}, from, to)
+		if !ok {
+			continue
+		}
+		d := iv.end.Sub(iv.start)
+		actual += d
+		if linked != nil {
+			onPlan += d
+		} else {
+			unplanned += d
+		}
+		actualPieces = append(actualPieces, pieceOf(iv, key, bucket, loc)...)
+	}
+
+	plannedTotal := total(union(plannedAll))
+	report.Totals = models.PlanActualTotals{
+		PlannedHours:   hours(plannedTotal),
+		ActualHours:    hours(actual),
+		OnPlanHours:    hours(onPlan),
+		UnplannedHours: hours(unplanned),
+	}
+	if plannedTotal > 0 {
+		report.Totals.Adherence = round(onPlan.Hours() / plannedTotal.Hours())
+	}
+
+	report.Groups = planActualGroups(plannedPieces, actualPieces, groupBy, from, to, loc, bucket, calendarNames)
+
+	for _, ea := range planned {
+		ea.ActualHours = round(ea.ActualHours)
+		switch {
+		case ea.PlannedHours > 0 && ea.ActualHours >= ea.PlannedHours*planDoneShare:
+			ea.Status = models.PlanStatusDone
+		case ea.ActualHours > 0:
+			ea.Status = models.PlanStatusPartial
+		case ea.End.After(now):
+			ea.Status = models.PlanStatusUpcoming
+		default:
+			ea.Status = models.PlanStatusMissed
+		}
+		report.Events = append(report.Events, *ea)
+	}
+	sort.Slice(report.Events, func(i, j int) bool {
+		if !report.Events[i].Start.Equal(report.Events[j].Start) {
+			return report.Events[i].Start.Before(report.Events[j].Start)
+		}
+		return report.Events[i].EventID < report.Events[j].EventID
+	})
+
+	return report, nil
+}
+
+// pieceOf cuts interval by day or week, for calendar grouping the whole interval goes to key
+func pieceOf(iv interval, key string, bucket func(t time.Time) (string, time.Time), loc *time.Location) []piece {
+	if bucket == nil {
+		return []piece{{key: key, interval: iv, counts: true}}
+	}
+	return splitBy(iv, loc, bucket)
+}
+
+func planActualGroups(plannedPieces, actualPieces []piece, groupBy string, from, to time.Time, loc *time.Location, bucket func(t time.Time) (string, time.Time), calendarNames map[string]string) []models.PlanActualGroup {
+	plannedBy := make(map[string][]interval)
+	actualBy := make(map[string]time.Duration)
+	eventCounts := make(map[string]int)
+	entryCounts := make(map[string]int)
+	for _, p := range plannedPieces {
+		plannedBy[p.key] = append(plannedBy[p.key], p.interval)
+		if p.counts {
+			eventCounts[p.key]++
+		}
+	}
+	for _, p := range actualPieces {
+		actualBy[p.key] += p.end.Sub(p.start)
+		if p.counts {
+			entryCounts[p.key]++
+		}
+	}
+
+	// days and weeks are listed without gaps, calendars only when they have plan or fact
+	var keys []string
+	if bucket != nil {
+		for t := from.In(loc); t.Before(to); {
+			key, next := bucket(t)
+			keys = append(keys, key)
+			t = next
+		}
+	} else {
+		seen := make(map[string]bool)
+		for _, m := range []map[string]int{eventCounts, entryCounts} {
+			for key := range m {
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			// unplanned time goes last
+			if (keys[i] == unplannedKey) != (keys[j] == unplannedKey) {
+				return keys[j] == unplannedKey
+			}
+			return keys[i] < keys[j]
+		})
+	}
+
+	groups := make([]models.PlanActualGroup, 0, len(keys))
+	for _, key := range keys {
+		plannedHours := hours(total(union(plannedBy[key])))
+		actualHours := hours(actualBy[key])
+		groups = append(groups, models.PlanActualGroup{
+			Key:          key,
+			Label:        planActualLabel(groupBy, key, calendarNames),
+			PlannedHours: plannedHours,
+			ActualHours:  actualHours,
+			DiffHours:    round(actualHours - plannedHours),
+			Events:       eventCounts[key],
+			Entries:      entryCounts[key],
+		})
+	}
+	return groups
+}
+
+func planActualLabel(groupBy, key string, calendarNames map[string]string) string {
+	switch groupBy {
+	case models.PlanGroupCalendar:
+		if key == unplannedKey {
+			return unplannedLabel
+		}
+		if name, ok := calendarNames[key]; ok && name != "" {
+			return name
+		}
+	case models.PlanGroupDay, models.PlanGroupWeek:
+		if day, err := time.Parse(dateLayout, key); err == nil {
+			label := weekdayLabels[day.Weekday()] + " " + day.Format("02.01")
+			if groupBy == models.PlanGroupWeek {
+				label = "Неделя с " + day.Format("02.01")
+			}
+			return label
+		}
+	}
+	return key
+}
@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanVsActual(t *testing.T) {
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC) }
+	end := func(day, hour, minute int) *time.Time { t := at(day, hour, minute); return &t }
+	from, to := at(2, 0, 0), at(4, 0, 0)
+	now := at(3, 12, 0)
+
+	events := []*models.CalendarEvent{
+		{ID: "a", CalendarID: "work", Summary: "Отчёт", Start: at(2, 10, 0), End: at(2, 12, 0)},
+		{ID: "b", CalendarID: "primary", Summary: "Встреча", Start: at(2, 11, 0), End: at(2, 13, 0)},
+		{ID: "c", CalendarID: "primary", Summary: "Спорт", Start: at(3, 9, 0), End: at(3, 10, 0)},
+		{ID: "d", CalendarID: "primary", Summary: "Чтение", Start: at(3, 15, 0), End: at(3, 16, 0)},
+		{ID: "trip", CalendarID: "primary", IsAllDay: true, Start: at(2, 0, 0), End: at(3, 0, 0)},
+		{ID: "gone", CalendarID: "primary", Status: "cancelled", Start: at(2, 14, 0), End: at(2, 15, 0)},
+	}
+	entries := []*models.TimeEntry{
+		{EventID: "a", Start: at(2, 10, 0), End: end(2, 11, 30)},                       // any calendar
+		{EventID: "b", CalendarID: "primary", Start: at(2, 11, 0), End: end(2, 13, 0)}, // whole event
+		{EventID: "a", CalendarID: "other", Start: at(2, 20, 0), End: end(2, 21, 0)},   // no such event
+		{Start: at(3, 10, 0)}, // running
+	}
+	names := map[string]string{"work": "Работа"}
+
+	report, err := PlanVsActual(events, entries, from, to, models.PlanGroupDay, time.UTC, now, names)
+	if err != nil {
+		t.Fatalf("PlanVsActual: %v", err)
+	}
+
+	// overlapping events are planned once
+	wantTotals := models.PlanActualTotals{PlannedHours: 5, ActualHours: 6.5, OnPlanHours: 3.5, UnplannedHours: 3, Adherence: 0.7}
+	if report.Totals != wantTotals {
+		t.Fatalf("totals = %+v, want %+v", report.Totals, wantTotals)
+	}
+	wantStatus := map[string]string{
+		"a": models.PlanStatusPartial,
+		"b": models.PlanStatusDone,
+		"c": models.PlanStatusMissed,
+		"d": models.PlanStatusUpcoming,
+	}
+	if len(report.Events) != len(wantStatus) {
+		t.Fatalf("events = %+v, want timed events only", report.Events)
+	}
+	for i, id := range []string{"a", "b", "c", "d"} {
+		if e := report.Events[i]; e.EventID != id || e.Status != wantStatus[id] {
+			t.Fatalf("event %d = %+v, want %s %s", i, e, id, wantStatus[id])
+		}
+	}
+	wantDays := []models.PlanActualGroup{
+		{Key: "2026-03-02", Label: "Пн 02.03", PlannedHours: 3, ActualHours: 4.5, DiffHours: 1.5, Events: 2, Entries: 3},
+		{Key: "2026-03-03", Label: "Вт 03.03", PlannedHours: 2, ActualHours: 2, DiffHours: 0, Events: 2, Entries: 1},
+	}
+	if !reflect.DeepEqual(report.Groups, wantDays) {
+		t.Fatalf("day groups = %+v, want %+v", report.Groups, wantDays)
+	}
+
+	report, err = PlanVsActual(events, entries, from, to, models.PlanGroupCalendar, time.UTC, now, names)
+	if err != nil {
+		t.Fatalf("PlanVsActual: %v", err)
+	}
+	wantCalendars := []models.PlanActualGroup{
+		{Key: "primary", Label: "primary", PlannedHours: 4, ActualHours: 2, DiffHours: -2, Events: 3, Entries: 1},
+		{Key: "work", Label: "Работа", PlannedHours: 2, ActualHours: 1.5, DiffHours: -0.5, Events: 1, Entries: 1},
+		{Key: "", Label: "Вне плана", ActualHours: 3, DiffHours: 3, Entries: 2},
+	}
+	if !reflect.DeepEqual(report.Groups, wantCalendars) {
+		t.Fatalf("calendar groups = %+v, want %+v", report.Groups, wantCalendars)
+	}
+
+	report, err = PlanVsActual(events, entries, from, to, models.PlanGroupWeek, time.UTC, now, names)
+	if err != nil {
+		t.Fatalf("PlanVsActual: %v", err)
+	}
+	if len(report.Groups) != 1 || report.Groups[0].Label != "Неделя с 02.03" || report.Groups[0].PlannedHours != 5 {
+		t.Fatalf("week groups = %+v, want one week", report.Groups)
+	}
+
+	if _, err := PlanVsActual(events, entries, from, to, "month", time.UTC, now, names); err == nil {
+		t.Fatal("PlanVsActual() with unknown grouping succeeded, want error")
+	}
+}
@@ -0,0 +1,312 @@
+package usecases
+
+import (
+	"fmt"
+	"life_forge/internal/models"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	workDayStartHour = 9
+	workDayEndHour   = 18
+	freeStreaksLimit = 5
+)
+
+// Google event colorId -> name of the color, user picks colors as categories
+var categoryLabels = map[string]string{
+	"":   "Без категории",
+	"1":  "Лаванда",
+	"2":  "Шалфей",
+	"3":  "Виноград",
+	"4":  "Фламинго",
+	"5":  "Банан",
+	"6":  "Мандарин",
+	"7":  "Павлин",
+	"8":  "Графит",
+	"9":  "Черника",
+	"10": "Базилик",
+	"11": "Томат",
+}
+
+var weekdayLabels = []string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"}
+
+type interval struct {
+	start, end time.Time
+}
+
+// piece is part of event that belongs to one group
+type piece struct {
+	key string
+	interval
+	counts bool // event is counted in group of its first piece only
+}
+
+// ComputeStats aggregates timed events of [from, to). All-day and cancelled events are skipped,
+// time inside one group and in totals is a union, so double-booked time is counted once
+func ComputeStats(events []*models.CalendarEvent, from, to time.Time, groupBy string, loc *time.Location, calendarNames map[string]string) (*models.Stats, error) {
+	switch groupBy {
+	case models.StatsGroupCalendar, models.StatsGroupCategory, models.StatsGroupWeekday, models.StatsGroupHour:
+	default:
+		return nil, fmt.Errorf("unknown group_by %q", groupBy)
+	}
+
+	stats := &models.Stats{
+		Start:       from,
+		End:         to,
+		TimeZone:    loc.String(),
+		GroupBy:     groupBy,
+		Groups:      []models.StatsGroup{},
+		Days:        []models.DayLoad{},
+		FreeStreaks: []models.FreeStreak{},
+	}
+
+	var all []interval
+	var pieces []piece
+	var scheduled time.Duration
+	eventsPerDay := make(map[string]int)
+
+	for _, e := range events {
+		if e.IsAllDay || e.Status == "cancelled" || e.Start.IsZero() {
+			continue
+		}
+		iv, ok := clip(interval{e.Start, e.End}, from, to)
+		if !ok {
+			continue
+		}
+
+		stats.Totals.Events++
+		scheduled += iv.end.Sub(iv.start)
+		all = append(all, iv)
+		eventsPerDay[iv.start.In(loc).Format("2006-01-02")]++
+
+		switch groupBy {
+		case models.StatsGroupCalendar:
+			pieces = append(pieces, piece{key: e.CalendarID, interval: iv, counts: true})
+		case models.StatsGroupCategory:
+			pieces = append(pieces, piece{key: e.ColorID, interval: iv, counts: true})
+		case models.StatsGroupWeekday:
+			pieces = append(pieces, splitBy(iv, loc, func(t time.Time) (string, time.Time) {
+				day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+				return strconv.Itoa(int(t.Weekday())), day.AddDate(0, 0, 1)
+			})...)
+		case models.StatsGroupHour:
+			pieces = append(pieces, splitBy(iv, loc, func(t time.Time) (string, time.Time) {
+				hour := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+				return strconv.Itoa(t.Hour()), hour.Add(time.Hour)
+			})...)
+		}
+	}
+
+	busy := union(all)
+	busyTotal := total(busy)
+	stats.Totals.ScheduledHours = hours(scheduled)
+	stats.Totals.BusyHours = hours(busyTotal)
+	stats.Totals.OverlapHours = hours(scheduled - busyTotal)
+	stats.Totals.FreeHours = hours(to.Sub(from) - busyTotal)
+
+	stats.Groups = groupStats(pieces, groupBy, calendarNames)
+	stats.FreeStreaks = freeStreaks(busy, from, to)
+
+	var workTotal, busyInWork time.Duration
+	from = from.In(loc)
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		dayIv, _ := clip(interval{day, day.AddDate(0, 0, 1)}, stats.Start, to)
+		load := models.DayLoad{
+			Date:      day.Format("2006-01-02"),
+			BusyHours: hours(total(intersect(busy, dayIv))),
+			Events:    eventsPerDay[day.Format("2006-01-02")],
+		}
+
+		if wd := day.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			work := interval{day.Add(workDayStartHour * time.Hour), day.Add(workDayEndHour * time.Hour)}
+			if work, ok := clip(work, stats.Start, to); ok {
+				workDur := work.end.Sub(work.start)
+				busyDur := total(intersect(busy, work))
+				load.WorkHours = hours(workDur)
+				load.MeetingLoad = round(busyDur.Hours() / workDur.Hours())
+				workTotal += workDur
+				busyInWork += busyDur
+			}
+		}
+		stats.Days = append(stats.Days, load)
+	}
+	if workTotal > 0 {
+		stats.Totals.MeetingLoad = round(busyInWork.Hours() / workTotal.Hours())
+	}
+
+	return stats, nil
+}
+
+func groupStats(pieces []piece, groupBy string, calendarNames map[string]string) []models.StatsGroup {
+	byKey := make(map[string][]interval)
+	counts := make(map[string]int)
+	for _, p := range pieces {
+		byKey[p.key] = append(byKey[p.key], p.interval)
+		if p.counts {
+			counts[p.key]++
+		}
+	}
+
+	// weekday and hour charts need every bar, even empty ones
+	var keys []string
+	switch groupBy {
+	case models.StatsGroupWeekday:
+		for _, wd := range []int{1, 2, 3, 4, 5, 6, 0} {
+			keys = append(keys, strconv.Itoa(wd))
+		}
+	case models.StatsGroupHour:
+		for h := 0; h < 24; h++ {
+			keys = append(keys, strconv.Itoa(h))
+		}
+	default:
+		for key := range byKey {
+			keys = append(keys, key)
+		}
+	}
+
+	groups := make([]models.StatsGroup, 0, len(keys))
+	for _, key := range keys {
+		groups = append(groups, models.StatsGroup{
+			Key:    key,
+			Label:  groupLabel(groupBy, key, calendarNames),
+			Hours:  hours(total(union(byKey[key]))),
+			Events: counts[key],
+		})
+	}
+
+	if groupBy == models.StatsGroupCalendar || groupBy == models.StatsGroupCategory {
+		sort.Slice(groups, func(i, j int) bool {
+			if groups[i].Hours != groups[j].Hours {
+				return groups[i].Hours > groups[j].Hours
+			}
+			return groups[i].Key < groups[j].Key
+		})
+	}
+	return groups
+}
+
+func groupLabel(groupBy, key string, calendarNames map[string]string) string {
+	switch groupBy {
+	case models.StatsGroupCalendar:
+		if name, ok := calendarNames[key]; ok && name != "" {
+			return name
+		}
+	case models.StatsGroupCategory:
+		if label, ok := categoryLabels[key]; ok {
+			return label
+		}
+	case models.StatsGroupWeekday:
+		if wd, err := strconv.Atoi(key); err == nil && wd >= 0 && wd < len(weekdayLabels) {
+			return weekdayLabels[wd]
+		}
+	case models.StatsGroupHour:
+		return key + ":00"
+	}
+	return key
+}
+
+// splitBy cuts interval at bucket bounds, bucket returns key of t and start of the next bucket
+func splitBy(iv interval, loc *time.Location, bucket func(t time.Time) (string, time.Time)) []piece {
+	var pieces []piece
+	for start := iv.start.In(loc); start.Before(iv.end); {
+		key, next := bucket(start)
+		end := next
+		if iv.end.Before(end) {
+			end = iv.end
+		}
+		pieces = append(pieces, piece{key: key, interval: interval{start, end}, counts: len(pieces) == 0})
+		start = next
+	}
+	return pieces
+}
+
+// freeStreaks returns the longest gaps between busy periods, longest first
+func freeStreaks(busy []interval, from, to time.Time) []models.FreeStreak {
+	var gaps []interval
+	cursor := from
+	for _, b := range busy {
+		if b.start.After(cursor) {
+			gaps = append(gaps, interval{cursor, b.start})
+		}
+		if b.end.After(cursor) {
+			cursor = b.end
+		}
+	}
+	if to.After(cursor) {
+		gaps = append(gaps, interval{cursor, to})
+	}
+
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].end.Sub(gaps[i].start) > gaps[j].end.Sub(gaps[j].start)
+	})
+	if len(gaps) > freeStreaksLimit {
+		gaps = gaps[:freeStreaksLimit]
+	}
+
+	streaks := make([]models.FreeStreak, 0, len(gaps))
+	for _, g := range gaps {
+		streaks = append(streaks, models.FreeStreak{Start: g.start, End: g.end, Hours: hours(g.end.Sub(g.start))})
+	}
+	return streaks
+}
+
+// union sorts intervals and joins overlapping ones
+func union(intervals []interval) []interval {
+	if len(intervals) == 0 {
+		return nil
+	}
+	sorted := append([]interval(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start.Before(sorted[j].start) })
+
+	merged := []interval{sorted[0]}
+	for _, iv := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if !iv.start.After(last.end) {
+			if iv.end.After(last.end) {
+				last.end = iv.end
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+func intersect(merged []interval, window interval) []interval {
+	var result []interval
+	for _, iv := range merged {
+		if c, ok := clip(iv, window.start, window.end); ok {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func clip(iv interval, from, to time.Time) (interval, bool) {
+	if iv.start.Before(from) {
+		iv.start = from
+	}
+	if iv.end.After(to) {
+		iv.end = to
+	}
+	return iv, iv.end.After(iv.start)
+}
+
+func total(intervals []interval) time.Duration {
+	var sum time.Duration
+	for _, iv := range intervals {
+		sum += iv.end.Sub(iv.start)
+	}
+	return sum
+}
+
+func hours(d time.Duration) float64 {
+	return round(d.Hours())
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
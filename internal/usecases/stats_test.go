@@ -0,0 +1,161 @@
+package usecases
+
+import (
+	"life_forge/internal/models"
+	"testing"
+)
+
+func statsEvents() []*models.CalendarEvent {
+	a := layoutEvent("a", layoutAt(0, "09:00"), layoutAt(0, "11:00"))
+	a.CalendarID, a.ColorID = "work", "1"
+	b := layoutEvent("b", layoutAt(0, "10:00"), layoutAt(0, "12:00"))
+	b.CalendarID, b.ColorID = "work", "1"
+	c := layoutEvent("c", layoutAt(0, "11:30"), layoutAt(0, "12:30"))
+	c.CalendarID = "home"
+	cancelled := layoutEvent("cancelled", layoutAt(0, "14:00"), layoutAt(0, "15:00"))
+	cancelled.Status = "cancelled"
+	allDay := layoutEvent("all-day", layoutAt(0, "00:00"), layoutAt(1, "00:00"))
+	allDay.IsAllDay = true
+	return []*models.CalendarEvent{a, b, c, cancelled, allDay}
+}
+
+func TestComputeStatsTotals(t *testing.T) {
+	stats, err := ComputeStats(statsEvents(), layoutAt(0, "00:00"), layoutAt(1, "00:00"), models.StatsGroupCalendar, layoutLoc, nil)
+	if err != nil {
+		t.Fatalf("ComputeStats: %v", err)
+	}
+
+	want := models.StatsTotals{
+		Events:         3,
+		BusyHours:      3.5,
+		ScheduledHours: 5,
+		OverlapHours:   1.5,
+		FreeHours:      20.5,
+		MeetingLoad:    0.39,
+	}
+	if stats.Totals != want {
+		t.Fatalf("totals = %+v, want %+v", stats.Totals, want)
+	}
+
+	if len(stats.Days) != 1 || stats.Days[0].BusyHours != 3.5 || stats.Days[0].WorkHours != 9 || stats.Days[0].Events != 3 {
+		t.Fatalf("days = %+v", stats.Days)
+	}
+	if len(stats.FreeStreaks) != 2 || stats.FreeStreaks[0].Hours != 11.5 || stats.FreeStreaks[1].Hours != 9 {
+		t.Fatalf("free streaks = %+v, want 11.5h evening then 9h morning", stats.FreeStreaks)
+	}
+}
+
+func TestComputeStatsGroups(t *testing.T) {
+	tests := []struct {
+		groupBy string
+		names   map[string]string
+		want    []models.StatsGroup // only listed groups are checked, in order for calendar and category
+		wantLen int
+	}{
+		{
+			groupBy: models.StatsGroupCalendar,
+			names:   map[string]string{"work": "Работа"},
+			want: []models.StatsGroup{
+				{Key: "work", Label: "Работа", Hours: 3, Events: 2},
+				{Key: "home", Label: "home", Hours: 1, Events: 1},
+			},
+			wantLen: 2,
+		},
+		{
+			groupBy: models.StatsGroupCategory,
+			want: []models.StatsGroup{
+				{Key: "1", Label: "Лаванда", Hours: 3, Events: 2},
+				{Key: "", Label: "Без категории", Hours: 1, Events: 1},
+			},
+			wantLen: 2,
+		},
+		{
+			groupBy: models.StatsGroupHour,
+			want: []models.StatsGroup{
+				{Key: "0", Label: "0:00"},
+				{Key: "9", Label: "9:00", Hours: 1, Events: 1},
+				{Key: "10", Label: "10:00", Hours: 1, Events: 1},
+				{Key: "11", Label: "11:00", Hours: 1, Events: 1},
+				{Key: "12", Label: "12:00", Hours: 0.5},
+			},
+			wantLen: 24,
+		},
+		{
+			groupBy: models.StatsGroupWeekday,
+			want: []models.StatsGroup{
+				{Key: "1", Label: "Пн"},
+				{Key: "2", Label: "Вт", Hours: 3.5, Events: 3},
+			},
+			wantLen: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			stats, err := ComputeStats(statsEvents(), layoutAt(0, "00:00"), layoutAt(1, "00:00"), tt.groupBy, layoutLoc, tt.names)
+			if err != nil {
+				t.Fatalf("ComputeStats: %v", err)
+			}
+			if len(stats.Groups) != tt.wantLen {
+				t.Fatalf("groups = %+v, want %d", stats.Groups, tt.wantLen)
+			}
+
+			byKey := make(map[string]models.StatsGroup)
+			for _, g := range stats.Groups {
+				byKey[g.Key] = g
+			}
+			for i, want := range tt.want {
+				got := byKey[want.Key]
+				if tt.groupBy == models.StatsGroupCalendar || tt.groupBy == models.StatsGroupCategory {
+					got = stats.Groups[i]
+				}
+				if got != want {
+					t.Fatalf("group %q = %+v, want %+v", want.Key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeStatsSplitsAtMidnight(t *testing.T) {
+	night := layoutEvent("night", layoutAt(0, "23:00"), layoutAt(1, "01:00"))
+
+	stats, err := ComputeStats([]*models.CalendarEvent{night}, layoutAt(0, "00:00"), layoutAt(2, "00:00"), models.StatsGroupWeekday, layoutLoc, nil)
+	if err != nil {
+		t.Fatalf("ComputeStats: %v", err)
+	}
+
+	for _, g := range stats.Groups {
+		switch g.Key {
+		case "2":
+			if g.Hours != 1 || g.Events != 1 {
+				t.Fatalf("tuesday = %+v, want 1h and the event", g)
+			}
+		case "3":
+			if g.Hours != 1 || g.Events != 0 {
+				t.Fatalf("wednesday = %+v, want 1h without counting event again", g)
+			}
+		}
+	}
+	if len(stats.Days) != 2 || stats.Days[0].BusyHours != 1 || stats.Days[1].BusyHours != 1 {
+		t.Fatalf("days = %+v", stats.Days)
+	}
+}
+
+func TestComputeStatsClipsToWindow(t *testing.T) {
+	long := layoutEvent("long", layoutAt(-1, "20:00"), layoutAt(0, "02:00"))
+
+	stats, err := ComputeStats([]*models.CalendarEvent{long}, layoutAt(0, "00:00"), layoutAt(1, "00:00"), models.StatsGroupCalendar, layoutLoc, nil)
+	if err != nil {
+		t.Fatalf("ComputeStats: %v", err)
+	}
+	if stats.Totals.Events != 1 || stats.Totals.BusyHours != 2 || stats.Totals.ScheduledHours != 2 {
+		t.Fatalf("totals = %+v, want only 2h inside window", stats.Totals)
+	}
+}
+
+func TestComputeStatsUnknownGroup(t *testing.T) {
+	if _, err := ComputeStats(nil, layoutAt(0, "00:00"), layoutAt(1, "00:00"), "project", layoutLoc, nil); err == nil {
+		t.Fatal("ComputeStats succeeded, want error for unknown group")
+	}
+}
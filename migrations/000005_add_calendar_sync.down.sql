@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS calendar_events;
+DROP TABLE IF EXISTS calendar_sync_state;
@@ -0,0 +1,18 @@
+CREATE TABLE calendar_sync_state (
+    calendar_id TEXT PRIMARY KEY,
+    is_primary BOOLEAN DEFAULT FALSE,
+    sync_token TEXT,
+    last_synced_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE calendar_events (
+    calendar_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    start_time TIMESTAMP WITH TIME ZONE,
+    end_time TIMESTAMP WITH TIME ZONE,
+    data JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (calendar_id, event_id)
+);
+
+CREATE INDEX calendar_events_time_idx ON calendar_events (calendar_id, start_time, end_time);
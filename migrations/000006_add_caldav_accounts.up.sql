@@ -0,0 +1,8 @@
+CREATE TABLE caldav_accounts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    username VARCHAR(255),
+    password TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ics_imports;
+DROP TABLE IF EXISTS ics_import_previews;
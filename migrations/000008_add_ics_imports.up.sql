@@ -0,0 +1,13 @@
+CREATE TABLE ics_import_previews (
+    id SERIAL PRIMARY KEY,
+    candidates JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE ics_imports (
+    uid TEXT NOT NULL,
+    calendar_id TEXT NOT NULL,
+    event_id TEXT,
+    imported_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (uid, calendar_id)
+);
@@ -0,0 +1,12 @@
+DELETE FROM events WHERE calendar_id IS NOT NULL AND external_id IS NULL;
+
+DROP INDEX IF EXISTS idx_events_local;
+
+ALTER TABLE events
+    DROP COLUMN calendar_id,
+    DROP COLUMN uid,
+    DROP COLUMN is_all_day,
+    DROP COLUMN exdates,
+    DROP COLUMN external_id,
+    DROP COLUMN updated_at,
+    ALTER COLUMN recurrence TYPE VARCHAR(50) USING LEFT(recurrence, 50);
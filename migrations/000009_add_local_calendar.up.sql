@@ -0,0 +1,11 @@
+ALTER TABLE events
+    ALTER COLUMN recurrence TYPE TEXT,
+    ADD COLUMN calendar_id TEXT,
+    ADD COLUMN uid TEXT,
+    ADD COLUMN is_all_day BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN exdates TIMESTAMP WITH TIME ZONE[] NOT NULL DEFAULT '{}',
+    ADD COLUMN external_id TEXT,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
+
+-- rows without calendar_id are history of events created in external calendars
+CREATE INDEX idx_events_local ON events (calendar_id, start_time) WHERE external_id IS NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS project_task_dependencies;
+DROP TABLE IF EXISTS project_tasks;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,13 @@
+CREATE TABLE weekly_reviews (
+    id SERIAL PRIMARY KEY,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    went_well TEXT[] NOT NULL DEFAULT '{}',
+    slipped TEXT[] NOT NULL DEFAULT '{}',
+    suggestions TEXT[] NOT NULL DEFAULT '{}',
+    stats JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_weekly_reviews_created ON weekly_reviews (created_at DESC);
@@ -0,0 +1,24 @@
+CREATE TABLE digest_settings (
+    user_id INT PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    email TEXT NOT NULL DEFAULT '',
+    send_time TEXT NOT NULL DEFAULT '08:00',
+    timezone TEXT NOT NULL DEFAULT '',
+    calendars TEXT[] NOT NULL DEFAULT '{}',
+    last_sent_date DATE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE digest_deliveries (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    digest_date DATE NOT NULL,
+    email TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT,
+    events INT NOT NULL DEFAULT 0,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_digest_deliveries_user ON digest_deliveries (user_id, sent_at DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS telegram_event_actions;
+DROP TABLE IF EXISTS telegram_link_codes;
+DROP TABLE IF EXISTS telegram_users;
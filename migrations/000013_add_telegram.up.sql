@@ -0,0 +1,22 @@
+CREATE TABLE telegram_users (
+    telegram_id BIGINT PRIMARY KEY,
+    user_id INT NOT NULL,
+    chat_id BIGINT NOT NULL,
+    username TEXT NOT NULL DEFAULT '',
+    linked_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE telegram_link_codes (
+    code TEXT PRIMARY KEY,
+    user_id INT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- callback_data of inline buttons is limited to 64 bytes, so buttons carry id of this row
+CREATE TABLE telegram_event_actions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    calendar_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
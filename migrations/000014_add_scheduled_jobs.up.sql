@@ -0,0 +1,23 @@
+CREATE TABLE scheduled_jobs (
+    name TEXT PRIMARY KEY,
+    schedule TEXT NOT NULL,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INT NOT NULL DEFAULT 0, -- failed attempts of the current run, reset on success
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_status TEXT NOT NULL DEFAULT '',
+    last_error TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name TEXT NOT NULL REFERENCES scheduled_jobs(name) ON DELETE CASCADE,
+    attempt INT NOT NULL,
+    instance TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_job_runs_job ON job_runs (job_name, started_at DESC);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS goal_sessions;
+DROP TABLE IF EXISTS goal_milestones;
+DROP TABLE IF EXISTS goals;
+DROP TABLE IF EXISTS goal_plans;
@@ -0,0 +1,39 @@
+-- plans drafted by the model, kept until user accepts or edits them
+CREATE TABLE goal_plans (
+    id SERIAL PRIMARY KEY,
+    plan JSONB NOT NULL,
+    goal_id INT, -- set when plan is accepted
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE goals (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    deadline DATE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE goal_milestones (
+    id SERIAL PRIMARY KEY,
+    goal_id INT NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    due_date DATE NOT NULL,
+    position INT NOT NULL
+);
+
+CREATE TABLE goal_sessions (
+    id SERIAL PRIMARY KEY,
+    goal_id INT NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    milestone_id INT REFERENCES goal_milestones(id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    calendar_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_goal_sessions_goal ON goal_sessions (goal_id, start_time);
@@ -0,0 +1,22 @@
+-- habit is a recurring calendar event user checks in every day (or week)
+CREATE TABLE habits (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    recurrence TEXT NOT NULL DEFAULT 'daily',
+    calendar_id TEXT NOT NULL DEFAULT '',
+    event_id TEXT NOT NULL DEFAULT '', -- recurring master event, empty if habit has no event
+    start_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_habits_title ON habits (LOWER(title)) WHERE NOT archived;
+
+CREATE TABLE habit_checkins (
+    habit_id INT NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    status TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (habit_id, day)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS journal_reflections;
+DROP TABLE IF EXISTS journal_entries;
@@ -0,0 +1,25 @@
+-- journal_entries from 000001 was dropped in 000003, the journal comes back with mood source and reflections
+CREATE TABLE journal_entries (
+    id SERIAL PRIMARY KEY,
+    entry_text TEXT NOT NULL,
+    mood_score INT CHECK (mood_score BETWEEN 1 AND 10),
+    mood_source TEXT NOT NULL DEFAULT '', -- user or ai
+    source TEXT NOT NULL DEFAULT 'api',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_journal_entries_created ON journal_entries (created_at);
+
+CREATE TABLE journal_reflections (
+    id SERIAL PRIMARY KEY,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    insights TEXT[] NOT NULL DEFAULT '{}',
+    suggestions TEXT[] NOT NULL DEFAULT '{}',
+    trend JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_journal_reflections_created ON journal_reflections (created_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS task_sync;
+DROP TABLE IF EXISTS tasks;
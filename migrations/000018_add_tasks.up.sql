@@ -0,0 +1,25 @@
+-- to-dos without fixed time, optionally mirrored to Google Tasks
+CREATE TABLE tasks (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    due DATE,
+    priority TEXT NOT NULL DEFAULT 'medium',
+    estimate_hours DOUBLE PRECISION,
+    status TEXT NOT NULL DEFAULT 'todo',
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    completed_at TIMESTAMP WITH TIME ZONE,
+    google_task_id TEXT UNIQUE,
+    dirty BOOLEAN NOT NULL DEFAULT TRUE, -- changed locally and not pushed to Google Tasks yet
+    deleted_at TIMESTAMP WITH TIME ZONE, -- tombstone until deletion is pushed
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_tasks_status_due ON tasks (status, due) WHERE deleted_at IS NULL;
+CREATE INDEX idx_tasks_dirty ON tasks (id) WHERE dirty;
+
+CREATE TABLE task_sync (
+    list_id TEXT PRIMARY KEY,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
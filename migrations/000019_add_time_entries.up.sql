@@ -0,0 +1,19 @@
+-- tracked time, linked to a calendar event or a task to compare plan with fact
+CREATE TABLE time_entries (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL DEFAULT '',
+    calendar_id TEXT NOT NULL DEFAULT '',
+    event_id TEXT NOT NULL DEFAULT '',
+    task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE, -- NULL while timer is running
+    note TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT 'timer',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (ended_at IS NULL OR ended_at > started_at)
+);
+
+-- only one timer runs at a time
+CREATE UNIQUE INDEX idx_time_entries_running ON time_entries ((TRUE)) WHERE ended_at IS NULL;
+CREATE INDEX idx_time_entries_started_at ON time_entries (started_at);
+CREATE INDEX idx_time_entries_event ON time_entries (calendar_id, event_id) WHERE event_id <> '';
@@ -0,0 +1,66 @@
+-- rows of other users are dropped, the first user's data stays as single-user data
+DELETE FROM events WHERE user_id <> 1;
+DELETE FROM calendar_sync_state WHERE user_id <> 1;
+DELETE FROM calendar_events WHERE user_id <> 1;
+DELETE FROM caldav_accounts WHERE user_id <> 1;
+DELETE FROM feed_tokens WHERE user_id <> 1;
+DELETE FROM ics_import_previews WHERE user_id <> 1;
+DELETE FROM ics_imports WHERE user_id <> 1;
+DELETE FROM projects WHERE user_id <> 1;
+DELETE FROM weekly_reviews WHERE user_id <> 1;
+DELETE FROM goal_plans WHERE user_id <> 1;
+DELETE FROM goals WHERE user_id <> 1;
+DELETE FROM habits WHERE user_id <> 1;
+DELETE FROM journal_entries WHERE user_id <> 1;
+DELETE FROM journal_reflections WHERE user_id <> 1;
+DELETE FROM time_entries WHERE user_id <> 1;
+DELETE FROM tasks WHERE user_id <> 1;
+DELETE FROM task_sync WHERE user_id <> 1;
+
+DROP INDEX IF EXISTS idx_time_entries_started_at;
+CREATE INDEX idx_time_entries_started_at ON time_entries (started_at);
+DROP INDEX IF EXISTS idx_journal_reflections_created;
+CREATE INDEX idx_journal_reflections_created ON journal_reflections (created_at DESC);
+DROP INDEX IF EXISTS idx_journal_entries_created;
+CREATE INDEX idx_journal_entries_created ON journal_entries (created_at);
+DROP INDEX IF EXISTS idx_weekly_reviews_created;
+CREATE INDEX idx_weekly_reviews_created ON weekly_reviews (created_at DESC);
+DROP INDEX IF EXISTS idx_tasks_user;
+DROP INDEX IF EXISTS idx_goals_user;
+DROP INDEX IF EXISTS idx_projects_user;
+DROP INDEX IF EXISTS idx_events_local;
+CREATE INDEX idx_events_local ON events (calendar_id, start_time) WHERE external_id IS NULL;
+DROP INDEX IF EXISTS calendar_events_time_idx;
+CREATE INDEX calendar_events_time_idx ON calendar_events (calendar_id, start_time, end_time);
+DROP INDEX IF EXISTS idx_time_entries_running;
+CREATE UNIQUE INDEX idx_time_entries_running ON time_entries ((TRUE)) WHERE ended_at IS NULL;
+DROP INDEX IF EXISTS idx_habits_title;
+CREATE UNIQUE INDEX idx_habits_title ON habits (LOWER(title)) WHERE NOT archived;
+
+ALTER TABLE tasks DROP CONSTRAINT tasks_google_task_id_key, ADD CONSTRAINT tasks_google_task_id_key UNIQUE (google_task_id);
+ALTER TABLE task_sync DROP CONSTRAINT task_sync_pkey, ADD PRIMARY KEY (list_id);
+ALTER TABLE ics_imports DROP CONSTRAINT ics_imports_pkey, ADD PRIMARY KEY (uid, calendar_id);
+ALTER TABLE calendar_events DROP CONSTRAINT calendar_events_pkey, ADD PRIMARY KEY (calendar_id, event_id);
+ALTER TABLE calendar_sync_state DROP CONSTRAINT calendar_sync_state_pkey, ADD PRIMARY KEY (calendar_id);
+
+ALTER TABLE events DROP COLUMN user_id;
+ALTER TABLE calendar_sync_state DROP COLUMN user_id;
+ALTER TABLE calendar_events DROP COLUMN user_id;
+ALTER TABLE caldav_accounts DROP COLUMN user_id;
+ALTER TABLE feed_tokens DROP COLUMN user_id;
+ALTER TABLE ics_import_previews DROP COLUMN user_id;
+ALTER TABLE ics_imports DROP COLUMN user_id;
+ALTER TABLE projects DROP COLUMN user_id;
+ALTER TABLE weekly_reviews DROP COLUMN user_id;
+ALTER TABLE goal_plans DROP COLUMN user_id;
+ALTER TABLE goals DROP COLUMN user_id;
+ALTER TABLE habits DROP COLUMN user_id;
+ALTER TABLE journal_entries DROP COLUMN user_id;
+ALTER TABLE journal_reflections DROP COLUMN user_id;
+ALTER TABLE tasks DROP COLUMN user_id;
+ALTER TABLE task_sync DROP COLUMN user_id;
+ALTER TABLE time_entries DROP COLUMN user_id;
+
+DROP TABLE IF EXISTS google_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,99 @@
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    password_hash TEXT NOT NULL, -- bcrypt
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_users_email ON users (LOWER(email));
+
+-- cookie keeps random token, only its sha256 is stored
+CREATE TABLE sessions (
+    token_hash TEXT PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_sessions_user ON sessions (user_id);
+
+-- OAuth token of the user's Google account, replaces token.json
+CREATE TABLE google_tokens (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- data created before accounts belongs to the first registered user (id 1, like the Context row),
+-- so user_id of existing tables has no foreign key
+ALTER TABLE events ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE calendar_sync_state ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE calendar_events ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE caldav_accounts ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE feed_tokens ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE ics_import_previews ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE ics_imports ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE projects ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE weekly_reviews ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE goal_plans ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE goals ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE habits ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE journal_entries ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE journal_reflections ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE tasks ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE task_sync ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+ALTER TABLE time_entries ADD COLUMN user_id INT NOT NULL DEFAULT 1;
+
+ALTER TABLE events ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE calendar_sync_state ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE calendar_events ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE caldav_accounts ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE feed_tokens ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE ics_import_previews ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE ics_imports ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE projects ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE weekly_reviews ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE goal_plans ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE goals ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE habits ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE journal_entries ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE journal_reflections ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE tasks ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE task_sync ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE time_entries ALTER COLUMN user_id DROP DEFAULT;
+
+-- keys that were unique for the only user become unique per user
+ALTER TABLE calendar_sync_state DROP CONSTRAINT calendar_sync_state_pkey, ADD PRIMARY KEY (user_id, calendar_id);
+ALTER TABLE calendar_events DROP CONSTRAINT calendar_events_pkey, ADD PRIMARY KEY (user_id, calendar_id, event_id);
+ALTER TABLE ics_imports DROP CONSTRAINT ics_imports_pkey, ADD PRIMARY KEY (user_id, uid, calendar_id);
+ALTER TABLE task_sync DROP CONSTRAINT task_sync_pkey, ADD PRIMARY KEY (user_id, list_id);
+ALTER TABLE tasks DROP CONSTRAINT tasks_google_task_id_key, ADD CONSTRAINT tasks_google_task_id_key UNIQUE (user_id, google_task_id);
+
+DROP INDEX idx_habits_title;
+CREATE UNIQUE INDEX idx_habits_title ON habits (user_id, LOWER(title)) WHERE NOT archived;
+
+DROP INDEX idx_time_entries_running;
+CREATE UNIQUE INDEX idx_time_entries_running ON time_entries (user_id) WHERE ended_at IS NULL;
+
+DROP INDEX calendar_events_time_idx;
+CREATE INDEX calendar_events_time_idx ON calendar_events (user_id, calendar_id, start_time, end_time);
+
+DROP INDEX idx_events_local;
+CREATE INDEX idx_events_local ON events (user_id, calendar_id, start_time) WHERE external_id IS NULL;
+
+CREATE INDEX idx_projects_user ON projects (user_id);
+CREATE INDEX idx_goals_user ON goals (user_id);
+CREATE INDEX idx_tasks_user ON tasks (user_id);
+
+DROP INDEX idx_weekly_reviews_created;
+CREATE INDEX idx_weekly_reviews_created ON weekly_reviews (user_id, created_at DESC);
+
+DROP INDEX idx_journal_entries_created;
+CREATE INDEX idx_journal_entries_created ON journal_entries (user_id, created_at);
+
+DROP INDEX idx_journal_reflections_created;
+CREATE INDEX idx_journal_reflections_created ON journal_reflections (user_id, created_at DESC);
+
+DROP INDEX idx_time_entries_started_at;
+CREATE INDEX idx_time_entries_started_at ON time_entries (user_id, started_at);
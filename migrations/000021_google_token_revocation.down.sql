@@ -0,0 +1 @@
+ALTER TABLE google_tokens DROP COLUMN IF EXISTS revoked_at;
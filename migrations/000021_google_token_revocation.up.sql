@@ -0,0 +1,2 @@
+-- set when refresh token is rejected with invalid_grant, cleared by next successful authorization
+ALTER TABLE google_tokens ADD COLUMN revoked_at TIMESTAMPTZ;
@@ -0,0 +1,7 @@
+-- encrypted tokens can not be decrypted in SQL, their users have to authorize Google again
+DROP TABLE IF EXISTS secrets;
+
+DELETE FROM google_tokens WHERE token IS NULL;
+ALTER TABLE google_tokens DROP COLUMN IF EXISTS key_id;
+ALTER TABLE google_tokens DROP COLUMN IF EXISTS token_encrypted;
+ALTER TABLE google_tokens ALTER COLUMN token SET NOT NULL;
@@ -0,0 +1,13 @@
+-- tokens are AES-GCM encrypted by the app, key_id names the keyring key;
+-- plaintext token stays only until app start or "secrets rotate" re-encrypts old rows
+ALTER TABLE google_tokens ALTER COLUMN token DROP NOT NULL;
+ALTER TABLE google_tokens ADD COLUMN token_encrypted BYTEA;
+ALTER TABLE google_tokens ADD COLUMN key_id TEXT;
+
+-- named application secrets, e.g. GigaChat key
+CREATE TABLE secrets (
+    name TEXT PRIMARY KEY,
+    value BYTEA NOT NULL,
+    key_id TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+-- only primary accounts survive, others have to be linked again after upgrade
+DELETE FROM google_tokens WHERE NOT is_primary;
+
+DROP INDEX IF EXISTS idx_google_tokens_primary;
+DROP INDEX IF EXISTS idx_google_tokens_email;
+
+ALTER TABLE google_tokens DROP CONSTRAINT google_tokens_pkey;
+ALTER TABLE google_tokens DROP COLUMN IF EXISTS created_at;
+ALTER TABLE google_tokens DROP COLUMN IF EXISTS is_primary;
+ALTER TABLE google_tokens DROP COLUMN IF EXISTS scopes;
+ALTER TABLE google_tokens DROP COLUMN IF EXISTS email;
+ALTER TABLE google_tokens DROP COLUMN IF EXISTS id;
+ALTER TABLE google_tokens ADD PRIMARY KEY (user_id);
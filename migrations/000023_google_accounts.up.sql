@@ -0,0 +1,14 @@
+-- several Google accounts per user, the primary one is synced into mirror and used by Tasks,
+-- calendars of others are read directly with "google:<id>:" prefix
+ALTER TABLE google_tokens DROP CONSTRAINT google_tokens_pkey;
+ALTER TABLE google_tokens ADD COLUMN id SERIAL PRIMARY KEY;
+ALTER TABLE google_tokens ADD COLUMN email TEXT NOT NULL DEFAULT '';
+ALTER TABLE google_tokens ADD COLUMN scopes TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE google_tokens ADD COLUMN is_primary BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE google_tokens ADD COLUMN created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
+
+-- existing token becomes primary account, its email is filled on first use
+UPDATE google_tokens SET is_primary = TRUE;
+
+CREATE UNIQUE INDEX idx_google_tokens_email ON google_tokens (user_id, LOWER(email)) WHERE email <> '';
+CREATE UNIQUE INDEX idx_google_tokens_primary ON google_tokens (user_id) WHERE is_primary;
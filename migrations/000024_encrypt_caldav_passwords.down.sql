@@ -0,0 +1,4 @@
+-- encrypted passwords can not be decrypted in SQL, such accounts have to be linked again
+DELETE FROM caldav_accounts WHERE password_encrypted IS NOT NULL;
+ALTER TABLE caldav_accounts DROP COLUMN IF EXISTS key_id;
+ALTER TABLE caldav_accounts DROP COLUMN IF EXISTS password_encrypted;
@@ -0,0 +1,4 @@
+-- CalDAV passwords are AES-GCM encrypted by the app like google_tokens,
+-- plaintext password stays only until app start or "secrets rotate" re-encrypts old rows
+ALTER TABLE caldav_accounts ADD COLUMN password_encrypted BYTEA;
+ALTER TABLE caldav_accounts ADD COLUMN key_id TEXT;
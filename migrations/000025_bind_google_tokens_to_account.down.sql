@@ -0,0 +1,3 @@
+-- tokens bound to account id can not be read by older versions, their users have to authorize Google again
+DELETE FROM google_tokens WHERE account_bound;
+ALTER TABLE google_tokens DROP COLUMN IF EXISTS account_bound;
@@ -0,0 +1,3 @@
+-- tokens encrypted before this migration are bound to user id, the app
+-- re-encrypts them bound to account id on start and sets account_bound
+ALTER TABLE google_tokens ADD COLUMN account_bound BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE calendar_sync_state DROP COLUMN IF EXISTS window_end;
+ALTER TABLE calendar_sync_state DROP COLUMN IF EXISTS window_start;
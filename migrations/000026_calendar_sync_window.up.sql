@@ -0,0 +1,4 @@
+-- mirror holds only instances inside the sync window; states without window
+-- come from unbounded full sync and are resynced on next run
+ALTER TABLE calendar_sync_state ADD COLUMN window_start TIMESTAMP WITH TIME ZONE;
+ALTER TABLE calendar_sync_state ADD COLUMN window_end TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,2 @@
+-- nothing to undo, mirror is rebuilt by sync
+SELECT 1;
@@ -0,0 +1,2 @@
+-- all-day events were mirrored from UTC midnight, next sync rebuilds mirror in CALENDAR_TIMEZONE
+UPDATE calendar_sync_state SET sync_token = NULL, window_start = NULL, window_end = NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_digest_settings_verify_token;
+ALTER TABLE digest_settings DROP COLUMN IF EXISTS manual_sent_at;
+ALTER TABLE digest_settings DROP COLUMN IF EXISTS verify_sent_at;
+ALTER TABLE digest_settings DROP COLUMN IF EXISTS verify_token_hash;
+ALTER TABLE digest_settings DROP COLUMN IF EXISTS email_verified;
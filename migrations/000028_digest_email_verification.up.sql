@@ -0,0 +1,8 @@
+-- digest goes only to confirmed addresses, existing ones have to be confirmed again;
+-- manual_sent_at limits on-demand sends
+ALTER TABLE digest_settings ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE digest_settings ADD COLUMN verify_token_hash TEXT;
+ALTER TABLE digest_settings ADD COLUMN verify_sent_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE digest_settings ADD COLUMN manual_sent_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX idx_digest_settings_verify_token ON digest_settings (verify_token_hash) WHERE verify_token_hash IS NOT NULL;
@@ -0,0 +1,2 @@
+-- background color of synced calendar, refreshed by every sync so views need not ask Google for it
+ALTER TABLE calendar_sync_state ADD COLUMN color TEXT NOT NULL DEFAULT '';